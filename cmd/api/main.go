@@ -19,15 +19,18 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/Strahinja-Polovina/packs/internal/application/service"
 	"github.com/Strahinja-Polovina/packs/internal/infrastructure/database"
 	"github.com/Strahinja-Polovina/packs/internal/infrastructure/repository"
 	"github.com/Strahinja-Polovina/packs/internal/presentation/routes"
 	"github.com/Strahinja-Polovina/packs/internal/presentation/server"
+	"github.com/Strahinja-Polovina/packs/migrations"
 	"github.com/Strahinja-Polovina/packs/pkg/config"
 	"github.com/Strahinja-Polovina/packs/pkg/logger"
 	"github.com/gin-gonic/gin"
@@ -51,9 +54,37 @@ func main() {
 		}
 	}()
 
+	// Apply the embedded schema migrations when asked to, either as the
+	// migrate subcommand or before serving
+	migrator, err := database.NewMigrator(db, migrations.FS, logger.GetLogger())
+	if err != nil {
+		logger.Fatal("Failed to load migrations: %v", err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), migrator, os.Args[2:]); err != nil {
+			logger.Fatal("Migration failed: %v", err)
+		}
+		return
+	}
+	if cfg.Database.MigrateOnStartup {
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			logger.Fatal("Failed to migrate database: %v", err)
+		}
+		logger.Info("Applied %d pending migrations", applied)
+	}
+
+	defaultObjective, err := service.ParseObjective(cfg.Solver.DefaultObjective)
+	if err != nil {
+		logger.Fatal("Invalid solver configuration: %v", err)
+	}
+
 	// Initialize repositories
+	productRepo := repository.NewProductPostgres(db, logger.GetLogger())
 	packRepo := repository.NewPackPostgres(db, logger.GetLogger())
 	orderRepo := repository.NewOrderPostgres(db, logger.GetLogger())
+	stockRepo := repository.NewStockPostgres(db, logger.GetLogger())
+	idempotencyRepo := repository.NewIdempotencyPostgres(db, logger.GetLogger())
 
 	// Create server
 	srv := server.New(server.Config{
@@ -64,12 +95,29 @@ func main() {
 
 	// Setup routes
 	routeConfig := routes.RouteConfig{
-		ServiceName:   cfg.Server.Name,
-		Port:          cfg.Server.Port,
-		PackRepo:      packRepo,
-		OrderRepo:     orderRepo,
-		Logger:        logger.GetLogger(),
-		EnableSwagger: cfg.App.EnableSwagger,
+		ServiceName:      cfg.Server.Name,
+		Port:             cfg.Server.Port,
+		ProductRepo:      productRepo,
+		PackRepo:         packRepo,
+		OrderRepo:        orderRepo,
+		StockRepo:        stockRepo,
+		IdempotencyRepo:  idempotencyRepo,
+		IdempotencyTTL:   cfg.Idempotency.TTL,
+		IdempotencyLease: cfg.Idempotency.Lease,
+		SolverConfig: service.SolverConfig{
+			DefaultObjective: defaultObjective,
+			Weights: service.SolverWeights{
+				Waste: cfg.Solver.WasteWeight,
+				Packs: cfg.Solver.PackWeight,
+				Cost:  cfg.Solver.CostWeight,
+			},
+			BatchWorkers: cfg.Solver.BatchWorkers,
+			TimeBudget:   cfg.Solver.TimeBudget,
+		},
+		RequestTimeout: cfg.Server.RequestTimeout,
+		BatchTimeout:   cfg.Server.BatchTimeout,
+		Logger:         logger.GetLogger(),
+		EnableSwagger:  cfg.App.EnableSwagger,
 	}
 
 	srv.SetupRoutes(func(router *gin.Engine) {
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Strahinja-Polovina/packs/internal/infrastructure/database"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+)
+
+// migrateUsage describes the migrate subcommand
+const migrateUsage = "usage: migrate up|down|reset|status"
+
+// runMigrate runs the migrate subcommand: up applies every pending migration,
+// down rolls back the latest one, reset rolls back all of them and status
+// lists them
+func runMigrate(ctx context.Context, migrator *database.Migrator, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info("Applied %d migrations", applied)
+	case "down":
+		migration, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if migration == nil {
+			logger.Info("No migration to roll back")
+		}
+	case "reset":
+		rolledBack, err := migrator.Reset(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info("Rolled back %d migrations", rolledBack)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Applied At\tMigration")
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%s\t%s\n", appliedAt, status.Migration.Name)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", args[0], migrateUsage)
+	}
+	return nil
+}
@@ -24,36 +24,67 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/orders": {
-            "get": {
-                "description": "Retrieve all orders from the system",
+        "/api/v1/calculations": {
+            "post": {
+                "description": "Calculate the optimal pack combination for an amount without creating an order. When the solver runs out of time it returns the best combination found so far with optimal set to false, or 503 when it found none.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "calculations"
+                ],
+                "summary": "Calculate a pack combination",
+                "parameters": [
+                    {
+                        "description": "Pack calculation request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.PackCalculationRequest"
+                        }
+                    }
                 ],
-                "summary": "Get all orders",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/service.OrderResponse"
-                            }
+                            "$ref": "#/definitions/service.PackCalculationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/calculations/batch": {
             "post": {
-                "description": "Create a new order from pack calculation",
+                "description": "Calculate the optimal pack combination for up to 1000 amounts in one request. Results are returned in input order; an amount that cannot be calculated gets an error instead of a result.",
                 "consumes": [
                     "application/json"
                 ],
@@ -61,69 +92,154 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "calculations"
                 ],
-                "summary": "Create a new order",
+                "summary": "Calculate pack combinations for many amounts",
                 "parameters": [
                     {
-                        "description": "Order creation request",
+                        "description": "Batch calculation request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/service.OrderRequest"
+                            "$ref": "#/definitions/service.BatchCalculationRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/service.OrderResponse"
+                            "$ref": "#/definitions/service.BatchCalculationResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             }
         },
-        "/api/v1/pack-sizes": {
+        "/api/v1/orders": {
             "get": {
-                "description": "Get all available pack sizes from the system",
+                "description": "Retrieve one page of orders. Pass next_cursor from a page as cursor to fetch the next one.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "packs"
+                    "orders"
+                ],
+                "summary": "List orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size, 1 to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "-created_at",
+                            "amount",
+                            "-amount"
+                        ],
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Only orders created at or after this time (RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Only orders created before this time (RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum requested amount",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum requested amount",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only orders that ship packs of this size",
+                        "name": "pack_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Only orders of this product",
+                        "name": "product_id",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get available pack sizes",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.PackSizesResponse"
+                            "$ref": "#/definitions/service.OrderListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Add a new pack size to the system",
+                "description": "Create a new order from pack calculation. Send either an amount, or lines that are each calculated on their own with their own amount and product. Send an Idempotency-Key header to make retries safe: a repeated request returns the original response, and reusing the key with a different payload returns 422.",
                 "consumes": [
                     "application/json"
                 ],
@@ -131,51 +247,127 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "packs"
+                    "orders"
                 ],
-                "summary": "Create a new pack size",
+                "summary": "Create a new order",
                 "parameters": [
                     {
-                        "description": "Pack size creation request",
+                        "description": "Order creation request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreatePackSizeRequest"
+                            "$ref": "#/definitions/service.OrderRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client-chosen key identifying this order request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/handlers.PackResponse"
+                            "$ref": "#/definitions/service.OrderResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "409": {
                         "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             }
         },
-        "/api/v1/pack-sizes/{id}": {
+        "/api/v1/orders/{id}": {
+            "get": {
+                "description": "Retrieve a single order with its line items. The ETag header holds its version for conditional changes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the order"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
             "put": {
-                "description": "Update an existing pack size",
+                "description": "Recalculate an order for a new requested amount or new lines against the current pack sets and replace its lines. Lines without a product keep the product of the line they replace. The replaced version is kept as a revision. Orders can be amended until picking starts. If-Match must hold the ETag of the order version the amendment is based on.",
                 "consumes": [
                     "application/json"
                 ],
@@ -183,25 +375,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "packs"
+                    "orders"
                 ],
-                "summary": "Update a pack size",
+                "summary": "Amend an order",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "Pack ID",
+                        "description": "Order ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Pack size update request",
+                        "type": "string",
+                        "description": "ETag of the order, or * to amend any version",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "New requested amount or lines",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.UpdatePackSizeRequest"
+                            "$ref": "#/definitions/service.OrderRequest"
                         }
                     }
                 ],
@@ -209,190 +408,2858 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.PackResponse"
+                            "$ref": "#/definitions/service.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the order"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Remove a pack size from the system",
+            }
+        },
+        "/api/v1/orders/{id}/cancel": {
+            "post": {
+                "description": "Cancel an order that has not shipped yet. Its packs are returned to tracked stock.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "packs"
+                    "orders"
                 ],
-                "summary": "Delete a pack size",
+                "summary": "Cancel an order",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "Pack ID",
+                        "description": "Order ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order, or * to change any version",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the order"
+                            }
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/problem.Details"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.CreatePackSizeRequest": {
-            "type": "object",
-            "required": [
-                "size"
-            ],
-            "properties": {
-                "size": {
-                    "type": "integer",
-                    "minimum": 1
-                }
-            }
-        },
-        "handlers.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.PackResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "size": {
-                    "type": "integer"
-                }
-            }
         },
-        "handlers.PackSizesResponse": {
-            "type": "object",
-            "properties": {
-                "count": {
+        "/api/v1/orders/{id}/confirm": {
+            "post": {
+                "description": "Move a draft order to confirmed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Confirm an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order, or * to change any version",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the order"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/orders/{id}/history": {
+            "get": {
+                "description": "Retrieve the status changes of an order, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get order status history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.OrderStatusChangeResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/orders/{id}/pack": {
+            "post": {
+                "description": "Move an order that is being picked to packed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Mark an order packed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order, or * to change any version",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the order"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/orders/{id}/pick": {
+            "post": {
+                "description": "Move a confirmed order to picking",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Start picking an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order, or * to change any version",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the order"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/orders/{id}/revisions": {
+            "get": {
+                "description": "Retrieve the versions an order had before it was amended, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get order revisions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.OrderRevisionResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/orders/{id}/ship": {
+            "post": {
+                "description": "Move a packed order to shipped. Shipped orders are final.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Ship an order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the order, or * to change any version",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the order"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes": {
+            "get": {
+                "description": "Get the pack sizes in effect now of every product, or of one product when product_id is given. With as_of, list the revisions of one product's pack sizes that were in effect at that time, or are scheduled to be; the default product is used when product_id is omitted. When one product's pack sizes in effect now are listed, the ETag header holds the version of its pack set for PUT /api/v1/pack-sizes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packs"
+                ],
+                "summary": "Get available pack sizes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Only list the pack sizes of this product",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "List the pack sizes in effect at this time (RFC 3339)",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PackSizesResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the product's pack set, without as_of"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Make the listed sizes the complete pack set of a product, or of the default product when product_id is omitted. Missing sizes are created and sizes left out are retired, all in one transaction, effective at valid_from or now when it is omitted. The sizes the product keeps are left alone. The response lists the changes; with dry_run=true they are only computed and validated. If-Match must hold the ETag of the pack set the changes are based on, unless dry_run is set; a dry run returns that ETag.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packs"
+                ],
+                "summary": "Replace the pack set of a product",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only compute and validate the changes",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the pack set, or * to replace any pack set; required unless dry_run is set",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Complete pack set",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.PackSetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.PackSetResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the pack set"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a new pack size to a product, or to the default product when product_id is omitted. Sizes are unique among a product's active packs. The pack takes effect at valid_from, or now when it is omitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packs"
+                ],
+                "summary": "Create a new pack size",
+                "parameters": [
+                    {
+                        "description": "Pack size creation request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreatePackSizeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PackResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/analysis": {
+            "get": {
+                "description": "Solve every amount from 1 to max for the least waste and report the GCD of the sizes, the largest amount no combination hits exactly (Frobenius number), the waste distribution, the average pack count and the most wasteful amounts. The current pack set of the product is analysed unless the body proposes sizes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packs"
+                ],
+                "summary": "Analyse the coverage of a pack set",
+                "parameters": [
+                    {
+                        "maximum": 100000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10000,
+                        "description": "Largest amount to analyse",
+                        "name": "max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Analyse the pack set of this product instead of the default product",
+                        "name": "product_id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Proposed pack set",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/service.PackAnalysisRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.PackAnalysisResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/recommendations": {
+            "post": {
+                "description": "Suggest the count pack sizes, between min_size and max_size and including any fixed sizes, with the least total waste and then the fewest packs over the requested amounts of the product's orders. Cancelled orders are left out. The recommended set is compared with the product's current pack sizes; negative changes are savings. The search adds sizes greedily and then swaps them while that helps, so the result is good but not guaranteed optimal.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packs"
+                ],
+                "summary": "Recommend pack sizes from order history",
+                "parameters": [
+                    {
+                        "description": "Recommendation request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.RecommendationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.RecommendationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pack-sizes/{id}": {
+            "get": {
+                "description": "Get the latest revision of an active pack. The ETag header holds its revision for conditional updates.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packs"
+                ],
+                "summary": "Get a pack size",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Pack ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PackResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Revision of the pack"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Change the size and attributes of a pack as its next revision, effective at valid_from or now when it is omitted. Earlier revisions stay in the history of the catalog. If-Match must hold the ETag of the revision the change is based on.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "packs"
+                ],
+                "summary": "Update a pack size",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Pack ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the pack, or * to update any revision",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Pack size update request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdatePackSizeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PackResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Revision of the pack"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Retire a pack size now. It is no longer listed or used for new calculations, but its revisions stay in the history of the catalog. If-Match must hold the ETag of the pack's latest revision.",
+                "tags": [
+                    "packs"
+                ],
+                "summary": "Delete a pack size",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Pack ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the pack, or * to delete any revision",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/products": {
+            "get": {
+                "description": "Get all products, each with its own set of pack sizes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get products",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProductsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a new product. Its pack sizes are added with the pack-sizes endpoints.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Create a product",
+                "parameters": [
+                    {
+                        "description": "Product creation request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{id}": {
+            "get": {
+                "description": "Get a product by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get a product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Rename an existing product",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Update a product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Product update request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a product. Products that still have pack sizes or orders, and the default product, cannot be deleted.",
+                "tags": [
+                    "products"
+                ],
+                "summary": "Delete a product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/products/{id}/pack-sizes": {
+            "get": {
+                "description": "Get the pack sizes of one product in ascending order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get the pack sizes of a product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PackSizesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/simulations": {
+            "post": {
+                "description": "Replay the requested amount of every order line of a product against a candidate pack set and compare the waste, pack count and cost with what was actually shipped. Cancelled orders are left out. The simulation runs in the background; poll the returned location until its status is completed or failed. Simulations are kept in memory and lost on restart.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "simulations"
+                ],
+                "summary": "Simulate a candidate pack set",
+                "parameters": [
+                    {
+                        "description": "Candidate pack set",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.SimulationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/service.SimulationResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the simulation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/simulations/{id}": {
+            "get": {
+                "description": "Get the status of a simulation, with its progress while it runs and its result once it has completed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "simulations"
+                ],
+                "summary": "Get a simulation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Simulation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.SimulationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stock": {
+            "get": {
+                "description": "Get the number of packs on hand for every pack size with tracked stock. Pack sizes that are not listed are not tracked and count as unlimited.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Get stock levels",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StockLevelsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stock/{pack_id}": {
+            "put": {
+                "description": "Set the number of packs on hand for a pack size and start tracking its stock",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Set a stock level",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Pack ID",
+                        "name": "pack_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stock level",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SetStockRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StockLevelResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove the stock level of a pack size so it counts as unlimited again",
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Stop tracking stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Pack ID",
+                        "name": "pack_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stock/{pack_id}/adjustments": {
+            "post": {
+                "description": "Add packs to stock with a positive delta or remove them with a negative one. Untracked pack sizes start from zero.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stock"
+                ],
+                "summary": "Adjust a stock level",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Pack ID",
+                        "name": "pack_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stock adjustment",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AdjustStockRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StockLevelResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Details"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.AdjustStockRequest": {
+            "type": "object",
+            "required": [
+                "delta"
+            ],
+            "properties": {
+                "delta": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.CreatePackSizeRequest": {
+            "type": "object",
+            "required": [
+                "size"
+            ],
+            "properties": {
+                "dimensions_mm": {
+                    "$ref": "#/definitions/handlers.DimensionsPayload"
+                },
+                "product_id": {
+                    "description": "ProductID is the product the pack belongs to; the default product is used when it is omitted",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "size": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "unit_cost_cents": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "valid_from": {
+                    "description": "ValidFrom is when the change takes effect; it takes effect now when omitted",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "weight_grams": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "handlers.DimensionsPayload": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "length": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "width": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "handlers.PackResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "dimensions_mm": {
+                    "$ref": "#/definitions/handlers.DimensionsPayload"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "revision": {
+                    "description": "Revision increases with every change to the pack and is its ETag",
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "unit_cost_cents": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_to": {
+                    "type": "string"
+                },
+                "weight_grams": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "handlers.PackSizesResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.PackResponse"
+                    }
+                }
+            }
+        },
+        "handlers.ProductRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ProductResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ProductsResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ProductResponse"
+                    }
+                }
+            }
+        },
+        "handlers.SetStockRequest": {
+            "type": "object",
+            "required": [
+                "quantity"
+            ],
+            "properties": {
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "handlers.StockLevelResponse": {
+            "type": "object",
+            "properties": {
+                "pack_id": {
+                    "type": "string"
+                },
+                "pack_size": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.StockLevelsResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "stock": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.StockLevelResponse"
+                    }
+                }
+            }
+        },
+        "handlers.UpdatePackSizeRequest": {
+            "type": "object",
+            "required": [
+                "size"
+            ],
+            "properties": {
+                "dimensions_mm": {
+                    "$ref": "#/definitions/handlers.DimensionsPayload"
+                },
+                "size": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "unit_cost_cents": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "valid_from": {
+                    "description": "ValidFrom is when the change takes effect; it takes effect now when omitted",
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "weight_grams": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "problem.Details": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "pack_not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "pack not found"
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/pack-sizes/5f0c6a1e-2b1d-4a53-9d3e-7c1f2a9b8e10"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:packs:problem:pack_not_found"
+                }
+            }
+        },
+        "service.AmountAnalysis": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "total_amount": {
+                    "type": "integer"
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "waste": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.BatchCalculationItem": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_code": {
+                    "description": "ErrorCode is the stable code of Error when it is a domain error",
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "result": {
+                    "$ref": "#/definitions/service.PackCalculationResponse"
+                }
+            }
+        },
+        "service.BatchCalculationRequest": {
+            "type": "object",
+            "required": [
+                "amounts"
+            ],
+            "properties": {
+                "alternatives": {
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "amounts": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "objective": {
+                    "$ref": "#/definitions/service.Objective"
+                },
+                "product_id": {
+                    "description": "ProductID selects the pack set; the default product is used when it is empty",
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
+        "service.BatchCalculationResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.BatchCalculationItem"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.Objective": {
+            "type": "string",
+            "enum": [
+                "min_waste",
+                "min_packs",
+                "min_cost",
+                "weighted"
+            ],
+            "x-enum-varnames": [
+                "ObjectiveMinWaste",
+                "ObjectiveMinPacks",
+                "ObjectiveMinCost",
+                "ObjectiveWeighted"
+            ]
+        },
+        "service.OrderItemResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "pack_size": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.OrderLineRequest": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "product_id": {
+                    "description": "ProductID selects the pack set of the line; the default product is used when it is empty.\nAmended lines keep the product of the line with the same number.",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "reference": {
+                    "description": "Reference is the customer's own label for the line, unique within the order",
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "service.OrderLineResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount is the requested amount; for lines created before it was recorded it equals TotalAmount",
+                    "type": "integer"
+                },
+                "combination": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.OrderItemResponse"
+                    }
+                },
+                "line": {
+                    "description": "Line numbers the lines of an order from 1, in the order they were requested",
+                    "type": "integer"
+                },
+                "objective": {
+                    "$ref": "#/definitions/service.Objective"
+                },
+                "pack_sizes": {
+                    "description": "PackSizes are the pack sizes that were available when the line was calculated",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "shipping_weight_grams": {
+                    "description": "ShippingWeight is the gross weight in grams, reported when every pack used has a weight",
+                    "type": "integer"
+                },
+                "solver_version": {
+                    "type": "string"
+                },
+                "total_amount": {
+                    "type": "integer"
+                },
+                "total_cost_cents": {
+                    "description": "TotalCost is the price of the line's packs in cents, reported when every pack used has a unit cost",
+                    "type": "integer"
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "waste": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.OrderListResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "description": "NextCursor continues the listing; it is omitted on the last page",
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.OrderResponse"
+                    }
+                }
+            }
+        },
+        "service.OrderRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount places a single-line order; leave it empty when Lines are given",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "lines": {
+                    "description": "Lines places an order of up to 100 lines, for example the lines of a purchase order",
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "$ref": "#/definitions/service.OrderLineRequest"
+                    }
+                },
+                "objective": {
+                    "$ref": "#/definitions/service.Objective"
+                },
+                "product_id": {
+                    "description": "ProductID selects the pack set of a single-line order; the default product is used when it is empty.\nAmendments keep the product of the order.",
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
+        "service.OrderResponse": {
+            "type": "object",
+            "properties": {
+                "amendable": {
+                    "description": "Amendable is true until picking starts",
+                    "type": "boolean"
+                },
+                "amount": {
+                    "description": "Amount is the requested amount; for orders created before it was recorded it equals TotalAmount",
+                    "type": "integer"
+                },
+                "combination": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.OrderItemResponse"
+                    }
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.OrderLineResponse"
+                    }
+                },
+                "objective": {
+                    "$ref": "#/definitions/service.Objective"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "pack_sizes": {
+                    "description": "PackSizes are the pack sizes that were available when the order was calculated",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "product_id": {
+                    "description": "ProductID is the product of a single-line order",
+                    "type": "string"
+                },
+                "revision": {
+                    "description": "Revision increases every time the order is amended",
+                    "type": "integer"
+                },
+                "shipping_weight_grams": {
+                    "description": "ShippingWeight is the gross weight in grams, reported when every pack used has a weight",
+                    "type": "integer"
+                },
+                "solver_version": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "confirmed",
+                        "picking",
+                        "packed",
+                        "shipped",
+                        "cancelled"
+                    ]
+                },
+                "total_amount": {
+                    "type": "integer"
+                },
+                "total_cost_cents": {
+                    "description": "TotalCost is the price of all packs in cents, reported when every pack used has a unit cost",
+                    "type": "integer"
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "transitions": {
+                    "description": "Transitions are the statuses the order may move to next",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version increases with every change to the order and is its ETag",
+                    "type": "integer"
+                },
+                "waste": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.OrderRevisionResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount is the requested amount, or the shipped total for versions created before it was recorded",
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.OrderItemResponse"
+                    }
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.OrderLineResponse"
+                    }
+                },
+                "objective": {
+                    "$ref": "#/definitions/service.Objective"
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "revised_at": {
+                    "description": "RevisedAt is when this version was replaced",
+                    "type": "string"
+                },
+                "revision": {
+                    "type": "integer"
+                },
+                "solver_version": {
+                    "type": "string"
+                },
+                "total_amount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.OrderStatusChangeResponse": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "from": {
+                    "description": "From is omitted for the status the order was created with",
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.PackAlternative": {
+            "type": "object",
+            "properties": {
+                "combination": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "shipping_weight_grams": {
+                    "type": "integer"
+                },
+                "total_amount": {
+                    "type": "integer"
+                },
+                "total_cost_cents": {
+                    "type": "integer"
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "waste": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.PackAnalysisRequest": {
+            "type": "object",
+            "properties": {
+                "sizes": {
+                    "description": "Sizes is a proposed pack set to analyse instead of the current one",
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "service.PackAnalysisResponse": {
+            "type": "object",
+            "properties": {
+                "average_packs": {
+                    "type": "number"
+                },
+                "average_waste": {
+                    "type": "number"
+                },
+                "exact_amounts": {
+                    "description": "ExactAmounts counts the amounts up to Max that ship without waste",
+                    "type": "integer"
+                },
+                "frobenius_number": {
+                    "description": "FrobeniusNumber is the largest amount no combination hits exactly, 0 when every\namount can be hit. It is omitted when GCD is above 1, as no other amount is ever hit.",
                     "type": "integer"
                 },
-                "packs": {
+                "gcd": {
+                    "description": "GCD is the greatest common divisor of the sizes; only its multiples can be hit exactly",
+                    "type": "integer"
+                },
+                "max": {
+                    "type": "integer"
+                },
+                "max_waste": {
+                    "type": "integer"
+                },
+                "pack_sizes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/handlers.PackResponse"
+                        "type": "integer"
+                    }
+                },
+                "product_id": {
+                    "description": "ProductID is set when the current pack set of a product was analysed",
+                    "type": "string"
+                },
+                "proposed": {
+                    "description": "Proposed is true when the sizes came from the request",
+                    "type": "boolean"
+                },
+                "waste_distribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.WasteBucket"
+                    }
+                },
+                "worst_cases": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.AmountAnalysis"
                     }
                 }
             }
         },
-        "handlers.UpdatePackSizeRequest": {
+        "service.PackCalculationRequest": {
             "type": "object",
             "required": [
-                "size"
+                "amount"
             ],
             "properties": {
-                "size": {
+                "alternatives": {
+                    "description": "Alternatives asks for the N best combinations, each shipping a different total",
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 0
+                },
+                "amount": {
                     "type": "integer",
                     "minimum": 1
+                },
+                "objective": {
+                    "$ref": "#/definitions/service.Objective"
+                },
+                "product_id": {
+                    "description": "ProductID selects the pack set; the default product is used when it is empty",
+                    "type": "string",
+                    "format": "uuid"
                 }
             }
         },
-        "service.OrderItemResponse": {
+        "service.PackCalculationResponse": {
             "type": "object",
             "properties": {
+                "alternatives": {
+                    "description": "Alternatives lists the requested number of best combinations ranked by the\nobjective, starting with the one above",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PackAlternative"
+                    }
+                },
                 "amount": {
                     "type": "integer"
                 },
-                "pack_size": {
+                "combination": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "objective": {
+                    "$ref": "#/definitions/service.Objective"
+                },
+                "optimal": {
+                    "description": "Optimal is false when the solver ran out of time and returned the best\ncombination it had found so far",
+                    "type": "boolean"
+                },
+                "pack_sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "product_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "shipping_weight_grams": {
+                    "description": "ShippingWeight is the gross weight in grams, reported when every pack used has a weight",
                     "type": "integer"
                 },
-                "quantity": {
+                "total_amount": {
+                    "type": "integer"
+                },
+                "total_cost_cents": {
+                    "description": "TotalCost is the price of all packs in cents, reported when every pack used has a unit cost",
+                    "type": "integer"
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "waste": {
                     "type": "integer"
                 }
             }
         },
-        "service.OrderRequest": {
+        "service.PackSetRequest": {
+            "type": "object",
+            "properties": {
+                "product_id": {
+                    "description": "ProductID selects the product; the default product is used when it is empty",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "valid_from": {
+                    "description": "ValidFrom is when the new pack set takes effect; it takes effect now when omitted",
+                    "type": "string",
+                    "format": "date-time"
+                }
+            }
+        },
+        "service.PackSetResponse": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "description": "Added are the sizes that are created",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "dry_run": {
+                    "description": "DryRun is true when the changes were not applied",
+                    "type": "boolean"
+                },
+                "pack_sizes": {
+                    "description": "PackSizes is the resulting pack set",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "product_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "removed": {
+                    "description": "Removed are the sizes that are retired",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "unchanged": {
+                    "description": "Unchanged are the sizes the product keeps",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version is the ETag of the product's pack set: of the resulting set, or\nof the set the changes apply to on a dry run",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.RecommendationRequest": {
             "type": "object",
             "required": [
-                "amount"
+                "count",
+                "max_size",
+                "min_size"
             ],
             "properties": {
-                "amount": {
+                "count": {
+                    "description": "Count is the number of sizes in the recommended set, fixed sizes included",
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 1
+                },
+                "created_from": {
+                    "description": "CreatedFrom and CreatedTo limit the history to orders created in that period",
+                    "type": "string"
+                },
+                "created_to": {
+                    "type": "string"
+                },
+                "fixed_sizes": {
+                    "description": "FixedSizes are always part of the recommended set",
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "max_size": {
+                    "type": "integer",
+                    "maximum": 1000000,
+                    "minimum": 1
+                },
+                "min_size": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "product_id": {
+                    "description": "ProductID selects the order history; the default product is used when it is empty",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "step": {
+                    "description": "Step spaces the candidate sizes; by default at most 200 are spread over the range",
                     "type": "integer",
                     "minimum": 1
                 }
             }
         },
-        "service.OrderResponse": {
+        "service.RecommendationResponse": {
             "type": "object",
             "properties": {
-                "amount": {
+                "change": {
+                    "description": "Change is the recommended totals minus the current ones; negative values are savings",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.SimulationChanges"
+                        }
+                    ]
+                },
+                "current": {
+                    "description": "Current is omitted when the product has no pack sizes yet, or sizes too\nlarge to analyse",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.SimulationTotals"
+                        }
+                    ]
+                },
+                "current_pack_sizes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "distinct_amounts": {
                     "type": "integer"
                 },
-                "combination": {
-                    "type": "object",
-                    "additionalProperties": {
+                "evaluations": {
+                    "description": "Evaluations counts the pack sets scored during the search",
+                    "type": "integer"
+                },
+                "excluded_lines": {
+                    "description": "ExcludedLines counts the lines above the largest amount taken into account",
+                    "type": "integer"
+                },
+                "fixed_sizes": {
+                    "type": "array",
+                    "items": {
                         "type": "integer"
                     }
                 },
-                "items": {
+                "lines": {
+                    "type": "integer"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "pack_sizes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/service.OrderItemResponse"
+                        "type": "integer"
                     }
                 },
-                "order_id": {
+                "product_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "recommended": {
+                    "$ref": "#/definitions/service.SimulationTotals"
+                },
+                "requested_amount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.SimulationChanges": {
+            "type": "object",
+            "properties": {
+                "total_cost_cents": {
+                    "type": "integer"
+                },
+                "total_cost_percent": {
+                    "type": "number"
+                },
+                "total_packs": {
+                    "type": "integer"
+                },
+                "total_packs_percent": {
+                    "type": "number"
+                },
+                "waste": {
+                    "type": "integer"
+                },
+                "waste_percent": {
+                    "type": "number"
+                }
+            }
+        },
+        "service.SimulationPack": {
+            "type": "object",
+            "required": [
+                "size"
+            ],
+            "properties": {
+                "size": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "unit_cost_cents": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "service.SimulationRequest": {
+            "type": "object",
+            "required": [
+                "packs"
+            ],
+            "properties": {
+                "created_from": {
+                    "description": "CreatedFrom and CreatedTo limit the replay to orders created in that period",
                     "type": "string"
                 },
+                "created_to": {
+                    "type": "string"
+                },
+                "objective": {
+                    "description": "Objective solves the replayed amounts; the default objective is used when it is empty",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.Objective"
+                        }
+                    ]
+                },
+                "packs": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/service.SimulationPack"
+                    }
+                },
+                "product_id": {
+                    "description": "ProductID selects the order history; the default product is used when it is empty",
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
+        "service.SimulationResponse": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "objective": {
+                    "$ref": "#/definitions/service.Objective"
+                },
                 "pack_sizes": {
                     "type": "array",
                     "items": {
                         "type": "integer"
                     }
                 },
+                "processed_orders": {
+                    "description": "ProcessedOrders counts the orders replayed so far",
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "result": {
+                    "$ref": "#/definitions/service.SimulationResult"
+                },
+                "status": {
+                    "$ref": "#/definitions/service.SimulationStatus"
+                }
+            }
+        },
+        "service.SimulationResult": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "$ref": "#/definitions/service.SimulationTotals"
+                },
+                "change": {
+                    "$ref": "#/definitions/service.SimulationChanges"
+                },
+                "lines": {
+                    "description": "Lines counts the replayed order lines of the product",
+                    "type": "integer"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "requested_amount": {
+                    "type": "integer"
+                },
+                "simulated": {
+                    "$ref": "#/definitions/service.SimulationTotals"
+                }
+            }
+        },
+        "service.SimulationStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "completed",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "SimulationPending",
+                "SimulationRunning",
+                "SimulationCompleted",
+                "SimulationFailed"
+            ]
+        },
+        "service.SimulationTotals": {
+            "type": "object",
+            "properties": {
                 "total_amount": {
                     "type": "integer"
                 },
+                "total_cost_cents": {
+                    "description": "TotalCost is the price of all packs in cents, reported when every pack used has a unit cost",
+                    "type": "integer"
+                },
                 "total_packs": {
                     "type": "integer"
+                },
+                "waste": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.WasteBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
                 }
             }
         }
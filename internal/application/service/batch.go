@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+)
+
+// MaxBatchSize is the largest number of amounts accepted in one batch calculation
+const MaxBatchSize = 1000
+
+// BatchCalculationRequest represents a request to calculate pack combinations for many amounts
+type BatchCalculationRequest struct {
+	Amounts []int64 `json:"amounts" binding:"required,min=1,max=1000"`
+	// ProductID selects the pack set; the default product is used when it is empty
+	ProductID    string    `json:"product_id,omitempty" binding:"omitempty,uuid" format:"uuid"`
+	Objective    Objective `json:"objective,omitempty"`
+	Alternatives int       `json:"alternatives,omitempty" binding:"omitempty,min=0,max=10"`
+}
+
+// BatchCalculationItem is the outcome for one amount of a batch, in input order
+type BatchCalculationItem struct {
+	Index  int                      `json:"index"`
+	Amount int64                    `json:"amount"`
+	Result *PackCalculationResponse `json:"result,omitempty"`
+	Error  string                   `json:"error,omitempty"`
+	// ErrorCode is the stable code of Error when it is a domain error
+	ErrorCode string `json:"error_code,omitempty"`
+
+	// err is the error behind Error, kept for counting failures
+	err error
+}
+
+// BatchCalculationResponse represents the response with one item per requested amount
+type BatchCalculationResponse struct {
+	Count     int                    `json:"count"`
+	Succeeded int                    `json:"succeeded"`
+	Failed    int                    `json:"failed"`
+	Items     []BatchCalculationItem `json:"items"`
+}
+
+// CalculateBatch calculates pack combinations for many amounts. The pack set is
+// loaded once and the amounts are solved concurrently by a bounded pool of
+// workers. Each item gets the same result or error as a single
+// CalculateOptimalPacks call for its amount.
+func (s *PackService) CalculateBatch(ctx context.Context, req BatchCalculationRequest) (*BatchCalculationResponse, error) {
+	s.logger.Info("Calculating batch of %d amounts", len(req.Amounts))
+
+	if len(req.Amounts) == 0 || len(req.Amounts) > MaxBatchSize {
+		s.logger.Error("Invalid batch size: %d", len(req.Amounts))
+		return nil, fmt.Errorf("%w: got %d amounts, allowed 1 to %d", entity.ErrInvalidBatch, len(req.Amounts), MaxBatchSize)
+	}
+
+	packs, err := s.productPacks(ctx, &req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]BatchCalculationItem, len(req.Amounts))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(s.batchWorkers, len(req.Amounts)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				items[i] = s.calculateBatchItem(ctx, req, i, packs)
+			}
+		}()
+	}
+	for i := range req.Amounts {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	response := &BatchCalculationResponse{
+		Count: len(items),
+		Items: items,
+	}
+	for _, item := range items {
+		if item.err != nil {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+
+	s.logger.Info("Batch calculation completed - Succeeded: %d, Failed: %d", response.Succeeded, response.Failed)
+	return response, nil
+}
+
+// calculateBatchItem solves the amount at index i of a batch
+func (s *PackService) calculateBatchItem(ctx context.Context, req BatchCalculationRequest, i int, packs []entity.Pack) BatchCalculationItem {
+	item := BatchCalculationItem{
+		Index:  i,
+		Amount: req.Amounts[i],
+	}
+
+	if err := ctx.Err(); err != nil {
+		item.err = err
+	} else {
+		item.Result, item.err = s.calculateWith(ctx, PackCalculationRequest{
+			Amount:       req.Amounts[i],
+			ProductID:    req.ProductID,
+			Objective:    req.Objective,
+			Alternatives: req.Alternatives,
+		}, packs, nil)
+	}
+
+	if item.err != nil {
+		item.Error = item.err.Error()
+		if domainErr, ok := entity.AsError(item.err); ok {
+			item.ErrorCode = domainErr.Code()
+		}
+	}
+	return item
+}
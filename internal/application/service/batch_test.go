@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+)
+
+func TestPackService_CalculateBatch(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	config := DefaultSolverConfig()
+	config.BatchWorkers = 3
+	service := NewPackService(mockRepo, config, logger.GetLogger())
+
+	amounts := []int64{1, 250, 251, 501, 0, 12001, -5, 500000}
+	result, err := service.CalculateBatch(context.Background(), BatchCalculationRequest{Amounts: amounts})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if mockRepo.listCalls != 1 {
+		t.Errorf("Expected pack set to be loaded once, got %d loads", mockRepo.listCalls)
+	}
+
+	if result.Count != len(amounts) || len(result.Items) != len(amounts) {
+		t.Fatalf("Expected %d items, got %d", len(amounts), len(result.Items))
+	}
+	if result.Succeeded != 6 || result.Failed != 2 {
+		t.Errorf("Expected 6 succeeded and 2 failed, got %d and %d", result.Succeeded, result.Failed)
+	}
+
+	for i, item := range result.Items {
+		if item.Index != i || item.Amount != amounts[i] {
+			t.Errorf("Item %d: expected index %d and amount %d, got %d and %d", i, i, amounts[i], item.Index, item.Amount)
+		}
+
+		single, singleErr := service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{Amount: amounts[i]})
+		if singleErr != nil {
+			if !errors.Is(item.err, entity.ErrInvalidAmount) || item.Error != singleErr.Error() || item.ErrorCode != "amount_invalid" || item.Result != nil {
+				t.Errorf("Item %d: expected error %q, got %q", i, singleErr, item.Error)
+			}
+			continue
+		}
+
+		if item.Result == nil {
+			t.Errorf("Item %d: expected a result, got error %q", i, item.Error)
+			continue
+		}
+		if item.Result.TotalAmount != single.TotalAmount || item.Result.TotalPacks != single.TotalPacks ||
+			len(item.Result.Combination) != len(single.Combination) {
+			t.Errorf("Item %d: batch result %+v differs from single result %+v", i, item.Result, single)
+		}
+		for size, count := range single.Combination {
+			if item.Result.Combination[size] != count {
+				t.Errorf("Item %d: expected combination %v, got %v", i, single.Combination, item.Result.Combination)
+				break
+			}
+		}
+	}
+}
+
+func TestPackService_CalculateBatch_InvalidSize(t *testing.T) {
+	service := NewPackService(NewMockPackRepository(), DefaultSolverConfig(), logger.GetLogger())
+
+	if _, err := service.CalculateBatch(context.Background(), BatchCalculationRequest{}); !errors.Is(err, entity.ErrInvalidBatch) {
+		t.Errorf("Expected ErrInvalidBatch for an empty batch, got %v", err)
+	}
+
+	amounts := make([]int64, MaxBatchSize+1)
+	if _, err := service.CalculateBatch(context.Background(), BatchCalculationRequest{Amounts: amounts}); !errors.Is(err, entity.ErrInvalidBatch) {
+		t.Errorf("Expected ErrInvalidBatch for an oversized batch, got %v", err)
+	}
+}
+
+func TestPackService_CalculateBatch_Cancelled(t *testing.T) {
+	service := NewPackService(NewMockPackRepository(), DefaultSolverConfig(), logger.GetLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := service.CalculateBatch(ctx, BatchCalculationRequest{Amounts: []int64{250, 500}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, item := range result.Items {
+		if !errors.Is(item.err, context.Canceled) {
+			t.Errorf("Expected item %d to fail with context.Canceled, got %v", item.Index, item.err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+)
+
+const (
+	// DefaultIdempotencyTTL is how long responses are kept when no TTL is configured
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// DefaultIdempotencyLease is how long a claim without a response holds its
+	// key when no lease is configured
+	DefaultIdempotencyLease = time.Minute
+	// MaxIdempotencyKeyLength is the longest idempotency key accepted
+	MaxIdempotencyKeyLength = 255
+)
+
+// IdempotencyService makes retried requests return the response of the
+// first attempt instead of repeating its side effects
+type IdempotencyService struct {
+	repo   repository.IdempotencyRepository
+	ttl    time.Duration
+	lease  time.Duration
+	logger *logger.Logger
+	now    func() time.Time
+}
+
+// NewIdempotencyService creates a new idempotency service. Responses are kept
+// for ttl, or DefaultIdempotencyTTL when ttl is not positive. A claim that
+// never gets a response, e.g. because its process died, can be taken over
+// after lease, or DefaultIdempotencyLease when lease is not positive; it
+// should outlast the requests it guards.
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl, lease time.Duration, logger *logger.Logger) *IdempotencyService {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	if lease <= 0 {
+		lease = DefaultIdempotencyLease
+	}
+
+	return &IdempotencyService{
+		repo:   repo,
+		ttl:    ttl,
+		lease:  lease,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// StoredResponse is a response recorded for an idempotency key
+type StoredResponse struct {
+	StatusCode  int
+	ContentType string
+	// Headers are the response headers replayed along with the body
+	Headers map[string]string
+	Body    []byte
+}
+
+// Begin claims key for a request on scope with the given payload fingerprint.
+// It returns the stored response when the key was already used for the same
+// request and that request has finished, or nil when the caller should
+// handle the request and then call Complete or Release.
+func (s *IdempotencyService) Begin(ctx context.Context, scope, key, fingerprint string) (*StoredResponse, error) {
+	if err := validateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	existing, claimed, err := s.repo.Claim(ctx, repository.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.lease),
+	})
+	if err != nil {
+		s.logger.Error("Failed to claim idempotency key %q: %v", key, err)
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if claimed {
+		s.logger.Debug("Claimed idempotency key %q for %s", key, scope)
+		return nil, nil
+	}
+
+	if existing.Fingerprint != fingerprint {
+		s.logger.Warn("Idempotency key %q reused with a different request on %s", key, scope)
+		return nil, entity.ErrIdempotencyKeyReused
+	}
+	if !existing.Completed {
+		s.logger.Warn("Idempotency key %q is still in flight on %s", key, scope)
+		return nil, entity.ErrIdempotencyKeyInFlight
+	}
+
+	s.logger.Info("Replaying response for idempotency key %q on %s", key, scope)
+	return &StoredResponse{
+		StatusCode:  existing.StatusCode,
+		ContentType: existing.ContentType,
+		Headers:     existing.Headers,
+		Body:        existing.Body,
+	}, nil
+}
+
+// Complete stores the response of a request started with Begin
+func (s *IdempotencyService) Complete(ctx context.Context, scope, key, fingerprint string, response StoredResponse) error {
+	now := s.now()
+	err := s.repo.Complete(ctx, repository.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		Completed:   true,
+		StatusCode:  response.StatusCode,
+		ContentType: response.ContentType,
+		Headers:     response.Headers,
+		Body:        response.Body,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	})
+	if err != nil {
+		s.logger.Error("Failed to store response for idempotency key %q: %v", key, err)
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release gives up a key claimed with Begin, so a retry handles the request again
+func (s *IdempotencyService) Release(ctx context.Context, scope, key string) error {
+	if err := s.repo.Release(ctx, scope, key); err != nil {
+		s.logger.Error("Failed to release idempotency key %q: %v", key, err)
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func validateIdempotencyKey(key string) error {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return entity.ErrInvalidIdempotencyKey
+	}
+	for _, r := range key {
+		if r < 0x21 || r > 0x7e {
+			return entity.ErrInvalidIdempotencyKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+)
+
+// MockIdempotencyRepository implements repository.IdempotencyRepository for testing
+type MockIdempotencyRepository struct {
+	records map[string]repository.IdempotencyRecord
+}
+
+func NewMockIdempotencyRepository() *MockIdempotencyRepository {
+	return &MockIdempotencyRepository{
+		records: make(map[string]repository.IdempotencyRecord),
+	}
+}
+
+func (m *MockIdempotencyRepository) Claim(ctx context.Context, record repository.IdempotencyRecord) (*repository.IdempotencyRecord, bool, error) {
+	id := record.Scope + " " + record.Key
+	if existing, ok := m.records[id]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		return &existing, false, nil
+	}
+	m.records[id] = record
+	return nil, true, nil
+}
+
+func (m *MockIdempotencyRepository) Complete(ctx context.Context, record repository.IdempotencyRecord) error {
+	m.records[record.Scope+" "+record.Key] = record
+	return nil
+}
+
+func (m *MockIdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	delete(m.records, scope+" "+key)
+	return nil
+}
+
+// newTestIdempotencyService returns a service with a clock the test can move
+func newTestIdempotencyService(ttl time.Duration) (*IdempotencyService, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	service := NewIdempotencyService(NewMockIdempotencyRepository(), ttl, 0, logger.GetLogger())
+	service.now = func() time.Time { return now }
+	return service, &now
+}
+
+func TestIdempotencyService_Replay(t *testing.T) {
+	service, _ := newTestIdempotencyService(time.Hour)
+	ctx := context.Background()
+	scope := "POST /api/v1/orders"
+
+	stored, err := service.Begin(ctx, scope, "key-1", "payload-a")
+	if err != nil || stored != nil {
+		t.Fatalf("Expected the first request to claim the key, got %v, %v", stored, err)
+	}
+
+	// A retry while the first request is still running must not run it twice
+	if _, err := service.Begin(ctx, scope, "key-1", "payload-a"); !errors.Is(err, entity.ErrIdempotencyKeyInFlight) {
+		t.Errorf("Expected ErrIdempotencyKeyInFlight, got %v", err)
+	}
+
+	response := StoredResponse{
+		StatusCode:  201,
+		ContentType: "application/json",
+		Headers:     map[string]string{"ETag": `"1"`, "Location": "/api/v1/orders/1"},
+		Body:        []byte(`{"order_id":"1"}`),
+	}
+	if err := service.Complete(ctx, scope, "key-1", "payload-a", response); err != nil {
+		t.Fatalf("Unexpected error completing request: %v", err)
+	}
+
+	stored, err = service.Begin(ctx, scope, "key-1", "payload-a")
+	if err != nil {
+		t.Fatalf("Unexpected error replaying request: %v", err)
+	}
+	if stored == nil {
+		t.Fatal("Expected the stored response to be replayed")
+	}
+	if stored.StatusCode != 201 || stored.ContentType != "application/json" || string(stored.Body) != `{"order_id":"1"}` {
+		t.Errorf("Expected the original response, got %d %s %s", stored.StatusCode, stored.ContentType, stored.Body)
+	}
+	if stored.Headers["ETag"] != `"1"` || stored.Headers["Location"] != "/api/v1/orders/1" {
+		t.Errorf("Expected the original headers, got %v", stored.Headers)
+	}
+
+	if _, err := service.Begin(ctx, scope, "key-1", "payload-b"); !errors.Is(err, entity.ErrIdempotencyKeyReused) {
+		t.Errorf("Expected ErrIdempotencyKeyReused for a different payload, got %v", err)
+	}
+
+	// Keys are scoped to the endpoint they were used on
+	stored, err = service.Begin(ctx, "POST /web/orders", "key-1", "payload-b")
+	if err != nil || stored != nil {
+		t.Errorf("Expected the key to be free on another endpoint, got %v, %v", stored, err)
+	}
+}
+
+func TestIdempotencyService_Expiry(t *testing.T) {
+	service, now := newTestIdempotencyService(time.Hour)
+	ctx := context.Background()
+	scope := "POST /api/v1/orders"
+
+	if _, err := service.Begin(ctx, scope, "key-1", "payload-a"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := service.Complete(ctx, scope, "key-1", "payload-a", StoredResponse{StatusCode: 201}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	*now = now.Add(59 * time.Minute)
+	if _, err := service.Begin(ctx, scope, "key-1", "payload-b"); !errors.Is(err, entity.ErrIdempotencyKeyReused) {
+		t.Errorf("Expected the key to be taken before it expires, got %v", err)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	stored, err := service.Begin(ctx, scope, "key-1", "payload-b")
+	if err != nil || stored != nil {
+		t.Errorf("Expected an expired key to be claimable again, got %v, %v", stored, err)
+	}
+}
+
+func TestIdempotencyService_Lease(t *testing.T) {
+	service, now := newTestIdempotencyService(time.Hour)
+	ctx := context.Background()
+	scope := "POST /api/v1/orders"
+
+	if service.lease != DefaultIdempotencyLease {
+		t.Errorf("Expected default lease %v, got %v", DefaultIdempotencyLease, service.lease)
+	}
+
+	// The first request never completes, e.g. because its process died
+	if _, err := service.Begin(ctx, scope, "key-1", "payload-a"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	*now = now.Add(DefaultIdempotencyLease - time.Second)
+	if _, err := service.Begin(ctx, scope, "key-1", "payload-a"); !errors.Is(err, entity.ErrIdempotencyKeyInFlight) {
+		t.Errorf("Expected the key to stay in flight during the lease, got %v", err)
+	}
+
+	*now = now.Add(2 * time.Second)
+	stored, err := service.Begin(ctx, scope, "key-1", "payload-a")
+	if err != nil || stored != nil {
+		t.Fatalf("Expected a retry to take over the lapsed claim, got %v, %v", stored, err)
+	}
+
+	// A completed response is kept for the TTL, not the lease
+	if err := service.Complete(ctx, scope, "key-1", "payload-a", StoredResponse{StatusCode: 201}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	*now = now.Add(2 * DefaultIdempotencyLease)
+	stored, err = service.Begin(ctx, scope, "key-1", "payload-a")
+	if err != nil || stored == nil {
+		t.Errorf("Expected the response to be replayed after the lease, got %v, %v", stored, err)
+	}
+}
+
+func TestIdempotencyService_Release(t *testing.T) {
+	service, _ := newTestIdempotencyService(0)
+	ctx := context.Background()
+	scope := "POST /api/v1/orders"
+
+	if service.ttl != DefaultIdempotencyTTL {
+		t.Errorf("Expected default TTL %v, got %v", DefaultIdempotencyTTL, service.ttl)
+	}
+
+	if _, err := service.Begin(ctx, scope, "key-1", "payload-a"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := service.Release(ctx, scope, "key-1"); err != nil {
+		t.Fatalf("Unexpected error releasing key: %v", err)
+	}
+
+	stored, err := service.Begin(ctx, scope, "key-1", "payload-b")
+	if err != nil || stored != nil {
+		t.Errorf("Expected a released key to be claimable again, got %v, %v", stored, err)
+	}
+}
+
+func TestIdempotencyService_InvalidKey(t *testing.T) {
+	service, _ := newTestIdempotencyService(time.Hour)
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "Empty", key: ""},
+		{name: "Too long", key: strings.Repeat("k", MaxIdempotencyKeyLength+1)},
+		{name: "Contains a space", key: "order 1"},
+		{name: "Not ASCII", key: "bestellung-ä"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Begin(context.Background(), "POST /api/v1/orders", tt.key, "payload")
+			if !errors.Is(err, entity.ErrInvalidIdempotencyKey) {
+				t.Errorf("Expected ErrInvalidIdempotencyKey, got %v", err)
+			}
+		})
+	}
+}
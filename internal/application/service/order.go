@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
 	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
@@ -14,99 +16,391 @@ import (
 type OrderService struct {
 	orderRepo   repository.OrderRepository
 	packRepo    repository.PackRepository
+	stockRepo   repository.StockRepository
 	packService *PackService
 	logger      *logger.Logger
 }
 
 // NewOrderService creates a new order service
-func NewOrderService(orderRepo repository.OrderRepository, packRepo repository.PackRepository, packService *PackService, logger *logger.Logger) *OrderService {
+func NewOrderService(orderRepo repository.OrderRepository, packRepo repository.PackRepository, stockRepo repository.StockRepository, packService *PackService, logger *logger.Logger) *OrderService {
 	return &OrderService{
 		orderRepo:   orderRepo,
 		packRepo:    packRepo,
+		stockRepo:   stockRepo,
 		packService: packService,
 		logger:      logger,
 	}
 }
 
-// OrderRequest represents a request to create an order
+// OrderRequest represents a request to create or amend an order. A request
+// either has a single Amount or a list of Lines, each solved on its own.
 type OrderRequest struct {
-	Amount int `json:"amount" form:"amount" binding:"required,min=1"`
+	// Amount places a single-line order; leave it empty when Lines are given
+	Amount int64 `json:"amount,omitempty" form:"amount" binding:"omitempty,min=1"`
+	// ProductID selects the pack set of a single-line order; the default product is used when it is empty.
+	// Amendments keep the product of the order.
+	ProductID string    `json:"product_id,omitempty" form:"product_id" binding:"omitempty,uuid" format:"uuid"`
+	Objective Objective `json:"objective,omitempty" form:"objective"`
+	// Lines places an order of up to 100 lines, for example the lines of a purchase order
+	Lines []OrderLineRequest `json:"lines,omitempty" form:"-" binding:"omitempty,max=100,dive"`
 }
 
-// OrderResponse represents the response with order details
+// OrderLineRequest represents one line of an order request
+type OrderLineRequest struct {
+	// Reference is the customer's own label for the line, unique within the order
+	Reference string `json:"reference,omitempty" binding:"max=100"`
+	Amount    int64  `json:"amount" binding:"required,min=1"`
+	// ProductID selects the pack set of the line; the default product is used when it is empty.
+	// Amended lines keep the product of the line with the same number.
+	ProductID string `json:"product_id,omitempty" binding:"omitempty,uuid" format:"uuid"`
+}
+
+// orderLines returns the lines a request asks for. A request without lines
+// is a single line for its Amount.
+func (r OrderRequest) orderLines() ([]OrderLineRequest, error) {
+	if len(r.Lines) == 0 {
+		return []OrderLineRequest{{Amount: r.Amount, ProductID: r.ProductID}}, nil
+	}
+	if r.Amount != 0 || r.ProductID != "" {
+		return nil, fmt.Errorf("%w: amount and product_id are given per line when an order has lines", entity.ErrInvalidOrderLine)
+	}
+	if len(r.Lines) > entity.MaxOrderLines {
+		return nil, fmt.Errorf("%w: an order has at most %d lines", entity.ErrInvalidOrderLine, entity.MaxOrderLines)
+	}
+	return r.Lines, nil
+}
+
+// OrderResponse represents the response with order details. Amounts, packs,
+// waste, cost and weight are grand totals over all lines. The fields that
+// describe a single calculation are only set for single-line orders.
 type OrderResponse struct {
-	OrderID     uuid.UUID           `json:"order_id"`
-	Amount      int                 `json:"amount"`
-	PackSizes   []int               `json:"pack_sizes"`
-	Combination map[int]int         `json:"combination"`
-	TotalPacks  int                 `json:"total_packs"`
-	TotalAmount int                 `json:"total_amount"`
-	Items       []OrderItemResponse `json:"items"`
+	OrderID uuid.UUID `json:"order_id"`
+	// ProductID is the product of a single-line order
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+	Status    string     `json:"status" enums:"draft,confirmed,picking,packed,shipped,cancelled"`
+	// Transitions are the statuses the order may move to next
+	Transitions []string `json:"transitions"`
+	// Revision increases every time the order is amended
+	Revision int `json:"revision"`
+	// Version increases with every change to the order and is its ETag
+	Version int `json:"version"`
+	// Amendable is true until picking starts
+	Amendable bool `json:"amendable"`
+	// Amount is the requested amount; for orders created before it was recorded it equals TotalAmount
+	Amount    int64     `json:"amount"`
+	Objective Objective `json:"objective,omitempty"`
+	// PackSizes are the pack sizes that were available when the order was calculated
+	PackSizes     []int64             `json:"pack_sizes,omitempty"`
+	SolverVersion string              `json:"solver_version,omitempty"`
+	Combination   map[int64]int64     `json:"combination,omitempty"`
+	TotalPacks    int64               `json:"total_packs"`
+	TotalAmount   int64               `json:"total_amount"`
+	Waste         int64               `json:"waste"`
+	Items         []OrderItemResponse `json:"items,omitempty"`
+	// TotalCost is the price of all packs in cents, reported when every pack used has a unit cost
+	TotalCost *int64 `json:"total_cost_cents,omitempty"`
+	// ShippingWeight is the gross weight in grams, reported when every pack used has a weight
+	ShippingWeight *int64              `json:"shipping_weight_grams,omitempty"`
+	Lines          []OrderLineResponse `json:"lines"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+// OrderLineResponse represents one line of an order with its own totals
+type OrderLineResponse struct {
+	// Line numbers the lines of an order from 1, in the order they were requested
+	Line      int       `json:"line"`
+	Reference string    `json:"reference,omitempty"`
+	ProductID uuid.UUID `json:"product_id"`
+	// Amount is the requested amount; for lines created before it was recorded it equals TotalAmount
+	Amount    int64     `json:"amount"`
+	Objective Objective `json:"objective,omitempty"`
+	// PackSizes are the pack sizes that were available when the line was calculated
+	PackSizes     []int64             `json:"pack_sizes"`
+	SolverVersion string              `json:"solver_version,omitempty"`
+	Combination   map[int64]int64     `json:"combination"`
+	TotalPacks    int64               `json:"total_packs"`
+	TotalAmount   int64               `json:"total_amount"`
+	Waste         int64               `json:"waste"`
+	Items         []OrderItemResponse `json:"items"`
+	// TotalCost is the price of the line's packs in cents, reported when every pack used has a unit cost
+	TotalCost *int64 `json:"total_cost_cents,omitempty"`
+	// ShippingWeight is the gross weight in grams, reported when every pack used has a weight
+	ShippingWeight *int64 `json:"shipping_weight_grams,omitempty"`
+}
+
+// OrderStatusChangeResponse represents one entry of an order's status history
+type OrderStatusChangeResponse struct {
+	// From is omitted for the status the order was created with
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// OrderRevisionResponse represents a replaced version of an amended order.
+// The fields that describe a single calculation are only set for versions
+// with one line.
+type OrderRevisionResponse struct {
+	Revision int `json:"revision"`
+	// Amount is the requested amount, or the shipped total for versions created before it was recorded
+	Amount        int64               `json:"amount"`
+	Objective     Objective           `json:"objective,omitempty"`
+	PackSizes     []int64             `json:"pack_sizes,omitempty"`
+	SolverVersion string              `json:"solver_version,omitempty"`
+	Items         []OrderItemResponse `json:"items,omitempty"`
+	TotalAmount   int64               `json:"total_amount"`
+	Lines         []OrderLineResponse `json:"lines"`
+	// RevisedAt is when this version was replaced
+	RevisedAt time.Time `json:"revised_at"`
 }
 
 // OrderItemResponse represents an order item in the response
 type OrderItemResponse struct {
-	PackSize int `json:"pack_size"`
-	Quantity int `json:"quantity"`
-	Amount   int `json:"amount"`
+	PackSize int64 `json:"pack_size"`
+	Quantity int64 `json:"quantity"`
+	Amount   int64 `json:"amount"`
 }
 
-// CreateOrderFromCalculation creates an order from pack calculation
+// CreateOrderFromCalculation creates an order from pack calculation. Every
+// line is calculated on its own, using only packs that are in stock; the
+// repository takes them out of stock in the same transaction that stores the order.
 func (s *OrderService) CreateOrderFromCalculation(ctx context.Context, req OrderRequest) (*OrderResponse, error) {
-	s.logger.Info("Creating order from calculation for amount: %d", req.Amount)
+	lineRequests, err := req.orderLines()
+	if err != nil {
+		s.logger.Warn("Invalid order lines: %v", err)
+		return nil, err
+	}
+	s.logger.Info("Creating order from calculation with %d lines", len(lineRequests))
+
+	lines, packs, err := s.calculateLines(ctx, lineRequests, req.Objective, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	calcReq := PackCalculationRequest(req)
+	order, err := entity.NewOrderWithLines(uuid.New(), lines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
 
-	calculation, err := s.packService.CalculateOptimalPacks(ctx, calcReq)
+	err = s.orderRepo.Create(ctx, order)
 	if err != nil {
-		s.logger.Error("Failed to calculate optimal packs: %v", err)
-		return nil, fmt.Errorf("failed to calculate optimal packs: %w", err)
+		s.logger.Error("Failed to create order: %v", err)
+		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
 
-	order := entity.NewOrder(uuid.New())
+	response := newOrderResponse(order, packs)
 
-	var items []OrderItemResponse
-	for packSize, quantity := range calculation.Combination {
-		packs := s.packRepo.List(ctx)
-		var pack *entity.Pack
-		for _, p := range packs {
-			if p.Size() == packSize {
-				pack = &p
-				break
+	s.logger.Info("Order created successfully with ID: %s", order.ID())
+	return &response, nil
+}
+
+// calculateLines solves every requested line against the current pack set of
+// its product and returns the lines with the packs they were solved with.
+// Lines draw on the same stock in turn, so two lines cannot both take the last
+// pack of a size. When amending, the packs the order holds go back to stock
+// first and lines without a product keep the product of the line they replace.
+func (s *OrderService) calculateLines(ctx context.Context, lineRequests []OrderLineRequest, objective Objective, amended *entity.Order) ([]entity.OrderLine, []entity.Pack, error) {
+	var previous []entity.OrderLine
+	if amended != nil {
+		previous = amended.Lines()
+	}
+
+	var allPacks []entity.Pack
+	packsByProduct := make(map[uuid.UUID][]entity.Pack)
+	stockByProduct := make(map[uuid.UUID]map[int64]int64)
+
+	lines := make([]entity.OrderLine, 0, len(lineRequests))
+	for i, lineRequest := range lineRequests {
+		number := i + 1
+
+		productID := entity.DefaultProductID
+		if lineRequest.ProductID != "" {
+			id, err := resolveProductID(lineRequest.ProductID)
+			if err != nil {
+				s.logger.Error("Invalid product provided for line %d: %v", number, err)
+				return nil, nil, fmt.Errorf("line %d: %w", number, err)
 			}
+			productID = id
+		} else if i < len(previous) {
+			productID = previous[i].ProductID()
 		}
 
-		if pack != nil {
-			err := order.AddItem(pack.Size(), quantity)
+		packs, loaded := packsByProduct[productID]
+		if !loaded {
+			var err error
+			packs, err = s.packRepo.ListByProduct(ctx, productID)
+			if err != nil {
+				s.logger.Error("Failed to load packs of product %s: %v", productID, err)
+				return nil, nil, fmt.Errorf("line %d: failed to load product packs: %w", number, err)
+			}
+			packsByProduct[productID] = packs
+			allPacks = append(allPacks, packs...)
+
+			stock, err := s.stockLevels(ctx, packs)
 			if err != nil {
-				return nil, fmt.Errorf("failed to add item to order: %w", err)
+				s.logger.Error("Failed to load stock of product %s: %v", productID, err)
+				return nil, nil, fmt.Errorf("line %d: failed to load stock levels: %w", number, err)
+			}
+			for _, line := range previous {
+				if line.ProductID() != productID {
+					continue
+				}
+				for _, item := range line.GetItems() {
+					if _, tracked := stock[item.PackageSize()]; tracked {
+						stock[item.PackageSize()] += item.Quantity()
+					}
+				}
 			}
+			stockByProduct[productID] = stock
+		}
+		stock := stockByProduct[productID]
+
+		calcReq := PackCalculationRequest{
+			Amount:    lineRequest.Amount,
+			ProductID: productID.String(),
+			Objective: objective,
+		}
+		calculation, err := s.packService.calculateWith(ctx, calcReq, packs, stock)
+		if err != nil {
+			s.logger.Error("Failed to calculate optimal packs for line %d: %v", number, err)
+			return nil, nil, fmt.Errorf("line %d: failed to calculate optimal packs: %w", number, err)
+		}
+
+		// Later lines of the same product only get what this line leaves
+		for size, quantity := range calculation.Combination {
+			if _, tracked := stock[size]; tracked {
+				stock[size] -= quantity
+			}
+		}
 
-			items = append(items, OrderItemResponse{
-				PackSize: pack.Size(),
-				Quantity: quantity,
-				Amount:   pack.Size() * quantity,
-			})
+		line, err := entity.NewCalculatedOrderLine(number, lineRequest.Reference, productID, entity.OrderCalculation{
+			RequestedAmount: calculation.Amount,
+			PackSizes:       calculation.PackSizes,
+			Objective:       string(calculation.Objective),
+			SolverVersion:   SolverVersion,
+		}, calculation.Combination)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", number, err)
 		}
+		lines = append(lines, *line)
 	}
 
-	err = s.orderRepo.Create(ctx, order)
+	return lines, allPacks, nil
+}
+
+// stockLevels returns the packs on hand by size, for the given packs with
+// tracked stock
+func (s *OrderService) stockLevels(ctx context.Context, packs []entity.Pack) (map[int64]int64, error) {
+	sizes := make(map[uuid.UUID]int64, len(packs))
+	for _, pack := range packs {
+		sizes[pack.ID()] = pack.Size()
+	}
+
+	// The size of the revision in effect counts, which a scheduled change of
+	// the pack may not have yet
+	levels, err := s.stockRepo.List(ctx)
 	if err != nil {
-		s.logger.Error("Failed to create order: %v", err)
-		return nil, fmt.Errorf("failed to create order: %w", err)
+		return nil, err
+	}
+	stock := make(map[int64]int64)
+	for _, level := range levels {
+		if size, ok := sizes[level.PackID()]; ok {
+			stock[size] = level.Quantity()
+		}
 	}
+	return stock, nil
+}
 
-	s.logger.Info("Order created successfully with ID: %s", order.ID())
+// AmendOrder recalculates the lines of an order against the current pack
+// sets of their products and replaces them. The replaced version is kept as a
+// revision. Orders can only be amended until picking starts, and only while
+// they are at version, see AnyVersion.
+func (s *OrderService) AmendOrder(ctx context.Context, id uuid.UUID, req OrderRequest, version int) (*OrderResponse, error) {
+	lineRequests, err := req.orderLines()
+	if err != nil {
+		s.logger.Warn("Invalid order lines: %v", err)
+		return nil, err
+	}
+	s.logger.Info("Amending order %s to %d lines", id, len(lineRequests))
 
-	return &OrderResponse{
-		OrderID:     order.ID(),
-		Amount:      calculation.Amount,
-		PackSizes:   calculation.PackSizes,
-		Combination: calculation.Combination,
-		TotalPacks:  calculation.TotalPacks,
-		TotalAmount: calculation.TotalAmount,
-		Items:       items,
-	}, nil
+	order, err := s.orderRepo.Get(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to get order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if err := checkVersion(order.Version(), version); err != nil {
+		s.logger.Warn("Rejected amendment of order %s: %v", id, err)
+		return nil, err
+	}
+	if !order.CanAmend() {
+		s.logger.Warn("Order %s is %s and can no longer be amended", id, order.Status())
+		return nil, fmt.Errorf("%w: order is %s", entity.ErrOrderNotAmendable, order.Status())
+	}
+
+	lines, packs, err := s.calculateLines(ctx, lineRequests, req.Objective, order)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := order.Amend(lines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to amend order: %w", err)
+	}
+
+	if err := s.orderRepo.Update(ctx, order, previous); err != nil {
+		s.logger.Error("Failed to update order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to update order: %w", err)
+	}
+
+	response := newOrderResponse(order, packs)
+
+	s.logger.Info("Order %s amended to revision %d", id, order.Revision())
+	return &response, nil
+}
+
+// GetOrderRevisions retrieves the replaced versions of an order, oldest first
+func (s *OrderService) GetOrderRevisions(ctx context.Context, id uuid.UUID) ([]OrderRevisionResponse, error) {
+	s.logger.Info("Getting revisions of order %s", id)
+
+	if _, err := s.orderRepo.Get(ctx, id); err != nil {
+		s.logger.Error("Failed to get order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	revisions, err := s.orderRepo.Revisions(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to get revisions of order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to get order revisions: %w", err)
+	}
+
+	responses := make([]OrderRevisionResponse, len(revisions))
+	for i, revision := range revisions {
+		response := OrderRevisionResponse{
+			Revision:  revision.Revision,
+			Lines:     make([]OrderLineResponse, len(revision.Lines)),
+			RevisedAt: revision.RevisedAt,
+		}
+		// Revisions are not priced; the packs may have changed since
+		for j := range revision.Lines {
+			line := newOrderLineResponse(&revision.Lines[j], nil)
+			response.Lines[j] = line
+			response.Amount += line.Amount
+			response.TotalAmount += line.TotalAmount
+		}
+
+		if len(response.Lines) == 1 {
+			line := response.Lines[0]
+			response.Objective = line.Objective
+			response.SolverVersion = line.SolverVersion
+			response.Items = line.Items
+			if _, ok := revision.Lines[0].Calculation(); ok {
+				response.PackSizes = line.PackSizes
+			}
+		}
+		responses[i] = response
+	}
+
+	return responses, nil
 }
 
 // GetOrder retrieves an order by ID
@@ -119,58 +413,296 @@ func (s *OrderService) GetOrder(ctx context.Context, id uuid.UUID) (*OrderRespon
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
-	items := order.GetItems()
-	var itemResponses []OrderItemResponse
-	combination := make(map[int]int)
-	totalPacks := 0
-	totalAmount := 0
+	packs, err := calculationPacks(ctx, s.packRepo, []entity.Order{*order})
+	if err != nil {
+		s.logger.Error("Failed to load packs of order %s: %v", order.ID(), err)
+		return nil, err
+	}
+	response := newOrderResponse(order, packs[0])
 
-	for _, item := range items {
-		itemResponses = append(itemResponses, OrderItemResponse{
+	s.logger.Info("Order retrieved successfully with ID: %s", order.ID())
+
+	return &response, nil
+}
+
+// calculationPacks returns, for each order, the revisions of the packs of its
+// products that were in effect when its lines were calculated, so that later
+// changes to the catalog do not reprice it. The packs of all orders are loaded
+// with one query.
+func calculationPacks(ctx context.Context, packRepo repository.PackRepository, orders []entity.Order) ([][]entity.Pack, error) {
+	type catalogKey struct {
+		productID uuid.UUID
+		at        time.Time
+	}
+
+	var catalogs []repository.PackCatalog
+	positions := make(map[catalogKey]int)
+	orderCatalogs := make([][]int, len(orders))
+	for i := range orders {
+		at := orders[i].CalculatedAt()
+		for _, line := range orders[i].Lines() {
+			key := catalogKey{productID: line.ProductID(), at: at.UTC()}
+			position, ok := positions[key]
+			if !ok {
+				position = len(catalogs)
+				positions[key] = position
+				catalogs = append(catalogs, repository.PackCatalog{ProductID: line.ProductID(), At: at})
+			}
+			if !slices.Contains(orderCatalogs[i], position) {
+				orderCatalogs[i] = append(orderCatalogs[i], position)
+			}
+		}
+	}
+
+	catalogPacks, err := packRepo.ListAsOfMany(ctx, catalogs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product packs: %w", err)
+	}
+
+	packs := make([][]entity.Pack, len(orders))
+	for i, positions := range orderCatalogs {
+		for _, position := range positions {
+			packs[i] = append(packs[i], catalogPacks[position]...)
+		}
+	}
+	return packs, nil
+}
+
+// newOrderResponse builds the response for a stored order; the packs of each
+// line's product are used to price and weigh the shipped combination
+func newOrderResponse(order *entity.Order, packs []entity.Pack) OrderResponse {
+	lines := order.Lines()
+
+	response := OrderResponse{
+		OrderID:     order.ID(),
+		Status:      string(order.Status()),
+		Transitions: statusNames(order.Status().Transitions()),
+		Revision:    order.Revision(),
+		Version:     order.Version(),
+		Amendable:   order.CanAmend(),
+		Lines:       make([]OrderLineResponse, len(lines)),
+		CreatedAt:   order.CreatedAt(),
+		UpdatedAt:   order.UpdatedAt(),
+	}
+
+	var totalCost, shippingWeight int64
+	costKnown, weightKnown := true, true
+	for i := range lines {
+		line := newOrderLineResponse(&lines[i], packs)
+		response.Lines[i] = line
+
+		response.Amount += line.Amount
+		response.TotalPacks += line.TotalPacks
+		response.TotalAmount += line.TotalAmount
+		response.Waste += line.Waste
+		if line.TotalCost == nil || !costKnown {
+			costKnown = false
+		} else {
+			totalCost, costKnown = entity.CheckedAdd(totalCost, *line.TotalCost)
+		}
+		if line.ShippingWeight == nil || !weightKnown {
+			weightKnown = false
+		} else {
+			shippingWeight, weightKnown = entity.CheckedAdd(shippingWeight, *line.ShippingWeight)
+		}
+	}
+	if costKnown {
+		response.TotalCost = &totalCost
+	}
+	if weightKnown {
+		response.ShippingWeight = &shippingWeight
+	}
+
+	if len(lines) == 1 {
+		line := response.Lines[0]
+		response.ProductID = &line.ProductID
+		response.Objective = line.Objective
+		response.PackSizes = line.PackSizes
+		response.SolverVersion = line.SolverVersion
+		response.Combination = line.Combination
+		response.Items = line.Items
+	}
+
+	return response
+}
+
+// newOrderLineResponse builds the response for one order line; the packs of
+// the line's product are used to price and weigh the shipped combination
+func newOrderLineResponse(line *entity.OrderLine, packs []entity.Pack) OrderLineResponse {
+	productPacks := make([]entity.Pack, 0, len(packs))
+	for _, pack := range packs {
+		if pack.ProductID() == line.ProductID() {
+			productPacks = append(productPacks, pack)
+		}
+	}
+
+	response := OrderLineResponse{
+		Line:        line.Number(),
+		Reference:   line.Reference(),
+		ProductID:   line.ProductID(),
+		Amount:      line.GetRequestedAmount(),
+		Combination: make(map[int64]int64),
+		Waste:       line.GetWaste(),
+		Items:       []OrderItemResponse{},
+	}
+
+	for _, item := range line.GetItems() {
+		response.Items = append(response.Items, OrderItemResponse{
 			PackSize: item.PackageSize(),
 			Quantity: item.Quantity(),
 			Amount:   item.GetAmount(),
 		})
 
-		combination[item.PackageSize()] = item.Quantity()
-		totalPacks += item.Quantity()
-		totalAmount += item.GetAmount()
+		response.Combination[item.PackageSize()] = item.Quantity()
+		response.TotalPacks += item.Quantity()
+		response.TotalAmount += item.GetAmount()
 	}
 
-	var packSizes []int
-	for size := range combination {
-		packSizes = append(packSizes, size)
+	response.TotalCost, response.ShippingWeight = packTotals(productPacks, response.Combination)
+
+	if calculation, ok := line.Calculation(); ok {
+		response.Objective = Objective(calculation.Objective)
+		response.PackSizes = calculation.PackSizes
+		response.SolverVersion = calculation.SolverVersion
+	} else {
+		// Lines created before calculations were recorded only know the sizes they ship
+		for size := range response.Combination {
+			response.PackSizes = append(response.PackSizes, size)
+		}
+		sortSizes(response.PackSizes)
 	}
 
-	s.logger.Info("Order retrieved successfully with ID: %s", order.ID())
+	return response
+}
 
-	return &OrderResponse{
-		OrderID:     order.ID(),
-		Amount:      totalAmount,
-		PackSizes:   packSizes,
-		Combination: combination,
-		TotalPacks:  totalPacks,
-		TotalAmount: totalAmount,
-		Items:       itemResponses,
-	}, nil
+// statusNames converts statuses for a response
+func statusNames(statuses []entity.OrderStatus) []string {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+	return names
 }
 
-// GetAllOrders retrieves all orders
+// TransitionOrder moves an order at version to the given status, see
+// AnyVersion. Illegal transitions return entity.ErrInvalidStatusTransition.
+func (s *OrderService) TransitionOrder(ctx context.Context, id uuid.UUID, status entity.OrderStatus, version int) (*OrderResponse, error) {
+	s.logger.Info("Moving order %s to %s", id, status)
+
+	order, err := s.orderRepo.Get(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to get order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if err := checkVersion(order.Version(), version); err != nil {
+		s.logger.Warn("Rejected status change of order %s: %v", id, err)
+		return nil, err
+	}
+
+	change, err := order.TransitionTo(status)
+	if err != nil {
+		s.logger.Warn("Rejected status change of order %s: %v", id, err)
+		return nil, err
+	}
+
+	// A status change does not touch the lines, so their packs can be loaded
+	// before it is stored
+	packs, err := calculationPacks(ctx, s.packRepo, []entity.Order{*order})
+	if err != nil {
+		s.logger.Error("Failed to load packs of order %s: %v", id, err)
+		return nil, err
+	}
+
+	if err := s.orderRepo.UpdateStatus(ctx, order, change); err != nil {
+		s.logger.Error("Failed to update status of order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	response := newOrderResponse(order, packs[0])
+
+	s.logger.Info("Order %s moved from %s to %s", id, change.From, change.To)
+	return &response, nil
+}
+
+// GetOrderHistory retrieves the status history of an order, oldest first
+func (s *OrderService) GetOrderHistory(ctx context.Context, id uuid.UUID) ([]OrderStatusChangeResponse, error) {
+	s.logger.Info("Getting status history of order %s", id)
+
+	if _, err := s.orderRepo.Get(ctx, id); err != nil {
+		s.logger.Error("Failed to get order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	history, err := s.orderRepo.StatusHistory(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to get status history of order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to get order status history: %w", err)
+	}
+
+	responses := make([]OrderStatusChangeResponse, len(history))
+	for i, change := range history {
+		responses[i] = OrderStatusChangeResponse{
+			From:      string(change.From),
+			To:        string(change.To),
+			ChangedAt: change.ChangedAt,
+		}
+	}
+
+	return responses, nil
+}
+
+// ListOrders retrieves one page of orders matching the query
+func (s *OrderService) ListOrders(ctx context.Context, query OrderListQuery) (*OrderListResponse, error) {
+	s.logger.Info("Listing orders sorted by %s", query.Sort)
+
+	repoQuery, err := query.repositoryQuery()
+	if err != nil {
+		s.logger.Warn("Invalid order query: %v", err)
+		return nil, err
+	}
+
+	page, err := s.orderRepo.List(ctx, repoQuery)
+	if err != nil {
+		s.logger.Error("Failed to list orders: %v", err)
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	response := &OrderListResponse{
+		Orders: make([]OrderResponse, 0, len(page.Orders)),
+		Count:  len(page.Orders),
+	}
+	packs, err := calculationPacks(ctx, s.packRepo, page.Orders)
+	if err != nil {
+		s.logger.Error("Failed to load packs of orders: %v", err)
+		return nil, err
+	}
+	for i := range page.Orders {
+		response.Orders = append(response.Orders, newOrderResponse(&page.Orders[i], packs[i]))
+	}
+	if page.Next != nil {
+		response.NextCursor = encodeOrderCursor(repoQuery.SortBy, repoQuery.Descending, *page.Next)
+	}
+
+	s.logger.Info("Successfully retrieved %d orders", response.Count)
+	return response, nil
+}
+
+// GetAllOrders retrieves all orders, newest first
 func (s *OrderService) GetAllOrders(ctx context.Context) ([]OrderResponse, error) {
 	s.logger.Info("Getting all orders")
 
-	orders := s.orderRepo.List(ctx)
 	var responses []OrderResponse
-
-	s.logger.Debug("Found %d orders to process", len(orders))
-
-	for _, order := range orders {
-		response, err := s.GetOrder(ctx, order.ID())
+	query := OrderListQuery{Limit: MaxOrderPageSize}
+	for {
+		page, err := s.ListOrders(ctx, query)
 		if err != nil {
-			s.logger.Warn("Failed to convert order %s to response: %v", order.ID(), err)
-			continue
+			return nil, err
+		}
+		responses = append(responses, page.Orders...)
+		if page.NextCursor == "" {
+			break
 		}
-		responses = append(responses, *response)
+		query.Cursor = page.NextCursor
 	}
 
 	s.logger.Info("Successfully retrieved %d orders", len(responses))
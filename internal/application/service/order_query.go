@@ -0,0 +1,148 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultOrderPageSize is the page size used when a listing does not ask for one
+	DefaultOrderPageSize = 20
+	// MaxOrderPageSize is the largest page a listing may ask for
+	MaxOrderPageSize = 100
+	// DefaultOrderSort lists the newest orders first
+	DefaultOrderSort = "-created_at"
+)
+
+// OrderListQuery represents the query parameters of an order listing.
+// Sort is a field name, prefixed with "-" for descending order.
+type OrderListQuery struct {
+	Cursor      string     `form:"cursor"`
+	Limit       int        `form:"limit"`
+	Sort        string     `form:"sort"`
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinAmount   *int64     `form:"min_amount"`
+	MaxAmount   *int64     `form:"max_amount"`
+	ProductID   string     `form:"product_id"`
+	PackSize    *int64     `form:"pack_size"`
+}
+
+// OrderListResponse represents one page of orders
+type OrderListResponse struct {
+	Orders []OrderResponse `json:"orders"`
+	Count  int             `json:"count"`
+	// NextCursor continues the listing; it is omitted on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// orderCursor is the opaque cursor handed out to clients. It remembers the
+// sort it was issued for, since a position only makes sense in that order.
+type orderCursor struct {
+	Sort      string    `json:"s"`
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"c"`
+	Amount    int64     `json:"a"`
+}
+
+// repositoryQuery validates the listing query and converts it for the repository
+func (q OrderListQuery) repositoryQuery() (repository.OrderQuery, error) {
+	query := repository.OrderQuery{
+		CreatedFrom: q.CreatedFrom,
+		CreatedTo:   q.CreatedTo,
+		MinAmount:   q.MinAmount,
+		MaxAmount:   q.MaxAmount,
+		PackSize:    q.PackSize,
+		Limit:       q.Limit,
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultOrderPageSize
+	}
+	if query.Limit < 1 || query.Limit > MaxOrderPageSize {
+		return query, fmt.Errorf("%w: limit must be between 1 and %d", entity.ErrInvalidOrderQuery, MaxOrderPageSize)
+	}
+
+	sortBy := q.Sort
+	if sortBy == "" {
+		sortBy = DefaultOrderSort
+	}
+	query.Descending = strings.HasPrefix(sortBy, "-")
+	switch field := repository.OrderSortField(strings.TrimPrefix(sortBy, "-")); field {
+	case repository.OrderSortCreatedAt, repository.OrderSortAmount:
+		query.SortBy = field
+	default:
+		return query, fmt.Errorf("%w: unknown sort %q", entity.ErrInvalidOrderQuery, q.Sort)
+	}
+
+	if q.CreatedFrom != nil && q.CreatedTo != nil && !q.CreatedFrom.Before(*q.CreatedTo) {
+		return query, fmt.Errorf("%w: created_from must be before created_to", entity.ErrInvalidOrderQuery)
+	}
+	if q.MinAmount != nil && q.MaxAmount != nil && *q.MinAmount > *q.MaxAmount {
+		return query, fmt.Errorf("%w: min_amount cannot be greater than max_amount", entity.ErrInvalidOrderQuery)
+	}
+	if q.ProductID != "" {
+		productID, err := uuid.Parse(q.ProductID)
+		if err != nil {
+			return query, fmt.Errorf("%w: invalid product_id %q", entity.ErrInvalidOrderQuery, q.ProductID)
+		}
+		query.ProductID = &productID
+	}
+	if q.PackSize != nil && *q.PackSize <= 0 {
+		return query, fmt.Errorf("%w: %w", entity.ErrInvalidOrderQuery, entity.ErrPackSize)
+	}
+
+	if q.Cursor != "" {
+		cursor, err := decodeOrderCursor(q.Cursor)
+		if err != nil {
+			return query, err
+		}
+		if cursor.Sort != sortBy {
+			return query, fmt.Errorf("%w: cursor was issued for sort %q", entity.ErrInvalidOrderQuery, cursor.Sort)
+		}
+		query.After = &repository.OrderCursor{
+			ID:        cursor.ID,
+			CreatedAt: cursor.CreatedAt,
+			Amount:    cursor.Amount,
+		}
+	}
+
+	return query, nil
+}
+
+// encodeOrderCursor returns the opaque cursor for the position after the given order
+func encodeOrderCursor(sortBy repository.OrderSortField, descending bool, position repository.OrderCursor) string {
+	sort := string(sortBy)
+	if descending {
+		sort = "-" + sort
+	}
+
+	data, _ := json.Marshal(orderCursor{
+		Sort:      sort,
+		ID:        position.ID,
+		CreatedAt: position.CreatedAt,
+		Amount:    position.Amount,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeOrderCursor(value string) (orderCursor, error) {
+	var cursor orderCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: malformed cursor", entity.ErrInvalidOrderQuery)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return cursor, fmt.Errorf("%w: malformed cursor", entity.ErrInvalidOrderQuery)
+	}
+
+	return cursor, nil
+}
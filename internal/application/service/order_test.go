@@ -2,29 +2,104 @@ package service
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
 	"github.com/Strahinja-Polovina/packs/pkg/logger"
 	"github.com/google/uuid"
 )
 
 // MockOrderRepository implements repository.OrderRepository for testing
 type MockOrderRepository struct {
-	orders []entity.Order
+	orders    []entity.Order
+	history   map[uuid.UUID][]entity.OrderStatusChange
+	revisions map[uuid.UUID][]entity.OrderRevision
+	getCalls  int
 }
 
 func NewMockOrderRepository() *MockOrderRepository {
 	return &MockOrderRepository{
-		orders: []entity.Order{},
+		orders:    []entity.Order{},
+		history:   make(map[uuid.UUID][]entity.OrderStatusChange),
+		revisions: make(map[uuid.UUID][]entity.OrderRevision),
 	}
 }
 
-func (m *MockOrderRepository) List(ctx context.Context) []entity.Order {
-	return m.orders
+func (m *MockOrderRepository) List(ctx context.Context, query repository.OrderQuery) (*repository.OrderPage, error) {
+	key := func(order *entity.Order) repository.OrderCursor {
+		return repository.OrderCursor{ID: order.ID(), CreatedAt: order.CreatedAt(), Amount: order.GetRequestedAmount()}
+	}
+	// before reports whether a sorts before b in ascending order
+	before := func(a, b repository.OrderCursor) bool {
+		if query.SortBy == repository.OrderSortAmount && a.Amount != b.Amount {
+			return a.Amount < b.Amount
+		}
+		if query.SortBy == repository.OrderSortCreatedAt && !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	}
+	// follows reports whether a comes after b in the requested order
+	follows := func(a, b repository.OrderCursor) bool {
+		if query.Descending {
+			return before(a, b)
+		}
+		return before(b, a)
+	}
+
+	var matching []entity.Order
+	for _, order := range m.orders {
+		amount := order.GetRequestedAmount()
+		switch {
+		case query.CreatedFrom != nil && order.CreatedAt().Before(*query.CreatedFrom),
+			query.CreatedTo != nil && !order.CreatedAt().Before(*query.CreatedTo),
+			query.MinAmount != nil && amount < *query.MinAmount,
+			query.MaxAmount != nil && amount > *query.MaxAmount,
+			query.ProductID != nil && !shipsProduct(order, *query.ProductID),
+			query.PackSize != nil && !shipsPackSize(order, *query.PackSize),
+			query.After != nil && !follows(key(&order), *query.After):
+			continue
+		}
+		matching = append(matching, order)
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		return follows(key(&matching[j]), key(&matching[i]))
+	})
+
+	page := &repository.OrderPage{Orders: matching}
+	if len(matching) > query.Limit {
+		page.Orders = matching[:query.Limit]
+		next := key(&page.Orders[query.Limit-1])
+		page.Next = &next
+	}
+	return page, nil
+}
+
+func shipsPackSize(order entity.Order, size int64) bool {
+	for _, item := range order.GetItems() {
+		if item.PackageSize() == size {
+			return true
+		}
+	}
+	return false
+}
+
+func shipsProduct(order entity.Order, productID uuid.UUID) bool {
+	for _, line := range order.Lines() {
+		if line.ProductID() == productID {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *MockOrderRepository) Get(ctx context.Context, id uuid.UUID) (*entity.Order, error) {
+	m.getCalls++
 	for _, order := range m.orders {
 		if order.ID() == id {
 			return &order, nil
@@ -35,19 +110,49 @@ func (m *MockOrderRepository) Get(ctx context.Context, id uuid.UUID) (*entity.Or
 
 func (m *MockOrderRepository) Create(ctx context.Context, order *entity.Order) error {
 	m.orders = append(m.orders, *order)
+	m.history[order.ID()] = []entity.OrderStatusChange{{To: order.Status(), ChangedAt: order.CreatedAt()}}
 	return nil
 }
 
-func (m *MockOrderRepository) Update(ctx context.Context, order *entity.Order) error {
+func (m *MockOrderRepository) Update(ctx context.Context, order *entity.Order, previous entity.OrderRevision) error {
+	for i, o := range m.orders {
+		if o.ID() == order.ID() {
+			if o.Version() != order.Version()-1 {
+				return entity.ErrOrderModified
+			}
+			m.orders[i] = *order
+			m.revisions[order.ID()] = append(m.revisions[order.ID()], previous)
+			return nil
+		}
+	}
+	return entity.ErrOrderNotFound
+}
+
+func (m *MockOrderRepository) Revisions(ctx context.Context, id uuid.UUID) ([]entity.OrderRevision, error) {
+	return m.revisions[id], nil
+}
+
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, order *entity.Order, change entity.OrderStatusChange) error {
 	for i, o := range m.orders {
 		if o.ID() == order.ID() {
+			if o.Status() != change.From {
+				return entity.ErrInvalidStatusTransition
+			}
+			if o.Version() != order.Version()-1 {
+				return entity.ErrOrderModified
+			}
 			m.orders[i] = *order
+			m.history[order.ID()] = append(m.history[order.ID()], change)
 			return nil
 		}
 	}
 	return entity.ErrOrderNotFound
 }
 
+func (m *MockOrderRepository) StatusHistory(ctx context.Context, id uuid.UUID) ([]entity.OrderStatusChange, error) {
+	return m.history[id], nil
+}
+
 func (m *MockOrderRepository) Delete(ctx context.Context, order *entity.Order) error {
 	for i, o := range m.orders {
 		if o.ID() == order.ID() {
@@ -61,8 +166,8 @@ func (m *MockOrderRepository) Delete(ctx context.Context, order *entity.Order) e
 func TestOrderService_CreateOrderFromCalculation(t *testing.T) {
 	mockOrderRepo := NewMockOrderRepository()
 	mockPackRepo := NewMockPackRepository()
-	packService := NewPackService(mockPackRepo, logger.GetLogger())
-	orderService := NewOrderService(mockOrderRepo, mockPackRepo, packService, logger.GetLogger())
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
 
 	tests := []struct {
 		name        string
@@ -139,8 +244,8 @@ func TestOrderService_CreateOrderFromCalculation(t *testing.T) {
 func TestOrderService_GetOrder(t *testing.T) {
 	mockOrderRepo := NewMockOrderRepository()
 	mockPackRepo := NewMockPackRepository()
-	packService := NewPackService(mockPackRepo, logger.GetLogger())
-	orderService := NewOrderService(mockOrderRepo, mockPackRepo, packService, logger.GetLogger())
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
 
 	orderRequest := OrderRequest{Amount: 1000}
 	createdOrder, err := orderService.CreateOrderFromCalculation(context.Background(), orderRequest)
@@ -196,8 +301,8 @@ func TestOrderService_GetOrder(t *testing.T) {
 func TestOrderService_GetAllOrders(t *testing.T) {
 	mockOrderRepo := NewMockOrderRepository()
 	mockPackRepo := NewMockPackRepository()
-	packService := NewPackService(mockPackRepo, logger.GetLogger())
-	orderService := NewOrderService(mockOrderRepo, mockPackRepo, packService, logger.GetLogger())
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
 
 	orders, err := orderService.GetAllOrders(context.Background())
 	if err != nil {
@@ -232,8 +337,8 @@ func TestOrderService_GetAllOrders(t *testing.T) {
 func TestOrderService_Integration(t *testing.T) {
 	mockOrderRepo := NewMockOrderRepository()
 	mockPackRepo := NewMockPackRepository()
-	packService := NewPackService(mockPackRepo, logger.GetLogger())
-	orderService := NewOrderService(mockOrderRepo, mockPackRepo, packService, logger.GetLogger())
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
 
 	orderRequest := OrderRequest{Amount: 1250}
 	createdOrder, err := orderService.CreateOrderFromCalculation(context.Background(), orderRequest)
@@ -245,7 +350,7 @@ func TestOrderService_Integration(t *testing.T) {
 		t.Errorf("Expected total amount 1250, got %d", createdOrder.TotalAmount)
 	}
 
-	expectedWaste := 0
+	expectedWaste := int64(0)
 	actualWaste := createdOrder.TotalAmount - orderRequest.Amount
 	if actualWaste != expectedWaste {
 		t.Errorf("Expected waste %d, got %d", expectedWaste, actualWaste)
@@ -255,10 +360,934 @@ func TestOrderService_Integration(t *testing.T) {
 		t.Errorf("Expected 2 order items, got %d", len(createdOrder.Items))
 	}
 
-	expectedItems := map[int]int{1000: 1, 250: 1}
+	expectedItems := map[int64]int64{1000: 1, 250: 1}
 	for _, item := range createdOrder.Items {
 		if expectedCount, exists := expectedItems[item.PackSize]; !exists || item.Quantity != expectedCount {
 			t.Errorf("Unexpected item: pack size %d, quantity %d", item.PackSize, item.Quantity)
 		}
 	}
 }
+
+func TestOrderService_CreateOrderFromCalculation_Stock(t *testing.T) {
+	errStockUnavailable := errors.New("stock unavailable")
+
+	tests := []struct {
+		name        string
+		stock       map[int64]int64
+		listErr     error
+		amount      int64
+		expected    map[int64]int64
+		expectedErr error
+	}{
+		{
+			name:     "Untracked sizes are unlimited",
+			stock:    map[int64]int64{},
+			amount:   12001,
+			expected: map[int64]int64{5000: 2, 2000: 1, 250: 1},
+		},
+		{
+			name:     "Best combination out of stock uses next best",
+			stock:    map[int64]int64{250: 0},
+			amount:   12001,
+			expected: map[int64]int64{5000: 2, 2000: 1, 500: 1},
+		},
+		{
+			name:     "Limited stock is topped up with other sizes",
+			stock:    map[int64]int64{5000: 1},
+			amount:   12000,
+			expected: map[int64]int64{5000: 1, 2000: 3, 1000: 1},
+		},
+		{
+			name:        "Not enough stock",
+			stock:       map[int64]int64{250: 1, 500: 0, 1000: 0, 2000: 0, 5000: 0},
+			amount:      300,
+			expectedErr: entity.ErrInsufficientStock,
+		},
+		{
+			name:        "Stock cannot be loaded",
+			listErr:     errStockUnavailable,
+			amount:      300,
+			expectedErr: errStockUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrderRepo := NewMockOrderRepository()
+			mockPackRepo := NewMockPackRepository()
+			mockStockRepo := NewMockStockRepository(mockPackRepo)
+			for size, quantity := range tt.stock {
+				mockStockRepo.setStock(t, size, quantity)
+			}
+			mockStockRepo.listErr = tt.listErr
+			packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+			orderService := NewOrderService(mockOrderRepo, mockPackRepo, mockStockRepo, packService, logger.GetLogger())
+
+			result, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: tt.amount})
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+				if len(mockOrderRepo.orders) != 0 {
+					t.Errorf("Expected no order to be stored, got %d", len(mockOrderRepo.orders))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(result.Combination) != len(tt.expected) {
+				t.Errorf("Expected combination %v, got %v", tt.expected, result.Combination)
+				return
+			}
+			for size, count := range tt.expected {
+				if result.Combination[size] != count {
+					t.Errorf("Expected combination %v, got %v", tt.expected, result.Combination)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestOrderService_GetOrder_KeepsCalculation(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	created, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 251})
+	if err != nil {
+		t.Fatalf("Failed to create test order: %v", err)
+	}
+
+	// Pack sizes changing later must not change what the order reports
+	mockPackRepo.packs = mockPackRepo.packs[:2]
+
+	result, err := orderService.GetOrder(context.Background(), created.OrderID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Amount != 251 {
+		t.Errorf("Expected requested amount 251, got %d", result.Amount)
+	}
+	if result.TotalAmount != 500 {
+		t.Errorf("Expected total amount 500, got %d", result.TotalAmount)
+	}
+	if result.Waste != 249 {
+		t.Errorf("Expected waste 249, got %d", result.Waste)
+	}
+	if result.Objective != ObjectiveMinWaste {
+		t.Errorf("Expected objective %s, got %s", ObjectiveMinWaste, result.Objective)
+	}
+	if result.SolverVersion != SolverVersion {
+		t.Errorf("Expected solver version %s, got %s", SolverVersion, result.SolverVersion)
+	}
+	if len(result.PackSizes) != 5 {
+		t.Errorf("Expected the 5 pack sizes available at calculation time, got %v", result.PackSizes)
+	}
+}
+
+func TestOrderService_GetOrder_KeepsPrice(t *testing.T) {
+	cost, weight := int64(100), 400
+	pack, err := entity.NewPackWithAttributes(uuid.New(), 500, entity.PackAttributes{UnitCost: &cost, Weight: &weight})
+	if err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := &MockPackRepository{packs: []entity.Pack{*pack}}
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	created, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 1000})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+
+	// Re-costing the pack must not reprice the order, and neither must retiring it
+	newCost := int64(150)
+	revised, err := packService.UpdatePack(context.Background(), pack.ID(), PackChange{Size: 500, Attributes: entity.PackAttributes{UnitCost: &newCost}}, AnyVersion)
+	if err != nil {
+		t.Fatalf("Failed to re-cost pack: %v", err)
+	}
+	expectPrice := func(name string, response OrderResponse) {
+		t.Helper()
+		if response.TotalCost == nil || *response.TotalCost != 200 {
+			t.Errorf("%s: expected total cost 200, got %v", name, response.TotalCost)
+		}
+		if response.ShippingWeight == nil || *response.ShippingWeight != 800 {
+			t.Errorf("%s: expected shipping weight 800, got %v", name, response.ShippingWeight)
+		}
+	}
+
+	result, err := orderService.GetOrder(context.Background(), created.OrderID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectPrice("GetOrder", *result)
+
+	if err := packService.DeletePack(context.Background(), revised, AnyVersion); err != nil {
+		t.Fatalf("Failed to retire pack: %v", err)
+	}
+	page, err := orderService.ListOrders(context.Background(), OrderListQuery{})
+	if err != nil || len(page.Orders) != 1 {
+		t.Fatalf("Expected one order, got %v (error: %v)", page, err)
+	}
+	expectPrice("ListOrders", page.Orders[0])
+
+	result, err = orderService.TransitionOrder(context.Background(), created.OrderID, entity.OrderStatusConfirmed, AnyVersion)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectPrice("TransitionOrder", *result)
+}
+
+func TestOrderService_GetOrder_LegacyOrder(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	order := entity.NewOrder(uuid.New())
+	if err := order.AddItem(500, 1); err != nil {
+		t.Fatalf("Failed to add item: %v", err)
+	}
+	if err := mockOrderRepo.Create(context.Background(), order); err != nil {
+		t.Fatalf("Failed to store order: %v", err)
+	}
+
+	result, err := orderService.GetOrder(context.Background(), order.ID())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Amount != 500 || result.Waste != 0 || result.SolverVersion != "" {
+		t.Errorf("Expected legacy order to report its shipped amount without waste, got %+v", result)
+	}
+	if len(result.PackSizes) != 1 || result.PackSizes[0] != 500 {
+		t.Errorf("Expected pack sizes [500], got %v", result.PackSizes)
+	}
+}
+
+func TestOrderService_GetOrder_NotFound(t *testing.T) {
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(NewMockOrderRepository(), mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	_, err := orderService.GetOrder(context.Background(), uuid.New())
+	if !errors.Is(err, entity.ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+// addListedOrder stores an order for amount shipped as the given combination, created at the given time
+func addListedOrder(t *testing.T, repo *MockOrderRepository, amount int64, combination map[int64]int64, createdAt time.Time) uuid.UUID {
+	t.Helper()
+
+	order := entity.NewOrder(uuid.New())
+	for size, quantity := range combination {
+		if err := order.AddItem(size, quantity); err != nil {
+			t.Fatalf("Failed to add order item: %v", err)
+		}
+	}
+	if err := order.SetCalculation(entity.OrderCalculation{RequestedAmount: amount, PackSizes: []int64{250, 500, 1000}}); err != nil {
+		t.Fatalf("Failed to set order calculation: %v", err)
+	}
+	order.SetTimestamps(createdAt, createdAt)
+
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("Failed to store order: %v", err)
+	}
+	return order.ID()
+}
+
+func TestOrderService_ListOrders_Pagination(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	amounts := []int64{750, 250, 1000, 500, 1250}
+	ids := make([]uuid.UUID, len(amounts))
+	for i, amount := range amounts {
+		ids[i] = addListedOrder(t, mockOrderRepo, amount, map[int64]int64{250: amount / 250}, start.Add(time.Duration(i)*time.Hour))
+	}
+
+	tests := []struct {
+		name     string
+		sort     string
+		expected []uuid.UUID
+	}{
+		{name: "Newest first by default", sort: "", expected: []uuid.UUID{ids[4], ids[3], ids[2], ids[1], ids[0]}},
+		{name: "Oldest first", sort: "created_at", expected: ids},
+		{name: "Smallest amount first", sort: "amount", expected: []uuid.UUID{ids[1], ids[3], ids[0], ids[2], ids[4]}},
+		{name: "Largest amount first", sort: "-amount", expected: []uuid.UUID{ids[4], ids[2], ids[0], ids[3], ids[1]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := OrderListQuery{Sort: tt.sort, Limit: 2}
+			var listed []uuid.UUID
+			pages := 0
+			packLoads := mockPackRepo.listCalls
+
+			for {
+				page, err := orderService.ListOrders(context.Background(), query)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				pages++
+				if page.Count != len(page.Orders) {
+					t.Errorf("Expected count %d, got %d", len(page.Orders), page.Count)
+				}
+				for _, order := range page.Orders {
+					listed = append(listed, order.OrderID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+
+			if pages != 3 {
+				t.Errorf("Expected 3 pages, got %d", pages)
+			}
+			if loads := mockPackRepo.listCalls - packLoads; loads != pages {
+				t.Errorf("Expected the packs of each page to be loaded at once, got %d loads for %d pages", loads, pages)
+			}
+			if len(listed) != len(tt.expected) {
+				t.Fatalf("Expected %d orders, got %d", len(tt.expected), len(listed))
+			}
+			for i, id := range tt.expected {
+				if listed[i] != id {
+					t.Errorf("Expected order %s at position %d, got %s", id, i, listed[i])
+				}
+			}
+		})
+	}
+
+	if mockOrderRepo.getCalls != 0 {
+		t.Errorf("Expected listing to load orders without Get, got %d Get calls", mockOrderRepo.getCalls)
+	}
+}
+
+func TestOrderService_ListOrders_Filters(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	small := addListedOrder(t, mockOrderRepo, 251, map[int64]int64{500: 1}, start)
+	medium := addListedOrder(t, mockOrderRepo, 1250, map[int64]int64{1000: 1, 250: 1}, start.Add(24*time.Hour))
+	large := addListedOrder(t, mockOrderRepo, 5000, map[int64]int64{5000: 1}, start.Add(48*time.Hour))
+
+	from := start.Add(time.Hour)
+	to := start.Add(48 * time.Hour)
+	minAmount, maxAmount, packSize := int64(1000), int64(2000), int64(1000)
+
+	tests := []struct {
+		name     string
+		query    OrderListQuery
+		expected []uuid.UUID
+	}{
+		{name: "No filters", query: OrderListQuery{}, expected: []uuid.UUID{large, medium, small}},
+		{name: "Created from", query: OrderListQuery{CreatedFrom: &from}, expected: []uuid.UUID{large, medium}},
+		{name: "Created range excludes the end", query: OrderListQuery{CreatedFrom: &from, CreatedTo: &to}, expected: []uuid.UUID{medium}},
+		{name: "Minimum amount", query: OrderListQuery{MinAmount: &minAmount}, expected: []uuid.UUID{large, medium}},
+		{name: "Amount range", query: OrderListQuery{MinAmount: &minAmount, MaxAmount: &maxAmount}, expected: []uuid.UUID{medium}},
+		{name: "Pack size", query: OrderListQuery{PackSize: &packSize}, expected: []uuid.UUID{medium}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := orderService.ListOrders(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if page.NextCursor != "" {
+				t.Errorf("Expected a single page, got next cursor %q", page.NextCursor)
+			}
+			if len(page.Orders) != len(tt.expected) {
+				t.Fatalf("Expected %d orders, got %d", len(tt.expected), len(page.Orders))
+			}
+			for i, id := range tt.expected {
+				if page.Orders[i].OrderID != id {
+					t.Errorf("Expected order %s at position %d, got %s", id, i, page.Orders[i].OrderID)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderService_ListOrders_InvalidQuery(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		addListedOrder(t, mockOrderRepo, 250, map[int64]int64{250: 1}, start.Add(time.Duration(i)*time.Hour))
+	}
+
+	page, err := orderService.ListOrders(context.Background(), OrderListQuery{Limit: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	later := start.Add(time.Hour)
+	minAmount, maxAmount, packSize := int64(500), int64(250), int64(0)
+
+	tests := []struct {
+		name  string
+		query OrderListQuery
+	}{
+		{name: "Negative limit", query: OrderListQuery{Limit: -1}},
+		{name: "Limit too large", query: OrderListQuery{Limit: MaxOrderPageSize + 1}},
+		{name: "Unknown sort", query: OrderListQuery{Sort: "waste"}},
+		{name: "Empty created range", query: OrderListQuery{CreatedFrom: &later, CreatedTo: &start}},
+		{name: "Empty amount range", query: OrderListQuery{MinAmount: &minAmount, MaxAmount: &maxAmount}},
+		{name: "Invalid pack size", query: OrderListQuery{PackSize: &packSize}},
+		{name: "Malformed cursor", query: OrderListQuery{Cursor: "not a cursor"}},
+		{name: "Cursor for another sort", query: OrderListQuery{Cursor: page.NextCursor, Sort: "amount"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := orderService.ListOrders(context.Background(), tt.query)
+			if !errors.Is(err, entity.ErrInvalidOrderQuery) {
+				t.Errorf("Expected ErrInvalidOrderQuery, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOrderService_TransitionOrder(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	created, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 1250})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	if created.Status != string(entity.OrderStatusDraft) {
+		t.Errorf("Expected new order to be draft, got %s", created.Status)
+	}
+
+	lifecycle := []entity.OrderStatus{
+		entity.OrderStatusConfirmed,
+		entity.OrderStatusPicking,
+		entity.OrderStatusPacked,
+		entity.OrderStatusShipped,
+	}
+	for _, status := range lifecycle {
+		response, err := orderService.TransitionOrder(context.Background(), created.OrderID, status, AnyVersion)
+		if err != nil {
+			t.Fatalf("Unexpected error moving order to %s: %v", status, err)
+		}
+		if response.Status != string(status) {
+			t.Errorf("Expected status %s, got %s", status, response.Status)
+		}
+		if response.TotalAmount != 1250 {
+			t.Errorf("Expected total amount 1250 to be kept, got %d", response.TotalAmount)
+		}
+	}
+
+	stored, err := orderService.GetOrder(context.Background(), created.OrderID)
+	if err != nil {
+		t.Fatalf("Failed to get order: %v", err)
+	}
+	if stored.Status != string(entity.OrderStatusShipped) {
+		t.Errorf("Expected stored status shipped, got %s", stored.Status)
+	}
+	if len(stored.Transitions) != 0 {
+		t.Errorf("Expected no transitions from shipped, got %v", stored.Transitions)
+	}
+
+	history, err := orderService.GetOrderHistory(context.Background(), created.OrderID)
+	if err != nil {
+		t.Fatalf("Failed to get order history: %v", err)
+	}
+	expected := []OrderStatusChangeResponse{
+		{To: "draft"},
+		{From: "draft", To: "confirmed"},
+		{From: "confirmed", To: "picking"},
+		{From: "picking", To: "packed"},
+		{From: "packed", To: "shipped"},
+	}
+	if len(history) != len(expected) {
+		t.Fatalf("Expected %d history entries, got %d", len(expected), len(history))
+	}
+	for i, change := range expected {
+		if history[i].From != change.From || history[i].To != change.To {
+			t.Errorf("Expected history entry %d to be %q to %q, got %q to %q",
+				i, change.From, change.To, history[i].From, history[i].To)
+		}
+	}
+}
+
+func TestOrderService_TransitionOrder_Rejected(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	draft, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 500})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	cancelled, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 500})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	if _, err := orderService.TransitionOrder(context.Background(), cancelled.OrderID, entity.OrderStatusCancelled, AnyVersion); err != nil {
+		t.Fatalf("Failed to cancel order: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		orderID     uuid.UUID
+		status      entity.OrderStatus
+		expectedErr error
+	}{
+		{name: "Ship a draft", orderID: draft.OrderID, status: entity.OrderStatusShipped, expectedErr: entity.ErrInvalidStatusTransition},
+		{name: "Back to draft", orderID: draft.OrderID, status: entity.OrderStatusDraft, expectedErr: entity.ErrInvalidStatusTransition},
+		{name: "Confirm a cancelled order", orderID: cancelled.OrderID, status: entity.OrderStatusConfirmed, expectedErr: entity.ErrInvalidStatusTransition},
+		{name: "Unknown status", orderID: draft.OrderID, status: "lost", expectedErr: entity.ErrUnknownOrderStatus},
+		{name: "Missing order", orderID: uuid.New(), status: entity.OrderStatusConfirmed, expectedErr: entity.ErrOrderNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := orderService.TransitionOrder(context.Background(), tt.orderID, tt.status, AnyVersion)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	stored, err := orderService.GetOrder(context.Background(), draft.OrderID)
+	if err != nil {
+		t.Fatalf("Failed to get order: %v", err)
+	}
+	if stored.Status != string(entity.OrderStatusDraft) {
+		t.Errorf("Expected rejected transitions to keep the order draft, got %s", stored.Status)
+	}
+
+	if _, err := orderService.GetOrderHistory(context.Background(), uuid.New()); !errors.Is(err, entity.ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound for the history of a missing order, got %v", err)
+	}
+}
+
+func TestOrderService_AmendOrder(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	created, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 251})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	if created.Revision != 1 {
+		t.Errorf("Expected new order at revision 1, got %d", created.Revision)
+	}
+
+	// The amendment uses the pack set as it is now, not as it was when the order was created
+	pack, _ := entity.NewPack(uuid.New(), 1250)
+	if err := packService.CreatePack(context.Background(), pack); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+
+	amended, err := orderService.AmendOrder(context.Background(), created.OrderID, OrderRequest{Amount: 1250}, AnyVersion)
+	if err != nil {
+		t.Fatalf("Unexpected error amending order: %v", err)
+	}
+
+	if amended.Revision != 2 {
+		t.Errorf("Expected revision 2, got %d", amended.Revision)
+	}
+	if amended.Amount != 1250 || amended.TotalAmount != 1250 {
+		t.Errorf("Expected amount and total 1250, got %d and %d", amended.Amount, amended.TotalAmount)
+	}
+	if len(amended.Combination) != 1 || amended.Combination[1250] != 1 {
+		t.Errorf("Expected one 1250 pack, got %v", amended.Combination)
+	}
+
+	stored, err := orderService.GetOrder(context.Background(), created.OrderID)
+	if err != nil {
+		t.Fatalf("Failed to get order: %v", err)
+	}
+	if stored.Revision != 2 || stored.Amount != 1250 {
+		t.Errorf("Expected the stored order at revision 2 for 1250, got revision %d for %d", stored.Revision, stored.Amount)
+	}
+
+	revisions, err := orderService.GetOrderRevisions(context.Background(), created.OrderID)
+	if err != nil {
+		t.Fatalf("Failed to get order revisions: %v", err)
+	}
+	if len(revisions) != 1 {
+		t.Fatalf("Expected 1 revision, got %d", len(revisions))
+	}
+	if revisions[0].Revision != 1 || revisions[0].Amount != 251 || revisions[0].TotalAmount != 500 {
+		t.Errorf("Expected revision 1 for 251 shipping 500, got %+v", revisions[0])
+	}
+	if len(revisions[0].PackSizes) != 5 {
+		t.Errorf("Expected revision 1 to keep its pack set of 5 sizes, got %v", revisions[0].PackSizes)
+	}
+}
+
+func TestOrderService_AmendOrder_ReusesOwnStock(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	mockStockRepo := NewMockStockRepository(mockPackRepo)
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, mockStockRepo, packService, logger.GetLogger())
+
+	mockStockRepo.setStock(t, 5000, 1)
+	created, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 5000})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	// The repository takes the reserved pack out of stock
+	mockStockRepo.setStock(t, 5000, 0)
+
+	amended, err := orderService.AmendOrder(context.Background(), created.OrderID, OrderRequest{Amount: 4800}, AnyVersion)
+	if err != nil {
+		t.Fatalf("Unexpected error amending order: %v", err)
+	}
+	if len(amended.Combination) != 1 || amended.Combination[5000] != 1 {
+		t.Errorf("Expected the order to keep its 5000 pack, got %v", amended.Combination)
+	}
+}
+
+func TestOrderService_AmendOrder_Rejected(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	draft, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 500})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	picking, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 500})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	for _, status := range []entity.OrderStatus{entity.OrderStatusConfirmed, entity.OrderStatusPicking} {
+		if _, err := orderService.TransitionOrder(context.Background(), picking.OrderID, status, AnyVersion); err != nil {
+			t.Fatalf("Failed to move order to %s: %v", status, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		orderID     uuid.UUID
+		amount      int64
+		expectedErr error
+	}{
+		{name: "Picking started", orderID: picking.OrderID, amount: 750, expectedErr: entity.ErrOrderNotAmendable},
+		{name: "Missing order", orderID: uuid.New(), amount: 750, expectedErr: entity.ErrOrderNotFound},
+		{name: "Invalid amount", orderID: draft.OrderID, amount: 0, expectedErr: entity.ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := orderService.AmendOrder(context.Background(), tt.orderID, OrderRequest{Amount: tt.amount}, AnyVersion)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	if len(mockOrderRepo.revisions) != 0 {
+		t.Errorf("Expected rejected amendments to record no revisions, got %d", len(mockOrderRepo.revisions))
+	}
+}
+
+func TestOrderService_Version(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	created, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 500})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	if created.Version != 1 {
+		t.Errorf("Expected new order at version 1, got %d", created.Version)
+	}
+
+	confirmed, err := orderService.TransitionOrder(context.Background(), created.OrderID, entity.OrderStatusConfirmed, created.Version)
+	if err != nil {
+		t.Fatalf("Unexpected error confirming order: %v", err)
+	}
+	if confirmed.Version != 2 {
+		t.Errorf("Expected confirmed order at version 2, got %d", confirmed.Version)
+	}
+
+	// Writes based on the version read before the status change are rejected
+	_, err = orderService.AmendOrder(context.Background(), created.OrderID, OrderRequest{Amount: 750}, created.Version)
+	if !errors.Is(err, entity.ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch amending a stale version, got %v", err)
+	}
+	_, err = orderService.TransitionOrder(context.Background(), created.OrderID, entity.OrderStatusCancelled, created.Version)
+	if !errors.Is(err, entity.ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch cancelling a stale version, got %v", err)
+	}
+
+	amended, err := orderService.AmendOrder(context.Background(), created.OrderID, OrderRequest{Amount: 750}, confirmed.Version)
+	if err != nil {
+		t.Fatalf("Unexpected error amending order: %v", err)
+	}
+	if amended.Version != 3 || amended.Revision != 2 {
+		t.Errorf("Expected version 3 at revision 2, got version %d at revision %d", amended.Version, amended.Revision)
+	}
+
+	stored, err := orderService.GetOrder(context.Background(), created.OrderID)
+	if err != nil {
+		t.Fatalf("Failed to get order: %v", err)
+	}
+	if stored.Version != 3 || stored.Status != string(entity.OrderStatusConfirmed) {
+		t.Errorf("Expected the stored order confirmed at version 3, got %s at version %d", stored.Status, stored.Version)
+	}
+}
+
+func TestOrderService_CreateOrderFromCalculation_Product(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	productID := uuid.New()
+	mockPackRepo.addProduct(t, productID, 250, 600)
+	mockStockRepo := NewMockStockRepository(mockPackRepo)
+	// Stock of the default product's 250 pack must not limit the other product
+	mockStockRepo.setStock(t, 250, 0)
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, mockStockRepo, packService, logger.GetLogger())
+
+	result, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{
+		Amount:    850,
+		ProductID: productID.String(),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.ProductID == nil || *result.ProductID != productID {
+		t.Errorf("Expected order of product %s, got %v", productID, result.ProductID)
+	}
+	expected := map[int64]int64{600: 1, 250: 1}
+	if len(result.Combination) != len(expected) || result.Combination[600] != 1 || result.Combination[250] != 1 {
+		t.Errorf("Expected combination %v, got %v", expected, result.Combination)
+	}
+	if len(result.PackSizes) != 2 {
+		t.Errorf("Expected the two pack sizes of the product, got %v", result.PackSizes)
+	}
+	if stored := mockOrderRepo.orders[0]; stored.ProductID() != productID {
+		t.Errorf("Expected stored order of product %s, got %s", productID, stored.ProductID())
+	}
+
+	_, err = orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{
+		Amount:    850,
+		ProductID: uuid.NewString(),
+	})
+	if !errors.Is(err, entity.ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound for an unknown product, got %v", err)
+	}
+}
+
+func TestOrderService_CreateOrderFromCalculation_Lines(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	productID := uuid.New()
+	mockPackRepo.addProduct(t, productID, 250, 600)
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	result, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{
+		Lines: []OrderLineRequest{
+			{Reference: "PO-1/10", Amount: 251},
+			{Reference: "PO-1/20", Amount: 850, ProductID: productID.String()},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result.Lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(result.Lines))
+	}
+	first, second := result.Lines[0], result.Lines[1]
+	if first.Line != 1 || first.Reference != "PO-1/10" || first.ProductID != entity.DefaultProductID {
+		t.Errorf("Expected line 1 PO-1/10 of the default product, got %+v", first)
+	}
+	if len(first.Combination) != 1 || first.Combination[500] != 1 || first.Waste != 249 {
+		t.Errorf("Expected line 1 to ship one 500 pack with waste 249, got %v and %d", first.Combination, first.Waste)
+	}
+	if second.Line != 2 || second.Reference != "PO-1/20" || second.ProductID != productID {
+		t.Errorf("Expected line 2 PO-1/20 of product %s, got %+v", productID, second)
+	}
+	if len(second.Combination) != 2 || second.Combination[600] != 1 || second.Combination[250] != 1 || second.Waste != 0 {
+		t.Errorf("Expected line 2 to ship 600 and 250 without waste, got %v and %d", second.Combination, second.Waste)
+	}
+
+	if result.Amount != 1101 || result.TotalAmount != 1350 || result.Waste != 249 || result.TotalPacks != 3 {
+		t.Errorf("Expected grand totals 1101 requested, 1350 shipped, 249 waste in 3 packs, got %d, %d, %d in %d",
+			result.Amount, result.TotalAmount, result.Waste, result.TotalPacks)
+	}
+	if result.ProductID != nil || result.Combination != nil || result.Items != nil {
+		t.Errorf("Expected single-line fields to be left out of a multi-line order")
+	}
+
+	stored := mockOrderRepo.orders[0]
+	if lines := stored.Lines(); len(lines) != 2 || lines[1].Reference() != "PO-1/20" || lines[1].ProductID() != productID {
+		t.Errorf("Expected the stored order to keep both lines, got %v", lines)
+	}
+
+	listed, err := orderService.ListOrders(context.Background(), OrderListQuery{ProductID: productID.String()})
+	if err != nil {
+		t.Fatalf("Failed to list orders: %v", err)
+	}
+	if listed.Count != 1 || listed.Orders[0].OrderID != result.OrderID {
+		t.Errorf("Expected the order to be listed for the product of its second line, got %d orders", listed.Count)
+	}
+}
+
+func TestOrderService_CreateOrderFromCalculation_LinesShareStock(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	mockStockRepo := NewMockStockRepository(mockPackRepo)
+	mockStockRepo.setStock(t, 5000, 1)
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, mockStockRepo, packService, logger.GetLogger())
+
+	result, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{
+		Lines: []OrderLineRequest{{Amount: 5000}, {Amount: 5000}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Lines[0].Combination[5000] != 1 {
+		t.Errorf("Expected line 1 to take the only 5000 pack, got %v", result.Lines[0].Combination)
+	}
+	if result.Lines[1].Combination[5000] != 0 || result.Lines[1].TotalAmount != 5000 {
+		t.Errorf("Expected line 2 to make 5000 from other sizes, got %v", result.Lines[1].Combination)
+	}
+}
+
+func TestOrderService_CreateOrderFromCalculation_InvalidLines(t *testing.T) {
+	tooMany := make([]OrderLineRequest, entity.MaxOrderLines+1)
+	for i := range tooMany {
+		tooMany[i] = OrderLineRequest{Amount: 250}
+	}
+
+	tests := []struct {
+		name        string
+		request     OrderRequest
+		expectedErr error
+	}{
+		{
+			name:        "Amount next to lines",
+			request:     OrderRequest{Amount: 250, Lines: []OrderLineRequest{{Amount: 250}}},
+			expectedErr: entity.ErrInvalidOrderLine,
+		},
+		{
+			name:        "Duplicate references",
+			request:     OrderRequest{Lines: []OrderLineRequest{{Reference: "A", Amount: 250}, {Reference: "A", Amount: 500}}},
+			expectedErr: entity.ErrInvalidOrderLine,
+		},
+		{
+			name:        "Too many lines",
+			request:     OrderRequest{Lines: tooMany},
+			expectedErr: entity.ErrInvalidOrderLine,
+		},
+		{
+			name:        "Unknown product",
+			request:     OrderRequest{Lines: []OrderLineRequest{{Amount: 250}, {Amount: 250, ProductID: uuid.NewString()}}},
+			expectedErr: entity.ErrProductNotFound,
+		},
+		{
+			name:        "Invalid line amount",
+			request:     OrderRequest{Lines: []OrderLineRequest{{Amount: 250}, {Amount: 0}}},
+			expectedErr: entity.ErrInvalidAmount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrderRepo := NewMockOrderRepository()
+			mockPackRepo := NewMockPackRepository()
+			packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+			orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+			_, err := orderService.CreateOrderFromCalculation(context.Background(), tt.request)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if len(mockOrderRepo.orders) != 0 {
+				t.Errorf("Expected no order to be stored, got %d", len(mockOrderRepo.orders))
+			}
+		})
+	}
+}
+
+func TestOrderService_AmendOrder_Lines(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	productID := uuid.New()
+	mockPackRepo.addProduct(t, productID, 250, 600)
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+
+	created, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{
+		Lines: []OrderLineRequest{{Amount: 251}, {Amount: 850, ProductID: productID.String()}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+
+	// Lines without a product keep the product of the line they replace
+	amended, err := orderService.AmendOrder(context.Background(), created.OrderID, OrderRequest{
+		Lines: []OrderLineRequest{{Amount: 1000}, {Amount: 600}, {Amount: 500}},
+	}, AnyVersion)
+	if err != nil {
+		t.Fatalf("Unexpected error amending order: %v", err)
+	}
+
+	if len(amended.Lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(amended.Lines))
+	}
+	expectedProducts := []uuid.UUID{entity.DefaultProductID, productID, entity.DefaultProductID}
+	for i, line := range amended.Lines {
+		if line.ProductID != expectedProducts[i] {
+			t.Errorf("Expected line %d of product %s, got %s", line.Line, expectedProducts[i], line.ProductID)
+		}
+	}
+	if amended.Lines[1].Combination[600] != 1 {
+		t.Errorf("Expected line 2 to use the 600 pack of its product, got %v", amended.Lines[1].Combination)
+	}
+	if amended.Revision != 2 || amended.Amount != 2100 {
+		t.Errorf("Expected revision 2 for 2100, got revision %d for %d", amended.Revision, amended.Amount)
+	}
+
+	revisions, err := orderService.GetOrderRevisions(context.Background(), created.OrderID)
+	if err != nil {
+		t.Fatalf("Failed to get order revisions: %v", err)
+	}
+	if len(revisions) != 1 || len(revisions[0].Lines) != 2 || revisions[0].Amount != 1101 {
+		t.Fatalf("Expected revision 1 with 2 lines for 1101, got %+v", revisions)
+	}
+	if revisions[0].Items != nil {
+		t.Errorf("Expected single-line fields to be left out of a multi-line revision")
+	}
+}
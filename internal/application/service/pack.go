@@ -2,293 +2,342 @@ package service
 
 import (
 	"context"
-	"sort"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
 
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
 	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
 	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
 )
 
 // PackService handles pack-related business logic
 type PackService struct {
-	packRepo repository.PackRepository
-	logger   *logger.Logger
+	packRepo         repository.PackRepository
+	solvers          map[Objective]Solver
+	defaultObjective Objective
+	batchWorkers     int
+	timeBudget       time.Duration
+	logger           *logger.Logger
 }
 
 // NewPackService creates a new pack service
-func NewPackService(packRepo repository.PackRepository, logger *logger.Logger) *PackService {
+func NewPackService(packRepo repository.PackRepository, solverConfig SolverConfig, logger *logger.Logger) *PackService {
+	solvers := make(map[Objective]Solver, len(Objectives))
+	for _, objective := range Objectives {
+		solver, err := NewSolver(objective, solverConfig.Weights)
+		if err != nil {
+			logger.Warn("Solver for objective %s is unavailable: %v", objective, err)
+			continue
+		}
+		solvers[objective] = solver
+	}
+
+	defaultObjective := solverConfig.DefaultObjective
+	if _, ok := solvers[defaultObjective]; !ok {
+		logger.Warn("Default objective %q is unavailable, falling back to %s", defaultObjective, ObjectiveMinWaste)
+		defaultObjective = ObjectiveMinWaste
+	}
+
+	batchWorkers := solverConfig.BatchWorkers
+	if batchWorkers <= 0 {
+		batchWorkers = runtime.GOMAXPROCS(0)
+	}
+
 	return &PackService{
-		packRepo: packRepo,
-		logger:   logger,
+		packRepo:         packRepo,
+		solvers:          solvers,
+		defaultObjective: defaultObjective,
+		batchWorkers:     batchWorkers,
+		timeBudget:       solverConfig.TimeBudget,
+		logger:           logger,
 	}
 }
 
+// MaxAlternatives is the largest number of alternative combinations a calculation returns
+const MaxAlternatives = 10
+
 // PackCalculationRequest represents a request to calculate pack combinations
 type PackCalculationRequest struct {
-	Amount int `json:"amount" binding:"required,min=1"`
+	Amount int64 `json:"amount" form:"amount" binding:"required,min=1"`
+	// ProductID selects the pack set; the default product is used when it is empty
+	ProductID string    `json:"product_id,omitempty" form:"product_id" binding:"omitempty,uuid" format:"uuid"`
+	Objective Objective `json:"objective,omitempty" form:"objective"`
+	// Alternatives asks for the N best combinations, each shipping a different total
+	Alternatives int `json:"alternatives,omitempty" form:"alternatives" binding:"omitempty,min=0,max=10"`
 }
 
 // PackCalculationResponse represents the response with calculated pack combinations
 type PackCalculationResponse struct {
-	Amount      int         `json:"amount"`
-	PackSizes   []int       `json:"pack_sizes"`
-	Combination map[int]int `json:"combination"`
-	TotalPacks  int         `json:"total_packs"`
-	TotalAmount int         `json:"total_amount"`
+	Amount      int64           `json:"amount"`
+	ProductID   string          `json:"product_id" format:"uuid"`
+	Objective   Objective       `json:"objective"`
+	PackSizes   []int64         `json:"pack_sizes"`
+	Combination map[int64]int64 `json:"combination"`
+	TotalPacks  int64           `json:"total_packs"`
+	TotalAmount int64           `json:"total_amount"`
+	Waste       int64           `json:"waste"`
+	// Optimal is false when the solver ran out of time and returned the best
+	// combination it had found so far
+	Optimal bool `json:"optimal"`
+	// TotalCost is the price of all packs in cents, reported when every pack used has a unit cost
+	TotalCost *int64 `json:"total_cost_cents,omitempty"`
+	// ShippingWeight is the gross weight in grams, reported when every pack used has a weight
+	ShippingWeight *int64 `json:"shipping_weight_grams,omitempty"`
+	// Alternatives lists the requested number of best combinations ranked by the
+	// objective, starting with the one above
+	Alternatives []PackAlternative `json:"alternatives,omitempty"`
+}
+
+// PackAlternative is one of the ranked combinations in a calculation
+type PackAlternative struct {
+	Rank           int             `json:"rank"`
+	Combination    map[int64]int64 `json:"combination"`
+	TotalPacks     int64           `json:"total_packs"`
+	TotalAmount    int64           `json:"total_amount"`
+	Waste          int64           `json:"waste"`
+	TotalCost      *int64          `json:"total_cost_cents,omitempty"`
+	ShippingWeight *int64          `json:"shipping_weight_grams,omitempty"`
 }
 
 // CalculateOptimalPacks calculates the optimal pack combination for a given amount
 func (s *PackService) CalculateOptimalPacks(ctx context.Context, req PackCalculationRequest) (*PackCalculationResponse, error) {
-	s.logger.Info("Calculating optimal packs for amount: %d", req.Amount)
+	return s.calculate(ctx, req, nil)
+}
 
-	if req.Amount <= 0 {
-		s.logger.Error("Invalid amount provided: %d", req.Amount)
-		return nil, entity.ErrInvalidAmount
-	}
+// CalculateAvailablePacks calculates the best pack combination that can be shipped
+// from stock. stock maps pack sizes to the packs on hand; sizes missing from it are
+// not tracked and treated as unlimited.
+func (s *PackService) CalculateAvailablePacks(ctx context.Context, req PackCalculationRequest, stock map[int64]int64) (*PackCalculationResponse, error) {
+	return s.calculate(ctx, req, stock)
+}
 
-	packs := s.packRepo.List(ctx)
-	packSizes := make([]int, len(packs))
-	for i, pack := range packs {
-		packSizes[i] = pack.Size()
+func (s *PackService) calculate(ctx context.Context, req PackCalculationRequest, stock map[int64]int64) (*PackCalculationResponse, error) {
+	packs, err := s.productPacks(ctx, &req.ProductID)
+	if err != nil {
+		return nil, err
 	}
+	return s.calculateWith(ctx, req, packs, stock)
+}
 
-	if len(packSizes) == 0 {
-		return nil, entity.ErrEmptyOrder
+// productPacks loads the pack set of the product a request names and
+// replaces an empty product ID with the default product
+func (s *PackService) productPacks(ctx context.Context, productID *string) ([]entity.Pack, error) {
+	id, err := resolveProductID(*productID)
+	if err != nil {
+		s.logger.Error("Invalid product provided: %v", err)
+		return nil, err
 	}
 
-	combination := s.calculateOptimalCombination(req.Amount, packSizes)
-
-	totalPacks := 0
-	totalAmount := 0
-	for size, quantity := range combination {
-		totalPacks += quantity
-		totalAmount += size * quantity
+	packs, err := s.packRepo.ListByProduct(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to load packs of product %s: %v", id, err)
+		return nil, err
 	}
 
-	s.logger.Info("Optimal pack calculation completed - Total packs: %d, Total amount: %d", totalPacks, totalAmount)
-
-	return &PackCalculationResponse{
-		Amount:      req.Amount,
-		PackSizes:   packSizes,
-		Combination: combination,
-		TotalPacks:  totalPacks,
-		TotalAmount: totalAmount,
-	}, nil
+	*productID = id.String()
+	return packs, nil
 }
 
-// calculateOptimalCombination finds the optimal pack combination following the rules:
-// 1. Only whole packs can be sent
-// 2. Send out the least amount of items to fulfill the order (minimize waste)
-// 3. Send out as few packs as possible (minimize pack count)
-// Rule #2 takes precedence over rule #3
-func (s *PackService) calculateOptimalCombination(amount int, packSizes []int) map[int]int {
-	sizes := make([]int, len(packSizes))
-	copy(sizes, packSizes)
-	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
-
-	return s.findOptimalPacks(amount, sizes)
-}
-
-// findOptimalPacks implements an efficient pack optimization algorithm
-func (s *PackService) findOptimalPacks(amount int, sizes []int) map[int]int {
-	bestCombination := s.greedyApproach(amount, sizes)
-	bestWaste, bestPackCount := s.calculateWasteAndPacks(amount, bestCombination)
+// calculateWith solves a calculation request against the already loaded pack
+// set of req.ProductID
+func (s *PackService) calculateWith(ctx context.Context, req PackCalculationRequest, packs []entity.Pack, stock map[int64]int64) (*PackCalculationResponse, error) {
+	s.logger.Info("Calculating optimal packs for amount: %d", req.Amount)
 
-	if amount <= 100000 || bestWaste > 0 {
-		if zeroWasteSolution := s.searchZeroWasteSolution(amount, sizes); len(zeroWasteSolution) > 0 {
-			waste, packCount := s.calculateWasteAndPacks(amount, zeroWasteSolution)
-			if waste < bestWaste || (waste == bestWaste && packCount < bestPackCount) {
-				return zeroWasteSolution
-			}
-		}
+	if req.Amount <= 0 {
+		s.logger.Error("Invalid amount provided: %d", req.Amount)
+		return nil, entity.ErrInvalidAmount
 	}
-
-	optimizedSolution := s.optimizeGreedySolution(amount, sizes, bestCombination)
-	optimizedWaste, optimizedPackCount := s.calculateWasteAndPacks(amount, optimizedSolution)
-
-	if optimizedWaste < bestWaste || (optimizedWaste == bestWaste && optimizedPackCount < bestPackCount) {
-		return optimizedSolution
+	if req.Amount > entity.MaxAmount {
+		s.logger.Error("Amount too large: %d", req.Amount)
+		return nil, fmt.Errorf("%w: got %d, allowed up to %d", entity.ErrAmountTooLarge, req.Amount, entity.MaxAmount)
 	}
 
-	return bestCombination
-}
+	if req.Alternatives < 0 || req.Alternatives > MaxAlternatives {
+		s.logger.Error("Invalid number of alternatives requested: %d", req.Alternatives)
+		return nil, fmt.Errorf("%w: got %d, allowed 0 to %d", entity.ErrInvalidAlternatives, req.Alternatives, MaxAlternatives)
+	}
 
-// greedyApproach implements a greedy algorithm using largest pack sizes first
-func (s *PackService) greedyApproach(amount int, sizes []int) map[int]int {
-	combination := make(map[int]int)
-	remaining := amount
+	solver, err := s.solverFor(req.Objective)
+	if err != nil {
+		s.logger.Error("Invalid objective provided: %q", req.Objective)
+		return nil, err
+	}
 
-	for _, size := range sizes {
-		if remaining <= 0 {
-			break
+	packSizes := make([]int64, len(packs))
+	options := make([]PackOption, len(packs))
+	for i, pack := range packs {
+		packSizes[i] = pack.Size()
+		unitCost, hasCost := pack.UnitCost()
+		if !hasCost && solver.UsesCost() {
+			s.logger.Error("Pack size %d has no unit cost, required by objective %s", pack.Size(), solver.Objective())
+			return nil, fmt.Errorf("%w: pack size %d", entity.ErrMissingPackCost, pack.Size())
 		}
-		count := remaining / size
-		if count > 0 {
-			combination[size] = count
-			remaining -= count * size
+		options[i] = PackOption{Size: pack.Size(), Cost: unitCost}
+		if onHand, tracked := stock[pack.Size()]; tracked {
+			options[i].Available = &onHand
 		}
 	}
 
-	if remaining > 0 && len(sizes) > 0 {
-		smallestSize := sizes[len(sizes)-1]
-		combination[smallestSize]++
+	if len(packSizes) == 0 {
+		return nil, entity.ErrEmptyOrder
 	}
 
-	return combination
-}
-
-// optimizeGreedySolution tries to improve the greedy solution
-func (s *PackService) optimizeGreedySolution(amount int, sizes []int, greedySolution map[int]int) map[int]int {
-	bestCombination := s.copyMap(greedySolution)
-	bestWaste, bestPackCount := s.calculateWasteAndPacks(amount, bestCombination)
-
-	for i := 0; i < len(sizes)-1; i++ {
-		for j := i + 1; j < len(sizes); j++ {
-			largerSize, smallerSize := sizes[i], sizes[j]
-
-			if bestCombination[largerSize] > 0 {
-				testCombination := s.copyMap(bestCombination)
-				testCombination[largerSize]--
-				if testCombination[largerSize] == 0 {
-					delete(testCombination, largerSize)
-				}
-
-				needed := largerSize
-				smallerCount := (needed + smallerSize - 1) / smallerSize
-				testCombination[smallerSize] += smallerCount
-
-				waste, packCount := s.calculateWasteAndPacks(amount, testCombination)
-				if waste < bestWaste || (waste == bestWaste && packCount < bestPackCount) {
-					bestWaste, bestPackCount = waste, packCount
-					bestCombination = testCombination
-				}
+	solutions, err := s.solve(ctx, solver, req.Amount, options, max(req.Alternatives, 1))
+	if err != nil && len(solutions) == 0 {
+		s.logger.Error("No combination found for amount %d: %v", req.Amount, err)
+		return nil, err
+	}
+	if len(solutions) == 0 {
+		s.logger.Warn("No combination in stock covers amount: %d", req.Amount)
+		return nil, fmt.Errorf("%w: no combination in stock covers %d items", entity.ErrInsufficientStock, req.Amount)
+	}
+	solution := solutions[0]
+	totalCost, shippingWeight := packTotals(packs, solution.Combination)
+
+	var alternatives []PackAlternative
+	if req.Alternatives > 0 {
+		alternatives = make([]PackAlternative, len(solutions))
+		for i, alternative := range solutions {
+			alternatives[i] = PackAlternative{
+				Rank:        i + 1,
+				Combination: alternative.Combination,
+				TotalPacks:  alternative.TotalPacks,
+				TotalAmount: alternative.TotalAmount,
+				Waste:       alternative.Waste,
 			}
+			alternatives[i].TotalCost, alternatives[i].ShippingWeight = packTotals(packs, alternative.Combination)
 		}
 	}
 
-	return bestCombination
-}
+	s.logger.Info("Optimal pack calculation completed - Objective: %s, Total packs: %d, Total amount: %d",
+		solver.Objective(), solution.TotalPacks, solution.TotalAmount)
 
-// copyMap creates a deep copy of a map[int]int
-func (s *PackService) copyMap(original map[int]int) map[int]int {
-	copy := make(map[int]int)
-	for k, v := range original {
-		copy[k] = v
-	}
-	return copy
+	return &PackCalculationResponse{
+		Amount:         req.Amount,
+		ProductID:      req.ProductID,
+		Objective:      solver.Objective(),
+		PackSizes:      packSizes,
+		Combination:    solution.Combination,
+		TotalPacks:     solution.TotalPacks,
+		TotalAmount:    solution.TotalAmount,
+		Waste:          solution.Waste,
+		Optimal:        err == nil,
+		TotalCost:      totalCost,
+		ShippingWeight: shippingWeight,
+		Alternatives:   alternatives,
+	}, nil
 }
 
-// searchZeroWasteSolution searches for combinations that result in zero waste
-func (s *PackService) searchZeroWasteSolution(amount int, sizes []int) map[int]int {
-	if len(sizes) == 0 {
-		return make(map[int]int)
+// solve runs the solver within the time budget. When the budget runs out
+// first, it returns the best combinations found so far along with the reason
+// the search stopped; when ctx itself is done, it returns only the error.
+func (s *PackService) solve(ctx context.Context, solver Solver, amount int64, options []PackOption, n int) ([]Solution, error) {
+	solveCtx := ctx
+	if s.timeBudget > 0 {
+		var cancel context.CancelFunc
+		solveCtx, cancel = context.WithTimeout(ctx, s.timeBudget)
+		defer cancel()
 	}
 
-	if amount <= 50000 {
-		return s.findExactSolution(amount, sizes)
+	solutions, err := solver.SolveTop(solveCtx, amount, options, n)
+	if err == nil {
+		return solutions, nil
 	}
-
-	return s.findLargeAmountSolution(amount, sizes)
-}
-
-// findExactSolution tries to find an exact solution for smaller amounts
-func (s *PackService) findExactSolution(amount int, sizes []int) map[int]int {
-	return s.recursiveExactSearch(amount, sizes, 0, make(map[int]int))
-}
-
-// findLargeAmountSolution uses a systematic approach for larger amounts
-func (s *PackService) findLargeAmountSolution(amount int, sizes []int) map[int]int {
-	largestSize := sizes[0]
-	maxLargest := amount / largestSize
-
-	for largestCount := maxLargest; largestCount >= maxLargest-20 && largestCount >= 0; largestCount-- {
-		remaining := amount - (largestSize * largestCount)
-		if remaining == 0 {
-			return map[int]int{largestSize: largestCount}
-		}
-		if remaining > 0 {
-			if solution := s.solveRemaining(remaining, sizes[1:]); len(solution) > 0 {
-				solution[largestSize] = largestCount
-				return solution
-			}
-		}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
-
-	return make(map[int]int)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("%w: stopped after %v", entity.ErrSolverBudgetExceeded, s.timeBudget)
+	}
+	s.logger.Warn("Solver stopped early for amount %d, keeping the best of %d combinations found: %v", amount, len(solutions), err)
+	return solutions, err
 }
 
-// recursiveExactSearch performs a recursive search for exact solutions
-func (s *PackService) recursiveExactSearch(amount int, sizes []int, index int, current map[int]int) map[int]int {
-	if amount == 0 {
-		return s.copyMap(current)
-	}
-	if amount < 0 || index >= len(sizes) {
-		return make(map[int]int)
+// packTotals returns the total cost and shipping weight of a combination.
+// A total is nil unless every pack size in the combination defines the
+// attribute, or when it does not fit in an int64.
+func packTotals(packs []entity.Pack, combination map[int64]int64) (*int64, *int64) {
+	if len(combination) == 0 {
+		return nil, nil
 	}
 
-	size := sizes[index]
-	maxCount := amount / size
+	bySize := make(map[int64]entity.Pack, len(packs))
+	for _, pack := range packs {
+		bySize[pack.Size()] = pack
+	}
 
-	for count := 0; count <= maxCount; count++ {
-		newCurrent := s.copyMap(current)
-		if count > 0 {
-			newCurrent[size] = count
+	var totalCost, totalWeight int64
+	hasCost, hasWeight := true, true
+	for size, quantity := range combination {
+		pack, ok := bySize[size]
+		if !ok {
+			return nil, nil
 		}
-
-		if result := s.recursiveExactSearch(amount-size*count, sizes, index+1, newCurrent); len(result) > 0 {
-			return result
+		if unitCost, ok := pack.UnitCost(); ok && hasCost {
+			totalCost, hasCost = addProduct(totalCost, unitCost, quantity)
+		} else {
+			hasCost = false
+		}
+		if weight, ok := pack.Weight(); ok && hasWeight {
+			totalWeight, hasWeight = addProduct(totalWeight, int64(weight), quantity)
+		} else {
+			hasWeight = false
 		}
 	}
 
-	return make(map[int]int)
-}
-
-// solveRemaining tries to solve the remaining amount with given sizes
-func (s *PackService) solveRemaining(amount int, sizes []int) map[int]int {
-	if len(sizes) == 0 {
-		return make(map[int]int)
+	var costResult *int64
+	if hasCost {
+		costResult = &totalCost
 	}
-	if len(sizes) == 1 {
-		if amount%sizes[0] == 0 {
-			return map[int]int{sizes[0]: amount / sizes[0]}
-		}
-		return make(map[int]int)
+	var weightResult *int64
+	if hasWeight {
+		weightResult = &totalWeight
 	}
-
-	return s.recursiveExactSearch(amount, sizes, 0, make(map[int]int))
+	return costResult, weightResult
 }
 
-// calculateWasteAndPacks calculates waste and pack count for a given combination
-func (s *PackService) calculateWasteAndPacks(amount int, combination map[int]int) (int, int) {
-	total := 0
-	packCount := 0
-
-	for size, count := range combination {
-		total += size * count
-		packCount += count
+// addProduct returns total+a*b, or false when that overflows an int64
+func addProduct(total, a, b int64) (int64, bool) {
+	product, ok := entity.CheckedMul(a, b)
+	if !ok {
+		return 0, false
 	}
+	return entity.CheckedAdd(total, product)
+}
 
-	waste := 0
-	if total > amount {
-		waste = total - amount
+// solverFor returns the solver for the requested objective, or the default one when none is given
+func (s *PackService) solverFor(objective Objective) (Solver, error) {
+	if objective == "" {
+		objective = s.defaultObjective
 	}
 
-	return waste, packCount
+	solver, ok := s.solvers[objective]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", entity.ErrUnknownObjective, objective)
+	}
+	return solver, nil
 }
 
-// GetAllPacks returns all available packs
-func (s *PackService) GetAllPacks(ctx context.Context) []entity.Pack {
+// GetAllPacks returns the packs of every product in effect now
+func (s *PackService) GetAllPacks(ctx context.Context) ([]entity.Pack, error) {
 	s.logger.Debug("Getting all packs")
 
-	packs := s.packRepo.List(ctx)
+	packs, err := s.packRepo.List(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list packs: %v", err)
+		return nil, err
+	}
 	s.logger.Debug("Retrieved %d packs", len(packs))
 
-	return packs
+	return packs, nil
 }
 
 // CreatePack creates a new pack
 func (s *PackService) CreatePack(ctx context.Context, pack *entity.Pack) error {
-	exists, err := s.packRepo.ExistsBySize(ctx, pack.Size())
+	exists, err := s.packRepo.ExistsBySize(ctx, pack.ProductID(), pack.Size(), pack.ValidFrom(), pack.ID())
 	if err != nil {
 		s.logger.Error("Failed to check if pack size exists: %v", err)
 		return err
@@ -302,34 +351,70 @@ func (s *PackService) CreatePack(ctx context.Context, pack *entity.Pack) error {
 	return s.packRepo.Create(ctx, pack)
 }
 
-// UpdatePack updates an existing pack
-func (s *PackService) UpdatePack(ctx context.Context, pack *entity.Pack) error {
-	currentPack, err := s.packRepo.Get(ctx, pack.ID())
+// PackChange is the next revision of a pack: its size and attributes,
+// effective from ValidFrom, or now when that is zero
+type PackChange struct {
+	Size       int64
+	Attributes entity.PackAttributes
+	ValidFrom  time.Time
+}
+
+// UpdatePack stores change as the next revision of the pack with the given ID
+// and returns that revision. The update is conditional on the stored revision
+// being version, see AnyVersion.
+func (s *PackService) UpdatePack(ctx context.Context, id uuid.UUID, change PackChange, version int) (*entity.Pack, error) {
+	currentPack, err := s.packRepo.Get(ctx, id)
 	if err != nil {
 		s.logger.Error("Failed to get current pack for update: %v", err)
-		return err
+		return nil, err
+	}
+	if err := checkVersion(currentPack.Revision(), version); err != nil {
+		s.logger.Warn("Rejected update of pack %s: %v", id, err)
+		return nil, err
 	}
 
-	if pack.Size() != currentPack.Size() {
-		exists, err := s.packRepo.ExistsBySize(ctx, pack.Size())
-		if err != nil {
-			s.logger.Error("Failed to check if pack size exists during update: %v", err)
-			return err
-		}
+	// The stored pack becomes the next revision, so that callers can neither
+	// skip revisions nor rewrite one
+	if err := currentPack.Revise(change.Size, change.Attributes, change.ValidFrom); err != nil {
+		s.logger.Warn("Invalid revision of pack %s: %v", id, err)
+		return nil, err
+	}
 
-		if exists {
-			s.logger.Warn("Attempted to update pack to duplicate size: %d", pack.Size())
-			return entity.ErrDuplicatePackSize
-		}
+	// The size must stay free from when the revision takes effect, not only
+	// now: the head revision may be one that takes effect later
+	exists, err := s.packRepo.ExistsBySize(ctx, currentPack.ProductID(), currentPack.Size(), currentPack.ValidFrom(), currentPack.ID())
+	if err != nil {
+		s.logger.Error("Failed to check if pack size exists during update: %v", err)
+		return nil, err
+	}
+	if exists {
+		s.logger.Warn("Attempted to update pack to duplicate size: %d", change.Size)
+		return nil, entity.ErrDuplicatePackSize
 	}
 
-	return s.packRepo.Update(ctx, pack)
+	if err := s.packRepo.Update(ctx, currentPack); err != nil {
+		return nil, err
+	}
+
+	return currentPack, nil
 }
 
-// DeletePack deletes a pack
-func (s *PackService) DeletePack(ctx context.Context, pack *entity.Pack) error {
+// DeletePack retires a pack now. Its revisions stay in the history of the
+// catalog. Deleting is conditional on pack's revision being version, see
+// AnyVersion.
+func (s *PackService) DeletePack(ctx context.Context, pack *entity.Pack, version int) error {
 	s.logger.Info("Deleting pack with ID: %s, size: %d", pack.ID(), pack.Size())
 
+	if err := checkVersion(pack.Revision(), version); err != nil {
+		s.logger.Warn("Rejected deletion of pack %s: %v", pack.ID(), err)
+		return err
+	}
+
+	if err := pack.Deactivate(time.Time{}); err != nil {
+		s.logger.Warn("Failed to deactivate pack %s: %v", pack.ID(), err)
+		return err
+	}
+
 	err := s.packRepo.Delete(ctx, pack)
 	if err != nil {
 		s.logger.Error("Failed to delete pack %s: %v", pack.ID(), err)
@@ -340,18 +425,22 @@ func (s *PackService) DeletePack(ctx context.Context, pack *entity.Pack) error {
 	return nil
 }
 
-// GetPackByID retrieves a pack by its ID
+// GetPackByID retrieves the latest revision of an active pack by its ID
 func (s *PackService) GetPackByID(ctx context.Context, id string) (*entity.Pack, error) {
 	s.logger.Debug("Getting pack by ID: %s", id)
 
-	packs := s.packRepo.List(ctx)
-	for _, pack := range packs {
-		if pack.ID().String() == id {
-			s.logger.Debug("Pack found with ID: %s", id)
-			return &pack, nil
-		}
+	packID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Warn("Invalid pack ID: %s", id)
+		return nil, entity.ErrPackNotFound
+	}
+
+	pack, err := s.packRepo.Get(ctx, packID)
+	if err != nil {
+		s.logger.Warn("Pack not found with ID: %s", id)
+		return nil, err
 	}
 
-	s.logger.Warn("Pack not found with ID: %s", id)
-	return nil, entity.ErrOrderNotFound
+	s.logger.Debug("Pack found with ID: %s", id)
+	return pack, nil
 }
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+)
+
+const (
+	// DefaultAnalysisAmount is the largest amount analysed when a request names none
+	DefaultAnalysisAmount = 10000
+	// MaxAnalysisAmount is the largest amount an analysis may cover
+	MaxAnalysisAmount = 100000
+	// MaxAnalysisPackSize is the largest size a proposed pack set may contain
+	MaxAnalysisPackSize = 1000000
+	// analysisWorstCases is how many of the most wasteful amounts are reported
+	analysisWorstCases = 10
+	// analysisWasteBuckets is how many waste ranges the distribution has
+	analysisWasteBuckets = 10
+)
+
+// PackAnalysisRequest selects the pack set to analyse and the amounts to cover.
+// The current pack set of the product is analysed unless Sizes proposes another.
+type PackAnalysisRequest struct {
+	// Max is the largest amount analysed; amounts 1 to Max are covered
+	Max int `json:"-" form:"max" binding:"omitempty,min=1,max=100000"`
+	// ProductID selects the current pack set; the default product is used when it is empty
+	ProductID string `json:"-" form:"product_id" binding:"omitempty,uuid" format:"uuid"`
+	// Sizes is a proposed pack set to analyse instead of the current one
+	Sizes []int64 `json:"sizes,omitempty" form:"-" binding:"omitempty,max=100,dive,min=1,max=1000000"`
+}
+
+// PackAnalysisResponse describes how well a pack set covers the amounts 1 to
+// Max when every amount is solved for the least waste
+type PackAnalysisResponse struct {
+	// ProductID is set when the current pack set of a product was analysed
+	ProductID string `json:"product_id,omitempty"`
+	// Proposed is true when the sizes came from the request
+	Proposed  bool    `json:"proposed"`
+	PackSizes []int64 `json:"pack_sizes"`
+	Max       int     `json:"max"`
+	// GCD is the greatest common divisor of the sizes; only its multiples can be hit exactly
+	GCD int64 `json:"gcd"`
+	// FrobeniusNumber is the largest amount no combination hits exactly, 0 when every
+	// amount can be hit. It is omitted when GCD is above 1, as no other amount is ever hit.
+	FrobeniusNumber *int64 `json:"frobenius_number,omitempty"`
+	// ExactAmounts counts the amounts up to Max that ship without waste
+	ExactAmounts      int              `json:"exact_amounts"`
+	AverageWaste      float64          `json:"average_waste"`
+	AveragePacks      float64          `json:"average_packs"`
+	MaxWaste          int64            `json:"max_waste"`
+	WasteDistribution []WasteBucket    `json:"waste_distribution"`
+	WorstCases        []AmountAnalysis `json:"worst_cases"`
+}
+
+// WasteBucket counts the analysed amounts whose waste is within From and To, inclusive
+type WasteBucket struct {
+	From  int64 `json:"from"`
+	To    int64 `json:"to"`
+	Count int   `json:"count"`
+}
+
+// AmountAnalysis is the least-waste result for one amount
+type AmountAnalysis struct {
+	Amount      int64 `json:"amount"`
+	TotalAmount int64 `json:"total_amount"`
+	Waste       int64 `json:"waste"`
+	TotalPacks  int64 `json:"total_packs"`
+}
+
+// AnalyzePackSet reports the coverage of the requested pack set
+func (s *PackService) AnalyzePackSet(ctx context.Context, req PackAnalysisRequest) (*PackAnalysisResponse, error) {
+	maxAmount := req.Max
+	if maxAmount == 0 {
+		maxAmount = DefaultAnalysisAmount
+	}
+	if maxAmount < 0 || maxAmount > MaxAnalysisAmount {
+		s.logger.Error("Invalid analysis range requested: %d", req.Max)
+		return nil, fmt.Errorf("%w: max must be 1 to %d", entity.ErrInvalidPackAnalysis, MaxAnalysisAmount)
+	}
+
+	sizes := req.Sizes
+	proposed := len(sizes) > 0
+	if !proposed {
+		packs, err := s.productPacks(ctx, &req.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		for _, pack := range packs {
+			sizes = append(sizes, pack.Size())
+		}
+	}
+	for _, size := range sizes {
+		if size <= 0 || size > MaxAnalysisPackSize {
+			s.logger.Error("Invalid pack size for analysis: %d", size)
+			return nil, fmt.Errorf("%w: pack sizes must be 1 to %d", entity.ErrInvalidPackAnalysis, MaxAnalysisPackSize)
+		}
+	}
+	if len(sizes) == 0 {
+		s.logger.Warn("Analysis requested for an empty pack set")
+		return nil, fmt.Errorf("%w: the pack set is empty", entity.ErrInvalidPackAnalysis)
+	}
+
+	s.logger.Info("Analysing %d pack sizes for amounts up to %d", len(sizes), maxAmount)
+	analysis, err := analyzePackSet(sizes, maxAmount)
+	if err != nil {
+		s.logger.Error("Failed to analyse pack sizes: %v", err)
+		return nil, err
+	}
+	analysis.Proposed = proposed
+	if !proposed {
+		analysis.ProductID = req.ProductID
+	}
+
+	return &analysis, nil
+}
+
+// analyzePackSet solves every amount from 1 to maxAmount for the least waste
+// with one exact-total DP over the pack set, like the min_waste objective
+func analyzePackSet(sizes []int64, maxAmount int) (PackAnalysisResponse, error) {
+	packOptions := make([]PackOption, len(sizes))
+	for i, size := range sizes {
+		packOptions[i] = PackOption{Size: size}
+	}
+	options := uniqueOptions(packOptions)
+
+	response := PackAnalysisResponse{
+		PackSizes:  make([]int64, len(options)),
+		Max:        maxAmount,
+		WorstCases: []AmountAnalysis{},
+	}
+	for i, option := range options {
+		response.PackSizes[len(options)-1-i] = option.Size
+		response.GCD = gcd(response.GCD, option.Size)
+	}
+	if response.GCD == 1 {
+		frobenius := frobeniusNumber(response.PackSizes)
+		response.FrobeniusNumber = &frobenius
+	}
+
+	cover, err := newCoverage(options, int64(maxAmount))
+	if err != nil {
+		return response, err
+	}
+	results := make([]AmountAnalysis, maxAmount)
+	var totalWaste, totalPacks int64
+	for amount := int64(1); amount <= int64(maxAmount); amount++ {
+		total, packs := cover.solve(amount)
+		result := AmountAnalysis{
+			Amount:      amount,
+			TotalAmount: total,
+			Waste:       total - amount,
+			TotalPacks:  packs,
+		}
+		results[amount-1] = result
+
+		totalWaste += result.Waste
+		totalPacks += result.TotalPacks
+		response.MaxWaste = max(response.MaxWaste, result.Waste)
+		if result.Waste == 0 {
+			response.ExactAmounts++
+		}
+	}
+	response.AverageWaste = float64(totalWaste) / float64(maxAmount)
+	response.AveragePacks = float64(totalPacks) / float64(maxAmount)
+	response.WasteDistribution = wasteDistribution(results, response.MaxWaste)
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Waste > results[j].Waste
+	})
+	for _, result := range results[:min(analysisWorstCases, len(results))] {
+		if result.Waste == 0 {
+			break
+		}
+		response.WorstCases = append(response.WorstCases, result)
+	}
+
+	return response, nil
+}
+
+// coverage holds the least-waste solution of every amount up to a limit,
+// found with one exact-total DP over a pack set like the min_waste objective
+type coverage struct {
+	divisor int64
+	table   *dpTable
+	// next[t] is the smallest reachable total at or above t
+	next []int
+}
+
+// newCoverage solves every amount up to maxAmount with the given options,
+// which must be sorted largest first as uniqueOptions returns them. Sizes above
+// MaxAnalysisPackSize, or a table above MaxSolverTable entries, are refused
+// with entity.ErrSolverBudgetExceeded.
+func newCoverage(options []PackOption, maxAmount int64) (coverage, error) {
+	var divisor int64
+	for _, option := range options {
+		if option.Size > MaxAnalysisPackSize {
+			return coverage{}, fmt.Errorf("%w: pack size %d is above the largest analysed size %d",
+				entity.ErrSolverBudgetExceeded, option.Size, MaxAnalysisPackSize)
+		}
+		divisor = gcd(divisor, option.Size)
+	}
+
+	// Every amount is covered by a total below its target plus the largest
+	// pack, all measured in units of the GCD
+	units := (maxAmount+divisor-1)/divisor + options[0].Size/divisor
+	if units > MaxSolverTable {
+		return coverage{}, fmt.Errorf("%w: a table of %d entries is needed", entity.ErrSolverBudgetExceeded, units)
+	}
+	limit := int(units)
+	table, err := newMinWasteSolver().fillUnbounded(context.Background(), options, divisor, limit)
+	if err != nil {
+		return coverage{}, err
+	}
+
+	next := make([]int, limit+1)
+	next[limit] = unreachable
+	for total := limit - 1; total >= 0; total-- {
+		next[total] = next[total+1]
+		if table.packs[total] != unreachable {
+			next[total] = total
+		}
+	}
+
+	return coverage{divisor: divisor, table: table, next: next}, nil
+}
+
+// solve returns the smallest total that covers amount and the fewest packs
+// that ship it
+func (c coverage) solve(amount int64) (total, packs int64) {
+	units := c.next[(amount+c.divisor-1)/c.divisor]
+	return int64(units) * c.divisor, int64(c.table.packs[units])
+}
+
+// wasteDistribution counts the results by waste. Exact amounts get a bucket of
+// their own; the remaining waste range is split into equal-width buckets.
+func wasteDistribution(results []AmountAnalysis, maxWaste int64) []WasteBucket {
+	buckets := []WasteBucket{{From: 0, To: 0}}
+	if maxWaste > 0 {
+		width := (maxWaste + analysisWasteBuckets - 2) / (analysisWasteBuckets - 1)
+		for from := int64(1); from <= maxWaste; from += width {
+			buckets = append(buckets, WasteBucket{From: from, To: min(from+width-1, maxWaste)})
+		}
+	}
+
+	for _, result := range results {
+		i := 0
+		if result.Waste > 0 {
+			i = 1 + int((result.Waste-1)/(buckets[1].To-buckets[1].From+1))
+		}
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// frobeniusNumber returns the largest amount that no combination of the
+// sizes hits exactly, or 0 when every amount can be hit. The sizes must be in
+// ascending order with a GCD of 1.
+//
+// It finds, for every remainder modulo the smallest size, the smallest
+// reachable amount with that remainder (Böcker and Lipták's round-robin
+// algorithm); the largest of those minus the smallest size is the answer.
+func frobeniusNumber(sizes []int64) int64 {
+	smallest := sizes[0]
+	if smallest == 1 {
+		return 0
+	}
+
+	const unset = -1
+	reachable := make([]int64, smallest)
+	for r := range reachable {
+		reachable[r] = unset
+	}
+	reachable[0] = 0
+
+	for _, size := range sizes[1:] {
+		d := gcd(smallest, size)
+		for p := int64(0); p < d; p++ {
+			// Start from the smallest amount known for this residue class
+			n := int64(unset)
+			for q := p; q < smallest; q += d {
+				if reachable[q] != unset && (n == unset || reachable[q] < n) {
+					n = reachable[q]
+				}
+			}
+			if n == unset {
+				continue
+			}
+
+			for i := int64(0); i < smallest/d-1; i++ {
+				n += size
+				r := n % smallest
+				if reachable[r] != unset && reachable[r] < n {
+					n = reachable[r]
+				}
+				reachable[r] = n
+			}
+		}
+	}
+
+	var largest int64
+	for _, n := range reachable {
+		largest = max(largest, n)
+	}
+	return max(largest-smallest, 0)
+}
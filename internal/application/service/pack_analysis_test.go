@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrobeniusNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		sizes    []int64
+		expected int64
+	}{
+		{name: "Every amount", sizes: []int64{1, 7}, expected: 0},
+		{name: "Two coprime sizes", sizes: []int64{3, 5}, expected: 7},
+		{name: "Chicken nuggets", sizes: []int64{6, 9, 20}, expected: 43},
+		{name: "Shared divisors", sizes: []int64{4, 6, 9}, expected: 11},
+		{name: "Consecutive sizes", sizes: []int64{10, 11, 12, 13}, expected: 29},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := frobeniusNumber(tt.sizes); got != tt.expected {
+				t.Errorf("Expected Frobenius number %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFrobeniusNumber_MatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(16))
+
+	for i := 0; i < 200; i++ {
+		analysis, err := analyzePackSet(randomSizes(rng, 2+rng.Intn(3), 2, 30), 1)
+		require.NoError(t, err)
+		if analysis.GCD != 1 {
+			continue
+		}
+		sizes := analysis.PackSizes
+
+		// Schur's bound keeps the Frobenius number below the product of the
+		// smallest and the largest size
+		bound := sizes[0] * sizes[len(sizes)-1]
+		hit := make([]bool, bound+1)
+		hit[0] = true
+		expected := int64(0)
+		for amount := int64(1); amount <= bound; amount++ {
+			for _, size := range sizes {
+				if size <= amount && hit[amount-size] {
+					hit[amount] = true
+					break
+				}
+			}
+			if !hit[amount] {
+				expected = amount
+			}
+		}
+
+		if got := *analysis.FrobeniusNumber; got != expected {
+			t.Fatalf("Sizes %v: expected Frobenius number %d, got %d", sizes, expected, got)
+		}
+	}
+}
+
+func TestAnalyzePackSet_MatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(61))
+
+	for i := 0; i < 50; i++ {
+		sizes := randomSizes(rng, 1+rng.Intn(3), 2, 40)
+		maxAmount := 1 + rng.Intn(120)
+		analysis, err := analyzePackSet(sizes, maxAmount)
+		require.NoError(t, err)
+
+		options := make([]PackOption, len(analysis.PackSizes))
+		for j, size := range analysis.PackSizes {
+			options[j] = PackOption{Size: size}
+		}
+
+		var totalWaste int64
+		for amount := int64(1); amount <= int64(maxAmount); amount++ {
+			expected, ok := bruteForceOptimum(ObjectiveMinWaste, SolverWeights{}, amount, options)
+			require.True(t, ok)
+			totalWaste += expected.waste
+			if expected.waste > analysis.MaxWaste {
+				t.Fatalf("Sizes %v, amount %d: waste %d is above the reported maximum %d",
+					analysis.PackSizes, amount, expected.waste, analysis.MaxWaste)
+			}
+		}
+
+		if want := float64(totalWaste) / float64(maxAmount); analysis.AverageWaste != want {
+			t.Fatalf("Sizes %v up to %d: expected average waste %v, got %v",
+				analysis.PackSizes, maxAmount, want, analysis.AverageWaste)
+		}
+		for _, worst := range analysis.WorstCases {
+			expected, _ := bruteForceOptimum(ObjectiveMinWaste, SolverWeights{}, worst.Amount, options)
+			if worst.Waste != expected.waste || worst.TotalPacks != expected.packs {
+				t.Fatalf("Sizes %v, amount %d: expected waste %d in %d packs, got %d in %d packs",
+					analysis.PackSizes, worst.Amount, expected.waste, expected.packs, worst.Waste, worst.TotalPacks)
+			}
+		}
+	}
+}
+
+func TestAnalyzePackSet_DefaultSizes(t *testing.T) {
+	analysis, err := analyzePackSet([]int64{5000, 250, 1000, 500, 2000, 500}, 1000)
+	require.NoError(t, err)
+
+	require.Equal(t, []int64{250, 500, 1000, 2000, 5000}, analysis.PackSizes)
+	if analysis.GCD != 250 {
+		t.Errorf("Expected GCD 250, got %d", analysis.GCD)
+	}
+	if analysis.FrobeniusNumber != nil {
+		t.Errorf("Expected no Frobenius number for sizes sharing a divisor, got %d", *analysis.FrobeniusNumber)
+	}
+	if analysis.ExactAmounts != 4 {
+		t.Errorf("Expected 250, 500, 750 and 1000 to be exact, got %d exact amounts", analysis.ExactAmounts)
+	}
+	if analysis.MaxWaste != 249 {
+		t.Errorf("Expected maximum waste 249, got %d", analysis.MaxWaste)
+	}
+	// Each block of 250 amounts wastes 0 to 249, 124.5 on average
+	if analysis.AverageWaste != 124.5 {
+		t.Errorf("Expected average waste 124.5, got %v", analysis.AverageWaste)
+	}
+	// 1-250 and 251-500 take one pack, 501-750 two and 751-1000 one
+	if analysis.AveragePacks != 1.25 {
+		t.Errorf("Expected 1.25 packs on average, got %v", analysis.AveragePacks)
+	}
+
+	counted := 0
+	for _, bucket := range analysis.WasteDistribution {
+		counted += bucket.Count
+	}
+	if counted != 1000 {
+		t.Errorf("Expected the distribution to count all 1000 amounts, got %d", counted)
+	}
+	if first := analysis.WasteDistribution[0]; first.From != 0 || first.To != 0 || first.Count != 4 {
+		t.Errorf("Expected the exact amounts in the first bucket, got %+v", first)
+	}
+	if last := analysis.WasteDistribution[len(analysis.WasteDistribution)-1]; last.To != 249 {
+		t.Errorf("Expected the last bucket to end at the maximum waste, got %+v", last)
+	}
+
+	require.Len(t, analysis.WorstCases, analysisWorstCases)
+	worst := analysis.WorstCases[0]
+	if worst.Amount != 1 || worst.Waste != 249 || worst.TotalAmount != 250 || worst.TotalPacks != 1 {
+		t.Errorf("Expected amount 1 to be the worst case, got %+v", worst)
+	}
+	for i := 1; i < len(analysis.WorstCases); i++ {
+		if analysis.WorstCases[i].Waste > analysis.WorstCases[i-1].Waste {
+			t.Fatalf("Expected worst cases by descending waste, got %+v", analysis.WorstCases)
+		}
+	}
+}
+
+func TestAnalyzePackSet_NoWaste(t *testing.T) {
+	analysis, err := analyzePackSet([]int64{1, 3}, 10)
+	require.NoError(t, err)
+
+	if analysis.MaxWaste != 0 || analysis.ExactAmounts != 10 {
+		t.Errorf("Expected every amount to be exact, got %+v", analysis)
+	}
+	if len(analysis.WorstCases) != 0 {
+		t.Errorf("Expected no worst cases without waste, got %+v", analysis.WorstCases)
+	}
+	require.Len(t, analysis.WasteDistribution, 1)
+	if analysis.FrobeniusNumber == nil || *analysis.FrobeniusNumber != 0 {
+		t.Errorf("Expected Frobenius number 0, got %v", analysis.FrobeniusNumber)
+	}
+}
+
+func TestPackService_AnalyzePackSet(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+	productID := uuid.New()
+	mockRepo.addProduct(t, productID, 6, 9, 20)
+
+	current, err := service.AnalyzePackSet(context.Background(), PackAnalysisRequest{})
+	require.NoError(t, err)
+	if current.Proposed || current.ProductID != entity.DefaultProductID.String() {
+		t.Errorf("Expected the current set of the default product, got product %q proposed %t", current.ProductID, current.Proposed)
+	}
+	if current.Max != DefaultAnalysisAmount {
+		t.Errorf("Expected the default range of %d, got %d", DefaultAnalysisAmount, current.Max)
+	}
+
+	product, err := service.AnalyzePackSet(context.Background(), PackAnalysisRequest{Max: 100, ProductID: productID.String()})
+	require.NoError(t, err)
+	require.Equal(t, []int64{6, 9, 20}, product.PackSizes)
+	if product.FrobeniusNumber == nil || *product.FrobeniusNumber != 43 {
+		t.Errorf("Expected Frobenius number 43, got %v", product.FrobeniusNumber)
+	}
+
+	proposed, err := service.AnalyzePackSet(context.Background(), PackAnalysisRequest{Max: 50, ProductID: productID.String(), Sizes: []int64{4, 7}})
+	require.NoError(t, err)
+	if !proposed.Proposed || proposed.ProductID != "" {
+		t.Errorf("Expected a proposed set without a product, got product %q proposed %t", proposed.ProductID, proposed.Proposed)
+	}
+	require.Equal(t, []int64{4, 7}, proposed.PackSizes)
+	if *proposed.FrobeniusNumber != 17 {
+		t.Errorf("Expected Frobenius number 17, got %d", *proposed.FrobeniusNumber)
+	}
+}
+
+func TestPackService_AnalyzePackSet_Invalid(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+	emptyProduct := uuid.New()
+	mockRepo.addProduct(t, emptyProduct)
+
+	tests := []struct {
+		name        string
+		req         PackAnalysisRequest
+		expectedErr error
+	}{
+		{name: "Range too large", req: PackAnalysisRequest{Max: MaxAnalysisAmount + 1}, expectedErr: entity.ErrInvalidPackAnalysis},
+		{name: "Negative range", req: PackAnalysisRequest{Max: -1}, expectedErr: entity.ErrInvalidPackAnalysis},
+		{name: "Zero size", req: PackAnalysisRequest{Sizes: []int64{250, 0}}, expectedErr: entity.ErrInvalidPackAnalysis},
+		{name: "Size too large", req: PackAnalysisRequest{Sizes: []int64{MaxAnalysisPackSize + 1}}, expectedErr: entity.ErrInvalidPackAnalysis},
+		{name: "Empty pack set", req: PackAnalysisRequest{ProductID: emptyProduct.String()}, expectedErr: entity.ErrInvalidPackAnalysis},
+		{name: "Unknown product", req: PackAnalysisRequest{ProductID: uuid.New().String()}, expectedErr: entity.ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.AnalyzePackSet(context.Background(), tt.req); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+// randomSizes returns count sizes between low and high, duplicates allowed
+func randomSizes(rng *rand.Rand, count int, low, high int64) []int64 {
+	sizes := make([]int64, count)
+	for i := range sizes {
+		sizes[i] = low + rng.Int63n(high-low+1)
+	}
+	return sizes
+}
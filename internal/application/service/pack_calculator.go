@@ -7,18 +7,30 @@ import (
 
 // PackCalculatorService provides pack calculation functionality
 type PackCalculatorService struct {
-	packService  *PackService
-	orderService *OrderService
+	packService           *PackService
+	orderService          *OrderService
+	stockService          *StockService
+	productService        *ProductService
+	simulationService     *SimulationService
+	recommendationService *RecommendationService
 }
 
 // NewPackCalculatorService creates a new pack calculator service
-func NewPackCalculatorService(packRepo repository.PackRepository, orderRepo repository.OrderRepository, logger *logger.Logger) *PackCalculatorService {
-	packService := NewPackService(packRepo, logger)
-	orderService := NewOrderService(orderRepo, packRepo, packService, logger)
+func NewPackCalculatorService(productRepo repository.ProductRepository, packRepo repository.PackRepository, orderRepo repository.OrderRepository, stockRepo repository.StockRepository, solverConfig SolverConfig, logger *logger.Logger) *PackCalculatorService {
+	packService := NewPackService(packRepo, solverConfig, logger)
+	orderService := NewOrderService(orderRepo, packRepo, stockRepo, packService, logger)
+	stockService := NewStockService(stockRepo, packRepo, logger)
+	productService := NewProductService(productRepo, packRepo, logger)
+	simulationService := NewSimulationService(orderRepo, packRepo, packService, logger)
+	recommendationService := NewRecommendationService(orderRepo, packService, logger)
 
 	return &PackCalculatorService{
-		packService:  packService,
-		orderService: orderService,
+		packService:           packService,
+		orderService:          orderService,
+		stockService:          stockService,
+		productService:        productService,
+		simulationService:     simulationService,
+		recommendationService: recommendationService,
 	}
 }
 
@@ -31,3 +43,23 @@ func (s *PackCalculatorService) GetPackService() *PackService {
 func (s *PackCalculatorService) GetOrderService() *OrderService {
 	return s.orderService
 }
+
+// GetStockService returns the underlying stock service for additional operations
+func (s *PackCalculatorService) GetStockService() *StockService {
+	return s.stockService
+}
+
+// GetProductService returns the underlying product service for additional operations
+func (s *PackCalculatorService) GetProductService() *ProductService {
+	return s.productService
+}
+
+// GetSimulationService returns the underlying simulation service for additional operations
+func (s *PackCalculatorService) GetSimulationService() *SimulationService {
+	return s.simulationService
+}
+
+// GetRecommendationService returns the underlying recommendation service for additional operations
+func (s *PackCalculatorService) GetRecommendationService() *RecommendationService {
+	return s.recommendationService
+}
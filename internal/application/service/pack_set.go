@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/google/uuid"
+)
+
+// PackSetRequest is the complete list of pack sizes a product should have.
+// Sizes the product has but the list leaves out are retired.
+type PackSetRequest struct {
+	// ProductID selects the product; the default product is used when it is empty
+	ProductID string  `json:"product_id,omitempty" form:"-" format:"uuid"`
+	Sizes     []int64 `json:"sizes" form:"-"`
+	// ValidFrom is when the new pack set takes effect; it takes effect now when omitted
+	ValidFrom *time.Time `json:"valid_from,omitempty" form:"-" format:"date-time"`
+	// DryRun computes and validates the changes without applying them
+	DryRun bool `json:"-" form:"dry_run"`
+}
+
+// PackSetResponse describes how the requested pack set differs from the
+// product's active packs
+type PackSetResponse struct {
+	ProductID string `json:"product_id" format:"uuid"`
+	// Added are the sizes that are created
+	Added []int64 `json:"added"`
+	// Removed are the sizes that are retired
+	Removed []int64 `json:"removed"`
+	// Unchanged are the sizes the product keeps
+	Unchanged []int64 `json:"unchanged"`
+	// PackSizes is the resulting pack set
+	PackSizes []int64   `json:"pack_sizes"`
+	ValidFrom time.Time `json:"valid_from"`
+	// Version is the ETag of the product's pack set: of the resulting set, or
+	// of the set the changes apply to on a dry run
+	Version int `json:"version"`
+	// DryRun is true when the changes were not applied
+	DryRun bool `json:"dry_run"`
+}
+
+// validate checks the requested sizes and returns them in ascending order
+func (r PackSetRequest) validate() ([]int64, error) {
+	if len(r.Sizes) == 0 {
+		return nil, fmt.Errorf("%w: the pack set cannot be empty", entity.ErrInvalidPackSet)
+	}
+	if r.ValidFrom != nil && r.ValidFrom.Before(time.Now()) {
+		return nil, entity.ErrPackEffectiveDate
+	}
+
+	sizes := append([]int64(nil), r.Sizes...)
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	for i, size := range sizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("%w: size %d is not greater than 0", entity.ErrInvalidPackSet, size)
+		}
+		if size > entity.MaxAmount {
+			return nil, fmt.Errorf("%w: size %d is above the largest supported amount %d", entity.ErrInvalidPackSet, size, entity.MaxAmount)
+		}
+		if i > 0 && sizes[i-1] == size {
+			return nil, fmt.Errorf("%w: size %d is listed more than once", entity.ErrInvalidPackSet, size)
+		}
+	}
+	return sizes, nil
+}
+
+// PackSetVersion returns the version of a product's pack set, see
+// entity.PackSetVersion
+func (s *PackService) PackSetVersion(ctx context.Context, productID uuid.UUID) (int, error) {
+	current, err := s.packRepo.ListActive(ctx, productID)
+	if err != nil {
+		s.logger.Error("Failed to load active packs of product %s: %v", productID, err)
+		return 0, err
+	}
+	return entity.PackSetVersion(current), nil
+}
+
+// ReplacePackSet makes the requested sizes the complete pack set of a
+// product. New sizes are created and missing ones retired in one transaction;
+// the attributes and history of the sizes it keeps are left alone. The
+// replacement is conditional on the pack set being at version, see AnyVersion
+// and PackSetVersion.
+func (s *PackService) ReplacePackSet(ctx context.Context, req PackSetRequest, version int) (*PackSetResponse, error) {
+	sizes, err := req.validate()
+	if err != nil {
+		s.logger.Warn("Invalid pack set: %v", err)
+		return nil, err
+	}
+
+	productID, err := resolveProductID(req.ProductID)
+	if err != nil {
+		s.logger.Error("Invalid product provided: %v", err)
+		return nil, err
+	}
+
+	current, err := s.packRepo.ListActive(ctx, productID)
+	if err != nil {
+		s.logger.Error("Failed to load active packs of product %s: %v", productID, err)
+		return nil, err
+	}
+	setVersion := entity.PackSetVersion(current)
+	if err := checkVersion(setVersion, version); err != nil {
+		s.logger.Warn("Rejected replacement of the pack set of product %s: %v", productID, err)
+		return nil, err
+	}
+
+	var validFrom time.Time
+	if req.ValidFrom != nil {
+		validFrom = *req.ValidFrom
+	}
+
+	response := &PackSetResponse{
+		ProductID: productID.String(),
+		Added:     []int64{},
+		Removed:   []int64{},
+		Unchanged: []int64{},
+		PackSizes: sizes,
+		ValidFrom: validFrom,
+		Version:   setVersion,
+		DryRun:    req.DryRun,
+	}
+	if validFrom.IsZero() {
+		response.ValidFrom = time.Now()
+	}
+
+	requested := make(map[int64]bool, len(sizes))
+	for _, size := range sizes {
+		requested[size] = true
+	}
+
+	kept := make(map[int64]bool, len(current))
+	var remaining []entity.Pack
+	var retired []*entity.Pack
+	for i := range current {
+		pack := &current[i]
+		if requested[pack.Size()] {
+			kept[pack.Size()] = true
+			remaining = append(remaining, *pack)
+			response.Unchanged = append(response.Unchanged, pack.Size())
+			continue
+		}
+		if err := pack.Deactivate(validFrom); err != nil {
+			return nil, err
+		}
+		retired = append(retired, pack)
+		response.Removed = append(response.Removed, pack.Size())
+	}
+
+	var created []*entity.Pack
+	for _, size := range sizes {
+		if kept[size] {
+			continue
+		}
+		pack, err := entity.NewScheduledPack(uuid.New(), productID, size, entity.PackAttributes{}, validFrom)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, pack)
+		remaining = append(remaining, *pack)
+		response.Added = append(response.Added, size)
+	}
+
+	if req.DryRun || (len(created) == 0 && len(retired) == 0) {
+		s.logger.Info("Pack set of product %s: adding %v, removing %v (dry run: %v)", productID, response.Added, response.Removed, req.DryRun)
+		return response, nil
+	}
+
+	if err := s.packRepo.ReplaceSet(ctx, productID, setVersion, created, retired); err != nil {
+		s.logger.Error("Failed to replace pack set of product %s: %v", productID, err)
+		// A pack retired meanwhile means the set changed since it was read
+		if errors.Is(err, entity.ErrPackNotFound) {
+			return nil, fmt.Errorf("%w: %v", entity.ErrPackModified, err)
+		}
+		return nil, err
+	}
+
+	response.Version = entity.PackSetVersion(remaining)
+
+	s.logger.Info("Replaced pack set of product %s: added %v, removed %v", productID, response.Added, response.Removed)
+	return response, nil
+}
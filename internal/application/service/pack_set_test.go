@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// packSizes returns the sizes of packs in order
+func packSizes(packs []entity.Pack) []int64 {
+	sizes := make([]int64, len(packs))
+	for i, pack := range packs {
+		sizes[i] = pack.Size()
+	}
+	return sizes
+}
+
+func TestPackService_ReplacePackSet(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	before := time.Now()
+	result, err := service.ReplacePackSet(context.Background(), PackSetRequest{Sizes: []int64{1000, 750, 500}}, AnyVersion)
+	require.NoError(t, err)
+
+	require.Equal(t, entity.DefaultProductID.String(), result.ProductID)
+	require.Equal(t, []int64{750}, result.Added)
+	require.Equal(t, []int64{250, 2000, 5000}, result.Removed)
+	require.Equal(t, []int64{500, 1000}, result.Unchanged)
+	require.Equal(t, []int64{500, 750, 1000}, result.PackSizes)
+	require.False(t, result.DryRun)
+
+	require.Equal(t, []int64{500, 750, 1000}, packSizes(allPacks(t, service)))
+
+	// The replaced sizes stay in the history of the catalog
+	past, err := mockRepo.ListAsOf(context.Background(), entity.DefaultProductID, before)
+	require.NoError(t, err)
+	require.Equal(t, []int64{250, 500, 1000, 2000, 5000}, packSizes(past))
+}
+
+func TestPackService_ReplacePackSet_DryRun(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	result, err := service.ReplacePackSet(context.Background(), PackSetRequest{Sizes: []int64{250, 300}, DryRun: true}, AnyVersion)
+	require.NoError(t, err)
+
+	require.True(t, result.DryRun)
+	require.Equal(t, []int64{300}, result.Added)
+	require.Equal(t, []int64{500, 1000, 2000, 5000}, result.Removed)
+	require.Equal(t, []int64{250}, result.Unchanged)
+	require.Equal(t, []int64{250, 500, 1000, 2000, 5000}, packSizes(allPacks(t, service)))
+}
+
+func TestPackService_ReplacePackSet_Scheduled(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	monday := time.Now().Add(72 * time.Hour)
+	result, err := service.ReplacePackSet(context.Background(), PackSetRequest{Sizes: []int64{300, 600}, ValidFrom: &monday}, AnyVersion)
+	require.NoError(t, err)
+	require.True(t, result.ValidFrom.Equal(monday))
+
+	// The current pack set applies until Monday
+	require.Equal(t, []int64{250, 500, 1000, 2000, 5000}, packSizes(allPacks(t, service)))
+
+	scheduled, err := mockRepo.ListAsOf(context.Background(), entity.DefaultProductID, monday)
+	require.NoError(t, err)
+	require.Equal(t, []int64{300, 600}, packSizes(scheduled))
+}
+
+func TestPackService_ReplacePackSet_Unchanged(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	result, err := service.ReplacePackSet(context.Background(), PackSetRequest{Sizes: []int64{5000, 2000, 1000, 500, 250}}, AnyVersion)
+	require.NoError(t, err)
+
+	require.Empty(t, result.Added)
+	require.Empty(t, result.Removed)
+	require.Len(t, result.Unchanged, 5)
+	require.Len(t, mockRepo.packs, 5)
+}
+
+func TestPackService_ReplacePackSet_Invalid(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+	yesterday := time.Now().Add(-24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		req         PackSetRequest
+		expectedErr error
+	}{
+		{name: "Empty pack set", req: PackSetRequest{}, expectedErr: entity.ErrInvalidPackSet},
+		{name: "Duplicate sizes", req: PackSetRequest{Sizes: []int64{250, 500, 250}}, expectedErr: entity.ErrInvalidPackSet},
+		{name: "Zero size", req: PackSetRequest{Sizes: []int64{0, 500}}, expectedErr: entity.ErrInvalidPackSet},
+		{name: "Negative size", req: PackSetRequest{Sizes: []int64{-250}}, expectedErr: entity.ErrInvalidPackSet},
+		{name: "Size too large", req: PackSetRequest{Sizes: []int64{entity.MaxAmount + 1}}, expectedErr: entity.ErrInvalidPackSet},
+		{name: "Effective in the past", req: PackSetRequest{Sizes: []int64{250}, ValidFrom: &yesterday}, expectedErr: entity.ErrPackEffectiveDate},
+		{name: "Unknown product", req: PackSetRequest{ProductID: uuid.New().String(), Sizes: []int64{250}}, expectedErr: entity.ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.ReplacePackSet(context.Background(), tt.req, AnyVersion); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if len(mockRepo.packs) != 5 {
+				t.Errorf("Expected the pack set to stay unchanged, got %d revisions", len(mockRepo.packs))
+			}
+		})
+	}
+}
+
+func TestPackService_ReplacePackSet_Version(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	// A dry run reports the version the changes are based on
+	dryRun, err := service.ReplacePackSet(context.Background(), PackSetRequest{Sizes: []int64{250, 500}, DryRun: true}, AnyVersion)
+	require.NoError(t, err)
+	version, err := service.PackSetVersion(context.Background(), entity.DefaultProductID)
+	require.NoError(t, err)
+	require.Equal(t, version, dryRun.Version)
+
+	// A pack added meanwhile would be retired without the client knowing
+	added, err := entity.NewPack(uuid.New(), 750)
+	require.NoError(t, err)
+	require.NoError(t, service.CreatePack(context.Background(), added))
+
+	_, err = service.ReplacePackSet(context.Background(), PackSetRequest{Sizes: []int64{250, 500}}, dryRun.Version)
+	require.ErrorIs(t, err, entity.ErrVersionMismatch)
+	require.Equal(t, []int64{250, 500, 750, 1000, 2000, 5000}, packSizes(allPacks(t, service)))
+
+	version, err = service.PackSetVersion(context.Background(), entity.DefaultProductID)
+	require.NoError(t, err)
+	result, err := service.ReplacePackSet(context.Background(), PackSetRequest{Sizes: []int64{250, 500}}, version)
+	require.NoError(t, err)
+	require.Equal(t, []int64{250, 500}, packSizes(allPacks(t, service)))
+
+	// The response holds the version of the new pack set
+	version, err = service.PackSetVersion(context.Background(), entity.DefaultProductID)
+	require.NoError(t, err)
+	require.Equal(t, version, result.Version)
+	require.NotEqual(t, dryRun.Version, result.Version)
+}
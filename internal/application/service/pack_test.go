@@ -3,16 +3,23 @@ package service
 import (
 	"context"
 	"errors"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
 	"github.com/Strahinja-Polovina/packs/pkg/logger"
 	"github.com/google/uuid"
 )
 
 // MockPackRepository implements repository.PackRepository for testing
 type MockPackRepository struct {
+	// packs holds every revision of every pack
 	packs []entity.Pack
+	// products are the product IDs ListByProduct knows about besides the default product
+	products  map[uuid.UUID]bool
+	listCalls int
 }
 
 func NewMockPackRepository() *MockPackRepository {
@@ -27,15 +34,106 @@ func NewMockPackRepository() *MockPackRepository {
 	}
 }
 
-func (m *MockPackRepository) List(ctx context.Context) []entity.Pack {
-	return m.packs
+// addProduct registers a product with packs of the given sizes
+func (m *MockPackRepository) addProduct(t *testing.T, productID uuid.UUID, sizes ...int64) {
+	t.Helper()
+
+	if m.products == nil {
+		m.products = make(map[uuid.UUID]bool)
+	}
+	m.products[productID] = true
+	for _, size := range sizes {
+		pack, err := entity.NewProductPack(uuid.New(), productID, size, entity.PackAttributes{})
+		if err != nil {
+			t.Fatalf("Failed to create pack of size %d: %v", size, err)
+		}
+		m.packs = append(m.packs, *pack)
+	}
 }
 
-func (m *MockPackRepository) Get(ctx context.Context, id uuid.UUID) (*entity.Pack, error) {
+func (m *MockPackRepository) List(ctx context.Context) ([]entity.Pack, error) {
+	m.listCalls++
+	return m.inEffect(time.Now(), func(entity.Pack) bool { return true }), nil
+}
+
+// allPacks returns the packs of every product in effect now
+func allPacks(t *testing.T, service *PackService) []entity.Pack {
+	t.Helper()
+
+	packs, err := service.GetAllPacks(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get all packs: %v", err)
+	}
+	return packs
+}
+
+func (m *MockPackRepository) ListByProduct(ctx context.Context, productID uuid.UUID) ([]entity.Pack, error) {
+	return m.ListAsOf(ctx, productID, time.Now())
+}
+
+func (m *MockPackRepository) ListAsOf(ctx context.Context, productID uuid.UUID, at time.Time) ([]entity.Pack, error) {
+	m.listCalls++
+	if productID != entity.DefaultProductID && !m.products[productID] {
+		return nil, entity.ErrProductNotFound
+	}
+
+	return m.inEffect(at, func(pack entity.Pack) bool { return pack.ProductID() == productID }), nil
+}
+
+func (m *MockPackRepository) ListAsOfMany(ctx context.Context, catalogs []repository.PackCatalog) ([][]entity.Pack, error) {
+	m.listCalls++
+	packs := make([][]entity.Pack, len(catalogs))
+	for i, catalog := range catalogs {
+		packs[i] = m.inEffect(catalog.At, func(pack entity.Pack) bool { return pack.ProductID() == catalog.ProductID })
+	}
+	return packs, nil
+}
+
+// inEffect returns the matching revisions in effect at the given time, in ascending order by size
+func (m *MockPackRepository) inEffect(at time.Time, match func(entity.Pack) bool) []entity.Pack {
+	packs := []entity.Pack{}
 	for _, pack := range m.packs {
-		if pack.ID() == id {
-			return &pack, nil
+		if pack.EffectiveAt(at) && match(pack) {
+			packs = append(packs, pack)
+		}
+	}
+	sort.SliceStable(packs, func(i, j int) bool { return packs[i].Size() < packs[j].Size() })
+	return packs
+}
+
+// latest returns the index of the latest revision of an active pack, or -1
+func (m *MockPackRepository) latest(id uuid.UUID) int {
+	index := -1
+	for i, pack := range m.packs {
+		if pack.ID() == id && pack.Active() && (index < 0 || pack.Revision() > m.packs[index].Revision()) {
+			index = i
+		}
+	}
+	return index
+}
+
+// endRevisions ends the revisions of a pack still in effect at the given time
+func (m *MockPackRepository) endRevisions(id uuid.UUID, at time.Time, active bool) {
+	for i := range m.packs {
+		pack := &m.packs[i]
+		if pack.ID() != id {
+			continue
+		}
+		validTo, ends := pack.ValidTo()
+		if !ends || validTo.After(at) {
+			validTo = at
+			if at.Before(pack.ValidFrom()) {
+				validTo = pack.ValidFrom()
+			}
 		}
+		_ = pack.SetRevision(pack.Revision(), pack.ValidFrom(), validTo, active)
+	}
+}
+
+func (m *MockPackRepository) Get(ctx context.Context, id uuid.UUID) (*entity.Pack, error) {
+	if i := m.latest(id); i >= 0 {
+		pack := m.packs[i]
+		return &pack, nil
 	}
 	return nil, entity.ErrPackNotFound
 }
@@ -46,28 +144,75 @@ func (m *MockPackRepository) Create(ctx context.Context, pack *entity.Pack) erro
 }
 
 func (m *MockPackRepository) Update(ctx context.Context, pack *entity.Pack) error {
-	for i, p := range m.packs {
-		if p.ID() == pack.ID() {
-			m.packs[i] = *pack
-			return nil
-		}
+	i := m.latest(pack.ID())
+	if i < 0 {
+		return entity.ErrPackNotFound
+	}
+	if pack.Revision() != m.packs[i].Revision()+1 {
+		return entity.ErrPackModified
 	}
-	return entity.ErrPackNotFound
+
+	m.endRevisions(pack.ID(), pack.ValidFrom(), true)
+	m.packs = append(m.packs, *pack)
+	return nil
 }
 
 func (m *MockPackRepository) Delete(ctx context.Context, pack *entity.Pack) error {
-	for i, p := range m.packs {
-		if p.ID() == pack.ID() {
-			m.packs = append(m.packs[:i], m.packs[i+1:]...)
-			return nil
+	validTo, ends := pack.ValidTo()
+	if m.latest(pack.ID()) < 0 || pack.Active() || !ends {
+		return entity.ErrPackNotFound
+	}
+
+	m.endRevisions(pack.ID(), validTo, false)
+	return nil
+}
+
+func (m *MockPackRepository) ListActive(ctx context.Context, productID uuid.UUID) ([]entity.Pack, error) {
+	m.listCalls++
+	if productID != entity.DefaultProductID && !m.products[productID] {
+		return nil, entity.ErrProductNotFound
+	}
+
+	packs := []entity.Pack{}
+	for i, pack := range m.packs {
+		if pack.ProductID() == productID && m.latest(pack.ID()) == i {
+			packs = append(packs, pack)
 		}
 	}
-	return entity.ErrPackNotFound
+	sort.SliceStable(packs, func(i, j int) bool { return packs[i].Size() < packs[j].Size() })
+	return packs, nil
 }
 
-func (m *MockPackRepository) ExistsBySize(ctx context.Context, size int) (bool, error) {
+func (m *MockPackRepository) ReplaceSet(ctx context.Context, productID uuid.UUID, version int, created, retired []*entity.Pack) error {
+	current, err := m.ListActive(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if entity.PackSetVersion(current) != version {
+		return entity.ErrPackModified
+	}
+	for _, pack := range retired {
+		if m.latest(pack.ID()) < 0 {
+			return entity.ErrPackModified
+		}
+	}
+	for _, pack := range retired {
+		if err := m.Delete(ctx, pack); err != nil {
+			return err
+		}
+	}
+	for _, pack := range created {
+		m.packs = append(m.packs, *pack)
+	}
+	return nil
+}
+
+func (m *MockPackRepository) ExistsBySize(ctx context.Context, productID uuid.UUID, size int64, at time.Time, exceptID uuid.UUID) (bool, error) {
 	for _, pack := range m.packs {
-		if pack.Size() == size {
+		if pack.ID() == exceptID || pack.ProductID() != productID || pack.Size() != size {
+			continue
+		}
+		if validTo, ends := pack.ValidTo(); !ends || (validTo.After(at) && validTo.After(pack.ValidFrom())) {
 			return true, nil
 		}
 	}
@@ -76,14 +221,14 @@ func (m *MockPackRepository) ExistsBySize(ctx context.Context, size int) (bool,
 
 func TestPackService_CalculateOptimalPacks(t *testing.T) {
 	mockRepo := NewMockPackRepository()
-	service := NewPackService(mockRepo, logger.GetLogger())
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
 
 	tests := []struct {
 		name          string
 		request       PackCalculationRequest
-		expectedPacks map[int]int
-		expectedTotal int
-		expectedWaste int
+		expectedPacks map[int64]int64
+		expectedTotal int64
+		expectedWaste int64
 		expectError   bool
 	}{
 		{
@@ -91,7 +236,7 @@ func TestPackService_CalculateOptimalPacks(t *testing.T) {
 			request: PackCalculationRequest{
 				Amount: 1000,
 			},
-			expectedPacks: map[int]int{1000: 1},
+			expectedPacks: map[int64]int64{1000: 1},
 			expectedTotal: 1000,
 			expectedWaste: 0,
 			expectError:   false,
@@ -101,7 +246,7 @@ func TestPackService_CalculateOptimalPacks(t *testing.T) {
 			request: PackCalculationRequest{
 				Amount: 1250,
 			},
-			expectedPacks: map[int]int{1000: 1, 250: 1},
+			expectedPacks: map[int64]int64{1000: 1, 250: 1},
 			expectedTotal: 1250,
 			expectedWaste: 0,
 			expectError:   false,
@@ -111,7 +256,7 @@ func TestPackService_CalculateOptimalPacks(t *testing.T) {
 			request: PackCalculationRequest{
 				Amount: 1,
 			},
-			expectedPacks: map[int]int{250: 1},
+			expectedPacks: map[int64]int64{250: 1},
 			expectedTotal: 250,
 			expectedWaste: 249,
 			expectError:   false,
@@ -121,11 +266,40 @@ func TestPackService_CalculateOptimalPacks(t *testing.T) {
 			request: PackCalculationRequest{
 				Amount: 12001,
 			},
-			expectedPacks: map[int]int{5000: 2, 2000: 1, 250: 1},
+			expectedPacks: map[int64]int64{5000: 2, 2000: 1, 250: 1},
 			expectedTotal: 12250,
 			expectedWaste: 249,
 			expectError:   false,
 		},
+		{
+			name: "Very large amount",
+			request: PackCalculationRequest{
+				Amount: 1_000_000_000_001,
+			},
+			expectedPacks: map[int64]int64{5000: 200_000_000, 250: 1},
+			expectedTotal: 1_000_000_000_250,
+			expectedWaste: 249,
+			expectError:   false,
+		},
+		{
+			name: "Fewest packs objective",
+			request: PackCalculationRequest{
+				Amount:    750,
+				Objective: ObjectiveMinPacks,
+			},
+			expectedPacks: map[int64]int64{1000: 1},
+			expectedTotal: 1000,
+			expectedWaste: 250,
+			expectError:   false,
+		},
+		{
+			name: "Unknown objective",
+			request: PackCalculationRequest{
+				Amount:    750,
+				Objective: "cheapest",
+			},
+			expectError: true,
+		},
 		{
 			name: "Zero amount",
 			request: PackCalculationRequest{
@@ -189,15 +363,22 @@ func TestPackService_CalculateOptimalPacks(t *testing.T) {
 			if result.TotalAmount < tt.request.Amount {
 				t.Errorf("Total amount %d is less than requested amount %d", result.TotalAmount, tt.request.Amount)
 			}
+
+			if result.Waste != result.TotalAmount-tt.request.Amount {
+				t.Errorf("Expected reported waste %d, got %d", result.TotalAmount-tt.request.Amount, result.Waste)
+			}
 		})
 	}
 }
 
 func TestPackService_GetAllPacks(t *testing.T) {
 	mockRepo := NewMockPackRepository()
-	service := NewPackService(mockRepo, logger.GetLogger())
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
 
-	packs := service.GetAllPacks(context.Background())
+	packs, err := service.GetAllPacks(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 
 	if len(packs) != 5 {
 		t.Errorf("Expected 5 packs, got %d", len(packs))
@@ -206,7 +387,7 @@ func TestPackService_GetAllPacks(t *testing.T) {
 
 func TestPackService_CreatePack(t *testing.T) {
 	mockRepo := NewMockPackRepository()
-	service := NewPackService(mockRepo, logger.GetLogger())
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
 
 	newPack, err := entity.NewPack(uuid.New(), 750)
 	if err != nil {
@@ -218,7 +399,7 @@ func TestPackService_CreatePack(t *testing.T) {
 		t.Errorf("Unexpected error creating pack: %v", err)
 	}
 
-	packs := service.GetAllPacks(context.Background())
+	packs := allPacks(t, service)
 	if len(packs) != 6 {
 		t.Errorf("Expected 6 packs after creation, got %d", len(packs))
 	}
@@ -226,7 +407,7 @@ func TestPackService_CreatePack(t *testing.T) {
 
 func TestPackService_CreatePack_DuplicateSize(t *testing.T) {
 	mockRepo := NewMockPackRepository()
-	service := NewPackService(mockRepo, logger.GetLogger())
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
 
 	duplicatePack, err := entity.NewPack(uuid.New(), 250)
 	if err != nil {
@@ -242,7 +423,7 @@ func TestPackService_CreatePack_DuplicateSize(t *testing.T) {
 		t.Errorf("Expected ErrDuplicatePackSize, got %v", err)
 	}
 
-	packs := service.GetAllPacks(context.Background())
+	packs := allPacks(t, service)
 	if len(packs) != 5 {
 		t.Errorf("Expected 5 packs after failed creation, got %d", len(packs))
 	}
@@ -250,20 +431,15 @@ func TestPackService_CreatePack_DuplicateSize(t *testing.T) {
 
 func TestPackService_UpdatePack(t *testing.T) {
 	mockRepo := NewMockPackRepository()
-	service := NewPackService(mockRepo, logger.GetLogger())
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
 
-	packs := service.GetAllPacks(context.Background())
+	packs := allPacks(t, service)
 	if len(packs) == 0 {
 		t.Fatal("No packs available for testing")
 	}
 
 	packToUpdate := packs[0]
-	updatedPack, err := entity.NewPack(packToUpdate.ID(), 999)
-	if err != nil {
-		t.Fatalf("Failed to create updated pack: %v", err)
-	}
-
-	err = service.UpdatePack(context.Background(), updatedPack)
+	_, err := service.UpdatePack(context.Background(), packToUpdate.ID(), PackChange{Size: 999}, AnyVersion)
 	if err != nil {
 		t.Errorf("Unexpected error updating pack: %v", err)
 	}
@@ -280,9 +456,9 @@ func TestPackService_UpdatePack(t *testing.T) {
 
 func TestPackService_UpdatePack_DuplicateSize(t *testing.T) {
 	mockRepo := NewMockPackRepository()
-	service := NewPackService(mockRepo, logger.GetLogger())
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
 
-	packs := service.GetAllPacks(context.Background())
+	packs := allPacks(t, service)
 	if len(packs) < 2 {
 		t.Fatal("Need at least 2 packs for testing")
 	}
@@ -290,12 +466,7 @@ func TestPackService_UpdatePack_DuplicateSize(t *testing.T) {
 	packToUpdate := packs[0]
 	duplicateSize := packs[1].Size()
 
-	updatedPack, err := entity.NewPack(packToUpdate.ID(), duplicateSize)
-	if err != nil {
-		t.Fatalf("Failed to create updated pack: %v", err)
-	}
-
-	err = service.UpdatePack(context.Background(), updatedPack)
+	_, err := service.UpdatePack(context.Background(), packToUpdate.ID(), PackChange{Size: duplicateSize}, AnyVersion)
 	if err == nil {
 		t.Errorf("Expected error when updating pack to duplicate size, but got none")
 	}
@@ -316,9 +487,9 @@ func TestPackService_UpdatePack_DuplicateSize(t *testing.T) {
 
 func TestPackService_UpdatePack_SameSize(t *testing.T) {
 	mockRepo := NewMockPackRepository()
-	service := NewPackService(mockRepo, logger.GetLogger())
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
 
-	packs := service.GetAllPacks(context.Background())
+	packs := allPacks(t, service)
 	if len(packs) == 0 {
 		t.Fatal("No packs available for testing")
 	}
@@ -326,12 +497,7 @@ func TestPackService_UpdatePack_SameSize(t *testing.T) {
 	packToUpdate := packs[0]
 	originalSize := packToUpdate.Size()
 
-	updatedPack, err := entity.NewPack(packToUpdate.ID(), originalSize)
-	if err != nil {
-		t.Fatalf("Failed to create updated pack: %v", err)
-	}
-
-	err = service.UpdatePack(context.Background(), updatedPack)
+	_, err := service.UpdatePack(context.Background(), packToUpdate.ID(), PackChange{Size: originalSize}, AnyVersion)
 	if err != nil {
 		t.Errorf("Unexpected error updating pack to same size: %v", err)
 	}
@@ -348,20 +514,20 @@ func TestPackService_UpdatePack_SameSize(t *testing.T) {
 
 func TestPackService_DeletePack(t *testing.T) {
 	mockRepo := NewMockPackRepository()
-	service := NewPackService(mockRepo, logger.GetLogger())
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
 
-	packs := service.GetAllPacks(context.Background())
+	packs := allPacks(t, service)
 	if len(packs) == 0 {
 		t.Fatal("No packs available for testing")
 	}
 
 	packToDelete := packs[0]
-	err := service.DeletePack(context.Background(), &packToDelete)
+	err := service.DeletePack(context.Background(), &packToDelete, AnyVersion)
 	if err != nil {
 		t.Errorf("Unexpected error deleting pack: %v", err)
 	}
 
-	remainingPacks := service.GetAllPacks(context.Background())
+	remainingPacks := allPacks(t, service)
 	if len(remainingPacks) != 4 {
 		t.Errorf("Expected 4 packs after deletion, got %d", len(remainingPacks))
 	}
@@ -372,11 +538,144 @@ func TestPackService_DeletePack(t *testing.T) {
 	}
 }
 
+func TestPackService_DeletePack_KeepsHistory(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+	productService := NewProductService(NewMockProductRepository(mockRepo), mockRepo, logger.GetLogger())
+
+	before := time.Now()
+	pack, err := service.GetPackByID(context.Background(), allPacks(t, service)[0].ID().String())
+	if err != nil {
+		t.Fatalf("Failed to get pack: %v", err)
+	}
+	if err := service.DeletePack(context.Background(), pack, AnyVersion); err != nil {
+		t.Fatalf("Unexpected error deleting pack: %v", err)
+	}
+
+	past, err := productService.GetProductPacksAsOf(context.Background(), entity.DefaultProductID, before)
+	if err != nil {
+		t.Fatalf("Unexpected error listing past packs: %v", err)
+	}
+	if len(past) != 5 || past[0].ID() != pack.ID() || past[0].Active() {
+		t.Errorf("Expected the retired pack in the catalog before its deletion, got %d packs", len(past))
+	}
+
+	// A retired size can be created again
+	recreated, _ := entity.NewPack(uuid.New(), pack.Size())
+	if err := service.CreatePack(context.Background(), recreated); err != nil {
+		t.Errorf("Unexpected error recreating a retired size: %v", err)
+	}
+}
+
+func TestPackService_UpdatePack_Scheduled(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+	productService := NewProductService(NewMockProductRepository(mockRepo), mockRepo, logger.GetLogger())
+
+	pack, err := service.GetPackByID(context.Background(), allPacks(t, service)[0].ID().String())
+	if err != nil {
+		t.Fatalf("Failed to get pack: %v", err)
+	}
+	monday := time.Now().Add(72 * time.Hour)
+	if _, err := service.UpdatePack(context.Background(), pack.ID(), PackChange{Size: 300, ValidFrom: monday}, AnyVersion); err != nil {
+		t.Fatalf("Unexpected error scheduling a size change: %v", err)
+	}
+
+	// The change is stored but does not take effect before Monday
+	stored, err := service.GetPackByID(context.Background(), pack.ID().String())
+	if err != nil || stored.Size() != 300 || stored.Revision() != 2 {
+		t.Fatalf("Expected revision 2 of size 300 to be stored, got %+v (error: %v)", stored, err)
+	}
+	result, err := service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{Amount: 250})
+	if err != nil {
+		t.Fatalf("Unexpected calculation error: %v", err)
+	}
+	if result.Combination[250] != 1 || result.TotalAmount != 250 {
+		t.Errorf("Expected the current 250 pack to be used before Monday, got %v", result.Combination)
+	}
+
+	scheduled, err := productService.GetProductPacksAsOf(context.Background(), entity.DefaultProductID, monday)
+	if err != nil {
+		t.Fatalf("Unexpected error listing scheduled packs: %v", err)
+	}
+	if scheduled[0].Size() != 300 || scheduled[0].Revision() != 2 || len(scheduled) != 5 {
+		t.Errorf("Expected revision 2 of size 300 in the catalog from Monday, got %d packs from size %d", len(scheduled), scheduled[0].Size())
+	}
+
+	// A change effective now supersedes the scheduled one
+	if _, err := service.UpdatePack(context.Background(), pack.ID(), PackChange{Size: 400}, AnyVersion); err != nil {
+		t.Fatalf("Unexpected error changing size: %v", err)
+	}
+	for _, at := range []time.Time{time.Now(), monday} {
+		packs, _ := productService.GetProductPacksAsOf(context.Background(), entity.DefaultProductID, at)
+		if len(packs) != 5 || packs[0].Size() != 400 || packs[0].Revision() != 3 {
+			t.Errorf("Expected only revision 3 of size 400 in effect at %v, got %d packs from size %d", at, len(packs), packs[0].Size())
+		}
+	}
+}
+
+func TestPackService_CreatePack_ScheduledSize(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	pack, err := service.GetPackByID(context.Background(), allPacks(t, service)[0].ID().String())
+	if err != nil {
+		t.Fatalf("Failed to get pack: %v", err)
+	}
+	oldSize := pack.Size()
+	if _, err := service.UpdatePack(context.Background(), pack.ID(), PackChange{Size: 300, ValidFrom: time.Now().Add(72 * time.Hour)}, AnyVersion); err != nil {
+		t.Fatalf("Unexpected error scheduling a size change: %v", err)
+	}
+
+	// The old size stays in effect until the change does
+	duplicate, _ := entity.NewPack(uuid.New(), oldSize)
+	if err := service.CreatePack(context.Background(), duplicate); !errors.Is(err, entity.ErrDuplicatePackSize) {
+		t.Errorf("Expected ErrDuplicatePackSize adding size %d before the change, got %v", oldSize, err)
+	}
+
+	// The scheduled size is taken from when the change takes effect
+	other := allPacks(t, service)[1]
+	if _, err := service.UpdatePack(context.Background(), other.ID(), PackChange{Size: 300}, AnyVersion); !errors.Is(err, entity.ErrDuplicatePackSize) {
+		t.Errorf("Expected ErrDuplicatePackSize changing to the scheduled size, got %v", err)
+	}
+}
+
+func TestPackService_Version(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	pack, err := service.GetPackByID(context.Background(), allPacks(t, service)[0].ID().String())
+	if err != nil {
+		t.Fatalf("Failed to get pack: %v", err)
+	}
+	read := pack.Revision()
+
+	if _, err := service.UpdatePack(context.Background(), pack.ID(), PackChange{Size: 300}, read); err != nil {
+		t.Fatalf("Unexpected error updating the read revision: %v", err)
+	}
+
+	// A second admin still holds the revision read before the update
+	if _, err := service.UpdatePack(context.Background(), pack.ID(), PackChange{Size: 400}, read); !errors.Is(err, entity.ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch updating a stale revision, got %v", err)
+	}
+	stored, err := service.GetPackByID(context.Background(), pack.ID().String())
+	if err != nil || stored.Size() != 300 {
+		t.Fatalf("Expected the stored pack to keep size 300, got %+v (error: %v)", stored, err)
+	}
+
+	if err := service.DeletePack(context.Background(), stored, read); !errors.Is(err, entity.ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch deleting a stale revision, got %v", err)
+	}
+	if err := service.DeletePack(context.Background(), stored, stored.Revision()); err != nil {
+		t.Errorf("Unexpected error deleting the current revision: %v", err)
+	}
+}
+
 func TestPackService_GetPackByID(t *testing.T) {
 	mockRepo := NewMockPackRepository()
-	service := NewPackService(mockRepo, logger.GetLogger())
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
 
-	packs := service.GetAllPacks(context.Background())
+	packs := allPacks(t, service)
 	if len(packs) == 0 {
 		t.Fatal("No packs available for testing")
 	}
@@ -402,3 +701,221 @@ func TestPackService_GetPackByID(t *testing.T) {
 		t.Errorf("Expected error when retrieving pack with invalid ID, but got none")
 	}
 }
+
+func TestPackService_CalculateOptimalPacks_CostAndWeight(t *testing.T) {
+	newPack := func(size int64, cost int64, weight int) entity.Pack {
+		pack, err := entity.NewPackWithAttributes(uuid.New(), size, entity.PackAttributes{
+			UnitCost: &cost,
+			Weight:   &weight,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create test pack: %v", err)
+		}
+		return *pack
+	}
+
+	mockRepo := &MockPackRepository{
+		packs: []entity.Pack{newPack(250, 100, 300), newPack(500, 150, 550), newPack(1000, 400, 1100)},
+	}
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	result, err := service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{
+		Amount:    900,
+		Objective: ObjectiveMinCost,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Combination[500] != 2 || len(result.Combination) != 1 {
+		t.Errorf("Expected two 500 packs as the cheapest combination, got %v", result.Combination)
+	}
+
+	if result.TotalCost == nil || *result.TotalCost != 300 {
+		t.Errorf("Expected total cost 300, got %v", result.TotalCost)
+	}
+
+	if result.ShippingWeight == nil || *result.ShippingWeight != 1100 {
+		t.Errorf("Expected shipping weight 1100, got %v", result.ShippingWeight)
+	}
+}
+
+func TestPackService_CalculateOptimalPacks_MissingCost(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	_, err := service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{
+		Amount:    900,
+		Objective: ObjectiveMinCost,
+	})
+	if !errors.Is(err, entity.ErrMissingPackCost) {
+		t.Errorf("Expected ErrMissingPackCost, got %v", err)
+	}
+
+	result, err := service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{Amount: 900})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.TotalCost != nil || result.ShippingWeight != nil {
+		t.Errorf("Expected no cost or weight totals without pack attributes, got %v and %v", result.TotalCost, result.ShippingWeight)
+	}
+}
+
+func TestPackService_CalculateOptimalPacks_AmountTooLarge(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	result, err := service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{Amount: entity.MaxAmount})
+	if err != nil {
+		t.Fatalf("Unexpected error for the largest supported amount: %v", err)
+	}
+	if result.TotalAmount != entity.MaxAmount || result.Waste != 0 {
+		t.Errorf("Expected the largest supported amount to ship without waste, got %d", result.TotalAmount)
+	}
+
+	_, err = service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{Amount: entity.MaxAmount + 1})
+	if !errors.Is(err, entity.ErrAmountTooLarge) {
+		t.Errorf("Expected ErrAmountTooLarge, got %v", err)
+	}
+}
+
+func TestPackService_CalculateOptimalPacks_TimeBudget(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	product := uuid.New()
+	// Coprime sizes near 1000 take a table of about a million totals
+	mockRepo.addProduct(t, product, 997, 1009)
+	req := PackCalculationRequest{Amount: 123_456_789, ProductID: product.String()}
+
+	result, err := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger()).CalculateOptimalPacks(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.Optimal || result.Waste != 0 {
+		t.Errorf("Expected an optimal combination without waste, got %+v", result)
+	}
+
+	config := DefaultSolverConfig()
+	config.TimeBudget = time.Nanosecond
+	service := NewPackService(mockRepo, config, logger.GetLogger())
+
+	result, err = service.CalculateOptimalPacks(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected the best combination found so far, got %v", err)
+	}
+	if result.Optimal {
+		t.Error("Expected the combination to be flagged as not optimal")
+	}
+	if result.TotalAmount < req.Amount || result.Waste != result.TotalAmount-req.Amount {
+		t.Errorf("Expected a combination covering %d, got %+v", req.Amount, result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := service.CalculateOptimalPacks(ctx, req); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled once the request is gone, got %v", err)
+	}
+}
+
+func TestPackService_CalculateOptimalPacks_Alternatives(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	result, err := service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{Amount: 251, Alternatives: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result.Alternatives) != 3 {
+		t.Fatalf("Expected 3 alternatives, got %d", len(result.Alternatives))
+	}
+
+	expectedTotals := []int64{500, 750, 1000}
+	for i, alternative := range result.Alternatives {
+		if alternative.Rank != i+1 {
+			t.Errorf("Expected rank %d, got %d", i+1, alternative.Rank)
+		}
+		if alternative.TotalAmount != expectedTotals[i] {
+			t.Errorf("Alternative %d: expected total %d, got %d", i+1, expectedTotals[i], alternative.TotalAmount)
+		}
+		if alternative.Waste != alternative.TotalAmount-251 {
+			t.Errorf("Alternative %d: expected waste %d, got %d", i+1, alternative.TotalAmount-251, alternative.Waste)
+		}
+	}
+
+	if result.TotalAmount != result.Alternatives[0].TotalAmount {
+		t.Errorf("Expected the first alternative to be the chosen combination")
+	}
+
+	result, err = service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{Amount: 251})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Alternatives != nil {
+		t.Errorf("Expected no alternatives unless requested, got %v", result.Alternatives)
+	}
+
+	_, err = service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{Amount: 251, Alternatives: MaxAlternatives + 1})
+	if !errors.Is(err, entity.ErrInvalidAlternatives) {
+		t.Errorf("Expected ErrInvalidAlternatives, got %v", err)
+	}
+}
+
+func TestPackService_CalculateOptimalPacks_Product(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	productID := uuid.New()
+	mockRepo.addProduct(t, productID, 3, 5)
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	result, err := service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{
+		Amount:    7,
+		ProductID: productID.String(),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.ProductID != productID.String() {
+		t.Errorf("Expected product ID %s, got %s", productID, result.ProductID)
+	}
+	if result.TotalAmount != 8 || result.Combination[3] != 1 || result.Combination[5] != 1 {
+		t.Errorf("Expected one 3 pack and one 5 pack, got %v", result.Combination)
+	}
+
+	defaultResult, err := service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{Amount: 7})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if defaultResult.ProductID != entity.DefaultProductID.String() {
+		t.Errorf("Expected default product, got %s", defaultResult.ProductID)
+	}
+	if defaultResult.Combination[250] != 1 {
+		t.Errorf("Expected the default pack set to be used, got %v", defaultResult.Combination)
+	}
+
+	for _, productID := range []string{uuid.NewString(), "not-a-uuid"} {
+		_, err := service.CalculateOptimalPacks(context.Background(), PackCalculationRequest{
+			Amount:    7,
+			ProductID: productID,
+		})
+		if !errors.Is(err, entity.ErrProductNotFound) {
+			t.Errorf("Expected ErrProductNotFound for product %q, got %v", productID, err)
+		}
+	}
+}
+
+func TestPackService_CreatePack_SizeUniquePerProduct(t *testing.T) {
+	mockRepo := NewMockPackRepository()
+	productID := uuid.New()
+	mockRepo.addProduct(t, productID)
+	service := NewPackService(mockRepo, DefaultSolverConfig(), logger.GetLogger())
+
+	pack, _ := entity.NewProductPack(uuid.New(), productID, 250, entity.PackAttributes{})
+	if err := service.CreatePack(context.Background(), pack); err != nil {
+		t.Fatalf("Expected size used by another product to be accepted, got %v", err)
+	}
+
+	duplicate, _ := entity.NewProductPack(uuid.New(), productID, 250, entity.PackAttributes{})
+	if err := service.CreatePack(context.Background(), duplicate); !errors.Is(err, entity.ErrDuplicatePackSize) {
+		t.Errorf("Expected ErrDuplicatePackSize, got %v", err)
+	}
+}
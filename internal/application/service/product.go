@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// ProductService handles product-related business logic
+type ProductService struct {
+	productRepo repository.ProductRepository
+	packRepo    repository.PackRepository
+	logger      *logger.Logger
+}
+
+// NewProductService creates a new product service
+func NewProductService(productRepo repository.ProductRepository, packRepo repository.PackRepository, logger *logger.Logger) *ProductService {
+	return &ProductService{
+		productRepo: productRepo,
+		packRepo:    packRepo,
+		logger:      logger,
+	}
+}
+
+// resolveProductID returns the product named by a request, or the default
+// product when the request names none
+func resolveProductID(productID string) (uuid.UUID, error) {
+	if productID == "" {
+		return entity.DefaultProductID, nil
+	}
+
+	id, err := uuid.Parse(productID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: invalid product ID %q", entity.ErrProductNotFound, productID)
+	}
+	return id, nil
+}
+
+// GetAllProducts returns all products
+func (s *ProductService) GetAllProducts(ctx context.Context) []entity.Product {
+	s.logger.Debug("Getting all products")
+
+	products := s.productRepo.List(ctx)
+	s.logger.Debug("Retrieved %d products", len(products))
+
+	return products
+}
+
+// GetProduct retrieves a product by its ID
+func (s *ProductService) GetProduct(ctx context.Context, id uuid.UUID) (*entity.Product, error) {
+	s.logger.Debug("Getting product by ID: %s", id)
+
+	product, err := s.productRepo.Get(ctx, id)
+	if err != nil {
+		s.logger.Warn("Failed to get product %s: %v", id, err)
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// GetProductPacks returns the packs of a product in effect now, in ascending order by size
+func (s *ProductService) GetProductPacks(ctx context.Context, id uuid.UUID) ([]entity.Pack, error) {
+	s.logger.Debug("Getting packs of product: %s", id)
+
+	packs, err := s.packRepo.ListByProduct(ctx, id)
+	if err != nil {
+		s.logger.Warn("Failed to get packs of product %s: %v", id, err)
+		return nil, err
+	}
+
+	return packs, nil
+}
+
+// GetProductPacksAsOf returns the revisions of a product's packs that were in
+// effect at the given time, or are scheduled to be, in ascending order by size
+func (s *ProductService) GetProductPacksAsOf(ctx context.Context, id uuid.UUID, at time.Time) ([]entity.Pack, error) {
+	s.logger.Debug("Getting packs of product %s as of %v", id, at)
+
+	packs, err := s.packRepo.ListAsOf(ctx, id, at)
+	if err != nil {
+		s.logger.Warn("Failed to get packs of product %s as of %v: %v", id, at, err)
+		return nil, err
+	}
+
+	return packs, nil
+}
+
+// CreateProduct creates a new product
+func (s *ProductService) CreateProduct(ctx context.Context, product *entity.Product) error {
+	exists, err := s.productRepo.ExistsByName(ctx, product.Name())
+	if err != nil {
+		s.logger.Error("Failed to check if product name exists: %v", err)
+		return err
+	}
+
+	if exists {
+		s.logger.Warn("Attempted to create product with duplicate name: %s", product.Name())
+		return entity.ErrDuplicateProductName
+	}
+
+	return s.productRepo.Create(ctx, product)
+}
+
+// UpdateProduct updates an existing product
+func (s *ProductService) UpdateProduct(ctx context.Context, product *entity.Product) error {
+	currentProduct, err := s.productRepo.Get(ctx, product.ID())
+	if err != nil {
+		s.logger.Error("Failed to get current product for update: %v", err)
+		return err
+	}
+
+	if product.Name() != currentProduct.Name() {
+		exists, err := s.productRepo.ExistsByName(ctx, product.Name())
+		if err != nil {
+			s.logger.Error("Failed to check if product name exists during update: %v", err)
+			return err
+		}
+
+		if exists {
+			s.logger.Warn("Attempted to rename product to duplicate name: %s", product.Name())
+			return entity.ErrDuplicateProductName
+		}
+	}
+
+	return s.productRepo.Update(ctx, product)
+}
+
+// DeleteProduct deletes a product that has no active packs or orders. The default
+// product is never deleted.
+func (s *ProductService) DeleteProduct(ctx context.Context, product *entity.Product) error {
+	s.logger.Info("Deleting product with ID: %s, name: %s", product.ID(), product.Name())
+
+	if product.IsDefault() {
+		s.logger.Warn("Attempted to delete the default product")
+		return entity.ErrDefaultProduct
+	}
+
+	if err := s.productRepo.Delete(ctx, product); err != nil {
+		s.logger.Error("Failed to delete product %s: %v", product.ID(), err)
+		return err
+	}
+
+	s.logger.Info("Product deleted successfully with ID: %s", product.ID())
+	return nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// MockProductRepository implements repository.ProductRepository for testing
+type MockProductRepository struct {
+	products []entity.Product
+	packRepo *MockPackRepository
+}
+
+func NewMockProductRepository(packRepo *MockPackRepository) *MockProductRepository {
+	product, _ := entity.NewProduct(entity.DefaultProductID, "Default")
+
+	return &MockProductRepository{
+		products: []entity.Product{*product},
+		packRepo: packRepo,
+	}
+}
+
+func (m *MockProductRepository) List(ctx context.Context) []entity.Product {
+	return m.products
+}
+
+func (m *MockProductRepository) Get(ctx context.Context, id uuid.UUID) (*entity.Product, error) {
+	for _, product := range m.products {
+		if product.ID() == id {
+			return &product, nil
+		}
+	}
+	return nil, entity.ErrProductNotFound
+}
+
+func (m *MockProductRepository) Create(ctx context.Context, product *entity.Product) error {
+	m.products = append(m.products, *product)
+	// The pack repository learns about products through the products table
+	if m.packRepo.products == nil {
+		m.packRepo.products = make(map[uuid.UUID]bool)
+	}
+	m.packRepo.products[product.ID()] = true
+	return nil
+}
+
+func (m *MockProductRepository) Update(ctx context.Context, product *entity.Product) error {
+	for i, p := range m.products {
+		if p.ID() == product.ID() {
+			m.products[i] = *product
+			return nil
+		}
+	}
+	return entity.ErrProductNotFound
+}
+
+func (m *MockProductRepository) Delete(ctx context.Context, product *entity.Product) error {
+	for _, pack := range m.packRepo.packs {
+		if pack.ProductID() == product.ID() && pack.Active() {
+			return entity.ErrProductInUse
+		}
+	}
+	for i, p := range m.products {
+		if p.ID() == product.ID() {
+			m.products = append(m.products[:i], m.products[i+1:]...)
+			// Retired packs go with the product
+			kept := m.packRepo.packs[:0]
+			for _, pack := range m.packRepo.packs {
+				if pack.ProductID() != product.ID() {
+					kept = append(kept, pack)
+				}
+			}
+			m.packRepo.packs = kept
+			return nil
+		}
+	}
+	return entity.ErrProductNotFound
+}
+
+func (m *MockProductRepository) ExistsByName(ctx context.Context, name string) (bool, error) {
+	for _, product := range m.products {
+		if product.Name() == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestProductService_CreateProduct(t *testing.T) {
+	mockPackRepo := NewMockPackRepository()
+	service := NewProductService(NewMockProductRepository(mockPackRepo), mockPackRepo, logger.GetLogger())
+
+	product, _ := entity.NewProduct(uuid.New(), "Widgets")
+	if err := service.CreateProduct(context.Background(), product); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(service.GetAllProducts(context.Background())) != 2 {
+		t.Errorf("Expected 2 products, got %d", len(service.GetAllProducts(context.Background())))
+	}
+
+	packs, err := service.GetProductPacks(context.Background(), product.ID())
+	if err != nil {
+		t.Fatalf("Unexpected error getting packs of new product: %v", err)
+	}
+	if len(packs) != 0 {
+		t.Errorf("Expected new product to have no packs, got %d", len(packs))
+	}
+
+	duplicate, _ := entity.NewProduct(uuid.New(), "Widgets")
+	if err := service.CreateProduct(context.Background(), duplicate); !errors.Is(err, entity.ErrDuplicateProductName) {
+		t.Errorf("Expected ErrDuplicateProductName, got %v", err)
+	}
+}
+
+func TestProductService_UpdateProduct(t *testing.T) {
+	mockPackRepo := NewMockPackRepository()
+	service := NewProductService(NewMockProductRepository(mockPackRepo), mockPackRepo, logger.GetLogger())
+
+	widgets, _ := entity.NewProduct(uuid.New(), "Widgets")
+	gadgets, _ := entity.NewProduct(uuid.New(), "Gadgets")
+	for _, product := range []*entity.Product{widgets, gadgets} {
+		if err := service.CreateProduct(context.Background(), product); err != nil {
+			t.Fatalf("Failed to create product: %v", err)
+		}
+	}
+
+	if err := widgets.Rename("Sprockets"); err != nil {
+		t.Fatalf("Unexpected error renaming product: %v", err)
+	}
+	if err := service.UpdateProduct(context.Background(), widgets); err != nil {
+		t.Fatalf("Unexpected error updating product: %v", err)
+	}
+	stored, _ := service.GetProduct(context.Background(), widgets.ID())
+	if stored.Name() != "Sprockets" {
+		t.Errorf("Expected stored name Sprockets, got %q", stored.Name())
+	}
+
+	_ = gadgets.Rename("Sprockets")
+	if err := service.UpdateProduct(context.Background(), gadgets); !errors.Is(err, entity.ErrDuplicateProductName) {
+		t.Errorf("Expected ErrDuplicateProductName, got %v", err)
+	}
+
+	unknown, _ := entity.NewProduct(uuid.New(), "Unknown")
+	if err := service.UpdateProduct(context.Background(), unknown); !errors.Is(err, entity.ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestProductService_DeleteProduct(t *testing.T) {
+	mockPackRepo := NewMockPackRepository()
+	service := NewProductService(NewMockProductRepository(mockPackRepo), mockPackRepo, logger.GetLogger())
+
+	defaultProduct, err := service.GetProduct(context.Background(), entity.DefaultProductID)
+	if err != nil {
+		t.Fatalf("Failed to get default product: %v", err)
+	}
+	if err := service.DeleteProduct(context.Background(), defaultProduct); !errors.Is(err, entity.ErrDefaultProduct) {
+		t.Errorf("Expected ErrDefaultProduct, got %v", err)
+	}
+
+	product, _ := entity.NewProduct(uuid.New(), "Widgets")
+	if err := service.CreateProduct(context.Background(), product); err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	mockPackRepo.addProduct(t, product.ID(), 10)
+
+	if err := service.DeleteProduct(context.Background(), product); !errors.Is(err, entity.ErrProductInUse) {
+		t.Errorf("Expected ErrProductInUse while the product has packs, got %v", err)
+	}
+
+	packs, _ := service.GetProductPacks(context.Background(), product.ID())
+	if err := packs[0].Deactivate(time.Time{}); err != nil {
+		t.Fatalf("Failed to deactivate pack: %v", err)
+	}
+	if err := mockPackRepo.Delete(context.Background(), &packs[0]); err != nil {
+		t.Fatalf("Failed to delete pack: %v", err)
+	}
+	if err := service.DeleteProduct(context.Background(), product); err != nil {
+		t.Errorf("Unexpected error deleting unused product: %v", err)
+	}
+	if _, err := service.GetProduct(context.Background(), product.ID()); !errors.Is(err, entity.ErrProductNotFound) {
+		t.Errorf("Expected deleted product to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxRecommendedSizes is the largest pack set a recommendation may ask for
+	MaxRecommendedSizes = 10
+	// DefaultRecommendationCandidates is how many evenly spaced sizes between
+	// the minimum and maximum size are tried when the request sets no step
+	DefaultRecommendationCandidates = 200
+	// MaxRecommendationCandidates is the most evenly spaced sizes a step may produce
+	MaxRecommendationCandidates = 1000
+	// MaxRecommendationAmount is the largest requested amount taken into account;
+	// order lines above it are left out of the demand
+	MaxRecommendationAmount = 1000000
+	// recommendationDemandCandidates is how many of the most ordered amounts
+	// within the size range are tried as sizes of their own
+	recommendationDemandCandidates = 50
+	// recommendationSweeps bounds the local search rounds
+	recommendationSweeps = 20
+)
+
+// RecommendationRequest asks for the best pack set of a product for its order
+// history. Candidate sizes are spaced Step apart between MinSize and MaxSize;
+// the current sizes and the most ordered amounts within that range are tried too.
+type RecommendationRequest struct {
+	// ProductID selects the order history; the default product is used when it is empty
+	ProductID string `json:"product_id,omitempty" binding:"omitempty,uuid" format:"uuid"`
+	// Count is the number of sizes in the recommended set, fixed sizes included
+	Count   int   `json:"count" binding:"required,min=1,max=10"`
+	MinSize int64 `json:"min_size" binding:"required,min=1"`
+	MaxSize int64 `json:"max_size" binding:"required,min=1,max=1000000"`
+	// Step spaces the candidate sizes; by default at most 200 are spread over the range
+	Step int64 `json:"step,omitempty" binding:"omitempty,min=1"`
+	// FixedSizes are always part of the recommended set
+	FixedSizes []int64 `json:"fixed_sizes,omitempty" binding:"omitempty,max=10,dive,min=1,max=1000000"`
+	// CreatedFrom and CreatedTo limit the history to orders created in that period
+	CreatedFrom *time.Time `json:"created_from,omitempty"`
+	CreatedTo   *time.Time `json:"created_to,omitempty"`
+}
+
+// RecommendationResponse is the recommended pack set with its projected
+// totals over the order history. Every amount is solved for the least waste,
+// ties broken by pack count, for both the recommended and the current set.
+type RecommendationResponse struct {
+	ProductID        string  `json:"product_id" format:"uuid"`
+	PackSizes        []int64 `json:"pack_sizes"`
+	FixedSizes       []int64 `json:"fixed_sizes"`
+	CurrentPackSizes []int64 `json:"current_pack_sizes"`
+	Orders           int     `json:"orders"`
+	Lines            int     `json:"lines"`
+	DistinctAmounts  int     `json:"distinct_amounts"`
+	// ExcludedLines counts the lines above the largest amount taken into account
+	ExcludedLines   int              `json:"excluded_lines,omitempty"`
+	RequestedAmount int64            `json:"requested_amount"`
+	Recommended     SimulationTotals `json:"recommended"`
+	// Current is omitted when the product has no pack sizes yet, or sizes too
+	// large to analyse
+	Current *SimulationTotals `json:"current,omitempty"`
+	// Change is the recommended totals minus the current ones; negative values are savings
+	Change *SimulationChanges `json:"change,omitempty"`
+	// Evaluations counts the pack sets scored during the search
+	Evaluations int `json:"evaluations"`
+}
+
+// RecommendationService suggests pack sets from order history
+type RecommendationService struct {
+	orderRepo   repository.OrderRepository
+	packService *PackService
+	logger      *logger.Logger
+}
+
+// NewRecommendationService creates a new recommendation service
+func NewRecommendationService(orderRepo repository.OrderRepository, packService *PackService, logger *logger.Logger) *RecommendationService {
+	return &RecommendationService{
+		orderRepo:   orderRepo,
+		packService: packService,
+		logger:      logger,
+	}
+}
+
+// RecommendPackSizes searches for the pack set with the least total waste,
+// then the fewest packs, over the requested amounts of the product's orders.
+// Sizes are added greedily, one at a time, and then swapped for other
+// candidates while that improves the set.
+func (s *RecommendationService) RecommendPackSizes(ctx context.Context, req RecommendationRequest) (*RecommendationResponse, error) {
+	if err := req.validate(); err != nil {
+		s.logger.Error("Invalid recommendation request: %v", err)
+		return nil, err
+	}
+
+	current, err := s.packService.productPacks(ctx, &req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	productID, err := uuid.Parse(req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entity.ErrProductNotFound, err)
+	}
+
+	response := &RecommendationResponse{
+		ProductID:        req.ProductID,
+		FixedSizes:       append([]int64{}, req.FixedSizes...),
+		CurrentPackSizes: make([]int64, len(current)),
+	}
+	sortSizes(response.FixedSizes)
+	for i, pack := range current {
+		response.CurrentPackSizes[i] = pack.Size()
+	}
+
+	counts := make(map[int64]int)
+	history := orderHistory{
+		productID:   productID,
+		createdFrom: req.CreatedFrom,
+		createdTo:   req.CreatedTo,
+	}
+	response.Orders, err = history.replay(ctx, s.orderRepo, nil, func(order entity.Order, line entity.OrderLine) error {
+		amount := line.GetRequestedAmount()
+		if amount > MaxRecommendationAmount {
+			response.ExcludedLines++
+			return nil
+		}
+		counts[amount]++
+		response.Lines++
+		response.RequestedAmount += amount
+		return nil
+	}, nil)
+	if err != nil {
+		s.logger.Error("Failed to load the order history of product %s: %v", productID, err)
+		return nil, err
+	}
+	if len(counts) == 0 {
+		s.logger.Warn("No order history to recommend pack sizes for product %s", productID)
+		return nil, fmt.Errorf("%w: product has no order history to learn from", entity.ErrInvalidRecommendation)
+	}
+
+	demand := newDemand(counts)
+	response.DistinctAmounts = len(demand.points)
+	candidates := req.candidates(response.CurrentPackSizes, demand)
+	s.logger.Info("Recommending %d pack sizes for product %s from %d candidates over %d distinct amounts",
+		req.Count, productID, len(candidates), len(demand.points))
+
+	sizes, totals, err := demand.search(ctx, response.FixedSizes, candidates, req.Count)
+	if err != nil {
+		s.logger.Error("Pack size recommendation stopped: %v", err)
+		return nil, err
+	}
+	sortSizes(sizes)
+	response.PackSizes = sizes
+	response.Recommended = totals
+
+	// Packs may be far larger than the analysed sizes, which leaves the
+	// current set without a baseline
+	if len(current) > 0 {
+		currentTotals, err := demand.evaluate(response.CurrentPackSizes)
+		switch {
+		case errors.Is(err, entity.ErrSolverBudgetExceeded):
+			s.logger.Warn("Leaving out the current pack sizes of product %s: %v", productID, err)
+		case err != nil:
+			return nil, err
+		default:
+			change := simulationChanges(currentTotals, totals)
+			response.Current = &currentTotals
+			response.Change = &change
+		}
+	}
+	response.Evaluations = demand.evaluations
+
+	s.logger.Info("Recommended pack sizes %v for product %s after %d evaluations", sizes, productID, demand.evaluations)
+	return response, nil
+}
+
+// validate checks the request beyond what binding covers
+func (r RecommendationRequest) validate() error {
+	if r.Count < 1 || r.Count > MaxRecommendedSizes {
+		return fmt.Errorf("%w: count must be 1 to %d", entity.ErrInvalidRecommendation, MaxRecommendedSizes)
+	}
+	if r.MinSize < 1 || r.MaxSize < r.MinSize || r.MaxSize > MaxAnalysisPackSize {
+		return fmt.Errorf("%w: sizes must range from 1 to %d with min_size at most max_size", entity.ErrInvalidRecommendation, MaxAnalysisPackSize)
+	}
+	if r.Step < 0 || (r.Step > 0 && (r.MaxSize-r.MinSize)/r.Step+1 > MaxRecommendationCandidates) {
+		return fmt.Errorf("%w: step must give at most %d sizes", entity.ErrInvalidRecommendation, MaxRecommendationCandidates)
+	}
+	if len(r.FixedSizes) > r.Count {
+		return fmt.Errorf("%w: %d fixed sizes do not fit in %d sizes", entity.ErrInvalidRecommendation, len(r.FixedSizes), r.Count)
+	}
+
+	seen := make(map[int64]bool, len(r.FixedSizes))
+	for _, size := range r.FixedSizes {
+		if size < 1 || size > MaxAnalysisPackSize || seen[size] {
+			return fmt.Errorf("%w: fixed sizes must be unique and 1 to %d", entity.ErrInvalidRecommendation, MaxAnalysisPackSize)
+		}
+		seen[size] = true
+	}
+	return nil
+}
+
+// candidates returns the sizes the search may add, in ascending order:
+// evenly spaced sizes of the range, plus the current sizes and the most
+// ordered amounts within it. Fixed sizes are left out.
+func (r RecommendationRequest) candidates(current []int64, demand *demand) []int64 {
+	step := r.Step
+	if step == 0 {
+		step = max(1, (r.MaxSize-r.MinSize+DefaultRecommendationCandidates)/DefaultRecommendationCandidates)
+	}
+
+	seen := make(map[int64]bool)
+	for _, size := range r.FixedSizes {
+		seen[size] = true
+	}
+	var candidates []int64
+	add := func(size int64) {
+		if size >= r.MinSize && size <= r.MaxSize && !seen[size] {
+			seen[size] = true
+			candidates = append(candidates, size)
+		}
+	}
+
+	for size := r.MinSize; size <= r.MaxSize; size += step {
+		add(size)
+	}
+	add(r.MaxSize)
+	for _, size := range current {
+		add(size)
+	}
+
+	popular := append([]demandPoint(nil), demand.points...)
+	sort.SliceStable(popular, func(i, j int) bool {
+		return popular[i].count > popular[j].count
+	})
+	added := 0
+	for _, point := range popular {
+		if added == recommendationDemandCandidates {
+			break
+		}
+		if point.amount >= r.MinSize && point.amount <= r.MaxSize {
+			add(point.amount)
+			added++
+		}
+	}
+
+	sortSizes(candidates)
+	return candidates
+}
+
+// demandPoint is a requested amount and the number of lines that requested it
+type demandPoint struct {
+	amount int64
+	count  int
+}
+
+// demand scores pack sets against the distribution of requested amounts
+type demand struct {
+	points      []demandPoint
+	maxAmount   int64
+	evaluations int
+}
+
+// newDemand builds the distribution from line counts per amount
+func newDemand(counts map[int64]int) *demand {
+	d := &demand{points: make([]demandPoint, 0, len(counts))}
+	for amount, count := range counts {
+		d.points = append(d.points, demandPoint{amount: amount, count: count})
+		d.maxAmount = max(d.maxAmount, amount)
+	}
+	sort.Slice(d.points, func(i, j int) bool {
+		return d.points[i].amount < d.points[j].amount
+	})
+	return d
+}
+
+// evaluate solves every requested amount with the sizes for the least waste
+// and sums the outcome. Sizes too large to analyse are refused, see newCoverage.
+func (d *demand) evaluate(sizes []int64) (SimulationTotals, error) {
+	d.evaluations++
+
+	options := make([]PackOption, len(sizes))
+	for i, size := range sizes {
+		options[i] = PackOption{Size: size}
+	}
+	cover, err := newCoverage(uniqueOptions(options), d.maxAmount)
+	if err != nil {
+		return SimulationTotals{}, err
+	}
+
+	var totals SimulationTotals
+	for _, point := range d.points {
+		total, packs := cover.solve(point.amount)
+		count := int64(point.count)
+		totals.TotalAmount += total * count
+		totals.Waste += (total - point.amount) * count
+		totals.TotalPacks += packs * count
+	}
+	return totals, nil
+}
+
+// search builds a set of count sizes from the fixed sizes and candidates.
+// It returns the best set found with its totals.
+func (d *demand) search(ctx context.Context, fixed, candidates []int64, count int) ([]int64, SimulationTotals, error) {
+	chosen := append([]int64{}, fixed...)
+	var best SimulationTotals
+	if len(chosen) > 0 {
+		var err error
+		if best, err = d.evaluate(chosen); err != nil {
+			return nil, best, err
+		}
+	}
+
+	used := make(map[int64]bool, count)
+	for _, size := range chosen {
+		used[size] = true
+	}
+
+	// Greedy: add the candidate that improves the set most, one at a time
+	for len(chosen) < count {
+		pick, pickTotals := int64(0), SimulationTotals{}
+		for _, candidate := range candidates {
+			if used[candidate] {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, best, err
+			}
+
+			totals, err := d.evaluate(append(chosen, candidate))
+			if err != nil {
+				return nil, best, err
+			}
+			if pick == 0 || betterTotals(totals, pickTotals) {
+				pick, pickTotals = candidate, totals
+			}
+		}
+		if pick == 0 {
+			break
+		}
+		chosen = append(chosen, pick)
+		used[pick] = true
+		best = pickTotals
+	}
+
+	// Local search: swap a chosen size for a candidate while that helps
+	for sweep := 0; sweep < recommendationSweeps; sweep++ {
+		improved := false
+		for i := len(fixed); i < len(chosen); i++ {
+			for _, candidate := range candidates {
+				if used[candidate] {
+					continue
+				}
+				if err := ctx.Err(); err != nil {
+					return nil, best, err
+				}
+
+				trial := append([]int64{}, chosen...)
+				trial[i] = candidate
+				totals, err := d.evaluate(trial)
+				if err != nil {
+					return nil, best, err
+				}
+				if betterTotals(totals, best) {
+					used[chosen[i]] = false
+					used[candidate] = true
+					chosen, best = trial, totals
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return chosen, best, nil
+}
+
+// betterTotals reports whether a wastes less than b, or as much in fewer packs
+func betterTotals(a, b SimulationTotals) bool {
+	if a.Waste != b.Waste {
+		return a.Waste < b.Waste
+	}
+	return a.TotalPacks < b.TotalPacks
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// newRecommendationFixture creates a recommendation service over orders of the
+// default product for the given amounts
+func newRecommendationFixture(t *testing.T, amounts ...int64) (*RecommendationService, *MockPackRepository) {
+	t.Helper()
+
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+	for _, amount := range amounts {
+		_, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: amount})
+		require.NoError(t, err)
+	}
+
+	return NewRecommendationService(mockOrderRepo, packService, logger.GetLogger()), mockPackRepo
+}
+
+func TestRecommendationService_RecommendPackSizes(t *testing.T) {
+	service, _ := newRecommendationFixture(t, 750, 750, 750, 750, 750, 1500, 1500, 1500)
+
+	result, err := service.RecommendPackSizes(context.Background(), RecommendationRequest{
+		Count:   1,
+		MinSize: 100,
+		MaxSize: 1000,
+		Step:    100,
+	})
+	require.NoError(t, err)
+
+	// 750 ships every order exactly, in 1 or 2 packs
+	require.Equal(t, []int64{750}, result.PackSizes)
+	if result.Orders != 8 || result.Lines != 8 || result.DistinctAmounts != 2 || result.RequestedAmount != 8250 {
+		t.Errorf("Expected 8 orders of 2 distinct amounts totalling 8250, got %+v", result)
+	}
+	if result.Recommended.Waste != 0 || result.Recommended.TotalPacks != 11 {
+		t.Errorf("Expected no waste in 11 packs, got %+v", result.Recommended)
+	}
+
+	// The current set ships 750 as 500+250 and 1500 as 1000+500
+	require.Equal(t, []int64{250, 500, 1000, 2000, 5000}, result.CurrentPackSizes)
+	require.NotNil(t, result.Current)
+	if result.Current.Waste != 0 || result.Current.TotalPacks != 16 {
+		t.Errorf("Expected the current set to ship without waste in 16 packs, got %+v", result.Current)
+	}
+	require.NotNil(t, result.Change)
+	if result.Change.Packs != -5 || result.Change.Waste != 0 {
+		t.Errorf("Expected 5 packs saved, got %+v", result.Change)
+	}
+	if result.Evaluations == 0 {
+		t.Error("Expected the evaluations to be counted")
+	}
+}
+
+func TestRecommendationService_RecommendPackSizes_FixedSizes(t *testing.T) {
+	service, _ := newRecommendationFixture(t, 300, 300, 300, 1000, 1200)
+
+	result, err := service.RecommendPackSizes(context.Background(), RecommendationRequest{
+		Count:      2,
+		MinSize:    100,
+		MaxSize:    600,
+		FixedSizes: []int64{1000},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []int64{1000}, result.FixedSizes)
+	require.Len(t, result.PackSizes, 2)
+	require.Contains(t, result.PackSizes, int64(1000))
+	// 300 ships the small orders exactly and 1200 as four of them
+	require.Equal(t, []int64{300, 1000}, result.PackSizes)
+	if result.Recommended.Waste != 0 {
+		t.Errorf("Expected no waste, got %+v", result.Recommended)
+	}
+}
+
+func TestRecommendationService_RecommendPackSizes_BestSingleSize(t *testing.T) {
+	amounts := []int64{130, 260, 275, 410, 990, 1000, 1001, 2222}
+	service, _ := newRecommendationFixture(t, amounts...)
+
+	req := RecommendationRequest{Count: 1, MinSize: 50, MaxSize: 600, Step: 10}
+	result, err := service.RecommendPackSizes(context.Background(), req)
+	require.NoError(t, err)
+
+	// A single size is found by trying every candidate, so nothing beats it
+	counts := make(map[int64]int)
+	for _, amount := range amounts {
+		counts[amount]++
+	}
+	demand := newDemand(counts)
+	for _, candidate := range req.candidates(result.CurrentPackSizes, demand) {
+		totals, err := demand.evaluate([]int64{candidate})
+		require.NoError(t, err)
+		if betterTotals(totals, result.Recommended) {
+			t.Fatalf("Size %d with %+v beats the recommended %v with %+v", candidate, totals, result.PackSizes, result.Recommended)
+		}
+	}
+}
+
+func TestRecommendationService_RecommendPackSizes_NoBetterThanCurrent(t *testing.T) {
+	service, _ := newRecommendationFixture(t, 251, 501, 1001, 12001, 777)
+
+	result, err := service.RecommendPackSizes(context.Background(), RecommendationRequest{Count: 5, MinSize: 250, MaxSize: 5000})
+	require.NoError(t, err)
+
+	// The current sizes are candidates, so the search never ends up worse
+	require.NotNil(t, result.Current)
+	if betterTotals(*result.Current, result.Recommended) {
+		t.Errorf("Expected the recommendation to match or beat the current set, got %+v against %+v", result.Recommended, *result.Current)
+	}
+}
+
+func TestRecommendationService_RecommendPackSizes_LargeCurrentSizes(t *testing.T) {
+	service, mockPackRepo := newRecommendationFixture(t, 750, 1500)
+
+	// A pack far above the analysed sizes would need a table of billions of entries
+	huge, err := entity.NewPack(uuid.New(), 1_000_000_007)
+	require.NoError(t, err)
+	mockPackRepo.packs = append(mockPackRepo.packs, *huge)
+
+	result, err := service.RecommendPackSizes(context.Background(), RecommendationRequest{Count: 1, MinSize: 100, MaxSize: 1000, Step: 50})
+	require.NoError(t, err)
+	require.Equal(t, []int64{750}, result.PackSizes)
+	require.Contains(t, result.CurrentPackSizes, int64(1_000_000_007))
+	require.Nil(t, result.Current)
+	require.Nil(t, result.Change)
+}
+
+func TestRecommendationService_RecommendPackSizes_Invalid(t *testing.T) {
+	service, mockPackRepo := newRecommendationFixture(t, 500)
+	emptyProduct := uuid.New()
+	mockPackRepo.addProduct(t, emptyProduct, 10)
+
+	tests := []struct {
+		name        string
+		req         RecommendationRequest
+		expectedErr error
+	}{
+		{name: "Zero count", req: RecommendationRequest{MinSize: 1, MaxSize: 10}, expectedErr: entity.ErrInvalidRecommendation},
+		{name: "Too many sizes", req: RecommendationRequest{Count: MaxRecommendedSizes + 1, MinSize: 1, MaxSize: 10}, expectedErr: entity.ErrInvalidRecommendation},
+		{name: "Empty range", req: RecommendationRequest{Count: 1, MinSize: 10, MaxSize: 9}, expectedErr: entity.ErrInvalidRecommendation},
+		{name: "Step too small", req: RecommendationRequest{Count: 1, MinSize: 1, MaxSize: 100000, Step: 1}, expectedErr: entity.ErrInvalidRecommendation},
+		{name: "Too many fixed sizes", req: RecommendationRequest{Count: 1, MinSize: 1, MaxSize: 10, FixedSizes: []int64{5, 6}}, expectedErr: entity.ErrInvalidRecommendation},
+		{name: "Duplicate fixed sizes", req: RecommendationRequest{Count: 3, MinSize: 1, MaxSize: 10, FixedSizes: []int64{5, 5}}, expectedErr: entity.ErrInvalidRecommendation},
+		{name: "No history", req: RecommendationRequest{ProductID: emptyProduct.String(), Count: 1, MinSize: 1, MaxSize: 10}, expectedErr: entity.ErrInvalidRecommendation},
+		{name: "Unknown product", req: RecommendationRequest{ProductID: uuid.New().String(), Count: 1, MinSize: 1, MaxSize: 10}, expectedErr: entity.ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.RecommendPackSizes(context.Background(), tt.req); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestRecommendationService_RecommendPackSizes_Cancelled(t *testing.T) {
+	service, _ := newRecommendationFixture(t, 500, 750)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := service.RecommendPackSizes(ctx, RecommendationRequest{Count: 2, MinSize: 1, MaxSize: 1000}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
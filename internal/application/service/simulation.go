@@ -0,0 +1,504 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxRunningSimulations is how many simulations may run at the same time
+	MaxRunningSimulations = 4
+	// MaxRetainedSimulations is how many simulations are remembered; the
+	// oldest finished ones are forgotten first
+	MaxRetainedSimulations = 100
+	// SimulationTimeout bounds how long one simulation may run
+	SimulationTimeout = 10 * time.Minute
+	// historyPageSize is how many orders are loaded at a time when replaying history
+	historyPageSize = 500
+)
+
+// SimulationStatus is the state of a simulation job
+type SimulationStatus string
+
+const (
+	SimulationPending   SimulationStatus = "pending"
+	SimulationRunning   SimulationStatus = "running"
+	SimulationCompleted SimulationStatus = "completed"
+	SimulationFailed    SimulationStatus = "failed"
+)
+
+// SimulationRequest describes a candidate pack set to replay the order
+// history of a product against
+type SimulationRequest struct {
+	// ProductID selects the order history; the default product is used when it is empty
+	ProductID string           `json:"product_id,omitempty" binding:"omitempty,uuid" format:"uuid"`
+	Packs     []SimulationPack `json:"packs" binding:"required,min=1,max=100,dive"`
+	// Objective solves the replayed amounts; the default objective is used when it is empty
+	Objective Objective `json:"objective,omitempty"`
+	// CreatedFrom and CreatedTo limit the replay to orders created in that period
+	CreatedFrom *time.Time `json:"created_from,omitempty"`
+	CreatedTo   *time.Time `json:"created_to,omitempty"`
+}
+
+// SimulationPack is one size of the candidate pack set. Without a unit cost,
+// the cost of the current pack of the same size is used, if there is one.
+type SimulationPack struct {
+	Size     int64  `json:"size" binding:"required,min=1"`
+	UnitCost *int64 `json:"unit_cost_cents,omitempty" binding:"omitempty,min=0"`
+}
+
+// SimulationResponse is the state of a simulation job and, once it has
+// completed, its result
+type SimulationResponse struct {
+	ID        uuid.UUID        `json:"id"`
+	Status    SimulationStatus `json:"status"`
+	ProductID string           `json:"product_id" format:"uuid"`
+	PackSizes []int64          `json:"pack_sizes"`
+	Objective Objective        `json:"objective"`
+	// ProcessedOrders counts the orders replayed so far
+	ProcessedOrders int               `json:"processed_orders"`
+	Result          *SimulationResult `json:"result,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	CompletedAt     *time.Time        `json:"completed_at,omitempty"`
+}
+
+// SimulationResult compares what the order history actually shipped with what
+// the candidate pack set would have shipped. Cancelled orders are left out.
+type SimulationResult struct {
+	Orders int `json:"orders"`
+	// Lines counts the replayed order lines of the product
+	Lines           int               `json:"lines"`
+	RequestedAmount int64             `json:"requested_amount"`
+	Actual          SimulationTotals  `json:"actual"`
+	Simulated       SimulationTotals  `json:"simulated"`
+	Change          SimulationChanges `json:"change"`
+}
+
+// SimulationTotals sums what was, or would have been, shipped
+type SimulationTotals struct {
+	TotalAmount int64 `json:"total_amount"`
+	Waste       int64 `json:"waste"`
+	TotalPacks  int64 `json:"total_packs"`
+	// TotalCost is the price of all packs in cents, reported when every pack used has a unit cost
+	TotalCost *int64 `json:"total_cost_cents,omitempty"`
+}
+
+// SimulationChanges are the simulated totals minus the actual ones; negative
+// values are savings. Percentages are relative to the actual totals and are
+// omitted when those are zero.
+type SimulationChanges struct {
+	Waste        int64    `json:"waste"`
+	WastePercent *float64 `json:"waste_percent,omitempty"`
+	Packs        int64    `json:"total_packs"`
+	PacksPercent *float64 `json:"total_packs_percent,omitempty"`
+	Cost         *int64   `json:"total_cost_cents,omitempty"`
+	CostPercent  *float64 `json:"total_cost_percent,omitempty"`
+}
+
+// SimulationService replays order history against candidate pack sets in
+// the background. Jobs are kept in memory only.
+type SimulationService struct {
+	orderRepo   repository.OrderRepository
+	packRepo    repository.PackRepository
+	packService *PackService
+	logger      *logger.Logger
+
+	mu      sync.Mutex
+	jobs    map[uuid.UUID]*SimulationResponse
+	created []uuid.UUID
+	running int
+}
+
+// NewSimulationService creates a new simulation service
+func NewSimulationService(orderRepo repository.OrderRepository, packRepo repository.PackRepository, packService *PackService, logger *logger.Logger) *SimulationService {
+	return &SimulationService{
+		orderRepo:   orderRepo,
+		packRepo:    packRepo,
+		packService: packService,
+		logger:      logger,
+		jobs:        make(map[uuid.UUID]*SimulationResponse),
+	}
+}
+
+// StartSimulation validates the request and starts replaying the order
+// history in the background. Poll GetSimulation for the result.
+func (s *SimulationService) StartSimulation(ctx context.Context, req SimulationRequest) (*SimulationResponse, error) {
+	current, err := s.packService.productPacks(ctx, &req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	solver, err := s.packService.solverFor(req.Objective)
+	if err != nil {
+		s.logger.Error("Invalid objective provided: %q", req.Objective)
+		return nil, err
+	}
+	req.Objective = solver.Objective()
+
+	candidates, err := candidatePacks(req.Packs, current)
+	if err != nil {
+		s.logger.Error("Invalid candidate pack set: %v", err)
+		return nil, err
+	}
+	if solver.UsesCost() {
+		for _, pack := range candidates {
+			if _, ok := pack.UnitCost(); !ok {
+				s.logger.Error("Candidate pack size %d has no unit cost, required by objective %s", pack.Size(), solver.Objective())
+				return nil, fmt.Errorf("%w: pack size %d", entity.ErrMissingPackCost, pack.Size())
+			}
+		}
+	}
+
+	job := &SimulationResponse{
+		ID:        uuid.New(),
+		Status:    SimulationPending,
+		ProductID: req.ProductID,
+		PackSizes: make([]int64, len(candidates)),
+		Objective: req.Objective,
+		CreatedAt: time.Now().UTC(),
+	}
+	for i, pack := range candidates {
+		job.PackSizes[i] = pack.Size()
+	}
+
+	s.mu.Lock()
+	if s.running >= MaxRunningSimulations {
+		s.mu.Unlock()
+		s.logger.Warn("Rejecting simulation, %d are already running", MaxRunningSimulations)
+		return nil, entity.ErrTooManySimulations
+	}
+	s.running++
+	s.jobs[job.ID] = job
+	s.created = append(s.created, job.ID)
+	s.forgetFinished()
+	snapshot := *job
+	s.mu.Unlock()
+
+	s.logger.Info("Starting simulation %s of %d pack sizes for product %s", job.ID, len(candidates), req.ProductID)
+	go s.run(job.ID, req, candidates)
+
+	return &snapshot, nil
+}
+
+// GetSimulation returns the state of a simulation job
+func (s *SimulationService) GetSimulation(ctx context.Context, id uuid.UUID) (*SimulationResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, entity.ErrSimulationNotFound
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// forgetFinished drops the oldest finished jobs while more than
+// MaxRetainedSimulations are kept. The caller must hold s.mu.
+func (s *SimulationService) forgetFinished() {
+	for i := 0; len(s.jobs) > MaxRetainedSimulations && i < len(s.created); {
+		id := s.created[i]
+		if status := s.jobs[id].Status; status != SimulationCompleted && status != SimulationFailed {
+			i++
+			continue
+		}
+		delete(s.jobs, id)
+		s.created = append(s.created[:i], s.created[i+1:]...)
+	}
+}
+
+// update applies change to a job under the lock
+func (s *SimulationService) update(id uuid.UUID, change func(job *SimulationResponse)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(s.jobs[id])
+}
+
+// run replays the order history of a job and records the outcome
+func (s *SimulationService) run(id uuid.UUID, req SimulationRequest, candidates []entity.Pack) {
+	ctx, cancel := context.WithTimeout(context.Background(), SimulationTimeout)
+	defer cancel()
+
+	s.update(id, func(job *SimulationResponse) { job.Status = SimulationRunning })
+
+	result, err := s.simulate(ctx, req, candidates, func(processed int) {
+		s.update(id, func(job *SimulationResponse) { job.ProcessedOrders = processed })
+	})
+
+	completedAt := time.Now().UTC()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	job := s.jobs[id]
+	job.CompletedAt = &completedAt
+	if err != nil {
+		s.logger.Error("Simulation %s failed: %v", id, err)
+		job.Status = SimulationFailed
+		job.Error = err.Error()
+		return
+	}
+
+	s.logger.Info("Simulation %s completed over %d orders", id, result.Orders)
+	job.Status = SimulationCompleted
+	job.Result = result
+}
+
+// simulate re-solves the requested amount of every order line of the product
+// with the candidate packs and sums both outcomes. What was shipped is priced
+// with the pack revisions each order was calculated with. progress is called
+// with the number of orders replayed after every page.
+func (s *SimulationService) simulate(ctx context.Context, req SimulationRequest, candidates []entity.Pack, progress func(processed int)) (*SimulationResult, error) {
+	productID, err := uuid.Parse(req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entity.ErrProductNotFound, err)
+	}
+
+	result := &SimulationResult{}
+	actualCost, simulatedCost := int64(0), int64(0)
+	actualCostKnown, simulatedCostKnown := true, true
+	solved := make(map[int64]*PackCalculationResponse)
+
+	var orderPacks map[uuid.UUID][]entity.Pack
+	prepare := func(orders []entity.Order) error {
+		packs, err := calculationPacks(ctx, s.packRepo, orders)
+		if err != nil {
+			return err
+		}
+		orderPacks = make(map[uuid.UUID][]entity.Pack, len(orders))
+		for i := range orders {
+			orderPacks[orders[i].ID()] = packs[i]
+		}
+		return nil
+	}
+
+	visit := func(order entity.Order, line entity.OrderLine) error {
+		amount := line.GetRequestedAmount()
+
+		simulated, ok := solved[amount]
+		if !ok {
+			var err error
+			simulated, err = s.packService.calculateWith(ctx, PackCalculationRequest{
+				Amount:    amount,
+				ProductID: req.ProductID,
+				Objective: req.Objective,
+			}, candidates, nil)
+			if err != nil {
+				return fmt.Errorf("order %s line %d: %w", order.ID(), line.Number(), err)
+			}
+			solved[amount] = simulated
+		}
+
+		combination := make(map[int64]int64, len(line.GetItems()))
+		actual := SimulationTotals{
+			TotalAmount: line.GetTotalAmount(),
+			Waste:       line.GetTotalAmount() - amount,
+		}
+		ok = true
+		for _, item := range line.GetItems() {
+			combination[item.PackageSize()] += item.Quantity()
+			if actual.TotalPacks, ok = entity.CheckedAdd(actual.TotalPacks, item.Quantity()); !ok {
+				break
+			}
+		}
+
+		result.Lines++
+		if ok {
+			result.RequestedAmount, ok = entity.CheckedAdd(result.RequestedAmount, amount)
+		}
+		if !ok || !result.Actual.add(actual) || !result.Simulated.add(SimulationTotals{
+			TotalAmount: simulated.TotalAmount,
+			Waste:       simulated.Waste,
+			TotalPacks:  simulated.TotalPacks,
+		}) {
+			return fmt.Errorf("order %s line %d: %w: the totals overflow", order.ID(), line.Number(), entity.ErrAmountTooLarge)
+		}
+
+		if cost, _ := packTotals(orderPacks[order.ID()], combination); cost != nil && actualCostKnown {
+			actualCost, actualCostKnown = entity.CheckedAdd(actualCost, *cost)
+		} else {
+			actualCostKnown = false
+		}
+		if simulated.TotalCost != nil && simulatedCostKnown {
+			simulatedCost, simulatedCostKnown = entity.CheckedAdd(simulatedCost, *simulated.TotalCost)
+		} else {
+			simulatedCostKnown = false
+		}
+		return nil
+	}
+
+	history := orderHistory{
+		productID:   productID,
+		createdFrom: req.CreatedFrom,
+		createdTo:   req.CreatedTo,
+	}
+	if result.Orders, err = history.replay(ctx, s.orderRepo, prepare, visit, progress); err != nil {
+		return nil, err
+	}
+
+	if actualCostKnown {
+		result.Actual.TotalCost = &actualCost
+	}
+	if simulatedCostKnown {
+		result.Simulated.TotalCost = &simulatedCost
+	}
+	result.Change = simulationChanges(result.Actual, result.Simulated)
+	return result, nil
+}
+
+// add adds other to the totals, or returns false when a total overflows an
+// int64. Costs are not added.
+func (t *SimulationTotals) add(other SimulationTotals) bool {
+	totalAmount, ok := entity.CheckedAdd(t.TotalAmount, other.TotalAmount)
+	if !ok {
+		return false
+	}
+	waste, ok := entity.CheckedAdd(t.Waste, other.Waste)
+	if !ok {
+		return false
+	}
+	totalPacks, ok := entity.CheckedAdd(t.TotalPacks, other.TotalPacks)
+	if !ok {
+		return false
+	}
+	t.TotalAmount, t.Waste, t.TotalPacks = totalAmount, waste, totalPacks
+	return true
+}
+
+// orderHistory selects the order lines of a product that are replayed by
+// simulations and recommendations. Nil periods are not applied.
+type orderHistory struct {
+	productID   uuid.UUID
+	createdFrom *time.Time
+	createdTo   *time.Time
+}
+
+// replay calls visit for every non-empty line of the product in orders that
+// were not cancelled, oldest order first, and returns the number of orders
+// visited. prepare, when set, is called with the orders of every page before
+// their lines are visited. progress, when set, is called with the number of
+// orders visited after every page.
+func (h orderHistory) replay(ctx context.Context, orderRepo repository.OrderRepository, prepare func(orders []entity.Order) error, visit func(order entity.Order, line entity.OrderLine) error, progress func(processed int)) (int, error) {
+	query := repository.OrderQuery{
+		CreatedFrom: h.createdFrom,
+		CreatedTo:   h.createdTo,
+		ProductID:   &h.productID,
+		SortBy:      repository.OrderSortCreatedAt,
+		Limit:       historyPageSize,
+	}
+
+	orders := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return orders, err
+		}
+
+		page, err := orderRepo.List(ctx, query)
+		if err != nil {
+			return orders, err
+		}
+
+		replayed := make([]entity.Order, 0, len(page.Orders))
+		for _, order := range page.Orders {
+			if order.Status() != entity.OrderStatusCancelled {
+				replayed = append(replayed, order)
+			}
+		}
+		if prepare != nil {
+			if err := prepare(replayed); err != nil {
+				return orders, err
+			}
+		}
+
+		for _, order := range replayed {
+			orders++
+
+			for _, line := range order.Lines() {
+				if line.ProductID() != h.productID || line.IsEmpty() {
+					continue
+				}
+				if err := visit(order, line); err != nil {
+					return orders, err
+				}
+			}
+		}
+		if progress != nil {
+			progress(orders)
+		}
+
+		if page.Next == nil {
+			return orders, nil
+		}
+		query.After = page.Next
+	}
+}
+
+// candidatePacks builds the candidate pack set of a simulation, borrowing
+// unit costs from the current pack set where the request gives none
+func candidatePacks(requested []SimulationPack, current []entity.Pack) ([]entity.Pack, error) {
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("%w: the candidate pack set is empty", entity.ErrInvalidSimulation)
+	}
+
+	currentBySize := make(map[int64]entity.Pack, len(current))
+	for _, pack := range current {
+		currentBySize[pack.Size()] = pack
+	}
+
+	seen := make(map[int64]bool, len(requested))
+	candidates := make([]entity.Pack, 0, len(requested))
+	for _, candidate := range requested {
+		if seen[candidate.Size] {
+			return nil, fmt.Errorf("%w: pack size %d is listed more than once", entity.ErrInvalidSimulation, candidate.Size)
+		}
+		seen[candidate.Size] = true
+
+		attributes := entity.PackAttributes{UnitCost: candidate.UnitCost}
+		if existing, ok := currentBySize[candidate.Size]; ok {
+			attributes = existing.Attributes()
+			if candidate.UnitCost != nil {
+				attributes.UnitCost = candidate.UnitCost
+			}
+		}
+
+		pack, err := entity.NewPackWithAttributes(uuid.New(), candidate.Size, attributes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: pack size %d: %v", entity.ErrInvalidSimulation, candidate.Size, err)
+		}
+		candidates = append(candidates, *pack)
+	}
+	return candidates, nil
+}
+
+// simulationChanges compares simulated totals with actual ones
+func simulationChanges(actual, simulated SimulationTotals) SimulationChanges {
+	changes := SimulationChanges{
+		Waste:        simulated.Waste - actual.Waste,
+		WastePercent: percentChange(float64(actual.Waste), float64(simulated.Waste)),
+		Packs:        simulated.TotalPacks - actual.TotalPacks,
+		PacksPercent: percentChange(float64(actual.TotalPacks), float64(simulated.TotalPacks)),
+	}
+	if actual.TotalCost != nil && simulated.TotalCost != nil {
+		cost := *simulated.TotalCost - *actual.TotalCost
+		changes.Cost = &cost
+		changes.CostPercent = percentChange(float64(*actual.TotalCost), float64(*simulated.TotalCost))
+	}
+	return changes
+}
+
+// percentChange returns the change from before to after in percent of before,
+// or nil when before is zero
+func percentChange(before, after float64) *float64 {
+	if before == 0 {
+		return nil
+	}
+	change := (after - before) * 100 / before
+	return &change
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForSimulation polls a simulation until it has finished
+func waitForSimulation(t *testing.T, service *SimulationService, id uuid.UUID) *SimulationResponse {
+	t.Helper()
+
+	var job *SimulationResponse
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = service.GetSimulation(context.Background(), id)
+		require.NoError(t, err)
+		return job.Status == SimulationCompleted || job.Status == SimulationFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestSimulationService_StartSimulation(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+	service := NewSimulationService(mockOrderRepo, mockPackRepo, packService, logger.GetLogger())
+
+	// 251 ships 500 in 1 pack, 750 ships 500+250 and 1001 ships 1000+250
+	for _, amount := range []int64{251, 750, 1001} {
+		_, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: amount})
+		require.NoError(t, err)
+	}
+	cancelled, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 4999})
+	require.NoError(t, err)
+	_, err = orderService.TransitionOrder(context.Background(), cancelled.OrderID, entity.OrderStatusCancelled, AnyVersion)
+	require.NoError(t, err)
+
+	packs := []SimulationPack{{Size: 250}, {Size: 500}, {Size: 750}, {Size: 1000}, {Size: 2000}, {Size: 5000}}
+	started, err := service.StartSimulation(context.Background(), SimulationRequest{Packs: packs})
+	require.NoError(t, err)
+	if started.ProductID != entity.DefaultProductID.String() || started.Objective != ObjectiveMinWaste {
+		t.Errorf("Expected the default product and objective, got %s and %s", started.ProductID, started.Objective)
+	}
+
+	job := waitForSimulation(t, service, started.ID)
+	require.Equal(t, SimulationCompleted, job.Status, job.Error)
+	require.NotNil(t, job.CompletedAt)
+	if job.ProcessedOrders != 3 {
+		t.Errorf("Expected 3 processed orders, got %d", job.ProcessedOrders)
+	}
+
+	result := job.Result
+	if result.Orders != 3 || result.Lines != 3 {
+		t.Errorf("Expected the 3 orders that were not cancelled, got %d orders and %d lines", result.Orders, result.Lines)
+	}
+	if result.RequestedAmount != 2002 {
+		t.Errorf("Expected requested amount 2002, got %d", result.RequestedAmount)
+	}
+	if result.Actual.TotalPacks != 5 || result.Simulated.TotalPacks != 4 {
+		t.Errorf("Expected 5 packs shipped and 4 simulated, got %d and %d", result.Actual.TotalPacks, result.Simulated.TotalPacks)
+	}
+	if result.Actual.Waste != 498 || result.Simulated.Waste != 498 {
+		t.Errorf("Expected waste 498 both times, got %d and %d", result.Actual.Waste, result.Simulated.Waste)
+	}
+	if result.Change.Packs != -1 || result.Change.PacksPercent == nil || *result.Change.PacksPercent != -20 {
+		t.Errorf("Expected 1 pack saved, -20%%, got %d and %v", result.Change.Packs, result.Change.PacksPercent)
+	}
+	if result.Change.Waste != 0 || result.Change.WastePercent == nil || *result.Change.WastePercent != 0 {
+		t.Errorf("Expected no change in waste, got %d and %v", result.Change.Waste, result.Change.WastePercent)
+	}
+	if result.Actual.TotalCost != nil || result.Change.Cost != nil {
+		t.Errorf("Expected no cost without unit costs, got %v and %v", result.Actual.TotalCost, result.Change.Cost)
+	}
+}
+
+func TestSimulationService_StartSimulation_Product(t *testing.T) {
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+	service := NewSimulationService(mockOrderRepo, mockPackRepo, packService, logger.GetLogger())
+	productID := uuid.New()
+	mockPackRepo.addProduct(t, productID, 6, 10)
+
+	_, err := orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 1000})
+	require.NoError(t, err)
+	_, err = orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Lines: []OrderLineRequest{
+		{Amount: 9, ProductID: productID.String()},
+		{Amount: 250},
+	}})
+	require.NoError(t, err)
+
+	started, err := service.StartSimulation(context.Background(), SimulationRequest{
+		ProductID: productID.String(),
+		Packs:     []SimulationPack{{Size: 3}, {Size: 10}},
+	})
+	require.NoError(t, err)
+
+	job := waitForSimulation(t, service, started.ID)
+	require.Equal(t, SimulationCompleted, job.Status, job.Error)
+	result := job.Result
+	if result.Orders != 1 || result.Lines != 1 {
+		t.Fatalf("Expected only the line of the product, got %d orders and %d lines", result.Orders, result.Lines)
+	}
+	// 9 shipped as 10 before and as 3+3+3 with the candidate set
+	if result.Actual.Waste != 1 || result.Simulated.Waste != 0 || result.Simulated.TotalPacks != 3 {
+		t.Errorf("Expected waste 1 to become 0 in 3 packs, got %+v and %+v", result.Actual, result.Simulated)
+	}
+	if result.Change.Waste != -1 || *result.Change.WastePercent != -100 {
+		t.Errorf("Expected all waste saved, got %d and %v", result.Change.Waste, *result.Change.WastePercent)
+	}
+}
+
+func TestSimulationService_StartSimulation_ActualCost(t *testing.T) {
+	cost := int64(100)
+	pack, err := entity.NewPackWithAttributes(uuid.New(), 500, entity.PackAttributes{UnitCost: &cost})
+	require.NoError(t, err)
+	mockOrderRepo := NewMockOrderRepository()
+	mockPackRepo := &MockPackRepository{packs: []entity.Pack{*pack}}
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	orderService := NewOrderService(mockOrderRepo, mockPackRepo, NewMockStockRepository(mockPackRepo), packService, logger.GetLogger())
+	service := NewSimulationService(mockOrderRepo, mockPackRepo, packService, logger.GetLogger())
+
+	_, err = orderService.CreateOrderFromCalculation(context.Background(), OrderRequest{Amount: 1000})
+	require.NoError(t, err)
+
+	// The order shipped at the price it was calculated with, not today's
+	newCost := int64(150)
+	_, err = packService.UpdatePack(context.Background(), pack.ID(), PackChange{Size: 500, Attributes: entity.PackAttributes{UnitCost: &newCost}}, AnyVersion)
+	require.NoError(t, err)
+
+	started, err := service.StartSimulation(context.Background(), SimulationRequest{Packs: []SimulationPack{{Size: 500}}})
+	require.NoError(t, err)
+
+	job := waitForSimulation(t, service, started.ID)
+	require.Equal(t, SimulationCompleted, job.Status, job.Error)
+	result := job.Result
+	if result.Actual.TotalCost == nil || *result.Actual.TotalCost != 200 {
+		t.Errorf("Expected actual cost 200, got %v", result.Actual.TotalCost)
+	}
+	if result.Simulated.TotalCost == nil || *result.Simulated.TotalCost != 300 {
+		t.Errorf("Expected simulated cost 300, got %v", result.Simulated.TotalCost)
+	}
+}
+
+func TestSimulationService_StartSimulation_Invalid(t *testing.T) {
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	service := NewSimulationService(NewMockOrderRepository(), mockPackRepo, packService, logger.GetLogger())
+	cost := int64(-1)
+
+	tests := []struct {
+		name        string
+		req         SimulationRequest
+		expectedErr error
+	}{
+		{name: "No packs", req: SimulationRequest{}, expectedErr: entity.ErrInvalidSimulation},
+		{name: "Duplicate size", req: SimulationRequest{Packs: []SimulationPack{{Size: 250}, {Size: 250}}}, expectedErr: entity.ErrInvalidSimulation},
+		{name: "Zero size", req: SimulationRequest{Packs: []SimulationPack{{Size: 0}}}, expectedErr: entity.ErrInvalidSimulation},
+		{name: "Negative cost", req: SimulationRequest{Packs: []SimulationPack{{Size: 250, UnitCost: &cost}}}, expectedErr: entity.ErrInvalidSimulation},
+		{name: "Unknown objective", req: SimulationRequest{Packs: []SimulationPack{{Size: 250}}, Objective: "fastest"}, expectedErr: entity.ErrUnknownObjective},
+		{name: "Cost objective without costs", req: SimulationRequest{Packs: []SimulationPack{{Size: 250}}, Objective: ObjectiveMinCost}, expectedErr: entity.ErrMissingPackCost},
+		{name: "Unknown product", req: SimulationRequest{ProductID: uuid.New().String(), Packs: []SimulationPack{{Size: 250}}}, expectedErr: entity.ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.StartSimulation(context.Background(), tt.req); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	if _, err := service.GetSimulation(context.Background(), uuid.New()); !errors.Is(err, entity.ErrSimulationNotFound) {
+		t.Errorf("Expected ErrSimulationNotFound, got %v", err)
+	}
+}
+
+func TestSimulationService_StartSimulation_TooMany(t *testing.T) {
+	mockPackRepo := NewMockPackRepository()
+	packService := NewPackService(mockPackRepo, DefaultSolverConfig(), logger.GetLogger())
+	service := NewSimulationService(NewMockOrderRepository(), mockPackRepo, packService, logger.GetLogger())
+	service.running = MaxRunningSimulations
+
+	_, err := service.StartSimulation(context.Background(), SimulationRequest{Packs: []SimulationPack{{Size: 250}}})
+	if !errors.Is(err, entity.ErrTooManySimulations) {
+		t.Errorf("Expected ErrTooManySimulations, got %v", err)
+	}
+}
+
+func TestCandidatePacks_BorrowsCurrentCosts(t *testing.T) {
+	cost, otherCost := int64(120), int64(90)
+	current, err := entity.NewPackWithAttributes(uuid.New(), 500, entity.PackAttributes{UnitCost: &cost})
+	require.NoError(t, err)
+
+	candidates, err := candidatePacks([]SimulationPack{{Size: 500}, {Size: 750, UnitCost: &otherCost}, {Size: 1000}}, []entity.Pack{*current})
+	require.NoError(t, err)
+
+	if got, ok := candidates[0].UnitCost(); !ok || got != cost {
+		t.Errorf("Expected the current cost %d for size 500, got %d", cost, got)
+	}
+	if got, ok := candidates[1].UnitCost(); !ok || got != otherCost {
+		t.Errorf("Expected the requested cost %d for size 750, got %d", otherCost, got)
+	}
+	if _, ok := candidates[2].UnitCost(); ok {
+		t.Error("Expected no cost for a new size without one")
+	}
+}
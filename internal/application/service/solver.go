@@ -0,0 +1,762 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+)
+
+// unreachable marks totals that no combination of packs can hit exactly
+const unreachable = -1
+
+// MaxSolverTable is the largest table, in totals, a solver fills for a single
+// case. Only pack sets with large sizes sharing no common divisor come near it.
+const MaxSolverTable = 1 << 24
+
+// checkEvery is how many totals a table fill handles between context checks
+const checkEvery = 1 << 12
+
+// SolverVersion identifies the solver implementation recorded on orders.
+// Bump it whenever a change can alter the combination chosen for a request.
+const SolverVersion = "dp-2"
+
+// Objective names the optimization rules a Solver applies
+type Objective string
+
+const (
+	// ObjectiveMinWaste ships the fewest items, then the fewest packs
+	ObjectiveMinWaste Objective = "min_waste"
+	// ObjectiveMinPacks ships the fewest packs, then the fewest items
+	ObjectiveMinPacks Objective = "min_packs"
+	// ObjectiveMinCost ships the cheapest combination, then the fewest items and packs
+	ObjectiveMinCost Objective = "min_cost"
+	// ObjectiveWeighted minimizes a weighted sum of waste, pack count and cost
+	ObjectiveWeighted Objective = "weighted"
+)
+
+// Objectives lists every supported objective
+var Objectives = []Objective{ObjectiveMinWaste, ObjectiveMinPacks, ObjectiveMinCost, ObjectiveWeighted}
+
+// ParseObjective validates an objective name
+func ParseObjective(name string) (Objective, error) {
+	for _, objective := range Objectives {
+		if string(objective) == name {
+			return objective, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", entity.ErrUnknownObjective, name)
+}
+
+// SolverWeights holds the coefficients used by the weighted objective
+type SolverWeights struct {
+	Waste float64
+	Packs float64
+	Cost  float64
+}
+
+// SolverConfig configures the solvers available to the pack service
+type SolverConfig struct {
+	DefaultObjective Objective
+	Weights          SolverWeights
+	// BatchWorkers bounds how many amounts of a batch are solved at once; 0 uses GOMAXPROCS
+	BatchWorkers int
+	// TimeBudget bounds how long a single calculation may search before the
+	// best combination found so far is used; 0 means no budget
+	TimeBudget time.Duration
+}
+
+// DefaultSolverConfig returns the solver configuration used when nothing else is set
+func DefaultSolverConfig() SolverConfig {
+	return SolverConfig{
+		DefaultObjective: ObjectiveMinWaste,
+		Weights: SolverWeights{
+			Waste: 1,
+			Packs: 1,
+			Cost:  0,
+		},
+		TimeBudget: 2 * time.Second,
+	}
+}
+
+// PackOption is a pack size a Solver may use
+type PackOption struct {
+	Size int64
+	Cost int64
+	// Available caps how many packs of this size may be used; nil means unlimited
+	Available *int64
+}
+
+// limited reports whether the option can only be used a bounded number of times
+func (o PackOption) limited() bool {
+	return o.Available != nil
+}
+
+// Solution is a pack combination chosen by a Solver
+type Solution struct {
+	Combination map[int64]int64
+	TotalAmount int64
+	TotalPacks  int64
+	Waste       int64
+	Cost        int64
+
+	// weight is the objective-specific additive score of the packs used
+	weight float64
+}
+
+// Solver chooses a pack combination that covers an amount
+type Solver interface {
+	Objective() Objective
+	// UsesCost reports whether the solver needs a unit cost for every pack
+	UsesCost() bool
+	// Solve returns the best combination, or the best found so far along with
+	// the reason the search stopped early
+	Solve(ctx context.Context, amount int64, packs []PackOption) (Solution, error)
+	// SolveTop returns up to n of the best combinations, each shipping a
+	// different total, ranked best first. Like Solve, it returns what it found
+	// so far along with an error when the search stopped early.
+	SolveTop(ctx context.Context, amount int64, packs []PackOption, n int) ([]Solution, error)
+}
+
+// NewSolver creates the solver for the given objective
+func NewSolver(objective Objective, weights SolverWeights) (Solver, error) {
+	switch objective {
+	case ObjectiveMinWaste:
+		return newMinWasteSolver(), nil
+	case ObjectiveMinPacks:
+		return newMinPacksSolver(), nil
+	case ObjectiveMinCost:
+		return newMinCostSolver(), nil
+	case ObjectiveWeighted:
+		return newWeightedSolver(weights)
+	default:
+		return nil, fmt.Errorf("%w: %q", entity.ErrUnknownObjective, objective)
+	}
+}
+
+// dpSolver is a Solver driven by an objective-specific pack weight and ranking.
+//
+// For every exact total it keeps the combination with the lowest additive
+// weight (ties broken by pack count), then ranks the covering totals with less.
+// This is exact as long as two combinations with the same total compare the
+// same way under weight as under less, which every objective below satisfies.
+type dpSolver struct {
+	objective  Objective
+	usesCost   bool
+	packWeight func(pack PackOption) float64
+	less       func(a, b *Solution) bool
+}
+
+// newMinWasteSolver follows the original rules:
+// 1. Only whole packs can be sent
+// 2. Send out the least amount of items to fulfill the order (minimize waste)
+// 3. Send out as few packs as possible (minimize pack count)
+// Rule #2 takes precedence over rule #3
+func newMinWasteSolver() *dpSolver {
+	return &dpSolver{
+		objective:  ObjectiveMinWaste,
+		packWeight: func(PackOption) float64 { return 1 },
+		less: func(a, b *Solution) bool {
+			if a.Waste != b.Waste {
+				return a.Waste < b.Waste
+			}
+			return a.TotalPacks < b.TotalPacks
+		},
+	}
+}
+
+// newMinPacksSolver ships as few packs as possible regardless of waste
+func newMinPacksSolver() *dpSolver {
+	return &dpSolver{
+		objective:  ObjectiveMinPacks,
+		packWeight: func(PackOption) float64 { return 1 },
+		less: func(a, b *Solution) bool {
+			if a.TotalPacks != b.TotalPacks {
+				return a.TotalPacks < b.TotalPacks
+			}
+			return a.Waste < b.Waste
+		},
+	}
+}
+
+// newMinCostSolver ships the cheapest combination
+func newMinCostSolver() *dpSolver {
+	return &dpSolver{
+		objective:  ObjectiveMinCost,
+		usesCost:   true,
+		packWeight: func(pack PackOption) float64 { return float64(pack.Cost) },
+		less: func(a, b *Solution) bool {
+			if a.Cost != b.Cost {
+				return a.Cost < b.Cost
+			}
+			if a.Waste != b.Waste {
+				return a.Waste < b.Waste
+			}
+			return a.TotalPacks < b.TotalPacks
+		},
+	}
+}
+
+// newWeightedSolver blends waste, pack count and cost into a single score
+func newWeightedSolver(weights SolverWeights) (*dpSolver, error) {
+	if weights.Waste < 0 || weights.Packs < 0 || weights.Cost < 0 {
+		return nil, fmt.Errorf("solver weights must not be negative")
+	}
+
+	score := func(s *Solution) float64 {
+		return weights.Waste*float64(s.Waste) + s.weight
+	}
+
+	return &dpSolver{
+		objective: ObjectiveWeighted,
+		usesCost:  weights.Cost > 0,
+		packWeight: func(pack PackOption) float64 {
+			return weights.Packs + weights.Cost*float64(pack.Cost)
+		},
+		less: func(a, b *Solution) bool {
+			if scoreA, scoreB := score(a), score(b); scoreA != scoreB {
+				return scoreA < scoreB
+			}
+			if a.Waste != b.Waste {
+				return a.Waste < b.Waste
+			}
+			return a.TotalPacks < b.TotalPacks
+		},
+	}, nil
+}
+
+// Objective returns the objective this solver optimizes
+func (s *dpSolver) Objective() Objective {
+	return s.objective
+}
+
+// UsesCost reports whether the solver needs a unit cost for every pack
+func (s *dpSolver) UsesCost() bool {
+	return s.usesCost
+}
+
+// Solve returns the best combination covering amount, or an empty one when
+// no combination of the options covers it. When ctx is done first, it returns
+// the best combination found so far along with the context's error.
+func (s *dpSolver) Solve(ctx context.Context, amount int64, packOptions []PackOption) (Solution, error) {
+	top, err := s.SolveTop(ctx, amount, packOptions, 1)
+	if len(top) > 0 {
+		return top[0], err
+	}
+	return Solution{Combination: make(map[int64]int64)}, err
+}
+
+// SolveTop ranks the covering totals with less and returns the best
+// combination for each of the first n of them, best first.
+//
+// Totals at or above amount+largest never need to be considered: dropping any
+// single pack from such a combination still covers amount and is no worse under
+// any objective. All sizes are first divided by their greatest common divisor,
+// which keeps the table small for the usual round-numbered pack sets, and packs
+// are set aside for large amounts (see reduce), so the tables never grow with
+// the amount.
+//
+// A greedy combination is ranked alongside the tables, so the search always
+// has an answer to fall back on. Tables above MaxSolverTable entries are
+// skipped with ErrSolverBudgetExceeded, and filling stops once ctx is done
+// with the context's error; either way the best combinations found so far are
+// returned along with the error.
+func (s *dpSolver) SolveTop(ctx context.Context, amount int64, packOptions []PackOption, n int) ([]Solution, error) {
+	options := uniqueOptions(packOptions)
+	if amount <= 0 || len(options) == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	var divisor int64
+	for _, option := range options {
+		divisor = gcd(divisor, option.Size)
+	}
+	target := (amount-1)/divisor + 1
+	largest := options[0].Size / divisor
+
+	type candidate struct {
+		solution    Solution
+		combination func() map[int64]int64
+	}
+	var candidates []candidate
+	byAmount := make(map[int64]int)
+	// Cases may overlap, so each total keeps its best combination
+	add := func(c candidate) {
+		if i, ok := byAmount[c.solution.TotalAmount]; ok {
+			if s.less(&c.solution, &candidates[i].solution) {
+				candidates[i] = c
+			}
+			return
+		}
+		byAmount[c.solution.TotalAmount] = len(candidates)
+		candidates = append(candidates, c)
+	}
+
+	var searchErr error
+	for _, r := range s.reduce(options, divisor, target) {
+		residual := target - r.units
+		limit := residual + largest
+		table := s.fillUnbounded
+		if stocked, ok := stockedUnits(r.options, divisor); ok {
+			if stocked < residual {
+				continue
+			}
+			limit = min(limit, stocked+1)
+			table = s.fillBounded
+		} else {
+			for _, option := range r.options {
+				if option.limited() {
+					table = s.fillBounded
+					break
+				}
+			}
+		}
+		if limit > MaxSolverTable {
+			searchErr = fmt.Errorf("%w: a table of %d entries is needed", entity.ErrSolverBudgetExceeded, limit)
+			continue
+		}
+
+		t, err := table(ctx, r.options, divisor, int(limit))
+		if err != nil {
+			searchErr = err
+			break
+		}
+
+		for total := int(max(residual, 0)); total < t.limit(); total++ {
+			if t.packs[total] == unreachable {
+				continue
+			}
+			totalAmount := (int64(total) + r.units) * divisor
+			add(candidate{
+				solution: Solution{
+					TotalAmount: totalAmount,
+					TotalPacks:  int64(t.packs[total]) + r.packs,
+					Waste:       totalAmount - amount,
+					Cost:        t.cost[total] + r.cost,
+					weight:      t.weight[total] + r.weight,
+				},
+				combination: func() map[int64]int64 {
+					combination := t.combination(total)
+					for size, count := range r.setAside {
+						if count > 0 {
+							combination[size] += count
+						}
+					}
+					return combination
+				},
+			})
+		}
+	}
+
+	// The greedy combination comes last, so a finished search keeps its own
+	// combination on ties
+	if solution, ok := s.greedy(options, amount); ok {
+		add(candidate{solution: solution, combination: func() map[int64]int64 { return solution.Combination }})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := &candidates[i].solution, &candidates[j].solution
+		if s.less(a, b) {
+			return true
+		}
+		if s.less(b, a) {
+			return false
+		}
+		return a.TotalAmount < b.TotalAmount
+	})
+
+	solutions := make([]Solution, 0, min(n, len(candidates)))
+	for _, c := range candidates[:min(n, len(candidates))] {
+		solution := c.solution
+		solution.Combination = c.combination()
+		solutions = append(solutions, solution)
+	}
+	return solutions, searchErr
+}
+
+// greedy covers amount with as many of the largest packs as fit, then the
+// smallest pack that covers what is left, or more large packs when none does.
+// It is quick but not optimal, and reports false when the packs available do
+// not cover amount.
+func (s *dpSolver) greedy(options []PackOption, amount int64) (Solution, bool) {
+	left := make([]int64, len(options))
+	counts := make([]int64, len(options))
+	remaining := amount
+	for i, option := range options {
+		left[i] = math.MaxInt64
+		if option.limited() {
+			left[i] = *option.Available
+		}
+		counts[i] = min(remaining/option.Size, left[i])
+		remaining -= counts[i] * option.Size
+	}
+
+	if remaining > 0 {
+		topUp := -1
+		for i, option := range options {
+			if counts[i] < left[i] && option.Size >= remaining {
+				topUp = i
+			}
+		}
+		if topUp >= 0 {
+			counts[topUp]++
+			remaining -= options[topUp].Size
+		}
+	}
+	for i, option := range options {
+		if remaining <= 0 {
+			break
+		}
+		extra := min((remaining-1)/option.Size+1, left[i]-counts[i])
+		counts[i] += extra
+		remaining -= extra * option.Size
+	}
+	if remaining > 0 {
+		return Solution{}, false
+	}
+
+	// Drop packs that are not needed, so the total stays below amount+largest
+	for i, option := range options {
+		for counts[i] > 0 && remaining+option.Size <= 0 {
+			counts[i]--
+			remaining += option.Size
+		}
+	}
+
+	solution := Solution{Combination: make(map[int64]int64)}
+	for i, option := range options {
+		if counts[i] == 0 {
+			continue
+		}
+		solution.Combination[option.Size] = counts[i]
+		solution.TotalAmount += counts[i] * option.Size
+		solution.TotalPacks += counts[i]
+		solution.Cost += counts[i] * option.Cost
+		solution.weight += float64(counts[i]) * s.packWeight(option)
+	}
+	solution.Waste = solution.TotalAmount - amount
+	return solution, true
+}
+
+// reduction is one case of reduce: packs set aside before the table is filled,
+// and the options left for the rest with their remaining availability
+type reduction struct {
+	options  []PackOption
+	setAside map[int64]int64 // packs set aside by size
+	units    int64           // units of the divisor the set-aside packs add up to
+	packs    int64
+	cost     int64
+	weight   float64
+}
+
+// setAside returns r with count more packs of option i, units each, set aside
+func (s *dpSolver) setAside(r reduction, i int, count, units int64) reduction {
+	option := r.options[i]
+	r.options = append([]PackOption(nil), r.options...)
+	if option.limited() {
+		r.options[i].Available = new(int64)
+		*r.options[i].Available = *option.Available - count
+	}
+
+	setAside := make(map[int64]int64, len(r.setAside)+1)
+	for size, n := range r.setAside {
+		setAside[size] = n
+	}
+	setAside[option.Size] += count
+	r.setAside = setAside
+
+	r.units += count * units
+	r.packs += count
+	r.cost += count * option.Cost
+	r.weight += float64(count) * s.packWeight(option)
+	return r
+}
+
+// reduce splits the search for target units into cases whose tables depend on
+// the pack sizes only, by setting aside packs the best combinations must use.
+//
+// Options are taken as pivots from the lowest weight per item up, the largest
+// one first on ties. Among any p packs of non-pivot sizes, p being the pivot
+// size in units, two of the p prefix sums of their sizes are equal modulo p or
+// one of them is 0, so some of those packs add up to a multiple of the pivot
+// size, at most largest pivot packs. Swapping them for pivot packs keeps the
+// total, does not add weight and, thanks to the tie rule, does not add packs
+// without removing weight. The best combination for any total therefore either
+// uses fewer than p packs of other sizes, leaving every pivot pack beyond
+// (p-1)*largest units to be set aside, or has no room for the swap because the
+// pivot is within largest packs of its availability.
+//
+// An unlimited pivot always has room. A limited one yields a case for each
+// branch: one setting aside pivot packs as above, and one setting aside all
+// but largest-1 of the available pivot packs, which continues with the next
+// pivot and counts the few left among the other sizes. The cases together
+// cover every best combination.
+func (s *dpSolver) reduce(options []PackOption, divisor, target int64) []reduction {
+	largest := options[0].Size / divisor
+
+	// options are sorted from largest to smallest, which a stable sort keeps on ties
+	pivots := make([]int, len(options))
+	for i := range pivots {
+		pivots[i] = i
+	}
+	sort.SliceStable(pivots, func(i, j int) bool {
+		a, b := options[pivots[i]], options[pivots[j]]
+		return s.packWeight(a)*float64(b.Size) < s.packWeight(b)*float64(a.Size)
+	})
+
+	var cases []reduction
+	current := reduction{options: options}
+	var capped int64 // units the capped pivots can still add
+	for _, i := range pivots {
+		pivot := current.options[i]
+		units := pivot.Size / divisor
+
+		var count int64
+		if others, ok := entity.CheckedMul(units-1, largest); ok {
+			if others, ok = entity.CheckedAdd(others, capped); ok && target-current.units > others {
+				count = (target - current.units - others) / units
+			}
+		}
+		if count == 0 {
+			// Nothing can be set aside, so this case is everything that is left
+			return append(cases, current)
+		}
+		if !pivot.limited() {
+			return append(cases, s.setAside(current, i, count, units))
+		}
+		if count <= *pivot.Available {
+			cases = append(cases, s.setAside(current, i, count, units))
+		}
+
+		kept := min(*pivot.Available, largest-1)
+		used, ok := entity.CheckedMul(*pivot.Available-kept, units)
+		if !ok || used >= target-current.units+largest {
+			// Using that many pivot packs overshoots every total worth considering
+			return cases
+		}
+		current = s.setAside(current, i, *pivot.Available-kept, units)
+		capped += kept * units
+	}
+	return append(cases, current)
+}
+
+// stockedUnits returns how many units the packs on hand add up to when every
+// option has limited availability; no total beyond that can be reached
+func stockedUnits(options []PackOption, divisor int64) (int64, bool) {
+	var stocked int64
+	for _, option := range options {
+		if !option.limited() {
+			return 0, false
+		}
+		units, ok := entity.CheckedMul(*option.Available, option.Size/divisor)
+		if !ok {
+			return math.MaxInt64, true
+		}
+		if stocked, ok = entity.CheckedAdd(stocked, units); !ok {
+			return math.MaxInt64, true
+		}
+	}
+	return stocked, true
+}
+
+// dpTable holds, for every total t in units of the pack size divisor, the best
+// combination found for exactly t: its weight, pack count and cost
+type dpTable struct {
+	weight []float64
+	packs  []int
+	cost   []int64
+	// combination rebuilds the pack sizes and counts behind a total
+	combination func(total int) map[int64]int64
+}
+
+func newDPTable(limit int) *dpTable {
+	t := &dpTable{
+		weight: make([]float64, limit),
+		packs:  make([]int, limit),
+		cost:   make([]int64, limit),
+	}
+	for total := 1; total < limit; total++ {
+		t.packs[total] = unreachable
+	}
+	return t
+}
+
+// limit returns the first total the table does not cover
+func (t *dpTable) limit() int {
+	return len(t.packs)
+}
+
+// relax replaces the entry for total with the entry for from plus count packs
+// of the given weight and cost when that is better, and reports whether it did
+func (t *dpTable) relax(total, from, count int, weight float64, cost int64) bool {
+	if t.packs[from] == unreachable {
+		return false
+	}
+	w := t.weight[from] + weight
+	p := t.packs[from] + count
+	if t.packs[total] != unreachable && (w > t.weight[total] || (w == t.weight[total] && p >= t.packs[total])) {
+		return false
+	}
+	t.weight[total] = w
+	t.packs[total] = p
+	t.cost[total] = t.cost[from] + cost
+	return true
+}
+
+// fillUnbounded runs an unbounded coin-change DP over every total below limit,
+// remembering the option added last to reach each total
+func (s *dpSolver) fillUnbounded(ctx context.Context, options []PackOption, divisor int64, limit int) (*dpTable, error) {
+	units := make([]int, len(options))
+	weights := make([]float64, len(options))
+	for i, option := range options {
+		units[i] = int(option.Size / divisor)
+		weights[i] = s.packWeight(option)
+	}
+
+	t := newDPTable(limit)
+	last := make([]int, limit)
+	for total := 1; total < limit; total++ {
+		if total%checkEvery == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		for i, unit := range units {
+			if unit <= total && t.relax(total, total-unit, 1, weights[i], options[i].Cost) {
+				last[total] = i
+			}
+		}
+	}
+
+	t.combination = func(total int) map[int64]int64 {
+		combination := make(map[int64]int64)
+		for remaining := total; remaining > 0; remaining -= units[last[remaining]] {
+			combination[options[last[remaining]].Size]++
+		}
+		return combination
+	}
+	return t, nil
+}
+
+// boundedItem is a group of packs the bounded DP takes as a whole
+type boundedItem struct {
+	option int // index of the pack option
+	count  int // packs in the group, or 0 for an option without a limit
+}
+
+// fillBounded is fillUnbounded for options with limited availability.
+//
+// Every limited option is split into groups of 1, 2, 4, ... packs (binary
+// splitting), so any count up to the limit is a sum of distinct groups. The
+// groups are 0/1 items, unlimited options stay unbounded items, and the table
+// is filled item by item. A bit per item and total records whether the item
+// was used, which is enough to rebuild any combination. The bound
+// amount+largest still holds: dropping a pack keeps a combination within its
+// limits.
+func (s *dpSolver) fillBounded(ctx context.Context, options []PackOption, divisor int64, limit int) (*dpTable, error) {
+	var items []boundedItem
+	for i, option := range options {
+		if !option.limited() {
+			items = append(items, boundedItem{option: i})
+			continue
+		}
+		// More packs than fit below the limit can never be part of the answer
+		available := int(min(*option.Available, int64(limit-1)/(option.Size/divisor)))
+		for group := 1; available > 0; group *= 2 {
+			count := min(group, available)
+			items = append(items, boundedItem{option: i, count: count})
+			available -= count
+		}
+	}
+
+	t := newDPTable(limit)
+	words := (limit + 63) / 64
+	used := make([]uint64, len(items)*words)
+
+	for j, item := range items {
+		option := options[item.option]
+		count := max(item.count, 1)
+		unit := int(option.Size/divisor) * count
+		weight := s.packWeight(option) * float64(count)
+		cost := option.Cost * int64(count)
+
+		relax := func(total int) {
+			if t.relax(total, total-unit, count, weight, cost) {
+				used[j*words+total/64] |= 1 << (total % 64)
+			}
+		}
+		if item.count == 0 {
+			for total := unit; total < limit; total++ {
+				if total%checkEvery == 0 && ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				relax(total)
+			}
+		} else {
+			for total := limit - 1; total >= unit; total-- {
+				if total%checkEvery == 0 && ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				relax(total)
+			}
+		}
+	}
+
+	t.combination = func(total int) map[int64]int64 {
+		combination := make(map[int64]int64)
+		for j := len(items) - 1; j >= 0 && total > 0; {
+			if used[j*words+total/64]&(1<<(total%64)) == 0 {
+				j--
+				continue
+			}
+			item := items[j]
+			option := options[item.option]
+			count := max(item.count, 1)
+			combination[option.Size] += int64(count)
+			total -= int(option.Size/divisor) * count
+			if item.count > 0 {
+				j--
+			}
+		}
+		return combination
+	}
+	return t, nil
+}
+
+// uniqueOptions returns the options with positive sizes sorted from largest to
+// smallest, keeping the first option seen for each size and dropping options
+// with nothing available
+func uniqueOptions(packOptions []PackOption) []PackOption {
+	options := make([]PackOption, 0, len(packOptions))
+	seen := make(map[int64]bool, len(packOptions))
+	for _, option := range packOptions {
+		if option.Size <= 0 || seen[option.Size] {
+			continue
+		}
+		seen[option.Size] = true
+		if option.limited() && *option.Available <= 0 {
+			continue
+		}
+		options = append(options, option)
+	}
+	sort.Slice(options, func(i, j int) bool {
+		return options[i].Size > options[j].Size
+	})
+	return options
+}
+
+// sortSizes sorts pack sizes in ascending order
+func sortSizes(sizes []int64) {
+	sort.Slice(sizes, func(i, j int) bool {
+		return sizes[i] < sizes[j]
+	})
+}
+
+// gcd returns the greatest common divisor of a and b
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
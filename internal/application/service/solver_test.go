@@ -0,0 +1,773 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+)
+
+// oracleResult is the outcome of a single combination evaluated by the brute-force oracle
+type oracleResult struct {
+	waste int64
+	packs int64
+	cost  int64
+}
+
+// oracleRank returns an independent ranking key per objective; lower keys are better
+func oracleRank(objective Objective, weights SolverWeights, r oracleResult) []float64 {
+	switch objective {
+	case ObjectiveMinPacks:
+		return []float64{float64(r.packs), float64(r.waste)}
+	case ObjectiveMinCost:
+		return []float64{float64(r.cost), float64(r.waste), float64(r.packs)}
+	case ObjectiveWeighted:
+		score := weights.Waste*float64(r.waste) + weights.Packs*float64(r.packs) + weights.Cost*float64(r.cost)
+		return []float64{score, float64(r.waste), float64(r.packs)}
+	default:
+		return []float64{float64(r.waste), float64(r.packs)}
+	}
+}
+
+func rankLess(a, b []float64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// bruteForceOptimum enumerates every combination that can matter for amount and
+// returns the best one under the given objective, or false when none covers amount
+func bruteForceOptimum(objective Objective, weights SolverWeights, amount int64, options []PackOption) (oracleResult, bool) {
+	var best *oracleResult
+
+	var search func(index int, total, packs, cost int64)
+	search = func(index int, total, packs, cost int64) {
+		if index == len(options) {
+			if total < amount {
+				return
+			}
+			candidate := oracleResult{waste: total - amount, packs: packs, cost: cost}
+			if best == nil || rankLess(oracleRank(objective, weights, candidate), oracleRank(objective, weights, *best)) {
+				best = &candidate
+			}
+			return
+		}
+
+		option := options[index]
+		maxCount := (amount + option.Size - 1) / option.Size
+		if option.Available != nil {
+			maxCount = min(maxCount, *option.Available)
+		}
+		for count := int64(0); count <= maxCount; count++ {
+			search(index+1, total+count*option.Size, packs+count, cost+count*option.Cost)
+		}
+	}
+	search(0, 0, 0, 0)
+
+	if best == nil {
+		return oracleResult{}, false
+	}
+	return *best, true
+}
+
+func sizeOptions(sizes ...int64) []PackOption {
+	options := make([]PackOption, len(sizes))
+	for i, size := range sizes {
+		options[i] = PackOption{Size: size, Cost: int64(size)}
+	}
+	return options
+}
+
+// mustSolve runs solver.Solve without a deadline and fails the test when the
+// search stops early
+func mustSolve(t *testing.T, solver Solver, amount int64, options []PackOption) Solution {
+	t.Helper()
+
+	result, err := solver.Solve(context.Background(), amount, options)
+	if err != nil {
+		t.Fatalf("%s, options %v, amount %d: unexpected error: %v", solver.Objective(), options, amount, err)
+	}
+	return result
+}
+
+// mustSolveTop is mustSolve for solver.SolveTop
+func mustSolveTop(t *testing.T, solver Solver, amount int64, options []PackOption, n int) []Solution {
+	t.Helper()
+
+	top, err := solver.SolveTop(context.Background(), amount, options, n)
+	if err != nil {
+		t.Fatalf("%s, options %v, amount %d: unexpected error: %v", solver.Objective(), options, amount, err)
+	}
+	return top
+}
+
+func newTestSolver(t *testing.T, objective Objective) Solver {
+	t.Helper()
+
+	solver, err := NewSolver(objective, DefaultSolverConfig().Weights)
+	if err != nil {
+		t.Fatalf("Failed to create solver for %s: %v", objective, err)
+	}
+	return solver
+}
+
+func TestMinWasteSolver_KnownCases(t *testing.T) {
+	solver := newTestSolver(t, ObjectiveMinWaste)
+
+	tests := []struct {
+		name     string
+		amount   int64
+		options  []PackOption
+		expected map[int64]int64
+	}{
+		{
+			name:     "Single item ships smallest pack",
+			amount:   1,
+			options:  sizeOptions(250, 500, 1000, 2000, 5000),
+			expected: map[int64]int64{250: 1},
+		},
+		{
+			name:     "One over a pack size prefers larger pack",
+			amount:   251,
+			options:  sizeOptions(250, 500, 1000, 2000, 5000),
+			expected: map[int64]int64{500: 1},
+		},
+		{
+			name:     "Waste beats pack count",
+			amount:   501,
+			options:  sizeOptions(250, 500, 1000, 2000, 5000),
+			expected: map[int64]int64{500: 1, 250: 1},
+		},
+		{
+			name:     "Large amount with awkward sizes",
+			amount:   500000,
+			options:  sizeOptions(23, 31, 53),
+			expected: map[int64]int64{23: 2, 31: 7, 53: 9429},
+		},
+		{
+			name:     "A trillion items",
+			amount:   1_000_000_000_000,
+			options:  sizeOptions(250, 500, 1000, 2000, 5000),
+			expected: map[int64]int64{5000: 200_000_000},
+		},
+		{
+			name:     "One over a trillion items",
+			amount:   1_000_000_000_001,
+			options:  sizeOptions(250, 500, 1000, 2000, 5000),
+			expected: map[int64]int64{5000: 200_000_000, 250: 1},
+		},
+		{
+			name:     "Greedy would overshoot",
+			amount:   6,
+			options:  sizeOptions(4, 3),
+			expected: map[int64]int64{3: 2},
+		},
+		{
+			name:     "Duplicate sizes are ignored",
+			amount:   10,
+			options:  sizeOptions(5, 5, 3),
+			expected: map[int64]int64{5: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := mustSolve(t, solver, tt.amount, tt.options)
+
+			if len(result.Combination) != len(tt.expected) {
+				t.Errorf("Expected combination %v, got %v", tt.expected, result.Combination)
+				return
+			}
+			for size, count := range tt.expected {
+				if result.Combination[size] != count {
+					t.Errorf("Expected combination %v, got %v", tt.expected, result.Combination)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestSolvers_ObjectivesDiffer(t *testing.T) {
+	options := []PackOption{
+		{Size: 250, Cost: 100},
+		{Size: 500, Cost: 150},
+		{Size: 1000, Cost: 400},
+	}
+
+	tests := []struct {
+		objective Objective
+		amount    int64
+		expected  map[int64]int64
+	}{
+		{objective: ObjectiveMinWaste, amount: 750, expected: map[int64]int64{500: 1, 250: 1}},
+		{objective: ObjectiveMinPacks, amount: 750, expected: map[int64]int64{1000: 1}},
+		{objective: ObjectiveMinCost, amount: 900, expected: map[int64]int64{500: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.objective), func(t *testing.T) {
+			result := mustSolve(t, newTestSolver(t, tt.objective), tt.amount, options)
+
+			if len(result.Combination) != len(tt.expected) {
+				t.Errorf("Expected combination %v, got %v", tt.expected, result.Combination)
+				return
+			}
+			for size, count := range tt.expected {
+				if result.Combination[size] != count {
+					t.Errorf("Expected combination %v, got %v", tt.expected, result.Combination)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestSolvers_EmptyInput(t *testing.T) {
+	for _, objective := range Objectives {
+		solver := newTestSolver(t, objective)
+
+		if result := mustSolve(t, solver, 100, nil); len(result.Combination) != 0 {
+			t.Errorf("%s: expected empty combination without pack sizes, got %v", objective, result.Combination)
+		}
+
+		if result := mustSolve(t, solver, 0, sizeOptions(250)); len(result.Combination) != 0 {
+			t.Errorf("%s: expected empty combination for zero amount, got %v", objective, result.Combination)
+		}
+	}
+}
+
+func TestNewSolver_Validation(t *testing.T) {
+	if _, err := NewSolver("cheapest", DefaultSolverConfig().Weights); !errors.Is(err, entity.ErrUnknownObjective) {
+		t.Errorf("Expected ErrUnknownObjective for unknown objective, got %v", err)
+	}
+
+	if _, err := NewSolver(ObjectiveWeighted, SolverWeights{Waste: -1}); err == nil {
+		t.Errorf("Expected error for negative weights, got none")
+	}
+
+	if _, err := ParseObjective("min_packs"); err != nil {
+		t.Errorf("Unexpected error parsing known objective: %v", err)
+	}
+}
+
+func TestSolvers_MatchBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	weights := SolverWeights{Waste: 1, Packs: 40, Cost: 0.5}
+
+	fixedSets := [][]PackOption{
+		sizeOptions(23, 31, 53),
+		sizeOptions(250, 500, 1000, 2000, 5000),
+		sizeOptions(3, 5),
+		sizeOptions(6, 9, 20),
+		sizeOptions(7),
+		{{Size: 4, Cost: 9}, {Size: 7, Cost: 10}, {Size: 15, Cost: 40}},
+	}
+
+	for _, objective := range Objectives {
+		solver, err := NewSolver(objective, weights)
+		if err != nil {
+			t.Fatalf("Failed to create solver for %s: %v", objective, err)
+		}
+
+		for _, options := range fixedSets {
+			for amount := int64(1); amount <= 300; amount++ {
+				assertMatchesBruteForce(t, solver, weights, amount, options)
+			}
+		}
+
+		for i := 0; i < 300; i++ {
+			options := make([]PackOption, 1+rng.Intn(4))
+			for j := range options {
+				options[j] = PackOption{Size: 1 + rng.Int63n(60), Cost: int64(1 + rng.Intn(100))}
+			}
+			amount := 1 + rng.Int63n(400)
+
+			assertMatchesBruteForce(t, solver, weights, amount, options)
+		}
+	}
+}
+
+func assertMatchesBruteForce(t *testing.T, solver Solver, weights SolverWeights, amount int64, options []PackOption) {
+	t.Helper()
+
+	result := mustSolve(t, solver, amount, options)
+
+	// Duplicate sizes keep their first option, so the oracle sees the same options as the solver
+	expected, feasible := bruteForceOptimum(solver.Objective(), weights, amount, uniqueOptions(options))
+	if !feasible {
+		if len(result.Combination) != 0 {
+			t.Fatalf("%s, options %v, amount %d: expected no combination, got %v", solver.Objective(), options, amount, result.Combination)
+		}
+		return
+	}
+
+	var total, packs, cost int64
+	for size, count := range result.Combination {
+		found := false
+		for _, option := range uniqueOptions(options) {
+			if option.Size == size {
+				cost += count * option.Cost
+				found = true
+				if option.Available != nil && count > *option.Available {
+					t.Fatalf("%s, options %v, amount %d: combination %v uses %d of size %d, only %d available",
+						solver.Objective(), options, amount, result.Combination, count, size, *option.Available)
+				}
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("%s, options %v, amount %d: combination %v uses unknown size %d", solver.Objective(), options, amount, result.Combination, size)
+		}
+		total += size * count
+		packs += count
+	}
+
+	if total < amount {
+		t.Fatalf("%s, options %v, amount %d: combination %v covers only %d", solver.Objective(), options, amount, result.Combination, total)
+	}
+
+	if total != result.TotalAmount || packs != result.TotalPacks || total-amount != result.Waste || cost != result.Cost {
+		t.Fatalf("%s, options %v, amount %d: reported totals %+v do not match combination", solver.Objective(), options, amount, result)
+	}
+
+	actual := oracleResult{waste: total - amount, packs: packs, cost: cost}
+	if rankLess(oracleRank(solver.Objective(), weights, expected), oracleRank(solver.Objective(), weights, actual)) {
+		t.Fatalf("%s, options %v, amount %d: expected %+v, got %+v (%v)",
+			solver.Objective(), options, amount, expected, actual, result.Combination)
+	}
+}
+
+func available(n int64) *int64 {
+	return &n
+}
+
+func TestSolvers_StockLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		options  []PackOption
+		expected map[int64]int64
+	}{
+		{
+			name:   "Best combination out of stock falls back to next best",
+			amount: 501,
+			options: []PackOption{
+				{Size: 250, Available: available(0)},
+				{Size: 500},
+				{Size: 1000},
+			},
+			expected: map[int64]int64{1000: 1},
+		},
+		{
+			name:   "Limited large packs are topped up with small ones",
+			amount: 12000,
+			options: []PackOption{
+				{Size: 250},
+				{Size: 500},
+				{Size: 1000},
+				{Size: 2000},
+				{Size: 5000, Available: available(1)},
+			},
+			expected: map[int64]int64{5000: 1, 2000: 3, 1000: 1},
+		},
+		{
+			name:   "Limited best pack at a huge amount",
+			amount: 1_000_000_000_000,
+			options: []PackOption{
+				{Size: 250},
+				{Size: 2000},
+				{Size: 5000, Available: available(100_000_000)},
+			},
+			expected: map[int64]int64{5000: 100_000_000, 2000: 250_000_000},
+		},
+		{
+			name:   "Plenty of stock at a huge amount",
+			amount: 1_000_000_000_000,
+			options: []PackOption{
+				{Size: 250},
+				{Size: 5000, Available: available(1_000_000_000)},
+			},
+			expected: map[int64]int64{5000: 200_000_000},
+		},
+		{
+			name:   "Not enough stock for a huge amount",
+			amount: 1_000_000_000_000,
+			options: []PackOption{
+				{Size: 250, Available: available(1_000)},
+				{Size: 5000, Available: available(1_000)},
+			},
+			expected: map[int64]int64{},
+		},
+		{
+			name:   "Not enough stock to cover the amount",
+			amount: 1000,
+			options: []PackOption{
+				{Size: 250, Available: available(1)},
+				{Size: 500, Available: available(1)},
+			},
+			expected: map[int64]int64{},
+		},
+	}
+
+	solver := newTestSolver(t, ObjectiveMinWaste)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := mustSolve(t, solver, tt.amount, tt.options)
+
+			if len(result.Combination) != len(tt.expected) {
+				t.Errorf("Expected combination %v, got %v", tt.expected, result.Combination)
+				return
+			}
+			for size, count := range tt.expected {
+				if result.Combination[size] != count {
+					t.Errorf("Expected combination %v, got %v", tt.expected, result.Combination)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestSolvers_StockLimitsMatchBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	weights := SolverWeights{Waste: 1, Packs: 40, Cost: 0.5}
+
+	for _, objective := range Objectives {
+		solver, err := NewSolver(objective, weights)
+		if err != nil {
+			t.Fatalf("Failed to create solver for %s: %v", objective, err)
+		}
+
+		for i := 0; i < 300; i++ {
+			options := make([]PackOption, 1+rng.Intn(4))
+			for j := range options {
+				options[j] = PackOption{Size: 1 + rng.Int63n(60), Cost: int64(1 + rng.Intn(100))}
+				if rng.Intn(3) > 0 {
+					options[j].Available = available(rng.Int63n(8))
+				}
+			}
+			amount := 1 + rng.Int63n(300)
+
+			assertMatchesBruteForce(t, solver, weights, amount, options)
+		}
+	}
+}
+
+func TestSolvers_SolveTop(t *testing.T) {
+	solver := newTestSolver(t, ObjectiveMinWaste)
+	options := sizeOptions(250, 500, 1000, 2000, 5000)
+
+	top := mustSolveTop(t, solver, 750, options, 3)
+	expected := []map[int64]int64{
+		{500: 1, 250: 1},
+		{1000: 1},
+		{1000: 1, 250: 1},
+	}
+
+	if len(top) != len(expected) {
+		t.Fatalf("Expected %d alternatives, got %d", len(expected), len(top))
+	}
+	for i, combination := range expected {
+		if len(top[i].Combination) != len(combination) {
+			t.Errorf("Alternative %d: expected %v, got %v", i+1, combination, top[i].Combination)
+			continue
+		}
+		for size, count := range combination {
+			if top[i].Combination[size] != count {
+				t.Errorf("Alternative %d: expected %v, got %v", i+1, combination, top[i].Combination)
+				break
+			}
+		}
+	}
+}
+
+func TestSolvers_SolveTopRanking(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	weights := SolverWeights{Waste: 1, Packs: 40, Cost: 0.5}
+
+	for _, objective := range Objectives {
+		solver, err := NewSolver(objective, weights)
+		if err != nil {
+			t.Fatalf("Failed to create solver for %s: %v", objective, err)
+		}
+
+		for i := 0; i < 200; i++ {
+			options := make([]PackOption, 1+rng.Intn(4))
+			for j := range options {
+				options[j] = PackOption{Size: 1 + rng.Int63n(60), Cost: int64(1 + rng.Intn(100))}
+				if rng.Intn(2) == 0 {
+					options[j].Available = available(rng.Int63n(8))
+				}
+			}
+			amount := 1 + rng.Int63n(300)
+
+			best := mustSolve(t, solver, amount, options)
+			top := mustSolveTop(t, solver, amount, options, 5)
+			if len(best.Combination) == 0 {
+				if len(top) != 0 {
+					t.Fatalf("%s, options %v, amount %d: expected no alternatives, got %d", objective, options, amount, len(top))
+				}
+				continue
+			}
+			if len(top) == 0 || top[0].TotalAmount != best.TotalAmount || top[0].TotalPacks != best.TotalPacks {
+				t.Fatalf("%s, options %v, amount %d: first alternative does not match the best solution", objective, options, amount)
+			}
+
+			seen := make(map[int64]bool)
+			for k, solution := range top {
+				if seen[solution.TotalAmount] {
+					t.Fatalf("%s, options %v, amount %d: total %d repeated", objective, options, amount, solution.TotalAmount)
+				}
+				seen[solution.TotalAmount] = true
+
+				var total, packs int64
+				for size, count := range solution.Combination {
+					total += size * count
+					packs += count
+				}
+				if total != solution.TotalAmount || packs != solution.TotalPacks || total < amount {
+					t.Fatalf("%s, options %v, amount %d: alternative %+v is inconsistent", objective, options, amount, solution)
+				}
+
+				if k > 0 {
+					previous := oracleResult{waste: top[k-1].Waste, packs: top[k-1].TotalPacks, cost: top[k-1].Cost}
+					current := oracleResult{waste: solution.Waste, packs: solution.TotalPacks, cost: solution.Cost}
+					if rankLess(oracleRank(objective, weights, current), oracleRank(objective, weights, previous)) {
+						t.Fatalf("%s, options %v, amount %d: alternatives out of order: %+v before %+v", objective, options, amount, top[k-1], solution)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSolvers_LargeAmounts(t *testing.T) {
+	sets := [][]PackOption{
+		{{Size: 23, Cost: 20}, {Size: 31, Cost: 30}, {Size: 53, Cost: 45}},
+		{{Size: 250, Cost: 100}, {Size: 500, Cost: 150}, {Size: 1000, Cost: 400}, {Size: 2000, Cost: 700}, {Size: 5000, Cost: 1500}},
+	}
+	amounts := []int64{1_000_000_000_000, 1_000_000_000_007, entity.MaxAmount - 1, entity.MaxAmount}
+
+	for _, objective := range Objectives {
+		solver := newTestSolver(t, objective)
+		for _, options := range sets {
+			for _, amount := range amounts {
+				result := mustSolve(t, solver, amount, options)
+
+				var total, packs, cost int64
+				for size, count := range result.Combination {
+					total += size * count
+					packs += count
+					for _, option := range options {
+						if option.Size == size {
+							cost += count * option.Cost
+						}
+					}
+				}
+				if total != result.TotalAmount || packs != result.TotalPacks || cost != result.Cost || total-amount != result.Waste {
+					t.Fatalf("%s, options %v, amount %d: reported totals %+v do not match combination", objective, options, amount, result)
+				}
+				if total < amount || total >= amount+options[len(options)-1].Size {
+					t.Fatalf("%s, options %v, amount %d: total %d is not within a pack of the amount", objective, options, amount, total)
+				}
+			}
+		}
+	}
+}
+
+// solveWithFullTable ranks every covering total from one table over all totals
+// below amount+largest, without setting any packs aside
+func solveWithFullTable(t *testing.T, s *dpSolver, amount int64, packOptions []PackOption, n int) []Solution {
+	t.Helper()
+
+	options := uniqueOptions(packOptions)
+	if len(options) == 0 {
+		return nil
+	}
+	var divisor int64
+	for _, option := range options {
+		divisor = gcd(divisor, option.Size)
+	}
+	target := (amount-1)/divisor + 1
+	table, err := s.fillBounded(context.Background(), options, divisor, int(target+options[0].Size/divisor))
+	if err != nil {
+		t.Fatalf("Failed to fill the table: %v", err)
+	}
+
+	var solutions []Solution
+	for total := int(target); total < table.limit(); total++ {
+		if table.packs[total] == unreachable {
+			continue
+		}
+		totalAmount := int64(total) * divisor
+		solutions = append(solutions, Solution{
+			TotalAmount: totalAmount,
+			TotalPacks:  int64(table.packs[total]),
+			Waste:       totalAmount - amount,
+			Cost:        table.cost[total],
+			weight:      table.weight[total],
+		})
+	}
+	sort.SliceStable(solutions, func(i, j int) bool {
+		return s.less(&solutions[i], &solutions[j])
+	})
+	return solutions[:min(n, len(solutions))]
+}
+
+func TestSolvers_ReductionMatchesFullTable(t *testing.T) {
+	rng := rand.New(rand.NewSource(19))
+	weights := SolverWeights{Waste: 1, Packs: 40, Cost: 0.5}
+
+	for _, objective := range Objectives {
+		solver, err := NewSolver(objective, weights)
+		if err != nil {
+			t.Fatalf("Failed to create solver for %s: %v", objective, err)
+		}
+		dp := solver.(*dpSolver)
+
+		for i := 0; i < 300; i++ {
+			options := make([]PackOption, 1+rng.Intn(4))
+			for j := range options {
+				options[j] = PackOption{Size: 2 + rng.Int63n(14), Cost: int64(1 + rng.Intn(30))}
+				if rng.Intn(2) == 0 {
+					options[j].Available = available(rng.Int63n(120))
+				}
+			}
+			amount := 1 + rng.Int63n(1500)
+
+			top := mustSolveTop(t, solver, amount, options, 5)
+			expected := solveWithFullTable(t, dp, amount, options, 5)
+			if len(top) != len(expected) {
+				t.Fatalf("%s, options %v, amount %d: expected %d alternatives, got %d", objective, options, amount, len(expected), len(top))
+			}
+			for k, solution := range top {
+				want := expected[k]
+				if solution.TotalAmount != want.TotalAmount || solution.TotalPacks != want.TotalPacks ||
+					(solver.UsesCost() && solution.Cost != want.Cost) {
+					t.Fatalf("%s, options %v, amount %d: alternative %d is %+v, expected %+v", objective, options, amount, k+1, solution, want)
+				}
+
+				var total int64
+				for size, count := range solution.Combination {
+					total += size * count
+					for _, option := range uniqueOptions(options) {
+						if option.Size == size && option.Available != nil && count > *option.Available {
+							t.Fatalf("%s, options %v, amount %d: combination %v uses %d of size %d, only %d available",
+								objective, options, amount, solution.Combination, count, size, *option.Available)
+						}
+					}
+				}
+				if total != solution.TotalAmount {
+					t.Fatalf("%s, options %v, amount %d: alternative %+v is inconsistent", objective, options, amount, solution)
+				}
+			}
+		}
+	}
+}
+
+func TestSolvers_StopEarly(t *testing.T) {
+	// Coprime sizes near 1000 need a table of about a million totals
+	options := sizeOptions(997, 1009)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, objective := range Objectives {
+		solver := newTestSolver(t, objective)
+
+		result, err := solver.Solve(cancelled, 123_456_789, options)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: expected context.Canceled, got %v", objective, err)
+		}
+		assertCovers(t, result, 123_456_789)
+
+		// Coprime sizes near 100000 would need ten billion totals
+		result, err = solver.Solve(context.Background(), 123_456_789, sizeOptions(99991, 100003))
+		if !errors.Is(err, entity.ErrSolverBudgetExceeded) {
+			t.Fatalf("%s: expected ErrSolverBudgetExceeded, got %v", objective, err)
+		}
+		assertCovers(t, result, 123_456_789)
+	}
+}
+
+// assertCovers checks that a combination found before the search stopped is
+// consistent and covers amount
+func assertCovers(t *testing.T, result Solution, amount int64) {
+	t.Helper()
+
+	var total, packs int64
+	for size, count := range result.Combination {
+		total += size * count
+		packs += count
+	}
+	if total < amount || total != result.TotalAmount || packs != result.TotalPacks || total-amount != result.Waste {
+		t.Fatalf("Amount %d: combination %v does not match the reported totals %+v", amount, result.Combination, result)
+	}
+}
+
+func TestSolvers_GreedyRespectsStock(t *testing.T) {
+	solver := newMinWasteSolver()
+
+	tests := []struct {
+		name     string
+		amount   int64
+		options  []PackOption
+		expected map[int64]int64
+		ok       bool
+	}{
+		{
+			name:     "Largest packs then the smallest that covers the rest",
+			amount:   12001,
+			options:  uniqueOptions(sizeOptions(250, 500, 1000, 2000, 5000)),
+			expected: map[int64]int64{5000: 2, 2000: 1, 250: 1},
+			ok:       true,
+		},
+		{
+			name:     "Limited large packs",
+			amount:   12001,
+			options:  uniqueOptions([]PackOption{{Size: 250}, {Size: 5000, Available: available(1)}}),
+			expected: map[int64]int64{5000: 1, 250: 29},
+			ok:       true,
+		},
+		{
+			name:     "Unneeded packs are dropped",
+			amount:   650,
+			options:  uniqueOptions([]PackOption{{Size: 700, Available: available(1)}, {Size: 600, Available: available(1)}}),
+			expected: map[int64]int64{700: 1},
+			ok:       true,
+		},
+		{
+			name:    "Not enough stock",
+			amount:  900,
+			options: uniqueOptions([]PackOption{{Size: 500, Available: available(1)}, {Size: 300, Available: available(1)}}),
+			ok:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := solver.greedy(tt.options, tt.amount)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok %v, got %v", tt.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			assertCovers(t, result, tt.amount)
+			if len(result.Combination) != len(tt.expected) {
+				t.Fatalf("Expected combination %v, got %v", tt.expected, result.Combination)
+			}
+			for size, count := range tt.expected {
+				if result.Combination[size] != count {
+					t.Fatalf("Expected combination %v, got %v", tt.expected, result.Combination)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// StockService handles stock-related business logic
+type StockService struct {
+	stockRepo repository.StockRepository
+	packRepo  repository.PackRepository
+	logger    *logger.Logger
+}
+
+// NewStockService creates a new stock service
+func NewStockService(stockRepo repository.StockRepository, packRepo repository.PackRepository, logger *logger.Logger) *StockService {
+	return &StockService{
+		stockRepo: stockRepo,
+		packRepo:  packRepo,
+		logger:    logger,
+	}
+}
+
+// GetStockLevels returns the stock of every tracked pack
+func (s *StockService) GetStockLevels(ctx context.Context) ([]entity.StockLevel, error) {
+	s.logger.Debug("Getting stock levels")
+
+	levels, err := s.stockRepo.List(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list stock levels: %v", err)
+		return nil, err
+	}
+	s.logger.Debug("Retrieved %d stock levels", len(levels))
+
+	return levels, nil
+}
+
+// SetStock sets the number of packs on hand, starting to track the pack if needed
+func (s *StockService) SetStock(ctx context.Context, packID uuid.UUID, quantity int64) (*entity.StockLevel, error) {
+	s.logger.Info("Setting stock for pack %s to %d", packID, quantity)
+
+	pack, err := s.packRepo.Get(ctx, packID)
+	if err != nil {
+		s.logger.Error("Failed to get pack %s for stock update: %v", packID, err)
+		return nil, err
+	}
+
+	stock, err := entity.NewStockLevel(pack.ID(), pack.Size(), quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.stockRepo.Save(ctx, stock); err != nil {
+		s.logger.Error("Failed to save stock for pack %s: %v", packID, err)
+		return nil, err
+	}
+
+	return stock, nil
+}
+
+// AdjustStock adds delta packs to the stock, or removes them when delta is negative
+func (s *StockService) AdjustStock(ctx context.Context, packID uuid.UUID, delta int64) (*entity.StockLevel, error) {
+	s.logger.Info("Adjusting stock for pack %s by %d", packID, delta)
+
+	stock, err := s.stockRepo.Adjust(ctx, packID, delta)
+	if err != nil {
+		s.logger.Error("Failed to adjust stock for pack %s: %v", packID, err)
+		return nil, err
+	}
+
+	return stock, nil
+}
+
+// StopTracking removes the stock level of a pack, making it unlimited again
+func (s *StockService) StopTracking(ctx context.Context, packID uuid.UUID) error {
+	s.logger.Info("Stopping stock tracking for pack %s", packID)
+
+	if err := s.stockRepo.Delete(ctx, packID); err != nil {
+		s.logger.Error("Failed to stop stock tracking for pack %s: %v", packID, err)
+		return err
+	}
+
+	return nil
+}
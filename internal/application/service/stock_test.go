@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// MockStockRepository implements repository.StockRepository for testing
+type MockStockRepository struct {
+	levels   map[uuid.UUID]entity.StockLevel
+	packRepo *MockPackRepository
+	listErr  error
+}
+
+func NewMockStockRepository(packRepo *MockPackRepository) *MockStockRepository {
+	return &MockStockRepository{
+		levels:   make(map[uuid.UUID]entity.StockLevel),
+		packRepo: packRepo,
+	}
+}
+
+func (m *MockStockRepository) List(ctx context.Context) ([]entity.StockLevel, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	levels := make([]entity.StockLevel, 0, len(m.levels))
+	for _, level := range m.levels {
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].PackSize() < levels[j].PackSize()
+	})
+	return levels, nil
+}
+
+func (m *MockStockRepository) Get(ctx context.Context, packID uuid.UUID) (*entity.StockLevel, error) {
+	level, ok := m.levels[packID]
+	if !ok {
+		return nil, entity.ErrStockNotTracked
+	}
+	return &level, nil
+}
+
+func (m *MockStockRepository) Save(ctx context.Context, stock *entity.StockLevel) error {
+	m.levels[stock.PackID()] = *stock
+	return nil
+}
+
+func (m *MockStockRepository) Adjust(ctx context.Context, packID uuid.UUID, delta int64) (*entity.StockLevel, error) {
+	level, ok := m.levels[packID]
+	if !ok {
+		pack, err := m.packRepo.Get(ctx, packID)
+		if err != nil {
+			return nil, err
+		}
+		created, _ := entity.NewStockLevel(pack.ID(), pack.Size(), 0)
+		level = *created
+	}
+
+	if err := level.Adjust(delta); err != nil {
+		return nil, err
+	}
+	m.levels[packID] = level
+	return &level, nil
+}
+
+func (m *MockStockRepository) Delete(ctx context.Context, packID uuid.UUID) error {
+	if _, ok := m.levels[packID]; !ok {
+		return entity.ErrStockNotTracked
+	}
+	delete(m.levels, packID)
+	return nil
+}
+
+// setStock tracks stock for the mock pack with the given size
+func (m *MockStockRepository) setStock(t *testing.T, size, quantity int64) {
+	t.Helper()
+
+	for _, pack := range m.packRepo.packs {
+		if pack.Size() == size {
+			level, err := entity.NewStockLevel(pack.ID(), size, quantity)
+			if err != nil {
+				t.Fatalf("Failed to create stock level: %v", err)
+			}
+			m.levels[pack.ID()] = *level
+			return
+		}
+	}
+	t.Fatalf("No mock pack with size %d", size)
+}
+
+func TestStockService_SetStock(t *testing.T) {
+	mockPackRepo := NewMockPackRepository()
+	service := NewStockService(NewMockStockRepository(mockPackRepo), mockPackRepo, logger.GetLogger())
+	pack := mockPackRepo.packs[0]
+
+	stock, err := service.SetStock(context.Background(), pack.ID(), 12)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stock.PackSize() != pack.Size() || stock.Quantity() != 12 {
+		t.Errorf("Expected %d packs of size %d, got %d of size %d", 12, pack.Size(), stock.Quantity(), stock.PackSize())
+	}
+
+	if levels, err := service.GetStockLevels(context.Background()); err != nil || len(levels) != 1 {
+		t.Errorf("Expected 1 tracked stock level, got %d (error: %v)", len(levels), err)
+	}
+
+	if _, err := service.SetStock(context.Background(), pack.ID(), -1); !errors.Is(err, entity.ErrInvalidStock) {
+		t.Errorf("Expected ErrInvalidStock for negative quantity, got %v", err)
+	}
+
+	if _, err := service.SetStock(context.Background(), uuid.New(), 5); !errors.Is(err, entity.ErrPackNotFound) {
+		t.Errorf("Expected ErrPackNotFound for unknown pack, got %v", err)
+	}
+}
+
+func TestStockService_AdjustStock(t *testing.T) {
+	mockPackRepo := NewMockPackRepository()
+	service := NewStockService(NewMockStockRepository(mockPackRepo), mockPackRepo, logger.GetLogger())
+	pack := mockPackRepo.packs[1]
+
+	stock, err := service.AdjustStock(context.Background(), pack.ID(), 5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stock.Quantity() != 5 {
+		t.Errorf("Expected untracked pack to start from zero, got %d", stock.Quantity())
+	}
+
+	stock, err = service.AdjustStock(context.Background(), pack.ID(), -3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stock.Quantity() != 2 {
+		t.Errorf("Expected quantity 2, got %d", stock.Quantity())
+	}
+
+	if _, err := service.AdjustStock(context.Background(), pack.ID(), -3); !errors.Is(err, entity.ErrInsufficientStock) {
+		t.Errorf("Expected ErrInsufficientStock, got %v", err)
+	}
+}
+
+func TestStockService_StopTracking(t *testing.T) {
+	mockPackRepo := NewMockPackRepository()
+	mockStockRepo := NewMockStockRepository(mockPackRepo)
+	service := NewStockService(mockStockRepo, mockPackRepo, logger.GetLogger())
+	mockStockRepo.setStock(t, 250, 3)
+
+	if err := service.StopTracking(context.Background(), mockPackRepo.packs[0].ID()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if levels, err := service.GetStockLevels(context.Background()); err != nil || len(levels) != 0 {
+		t.Errorf("Expected no tracked stock levels, got %d (error: %v)", len(levels), err)
+	}
+
+	if err := service.StopTracking(context.Background(), mockPackRepo.packs[0].ID()); !errors.Is(err, entity.ErrStockNotTracked) {
+		t.Errorf("Expected ErrStockNotTracked, got %v", err)
+	}
+}
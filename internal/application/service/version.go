@@ -0,0 +1,21 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+)
+
+// AnyVersion makes a write unconditional. Other versions make it conditional
+// on the stored version, see checkVersion.
+const AnyVersion = 0
+
+// checkVersion returns entity.ErrVersionMismatch unless expected is
+// AnyVersion or the current version. It catches writes based on a stale read;
+// writes that race after the check are caught by the repositories.
+func checkVersion(current, expected int) error {
+	if expected != AnyVersion && expected != current {
+		return fmt.Errorf("%w: expected version %d, current version is %d", entity.ErrVersionMismatch, expected, current)
+	}
+	return nil
+}
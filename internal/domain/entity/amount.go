@@ -0,0 +1,39 @@
+package entity
+
+import "math"
+
+// MaxAmount is the largest number of items one order line may request or
+// ship. Keeping every line at or below it means the totals of whole orders,
+// at most MaxOrderLines lines each, never overflow an int64.
+const MaxAmount int64 = 1_000_000_000_000_000
+
+// CheckedMul returns a*b, or false when the product overflows an int64
+func CheckedMul(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+// CheckedAdd returns a+b, or false when the sum overflows an int64
+func CheckedAdd(a, b int64) (int64, bool) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, false
+	}
+	return sum, true
+}
+
+// itemAmount returns packageSize*quantity, or ErrAmountTooLarge when that is
+// more than MaxAmount
+func itemAmount(packageSize, quantity int64) (int64, error) {
+	amount, ok := CheckedMul(packageSize, quantity)
+	if !ok || amount > MaxAmount {
+		return 0, ErrAmountTooLarge
+	}
+	return amount, nil
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCheckedMul(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     int64
+		expected int64
+		ok       bool
+	}{
+		{name: "Small product", a: 250, b: 4, expected: 1000, ok: true},
+		{name: "Zero", a: 0, b: math.MaxInt64, expected: 0, ok: true},
+		{name: "Negative product", a: -3, b: 7, expected: -21, ok: true},
+		{name: "Largest amount", a: 5000, b: MaxAmount / 5000, expected: MaxAmount, ok: true},
+		{name: "Overflow", a: math.MaxInt64/2 + 1, b: 2, ok: false},
+		{name: "Overflow of large factors", a: 1 << 32, b: 1 << 32, ok: false},
+		{name: "Negated minimum", a: -1, b: math.MinInt64, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := CheckedMul(tt.a, tt.b)
+			if ok != tt.ok || got != tt.expected {
+				t.Errorf("Expected %d, %v, got %d, %v", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestCheckedAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     int64
+		expected int64
+		ok       bool
+	}{
+		{name: "Small sum", a: 250, b: 500, expected: 750, ok: true},
+		{name: "Negative sum", a: -250, b: 100, expected: -150, ok: true},
+		{name: "Largest value", a: math.MaxInt64 - 1, b: 1, expected: math.MaxInt64, ok: true},
+		{name: "Overflow", a: math.MaxInt64, b: 1, ok: false},
+		{name: "Underflow", a: math.MinInt64, b: -1, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := CheckedAdd(tt.a, tt.b)
+			if ok != tt.ok || got != tt.expected {
+				t.Errorf("Expected %d, %v, got %d, %v", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
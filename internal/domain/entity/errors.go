@@ -2,13 +2,119 @@ package entity
 
 import "errors"
 
+// ErrorKind classifies domain errors by what went wrong, independent of how
+// the error reaches the caller
+type ErrorKind string
+
+const (
+	// ErrorKindInvalid means the input breaks a domain rule
+	ErrorKindInvalid ErrorKind = "invalid"
+	// ErrorKindNotFound means a referenced entity does not exist
+	ErrorKindNotFound ErrorKind = "not_found"
+	// ErrorKindConflict means the change conflicts with the current state
+	ErrorKindConflict ErrorKind = "conflict"
+	// ErrorKindStale means the change was based on an outdated version
+	ErrorKindStale ErrorKind = "stale"
+	// ErrorKindUnprocessable means a well-formed request cannot be processed
+	// as it stands
+	ErrorKindUnprocessable ErrorKind = "unprocessable"
+	// ErrorKindBusy means the request may succeed later, once other work ends
+	ErrorKindBusy ErrorKind = "busy"
+	// ErrorKindUnavailable means no result could be produced within the limits
+	ErrorKindUnavailable ErrorKind = "unavailable"
+)
+
+// Error is a domain error with a stable, machine-readable code. Domain errors
+// are compared by identity, so wrapped ones still match with errors.Is.
+type Error struct {
+	code    string
+	kind    ErrorKind
+	message string
+}
+
+// errorCodes are the codes of the domain errors defined so far
+var errorCodes = make(map[string]bool)
+
+// newError defines a domain error. Codes must be unique.
+func newError(code string, kind ErrorKind, message string) *Error {
+	if errorCodes[code] {
+		panic("duplicate domain error code " + code)
+	}
+	errorCodes[code] = true
+	return &Error{code: code, kind: kind, message: message}
+}
+
+// Error returns the human-readable message of the error
+func (e *Error) Error() string {
+	return e.message
+}
+
+// Code returns the stable identifier of the error, e.g. "pack_not_found"
+func (e *Error) Code() string {
+	return e.code
+}
+
+// Kind returns the class of the error
+func (e *Error) Kind() ErrorKind {
+	return e.kind
+}
+
+// AsError returns the first domain error in the chain of err
+func AsError(err error) (*Error, bool) {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr, true
+	}
+	return nil, false
+}
+
 // Domain errors
 var (
-	ErrPackSize          = errors.New("pack size must be greater than 0")
-	ErrPackNotFound      = errors.New("pack not found")
-	ErrOrderNotFound     = errors.New("order not found")
-	ErrInvalidQuantity   = errors.New("quantity must be greater than 0")
-	ErrEmptyOrder        = errors.New("order cannot be empty")
-	ErrInvalidAmount     = errors.New("amount must be greater than 0")
-	ErrDuplicatePackSize = errors.New("pack size already exists")
+	ErrPackSize                = newError("pack_size_invalid", ErrorKindInvalid, "pack size must be greater than 0")
+	ErrPackCost                = newError("pack_cost_invalid", ErrorKindInvalid, "pack cost cannot be negative")
+	ErrPackWeight              = newError("pack_weight_invalid", ErrorKindInvalid, "pack weight must be greater than 0")
+	ErrPackDimensions          = newError("pack_dimensions_invalid", ErrorKindInvalid, "pack dimensions must be greater than 0")
+	ErrPackNotFound            = newError("pack_not_found", ErrorKindNotFound, "pack not found")
+	ErrPackRetired             = newError("pack_retired", ErrorKindConflict, "pack has been retired")
+	ErrPackRevision            = newError("pack_revision_invalid", ErrorKindInvalid, "pack revision must be greater than 0")
+	ErrPackEffectiveDate       = newError("pack_effective_date_past", ErrorKindInvalid, "pack changes cannot take effect in the past")
+	ErrPackValidity            = newError("pack_validity_invalid", ErrorKindInvalid, "pack revision cannot end before it takes effect")
+	ErrPackModified            = newError("pack_modified", ErrorKindStale, "pack was modified concurrently")
+	ErrOrderNotFound           = newError("order_not_found", ErrorKindNotFound, "order not found")
+	ErrInvalidQuantity         = newError("quantity_invalid", ErrorKindInvalid, "quantity must be greater than 0")
+	ErrEmptyOrder              = newError("order_empty", ErrorKindInvalid, "order cannot be empty")
+	ErrInvalidAmount           = newError("amount_invalid", ErrorKindInvalid, "amount must be greater than 0")
+	ErrAmountTooLarge          = newError("amount_too_large", ErrorKindInvalid, "amount exceeds the largest supported amount")
+	ErrDuplicatePackSize       = newError("pack_size_duplicate", ErrorKindConflict, "pack size already exists")
+	ErrUnknownObjective        = newError("objective_unknown", ErrorKindInvalid, "unknown optimization objective")
+	ErrMissingPackCost         = newError("pack_cost_missing", ErrorKindInvalid, "every pack needs a unit cost for this objective")
+	ErrSolverBudgetExceeded    = newError("solver_budget_exceeded", ErrorKindUnavailable, "no pack combination was found within the solver budget")
+	ErrInvalidAlternatives     = newError("alternatives_invalid", ErrorKindInvalid, "invalid number of alternatives")
+	ErrInvalidBatch            = newError("batch_invalid", ErrorKindInvalid, "invalid batch size")
+	ErrInvalidStock            = newError("stock_invalid", ErrorKindInvalid, "stock quantity cannot be negative")
+	ErrInsufficientStock       = newError("stock_insufficient", ErrorKindConflict, "insufficient stock to fulfil the order")
+	ErrStockNotTracked         = newError("stock_not_tracked", ErrorKindNotFound, "stock is not tracked for this pack")
+	ErrInvalidOrderQuery       = newError("order_query_invalid", ErrorKindInvalid, "invalid order query")
+	ErrUnknownOrderStatus      = newError("order_status_unknown", ErrorKindInvalid, "unknown order status")
+	ErrInvalidStatusTransition = newError("order_status_transition_invalid", ErrorKindConflict, "order status transition is not allowed")
+	ErrOrderNotAmendable       = newError("order_not_amendable", ErrorKindConflict, "order can no longer be amended")
+	ErrInvalidRevision         = newError("order_revision_invalid", ErrorKindInvalid, "order revision must be greater than 0")
+	ErrInvalidVersion          = newError("version_invalid", ErrorKindInvalid, "version must be greater than 0")
+	ErrVersionMismatch         = newError("version_mismatch", ErrorKindStale, "version does not match the current version")
+	ErrOrderModified           = newError("order_modified", ErrorKindStale, "order was modified concurrently")
+	ErrInvalidIdempotencyKey   = newError("idempotency_key_invalid", ErrorKindInvalid, "idempotency key must be 1 to 255 printable characters")
+	ErrIdempotencyKeyReused    = newError("idempotency_key_reused", ErrorKindUnprocessable, "idempotency key was already used with a different request")
+	ErrIdempotencyKeyInFlight  = newError("idempotency_key_in_flight", ErrorKindConflict, "a request with this idempotency key is still being processed")
+	ErrInvalidProductName      = newError("product_name_invalid", ErrorKindInvalid, "product name must be 1 to 100 characters")
+	ErrProductNotFound         = newError("product_not_found", ErrorKindNotFound, "product not found")
+	ErrDuplicateProductName    = newError("product_name_duplicate", ErrorKindConflict, "product name already exists")
+	ErrProductInUse            = newError("product_in_use", ErrorKindConflict, "product still has packs or orders")
+	ErrDefaultProduct          = newError("product_default", ErrorKindConflict, "the default product cannot be deleted")
+	ErrInvalidOrderLine        = newError("order_line_invalid", ErrorKindInvalid, "invalid order line")
+	ErrInvalidPackAnalysis     = newError("pack_analysis_invalid", ErrorKindInvalid, "invalid pack analysis")
+	ErrInvalidSimulation       = newError("simulation_invalid", ErrorKindInvalid, "invalid simulation")
+	ErrSimulationNotFound      = newError("simulation_not_found", ErrorKindNotFound, "simulation not found")
+	ErrTooManySimulations      = newError("simulations_busy", ErrorKindBusy, "too many simulations are running")
+	ErrInvalidRecommendation   = newError("recommendation_invalid", ErrorKindInvalid, "invalid recommendation request")
+	ErrInvalidPackSet          = newError("pack_set_invalid", ErrorKindInvalid, "invalid pack set")
 )
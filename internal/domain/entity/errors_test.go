@@ -2,6 +2,7 @@ package entity
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/google/uuid"
@@ -23,6 +24,31 @@ func TestErrorDefinitions(t *testing.T) {
 			err:         ErrPackNotFound,
 			expectedMsg: "pack not found",
 		},
+		{
+			name:        "ErrPackRetired",
+			err:         ErrPackRetired,
+			expectedMsg: "pack has been retired",
+		},
+		{
+			name:        "ErrPackRevision",
+			err:         ErrPackRevision,
+			expectedMsg: "pack revision must be greater than 0",
+		},
+		{
+			name:        "ErrPackEffectiveDate",
+			err:         ErrPackEffectiveDate,
+			expectedMsg: "pack changes cannot take effect in the past",
+		},
+		{
+			name:        "ErrPackValidity",
+			err:         ErrPackValidity,
+			expectedMsg: "pack revision cannot end before it takes effect",
+		},
+		{
+			name:        "ErrPackModified",
+			err:         ErrPackModified,
+			expectedMsg: "pack was modified concurrently",
+		},
 		{
 			name:        "ErrOrderNotFound",
 			err:         ErrOrderNotFound,
@@ -43,6 +69,181 @@ func TestErrorDefinitions(t *testing.T) {
 			err:         ErrInvalidAmount,
 			expectedMsg: "amount must be greater than 0",
 		},
+		{
+			name:        "ErrAmountTooLarge",
+			err:         ErrAmountTooLarge,
+			expectedMsg: "amount exceeds the largest supported amount",
+		},
+		{
+			name:        "ErrUnknownObjective",
+			err:         ErrUnknownObjective,
+			expectedMsg: "unknown optimization objective",
+		},
+		{
+			name:        "ErrPackCost",
+			err:         ErrPackCost,
+			expectedMsg: "pack cost cannot be negative",
+		},
+		{
+			name:        "ErrPackWeight",
+			err:         ErrPackWeight,
+			expectedMsg: "pack weight must be greater than 0",
+		},
+		{
+			name:        "ErrPackDimensions",
+			err:         ErrPackDimensions,
+			expectedMsg: "pack dimensions must be greater than 0",
+		},
+		{
+			name:        "ErrMissingPackCost",
+			err:         ErrMissingPackCost,
+			expectedMsg: "every pack needs a unit cost for this objective",
+		},
+		{
+			name:        "ErrSolverBudgetExceeded",
+			err:         ErrSolverBudgetExceeded,
+			expectedMsg: "no pack combination was found within the solver budget",
+		},
+		{
+			name:        "ErrInvalidAlternatives",
+			err:         ErrInvalidAlternatives,
+			expectedMsg: "invalid number of alternatives",
+		},
+		{
+			name:        "ErrInvalidBatch",
+			err:         ErrInvalidBatch,
+			expectedMsg: "invalid batch size",
+		},
+		{
+			name:        "ErrInvalidStock",
+			err:         ErrInvalidStock,
+			expectedMsg: "stock quantity cannot be negative",
+		},
+		{
+			name:        "ErrInsufficientStock",
+			err:         ErrInsufficientStock,
+			expectedMsg: "insufficient stock to fulfil the order",
+		},
+		{
+			name:        "ErrStockNotTracked",
+			err:         ErrStockNotTracked,
+			expectedMsg: "stock is not tracked for this pack",
+		},
+		{
+			name:        "ErrInvalidOrderQuery",
+			err:         ErrInvalidOrderQuery,
+			expectedMsg: "invalid order query",
+		},
+		{
+			name:        "ErrUnknownOrderStatus",
+			err:         ErrUnknownOrderStatus,
+			expectedMsg: "unknown order status",
+		},
+		{
+			name:        "ErrInvalidStatusTransition",
+			err:         ErrInvalidStatusTransition,
+			expectedMsg: "order status transition is not allowed",
+		},
+		{
+			name:        "ErrOrderNotAmendable",
+			err:         ErrOrderNotAmendable,
+			expectedMsg: "order can no longer be amended",
+		},
+		{
+			name:        "ErrInvalidRevision",
+			err:         ErrInvalidRevision,
+			expectedMsg: "order revision must be greater than 0",
+		},
+		{
+			name:        "ErrInvalidVersion",
+			err:         ErrInvalidVersion,
+			expectedMsg: "version must be greater than 0",
+		},
+		{
+			name:        "ErrVersionMismatch",
+			err:         ErrVersionMismatch,
+			expectedMsg: "version does not match the current version",
+		},
+		{
+			name:        "ErrOrderModified",
+			err:         ErrOrderModified,
+			expectedMsg: "order was modified concurrently",
+		},
+		{
+			name:        "ErrInvalidIdempotencyKey",
+			err:         ErrInvalidIdempotencyKey,
+			expectedMsg: "idempotency key must be 1 to 255 printable characters",
+		},
+		{
+			name:        "ErrIdempotencyKeyReused",
+			err:         ErrIdempotencyKeyReused,
+			expectedMsg: "idempotency key was already used with a different request",
+		},
+		{
+			name:        "ErrIdempotencyKeyInFlight",
+			err:         ErrIdempotencyKeyInFlight,
+			expectedMsg: "a request with this idempotency key is still being processed",
+		},
+		{
+			name:        "ErrInvalidProductName",
+			err:         ErrInvalidProductName,
+			expectedMsg: "product name must be 1 to 100 characters",
+		},
+		{
+			name:        "ErrProductNotFound",
+			err:         ErrProductNotFound,
+			expectedMsg: "product not found",
+		},
+		{
+			name:        "ErrDuplicateProductName",
+			err:         ErrDuplicateProductName,
+			expectedMsg: "product name already exists",
+		},
+		{
+			name:        "ErrProductInUse",
+			err:         ErrProductInUse,
+			expectedMsg: "product still has packs or orders",
+		},
+		{
+			name:        "ErrDefaultProduct",
+			err:         ErrDefaultProduct,
+			expectedMsg: "the default product cannot be deleted",
+		},
+		{
+			name:        "ErrInvalidOrderLine",
+			err:         ErrInvalidOrderLine,
+			expectedMsg: "invalid order line",
+		},
+		{
+			name:        "ErrInvalidPackAnalysis",
+			err:         ErrInvalidPackAnalysis,
+			expectedMsg: "invalid pack analysis",
+		},
+		{
+			name:        "ErrInvalidSimulation",
+			err:         ErrInvalidSimulation,
+			expectedMsg: "invalid simulation",
+		},
+		{
+			name:        "ErrSimulationNotFound",
+			err:         ErrSimulationNotFound,
+			expectedMsg: "simulation not found",
+		},
+		{
+			name:        "ErrTooManySimulations",
+			err:         ErrTooManySimulations,
+			expectedMsg: "too many simulations are running",
+		},
+		{
+			name:        "ErrInvalidRecommendation",
+			err:         ErrInvalidRecommendation,
+			expectedMsg: "invalid recommendation request",
+		},
+		{
+			name:        "ErrInvalidPackSet",
+			err:         ErrInvalidPackSet,
+			expectedMsg: "invalid pack set",
+		},
 	}
 
 	for _, tt := range tests {
@@ -55,10 +256,56 @@ func TestErrorDefinitions(t *testing.T) {
 			if tt.err.Error() != tt.expectedMsg {
 				t.Errorf("Expected error message '%s', got '%s'", tt.expectedMsg, tt.err.Error())
 			}
+
+			domainErr, ok := AsError(tt.err)
+			if !ok || domainErr.Code() == "" || domainErr.Kind() == "" {
+				t.Errorf("Expected %s to be a domain error with a code and a kind", tt.name)
+			}
 		})
 	}
 }
 
+func TestErrorCodes(t *testing.T) {
+	// Codes are part of the API and must not change. newError rejects
+	// duplicates when the package is initialised.
+	codes := map[*Error]string{
+		ErrPackNotFound:            "pack_not_found",
+		ErrPackModified:            "pack_modified",
+		ErrDuplicatePackSize:       "pack_size_duplicate",
+		ErrOrderNotFound:           "order_not_found",
+		ErrInvalidStatusTransition: "order_status_transition_invalid",
+		ErrVersionMismatch:         "version_mismatch",
+		ErrInsufficientStock:       "stock_insufficient",
+		ErrProductNotFound:         "product_not_found",
+		ErrSolverBudgetExceeded:    "solver_budget_exceeded",
+		ErrTooManySimulations:      "simulations_busy",
+	}
+	for err, code := range codes {
+		if err.Code() != code {
+			t.Errorf("Expected code %q for %q, got %q", code, err, err.Code())
+		}
+	}
+}
+
+func TestAsError(t *testing.T) {
+	wrapped := fmt.Errorf("failed to amend order: %w", fmt.Errorf("%w: order is picking", ErrOrderNotAmendable))
+
+	domainErr, ok := AsError(wrapped)
+	if !ok || domainErr != ErrOrderNotAmendable {
+		t.Fatalf("Expected ErrOrderNotAmendable in the chain, got %v", domainErr)
+	}
+	if domainErr.Kind() != ErrorKindConflict || domainErr.Code() != "order_not_amendable" {
+		t.Errorf("Expected a conflict coded order_not_amendable, got %s %s", domainErr.Kind(), domainErr.Code())
+	}
+
+	if _, ok := AsError(errors.New("connection refused")); ok {
+		t.Error("Expected no domain error in a plain error")
+	}
+	if _, ok := AsError(nil); ok {
+		t.Error("Expected no domain error in nil")
+	}
+}
+
 func TestErrorsAreDistinct(t *testing.T) {
 	errors := []error{
 		ErrPackSize,
@@ -125,7 +372,7 @@ func TestPackSizeErrorUsage(t *testing.T) {
 
 func TestInvalidQuantityErrorUsage(t *testing.T) {
 	order := NewOrder(uuid.New())
-	packageSize := 250
+	packageSize := int64(250)
 
 	err := order.AddItem(packageSize, 0)
 	if !errors.Is(err, ErrInvalidQuantity) {
@@ -172,7 +419,7 @@ func TestInvalidQuantityErrorUsage(t *testing.T) {
 
 func TestOrderNotFoundErrorUsage(t *testing.T) {
 	order := NewOrder(uuid.New())
-	nonExistentPackageSize := 999
+	nonExistentPackageSize := int64(999)
 
 	err := order.RemoveItem(nonExistentPackageSize)
 	if !errors.Is(err, ErrOrderNotFound) {
@@ -0,0 +1,264 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// MaxOrderLines is the largest number of lines one order may have
+const MaxOrderLines = 100
+
+// MaxLineReferenceLength is the longest line reference, in characters
+const MaxLineReferenceLength = 100
+
+// OrderLine is one requested amount of an order. Every line is calculated on
+// its own against the pack set of its product.
+type OrderLine struct {
+	number      int
+	reference   string
+	productID   uuid.UUID
+	items       []OrderItem
+	calculation *OrderCalculation
+}
+
+// NewOrderLine creates an empty line. Lines are numbered from 1 in the order
+// they were requested; the reference is the customer's own, optional label.
+func NewOrderLine(number int, reference string, productID uuid.UUID) (*OrderLine, error) {
+	if number < 1 {
+		return nil, fmt.Errorf("%w: line number must be greater than 0", ErrInvalidOrderLine)
+	}
+
+	reference = strings.TrimSpace(reference)
+	if utf8.RuneCountInString(reference) > MaxLineReferenceLength {
+		return nil, fmt.Errorf("%w: line reference must be at most %d characters", ErrInvalidOrderLine, MaxLineReferenceLength)
+	}
+	if productID == uuid.Nil {
+		return nil, ErrProductNotFound
+	}
+
+	return &OrderLine{
+		number:    number,
+		reference: reference,
+		productID: productID,
+		items:     make([]OrderItem, 0),
+	}, nil
+}
+
+// NewCalculatedOrderLine creates a line from a calculation result, with the
+// largest packs first
+func NewCalculatedOrderLine(number int, reference string, productID uuid.UUID, calculation OrderCalculation, combination map[int64]int64) (*OrderLine, error) {
+	line, err := NewOrderLine(number, reference, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(combination) == 0 {
+		return nil, ErrEmptyOrder
+	}
+
+	sizes := make([]int64, 0, len(combination))
+	for size := range combination {
+		sizes = append(sizes, size)
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		return sizes[i] > sizes[j]
+	})
+
+	for _, size := range sizes {
+		if err := line.AddItem(size, combination[size]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := line.SetCalculation(calculation); err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
+
+// Number returns the position of the line in its order, starting at 1
+func (l *OrderLine) Number() int {
+	return l.number
+}
+
+// Reference returns the customer's reference for the line, if any
+func (l *OrderLine) Reference() string {
+	return l.reference
+}
+
+// ProductID returns the ID of the product the line ships
+func (l *OrderLine) ProductID() uuid.UUID {
+	return l.productID
+}
+
+// AddItem adds a package size with quantity to the line. It returns
+// ErrAmountTooLarge when the line would ship more than MaxAmount items.
+func (l *OrderLine) AddItem(packageSize, quantity int64) error {
+	if packageSize <= 0 {
+		return ErrPackSize
+	}
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+	amount, err := itemAmount(packageSize, quantity)
+	if err != nil {
+		return err
+	}
+	if amount > MaxAmount-l.GetTotalAmount() {
+		return ErrAmountTooLarge
+	}
+
+	for i, item := range l.items {
+		if item.packageSize == packageSize {
+			l.items[i].quantity += quantity
+			return nil
+		}
+	}
+
+	l.items = append(l.items, OrderItem{
+		packageSize: packageSize,
+		quantity:    quantity,
+	})
+	return nil
+}
+
+// RemoveItem removes a package size from the line
+func (l *OrderLine) RemoveItem(packageSize int64) error {
+	for i, item := range l.items {
+		if item.packageSize == packageSize {
+			l.items = append(l.items[:i], l.items[i+1:]...)
+			return nil
+		}
+	}
+	return ErrOrderNotFound
+}
+
+// UpdateItemQuantity updates the quantity of a specific package size in the
+// line. It returns ErrAmountTooLarge when the line would ship more than
+// MaxAmount items.
+func (l *OrderLine) UpdateItemQuantity(packageSize, quantity int64) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	for i, item := range l.items {
+		if item.packageSize == packageSize {
+			amount, err := itemAmount(packageSize, quantity)
+			if err != nil {
+				return err
+			}
+			if amount > MaxAmount-(l.GetTotalAmount()-item.GetAmount()) {
+				return ErrAmountTooLarge
+			}
+			l.items[i].quantity = quantity
+			return nil
+		}
+	}
+	return ErrOrderNotFound
+}
+
+// GetItems returns a copy of the line items
+func (l *OrderLine) GetItems() []OrderItem {
+	items := make([]OrderItem, len(l.items))
+	copy(items, l.items)
+	return items
+}
+
+// GetTotalAmount calculates the total amount covered by this line. Items are
+// only added while the total stays at or below MaxAmount, so it cannot
+// overflow.
+func (l *OrderLine) GetTotalAmount() int64 {
+	var total int64
+	for _, item := range l.items {
+		total += item.GetAmount()
+	}
+	return total
+}
+
+// IsEmpty checks if the line has no items
+func (l *OrderLine) IsEmpty() bool {
+	return len(l.items) == 0
+}
+
+// SetCalculation records the calculation the line was created from
+func (l *OrderLine) SetCalculation(calculation OrderCalculation) error {
+	if calculation.RequestedAmount <= 0 {
+		return ErrInvalidAmount
+	}
+	if calculation.RequestedAmount > MaxAmount {
+		return ErrAmountTooLarge
+	}
+
+	calculation.PackSizes = append([]int64(nil), calculation.PackSizes...)
+	l.calculation = &calculation
+	return nil
+}
+
+// Calculation returns the calculation the line was created from. Lines of
+// orders created before calculations were recorded have none.
+func (l *OrderLine) Calculation() (OrderCalculation, bool) {
+	if l.calculation == nil {
+		return OrderCalculation{}, false
+	}
+
+	calculation := *l.calculation
+	calculation.PackSizes = append([]int64(nil), l.calculation.PackSizes...)
+	return calculation, true
+}
+
+// GetRequestedAmount returns the amount the line was calculated for, or the
+// shipped total for lines created before calculations were recorded
+func (l *OrderLine) GetRequestedAmount() int64 {
+	if l.calculation == nil {
+		return l.GetTotalAmount()
+	}
+	return l.calculation.RequestedAmount
+}
+
+// GetWaste returns how many items the line ships beyond the requested amount,
+// or 0 when the requested amount is unknown
+func (l *OrderLine) GetWaste() int64 {
+	if l.calculation == nil {
+		return 0
+	}
+	return l.GetTotalAmount() - l.calculation.RequestedAmount
+}
+
+// clone returns a copy of the line that shares no state with it
+func (l *OrderLine) clone() OrderLine {
+	line := *l
+	line.items = l.GetItems()
+	if calculation, ok := l.Calculation(); ok {
+		line.calculation = &calculation
+	}
+	return line
+}
+
+// cloneLines validates the lines of an order and returns a copy of them.
+// Lines must be numbered 1, 2, ... in order and references must be unique.
+func cloneLines(lines []OrderLine) ([]OrderLine, error) {
+	if len(lines) == 0 || len(lines) > MaxOrderLines {
+		return nil, fmt.Errorf("%w: an order has 1 to %d lines", ErrInvalidOrderLine, MaxOrderLines)
+	}
+
+	references := make(map[string]bool, len(lines))
+	cloned := make([]OrderLine, len(lines))
+	for i := range lines {
+		line := &lines[i]
+		if line.number != i+1 {
+			return nil, fmt.Errorf("%w: line %d is numbered %d", ErrInvalidOrderLine, i+1, line.number)
+		}
+		if line.reference != "" {
+			if references[line.reference] {
+				return nil, fmt.Errorf("%w: reference %q is used by more than one line", ErrInvalidOrderLine, line.reference)
+			}
+			references[line.reference] = true
+		}
+		cloned[i] = line.clone()
+	}
+	return cloned, nil
+}
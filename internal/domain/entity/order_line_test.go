@@ -0,0 +1,154 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOrderLine(t *testing.T) {
+	tests := []struct {
+		name              string
+		number            int
+		reference         string
+		productID         uuid.UUID
+		expectedReference string
+		expectedErr       error
+	}{
+		{name: "Valid line", number: 1, reference: "PO-7/1", productID: DefaultProductID, expectedReference: "PO-7/1"},
+		{name: "Trimmed reference", number: 2, reference: "  A  ", productID: DefaultProductID, expectedReference: "A"},
+		{name: "No reference", number: 3, productID: DefaultProductID},
+		{name: "Longest reference", number: 1, reference: strings.Repeat("r", MaxLineReferenceLength), productID: DefaultProductID,
+			expectedReference: strings.Repeat("r", MaxLineReferenceLength)},
+		{name: "Reference too long", number: 1, reference: strings.Repeat("r", MaxLineReferenceLength+1), productID: DefaultProductID,
+			expectedErr: ErrInvalidOrderLine},
+		{name: "Zero line number", number: 0, productID: DefaultProductID, expectedErr: ErrInvalidOrderLine},
+		{name: "Missing product", number: 1, productID: uuid.Nil, expectedErr: ErrProductNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, err := NewOrderLine(tt.number, tt.reference, tt.productID)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if tt.expectedErr != nil {
+				return
+			}
+
+			if line.Number() != tt.number {
+				t.Errorf("Expected line number %d, got %d", tt.number, line.Number())
+			}
+			if line.Reference() != tt.expectedReference {
+				t.Errorf("Expected reference %q, got %q", tt.expectedReference, line.Reference())
+			}
+			if line.ProductID() != tt.productID {
+				t.Errorf("Expected product %s, got %s", tt.productID, line.ProductID())
+			}
+			if !line.IsEmpty() {
+				t.Error("Expected new line to be empty")
+			}
+		})
+	}
+}
+
+func TestNewCalculatedOrderLine(t *testing.T) {
+	calculation := OrderCalculation{RequestedAmount: 751, PackSizes: []int64{250, 500, 1000}}
+
+	line, err := NewCalculatedOrderLine(1, "", DefaultProductID, calculation, map[int64]int64{250: 1, 1000: 1})
+	require.NoError(t, err)
+
+	items := line.GetItems()
+	if len(items) != 2 || items[0].PackageSize() != 1000 || items[1].PackageSize() != 250 {
+		t.Errorf("Expected items sorted largest pack first, got %v", items)
+	}
+	if line.GetRequestedAmount() != 751 {
+		t.Errorf("Expected requested amount 751, got %d", line.GetRequestedAmount())
+	}
+	if line.GetWaste() != 499 {
+		t.Errorf("Expected waste 499, got %d", line.GetWaste())
+	}
+
+	if _, err := NewCalculatedOrderLine(1, "", DefaultProductID, calculation, map[int64]int64{}); !errors.Is(err, ErrEmptyOrder) {
+		t.Errorf("Expected ErrEmptyOrder for an empty combination, got %v", err)
+	}
+	if _, err := NewCalculatedOrderLine(1, "", DefaultProductID, calculation, map[int64]int64{500: 0}); !errors.Is(err, ErrInvalidQuantity) {
+		t.Errorf("Expected ErrInvalidQuantity, got %v", err)
+	}
+	if _, err := NewCalculatedOrderLine(1, "", DefaultProductID, OrderCalculation{}, map[int64]int64{500: 1}); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount, got %v", err)
+	}
+}
+
+func TestOrderLine_AmountLimit(t *testing.T) {
+	line, err := NewOrderLine(1, "", DefaultProductID)
+	require.NoError(t, err)
+
+	require.NoError(t, line.AddItem(5000, MaxAmount/5000-1))
+	require.NoError(t, line.AddItem(250, 20))
+	if line.GetTotalAmount() != MaxAmount {
+		t.Errorf("Expected total amount %d, got %d", MaxAmount, line.GetTotalAmount())
+	}
+
+	if err := line.AddItem(250, 1); !errors.Is(err, ErrAmountTooLarge) {
+		t.Errorf("Expected ErrAmountTooLarge when adding beyond the largest amount, got %v", err)
+	}
+	if err := line.UpdateItemQuantity(250, 21); !errors.Is(err, ErrAmountTooLarge) {
+		t.Errorf("Expected ErrAmountTooLarge when updating beyond the largest amount, got %v", err)
+	}
+	require.NoError(t, line.UpdateItemQuantity(250, 19))
+	if line.GetTotalAmount() != MaxAmount-250 {
+		t.Errorf("Expected total amount %d, got %d", MaxAmount-250, line.GetTotalAmount())
+	}
+
+	if err := line.SetCalculation(OrderCalculation{RequestedAmount: MaxAmount + 1}); !errors.Is(err, ErrAmountTooLarge) {
+		t.Errorf("Expected ErrAmountTooLarge for a calculation above the largest amount, got %v", err)
+	}
+}
+
+func TestNewOrderWithLines(t *testing.T) {
+	productID := uuid.New()
+	first, err := NewCalculatedOrderLine(1, "A", DefaultProductID, OrderCalculation{RequestedAmount: 251}, map[int64]int64{500: 1})
+	require.NoError(t, err)
+	second, err := NewCalculatedOrderLine(2, "B", productID, OrderCalculation{RequestedAmount: 1000}, map[int64]int64{1000: 1})
+	require.NoError(t, err)
+
+	order, err := NewOrderWithLines(uuid.New(), []OrderLine{*first, *second})
+	require.NoError(t, err)
+
+	if order.Status() != OrderStatusDraft || order.Revision() != 1 {
+		t.Errorf("Expected a draft order at revision 1, got %s at %d", order.Status(), order.Revision())
+	}
+	lines := order.Lines()
+	if len(lines) != 2 || lines[0].Reference() != "A" || lines[1].ProductID() != productID {
+		t.Fatalf("Expected lines A and B of two products, got %v", lines)
+	}
+	if order.GetRequestedAmount() != 1251 {
+		t.Errorf("Expected requested amount 1251 over both lines, got %d", order.GetRequestedAmount())
+	}
+	if order.GetTotalAmount() != 1500 {
+		t.Errorf("Expected total amount 1500 over both lines, got %d", order.GetTotalAmount())
+	}
+	if order.GetWaste() != 249 {
+		t.Errorf("Expected waste 249 over both lines, got %d", order.GetWaste())
+	}
+	if len(order.GetItems()) != 2 {
+		t.Errorf("Expected the items of both lines, got %v", order.GetItems())
+	}
+
+	// Lines are copies; changing them leaves the order alone
+	require.NoError(t, lines[0].AddItem(500, 1))
+	if order.GetTotalAmount() != 1500 {
+		t.Errorf("Expected the order to be unaffected by changes to its returned lines")
+	}
+
+	if _, err := NewOrderWithLines(uuid.New(), nil); !errors.Is(err, ErrInvalidOrderLine) {
+		t.Errorf("Expected ErrInvalidOrderLine for an order without lines, got %v", err)
+	}
+	if _, err := NewOrderWithLines(uuid.New(), []OrderLine{*second}); !errors.Is(err, ErrInvalidOrderLine) {
+		t.Errorf("Expected ErrInvalidOrderLine for lines not numbered from 1, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"time"
+)
+
+// OrderStatus is the stage of an order in its lifecycle
+type OrderStatus string
+
+const (
+	OrderStatusDraft     OrderStatus = "draft"
+	OrderStatusConfirmed OrderStatus = "confirmed"
+	OrderStatusPicking   OrderStatus = "picking"
+	OrderStatusPacked    OrderStatus = "packed"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// orderTransitions lists the statuses each status may move to. An order can
+// be cancelled until it has shipped; shipped and cancelled orders are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusDraft:     {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed: {OrderStatusPicking, OrderStatusCancelled},
+	OrderStatusPicking:   {OrderStatusPacked, OrderStatusCancelled},
+	OrderStatusPacked:    {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:   {},
+	OrderStatusCancelled: {},
+}
+
+// OrderStatusChange records one status transition of an order. From is empty
+// for the status an order was created with.
+type OrderStatusChange struct {
+	From      OrderStatus
+	To        OrderStatus
+	ChangedAt time.Time
+}
+
+// ParseOrderStatus returns the status with the given name
+func ParseOrderStatus(name string) (OrderStatus, error) {
+	status := OrderStatus(name)
+	if _, ok := orderTransitions[status]; !ok {
+		return "", ErrUnknownOrderStatus
+	}
+	return status, nil
+}
+
+// IsFinal reports whether no further transitions are allowed from the status
+func (s OrderStatus) IsFinal() bool {
+	return len(orderTransitions[s]) == 0
+}
+
+// CanTransitionTo reports whether an order may move from s to next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Transitions returns the statuses an order may move to from s
+func (s OrderStatus) Transitions() []OrderStatus {
+	return append([]OrderStatus(nil), orderTransitions[s]...)
+}
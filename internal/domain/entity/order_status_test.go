@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseOrderStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    OrderStatus
+		expectedErr error
+	}{
+		{name: "Draft", input: "draft", expected: OrderStatusDraft},
+		{name: "Shipped", input: "shipped", expected: OrderStatusShipped},
+		{name: "Cancelled", input: "cancelled", expected: OrderStatusCancelled},
+		{name: "Unknown", input: "lost", expectedErr: ErrUnknownOrderStatus},
+		{name: "Empty", input: "", expectedErr: ErrUnknownOrderStatus},
+		{name: "Wrong case", input: "Draft", expectedErr: ErrUnknownOrderStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := ParseOrderStatus(tt.input)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if status != tt.expected {
+				t.Errorf("Expected status %q, got %q", tt.expected, status)
+			}
+		})
+	}
+}
+
+func TestOrderStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from     OrderStatus
+		to       OrderStatus
+		expected bool
+	}{
+		{from: OrderStatusDraft, to: OrderStatusConfirmed, expected: true},
+		{from: OrderStatusDraft, to: OrderStatusCancelled, expected: true},
+		{from: OrderStatusDraft, to: OrderStatusShipped, expected: false},
+		{from: OrderStatusConfirmed, to: OrderStatusPicking, expected: true},
+		{from: OrderStatusConfirmed, to: OrderStatusDraft, expected: false},
+		{from: OrderStatusPicking, to: OrderStatusPacked, expected: true},
+		{from: OrderStatusPicking, to: OrderStatusCancelled, expected: true},
+		{from: OrderStatusPacked, to: OrderStatusShipped, expected: true},
+		{from: OrderStatusPacked, to: OrderStatusCancelled, expected: true},
+		{from: OrderStatusShipped, to: OrderStatusCancelled, expected: false},
+		{from: OrderStatusCancelled, to: OrderStatusConfirmed, expected: false},
+		{from: OrderStatusConfirmed, to: OrderStatusConfirmed, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.expected {
+				t.Errorf("Expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOrderStatus_IsFinal(t *testing.T) {
+	final := map[OrderStatus]bool{
+		OrderStatusDraft:     false,
+		OrderStatusConfirmed: false,
+		OrderStatusPicking:   false,
+		OrderStatusPacked:    false,
+		OrderStatusShipped:   true,
+		OrderStatusCancelled: true,
+	}
+
+	for status, expected := range final {
+		if status.IsFinal() != expected {
+			t.Errorf("Expected %s final to be %t", status, expected)
+		}
+		if expected && len(status.Transitions()) != 0 {
+			t.Errorf("Expected no transitions from %s, got %v", status, status.Transitions())
+		}
+	}
+}
@@ -1,115 +1,341 @@
 package entity
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type Order struct {
 	BaseEntity
-	items []OrderItem
+	lines    []OrderLine
+	status   OrderStatus
+	revision int
+	version  int
+	// calculatedAt is when the current lines were calculated
+	calculatedAt time.Time
+}
+
+// OrderCalculation records the calculation an order line was created from
+type OrderCalculation struct {
+	RequestedAmount int64
+	// PackSizes are the pack sizes that were available at calculation time
+	PackSizes     []int64
+	Objective     string
+	SolverVersion string
+}
+
+// OrderRevision is a replaced version of an amended order
+type OrderRevision struct {
+	Revision int
+	Lines    []OrderLine
+	// RevisedAt is when this version was replaced
+	RevisedAt time.Time
 }
 
 type OrderItem struct {
-	packageSize int
-	quantity    int
+	packageSize int64
+	quantity    int64
 }
 
-// NewOrder creates a new order with the given ID
+// NewOrder creates a new draft order of the default product with the given ID
 func NewOrder(id uuid.UUID) *Order {
+	return NewProductOrder(id, DefaultProductID)
+}
+
+// NewProductOrder creates a new draft order with one empty line for packs of
+// the given product
+func NewProductOrder(id, productID uuid.UUID) *Order {
+	base := NewBaseEntity(id)
 	return &Order{
-		BaseEntity: NewBaseEntity(id),
-		items:      make([]OrderItem, 0),
+		BaseEntity: base,
+		lines: []OrderLine{{
+			number:    1,
+			productID: productID,
+			items:     make([]OrderItem, 0),
+		}},
+		status:       OrderStatusDraft,
+		revision:     1,
+		version:      1,
+		calculatedAt: base.CreatedAt(),
 	}
 }
 
-// AddItem adds a package size with quantity to the order
-func (o *Order) AddItem(packageSize, quantity int) error {
-	if packageSize <= 0 {
-		return ErrPackSize
-	}
-	if quantity <= 0 {
-		return ErrInvalidQuantity
+// NewOrderWithLines creates a new draft order made of the given lines
+func NewOrderWithLines(id uuid.UUID, lines []OrderLine) (*Order, error) {
+	cloned, err := cloneLines(lines)
+	if err != nil {
+		return nil, err
 	}
 
-	for i, item := range o.items {
-		if item.packageSize == packageSize {
-			o.items[i].quantity += quantity
-			o.Update()
-			return nil
-		}
+	base := NewBaseEntity(id)
+	return &Order{
+		BaseEntity:   base,
+		lines:        cloned,
+		status:       OrderStatusDraft,
+		revision:     1,
+		version:      1,
+		calculatedAt: base.CreatedAt(),
+	}, nil
+}
+
+// Lines returns a copy of the order lines, in line number order
+func (o *Order) Lines() []OrderLine {
+	lines := make([]OrderLine, len(o.lines))
+	for i := range o.lines {
+		lines[i] = o.lines[i].clone()
 	}
+	return lines
+}
 
-	o.items = append(o.items, OrderItem{
-		packageSize: packageSize,
-		quantity:    quantity,
-	})
-	o.Update()
+// SetLines restores stored lines
+func (o *Order) SetLines(lines []OrderLine) error {
+	cloned, err := cloneLines(lines)
+	if err != nil {
+		return err
+	}
+	o.lines = cloned
 	return nil
 }
 
-// RemoveItem removes a package size from the order
-func (o *Order) RemoveItem(packageSize int) error {
-	for i, item := range o.items {
-		if item.packageSize == packageSize {
-			o.items = append(o.items[:i], o.items[i+1:]...)
-			o.Update()
-			return nil
-		}
+// firstLine returns the line that the single-line methods of Order work on
+func (o *Order) firstLine() *OrderLine {
+	return &o.lines[0]
+}
+
+// ProductID returns the ID of the product the first line ships. Use Lines
+// for orders with more than one line.
+func (o *Order) ProductID() uuid.UUID {
+	return o.firstLine().productID
+}
+
+// AddItem adds a package size with quantity to the first line of the order
+func (o *Order) AddItem(packageSize, quantity int64) error {
+	if err := o.firstLine().AddItem(packageSize, quantity); err != nil {
+		return err
 	}
-	return ErrOrderNotFound
+	o.Update()
+	return nil
 }
 
-// UpdateItemQuantity updates the quantity of a specific package size in the order
-func (o *Order) UpdateItemQuantity(packageSize, quantity int) error {
-	if quantity <= 0 {
-		return ErrInvalidQuantity
+// RemoveItem removes a package size from the first line of the order
+func (o *Order) RemoveItem(packageSize int64) error {
+	if err := o.firstLine().RemoveItem(packageSize); err != nil {
+		return err
 	}
+	o.Update()
+	return nil
+}
 
-	for i, item := range o.items {
-		if item.packageSize == packageSize {
-			o.items[i].quantity = quantity
-			o.Update()
-			return nil
-		}
+// UpdateItemQuantity updates the quantity of a specific package size in the
+// first line of the order
+func (o *Order) UpdateItemQuantity(packageSize, quantity int64) error {
+	if err := o.firstLine().UpdateItemQuantity(packageSize, quantity); err != nil {
+		return err
 	}
-	return ErrOrderNotFound
+	o.Update()
+	return nil
 }
 
-// GetItems returns a copy of the order items
+// GetItems returns a copy of the items of all lines, in line order
 func (o *Order) GetItems() []OrderItem {
-	items := make([]OrderItem, len(o.items))
-	copy(items, o.items)
+	var items []OrderItem
+	for i := range o.lines {
+		items = append(items, o.lines[i].items...)
+	}
+	if items == nil {
+		items = make([]OrderItem, 0)
+	}
 	return items
 }
 
-// GetTotalAmount calculates the total amount covered by this order
-func (o *Order) GetTotalAmount() int {
-	total := 0
-	for _, item := range o.items {
-		total += item.packageSize * item.quantity
+// GetTotalAmount calculates the total amount covered by this order. Lines
+// ship at most MaxAmount each, so the sum cannot overflow.
+func (o *Order) GetTotalAmount() int64 {
+	var total int64
+	for i := range o.lines {
+		total += o.lines[i].GetTotalAmount()
 	}
 	return total
 }
 
 // IsEmpty checks if the order has no items
 func (o *Order) IsEmpty() bool {
-	return len(o.items) == 0
+	for i := range o.lines {
+		if !o.lines[i].IsEmpty() {
+			return false
+		}
+	}
+	return true
 }
 
 // Clear removes all items from the order
 func (o *Order) Clear() {
-	o.items = make([]OrderItem, 0)
+	for i := range o.lines {
+		o.lines[i].items = make([]OrderItem, 0)
+	}
 	o.Update()
 }
 
-// NewOrderItem creates a new order item
-func NewOrderItem(packageSize, quantity int) (*OrderItem, error) {
+// SetCalculation records the calculation the first line was created from
+func (o *Order) SetCalculation(calculation OrderCalculation) error {
+	return o.firstLine().SetCalculation(calculation)
+}
+
+// Calculation returns the calculation the first line was created from.
+// Orders created before calculations were recorded have none.
+func (o *Order) Calculation() (OrderCalculation, bool) {
+	return o.firstLine().Calculation()
+}
+
+// GetRequestedAmount returns the amount requested over all lines, counting
+// the shipped total for lines created before calculations were recorded
+func (o *Order) GetRequestedAmount() int64 {
+	var amount int64
+	for i := range o.lines {
+		amount += o.lines[i].GetRequestedAmount()
+	}
+	return amount
+}
+
+// GetWaste returns how many items the order ships beyond the requested amount
+// of its lines; lines with an unknown requested amount count as 0
+func (o *Order) GetWaste() int64 {
+	var waste int64
+	for i := range o.lines {
+		waste += o.lines[i].GetWaste()
+	}
+	return waste
+}
+
+// Status returns the lifecycle status of the order
+func (o *Order) Status() OrderStatus {
+	return o.status
+}
+
+// SetStatus restores a stored status without checking transition rules
+func (o *Order) SetStatus(status OrderStatus) error {
+	if _, err := ParseOrderStatus(string(status)); err != nil {
+		return err
+	}
+	o.status = status
+	return nil
+}
+
+// TransitionTo moves the order to the next status and returns the change, or
+// ErrInvalidStatusTransition when the current status does not allow it
+func (o *Order) TransitionTo(next OrderStatus) (OrderStatusChange, error) {
+	if _, err := ParseOrderStatus(string(next)); err != nil {
+		return OrderStatusChange{}, err
+	}
+	if !o.status.CanTransitionTo(next) {
+		return OrderStatusChange{}, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, o.status, next)
+	}
+
+	change := OrderStatusChange{From: o.status, To: next}
+	o.status = next
+	o.version++
+	o.Update()
+	change.ChangedAt = o.UpdatedAt()
+
+	return change, nil
+}
+
+// Revision returns the version number of the order, starting at 1 and
+// increasing with every amendment
+func (o *Order) Revision() int {
+	return o.revision
+}
+
+// SetRevision restores a stored revision number
+func (o *Order) SetRevision(revision int) error {
+	if revision < 1 {
+		return ErrInvalidRevision
+	}
+	o.revision = revision
+	return nil
+}
+
+// Version returns the concurrency version of the order. It starts at 1 and
+// increases with every stored change, amendments and status changes alike.
+func (o *Order) Version() int {
+	return o.version
+}
+
+// SetVersion restores a stored version number
+func (o *Order) SetVersion(version int) error {
+	if version < 1 {
+		return ErrInvalidVersion
+	}
+	o.version = version
+	return nil
+}
+
+// CalculatedAt returns when the current lines were calculated: when the
+// order was created, or last amended
+func (o *Order) CalculatedAt() time.Time {
+	return o.calculatedAt
+}
+
+// SetCalculatedAt restores when the current lines were calculated
+func (o *Order) SetCalculatedAt(at time.Time) {
+	o.calculatedAt = at
+}
+
+// CanAmend reports whether the order may still be changed. Orders can be
+// amended until picking starts.
+func (o *Order) CanAmend() bool {
+	return o.status == OrderStatusDraft || o.status == OrderStatusConfirmed
+}
+
+// Amend replaces the lines of the order with newly calculated ones. It
+// returns the replaced version of the order.
+func (o *Order) Amend(lines []OrderLine) (OrderRevision, error) {
+	if !o.CanAmend() {
+		return OrderRevision{}, fmt.Errorf("%w: order is %s", ErrOrderNotAmendable, o.status)
+	}
+
+	cloned, err := cloneLines(lines)
+	if err != nil {
+		return OrderRevision{}, err
+	}
+	for i := range cloned {
+		if cloned[i].calculation == nil {
+			return OrderRevision{}, ErrInvalidAmount
+		}
+		if cloned[i].IsEmpty() {
+			return OrderRevision{}, ErrEmptyOrder
+		}
+	}
+
+	previous := OrderRevision{
+		Revision: o.revision,
+		Lines:    o.Lines(),
+	}
+
+	o.lines = cloned
+	o.revision++
+	o.version++
+	o.Update()
+	o.calculatedAt = o.UpdatedAt()
+	previous.RevisedAt = o.UpdatedAt()
+
+	return previous, nil
+}
+
+// NewOrderItem creates a new order item. Items of more than MaxAmount items
+// are rejected with ErrAmountTooLarge.
+func NewOrderItem(packageSize, quantity int64) (*OrderItem, error) {
 	if packageSize <= 0 {
 		return nil, ErrPackSize
 	}
 	if quantity <= 0 {
 		return nil, ErrInvalidQuantity
 	}
+	if _, err := itemAmount(packageSize, quantity); err != nil {
+		return nil, err
+	}
 
 	return &OrderItem{
 		packageSize: packageSize,
@@ -118,25 +344,30 @@ func NewOrderItem(packageSize, quantity int) (*OrderItem, error) {
 }
 
 // PackageSize returns the package size for this order item
-func (oi *OrderItem) PackageSize() int {
+func (oi *OrderItem) PackageSize() int64 {
 	return oi.packageSize
 }
 
 // Quantity returns the quantity of this order item
-func (oi *OrderItem) Quantity() int {
+func (oi *OrderItem) Quantity() int64 {
 	return oi.quantity
 }
 
 // SetQuantity sets the quantity of this order item
-func (oi *OrderItem) SetQuantity(quantity int) error {
+func (oi *OrderItem) SetQuantity(quantity int64) error {
 	if quantity <= 0 {
 		return ErrInvalidQuantity
 	}
+	if _, err := itemAmount(oi.packageSize, quantity); err != nil {
+		return err
+	}
 	oi.quantity = quantity
 	return nil
 }
 
-// GetAmount returns the total amount for this order item
-func (oi *OrderItem) GetAmount() int {
+// GetAmount returns the total amount for this order item. Items are checked
+// against MaxAmount when they are created or changed, so the product cannot
+// overflow.
+func (oi *OrderItem) GetAmount() int64 {
 	return oi.packageSize * oi.quantity
 }
@@ -44,12 +44,12 @@ func TestNewOrder(t *testing.T) {
 
 func TestOrder_AddItem(t *testing.T) {
 	order := NewOrder(uuid.New())
-	packageSize := 250
+	packageSize := int64(250)
 
 	tests := []struct {
 		name        string
-		packageSize int
-		quantity    int
+		packageSize int64
+		quantity    int64
 		expectError bool
 		expectedErr error
 	}{
@@ -143,7 +143,7 @@ func TestOrder_AddItem(t *testing.T) {
 
 func TestOrder_AddItem_DuplicatePackageSize(t *testing.T) {
 	order := NewOrder(uuid.New())
-	packageSize := 250
+	packageSize := int64(250)
 
 	err := order.AddItem(packageSize, 2)
 	if err != nil {
@@ -161,7 +161,7 @@ func TestOrder_AddItem_DuplicatePackageSize(t *testing.T) {
 	}
 
 	if len(items) > 0 {
-		expectedQuantity := 5 // 2 + 3
+		expectedQuantity := int64(5) // 2 + 3
 		if items[0].Quantity() != expectedQuantity {
 			t.Errorf("Expected quantity %d after adding duplicate package size, got %d", expectedQuantity, items[0].Quantity())
 		}
@@ -170,15 +170,15 @@ func TestOrder_AddItem_DuplicatePackageSize(t *testing.T) {
 
 func TestOrder_RemoveItem(t *testing.T) {
 	order := NewOrder(uuid.New())
-	packageSize1 := 250
-	packageSize2 := 500
+	packageSize1 := int64(250)
+	packageSize2 := int64(500)
 
 	_ = order.AddItem(packageSize1, 2)
 	_ = order.AddItem(packageSize2, 1)
 
 	tests := []struct {
 		name        string
-		packageSize int
+		packageSize int64
 		expectError bool
 		expectedErr error
 	}{
@@ -241,13 +241,13 @@ func TestOrder_RemoveItem(t *testing.T) {
 
 func TestOrder_UpdateItemQuantity(t *testing.T) {
 	order := NewOrder(uuid.New())
-	packageSize := 250
+	packageSize := int64(250)
 	_ = order.AddItem(packageSize, 2)
 
 	tests := []struct {
 		name        string
-		packageSize int
-		quantity    int
+		packageSize int64
+		quantity    int64
 		expectError bool
 		expectedErr error
 	}{
@@ -324,8 +324,8 @@ func TestOrder_UpdateItemQuantity(t *testing.T) {
 
 func TestOrder_GetItems(t *testing.T) {
 	order := NewOrder(uuid.New())
-	packageSize1 := 250
-	packageSize2 := 500
+	packageSize1 := int64(250)
+	packageSize2 := int64(500)
 
 	items := order.GetItems()
 	if len(items) != 0 {
@@ -356,20 +356,71 @@ func TestOrder_GetTotalAmount(t *testing.T) {
 		t.Errorf("Expected total amount to be 0 initially, got %d", order.GetTotalAmount())
 	}
 
-	packageSize1 := 250
-	packageSize2 := 500
+	packageSize1 := int64(250)
+	packageSize2 := int64(500)
 
 	err := order.AddItem(packageSize1, 2)
 	require.NoError(t, err)
 	err = order.AddItem(packageSize2, 1)
 	require.NoError(t, err)
 
-	expectedTotal := 1000 // (250 * 2) + (500 * 1)
+	expectedTotal := int64(1000) // (250 * 2) + (500 * 1)
 	if order.GetTotalAmount() != expectedTotal {
 		t.Errorf("Expected total amount %d, got %d", expectedTotal, order.GetTotalAmount())
 	}
 }
 
+func TestOrder_SetCalculation(t *testing.T) {
+	order := NewOrder(uuid.New())
+
+	if _, ok := order.Calculation(); ok {
+		t.Errorf("Expected new order to have no calculation")
+	}
+	if order.GetWaste() != 0 {
+		t.Errorf("Expected waste 0 without a calculation, got %d", order.GetWaste())
+	}
+
+	err := order.AddItem(500, 1)
+	require.NoError(t, err)
+
+	if order.GetRequestedAmount() != 500 {
+		t.Errorf("Expected requested amount to fall back to the total 500, got %d", order.GetRequestedAmount())
+	}
+
+	packSizes := []int64{250, 500, 1000}
+	err = order.SetCalculation(OrderCalculation{
+		RequestedAmount: 251,
+		PackSizes:       packSizes,
+		Objective:       "min_waste",
+		SolverVersion:   "dp-1",
+	})
+	require.NoError(t, err)
+
+	// The order keeps its own copy of the pack sizes
+	packSizes[0] = 999
+
+	calculation, ok := order.Calculation()
+	if !ok {
+		t.Fatalf("Expected order to have a calculation")
+	}
+	if calculation.RequestedAmount != 251 || calculation.Objective != "min_waste" || calculation.SolverVersion != "dp-1" {
+		t.Errorf("Unexpected calculation %+v", calculation)
+	}
+	if len(calculation.PackSizes) != 3 || calculation.PackSizes[0] != 250 {
+		t.Errorf("Expected pack sizes [250 500 1000], got %v", calculation.PackSizes)
+	}
+	if order.GetWaste() != 249 {
+		t.Errorf("Expected waste 249, got %d", order.GetWaste())
+	}
+	if order.GetRequestedAmount() != 251 {
+		t.Errorf("Expected requested amount 251, got %d", order.GetRequestedAmount())
+	}
+
+	if err := order.SetCalculation(OrderCalculation{RequestedAmount: 0}); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount, got %v", err)
+	}
+}
+
 func TestOrder_IsEmpty(t *testing.T) {
 	order := NewOrder(uuid.New())
 
@@ -377,7 +428,7 @@ func TestOrder_IsEmpty(t *testing.T) {
 		t.Error("Expected new order to be empty")
 	}
 
-	packageSize := 250
+	packageSize := int64(250)
 	_ = order.AddItem(packageSize, 1)
 
 	if order.IsEmpty() {
@@ -393,8 +444,8 @@ func TestOrder_IsEmpty(t *testing.T) {
 
 func TestOrder_Clear(t *testing.T) {
 	order := NewOrder(uuid.New())
-	packageSize1 := 250
-	packageSize2 := 500
+	packageSize1 := int64(250)
+	packageSize2 := int64(500)
 
 	_ = order.AddItem(packageSize1, 2)
 	_ = order.AddItem(packageSize2, 1)
@@ -424,12 +475,12 @@ func TestOrder_Clear(t *testing.T) {
 }
 
 func TestNewOrderItem(t *testing.T) {
-	packageSize := 250
+	packageSize := int64(250)
 
 	tests := []struct {
 		name        string
-		packageSize int
-		quantity    int
+		packageSize int64
+		quantity    int64
 		expectError bool
 		expectedErr error
 	}{
@@ -467,6 +518,26 @@ func TestNewOrderItem(t *testing.T) {
 			expectError: true,
 			expectedErr: ErrInvalidQuantity,
 		},
+		{
+			name:        "Valid order item with the largest amount",
+			packageSize: packageSize,
+			quantity:    MaxAmount / packageSize,
+			expectError: false,
+		},
+		{
+			name:        "Invalid order item above the largest amount",
+			packageSize: packageSize,
+			quantity:    MaxAmount/packageSize + 1,
+			expectError: true,
+			expectedErr: ErrAmountTooLarge,
+		},
+		{
+			name:        "Invalid order item with an overflowing amount",
+			packageSize: 1 << 40,
+			quantity:    1 << 40,
+			expectError: true,
+			expectedErr: ErrAmountTooLarge,
+		},
 	}
 
 	for _, tt := range tests {
@@ -509,7 +580,7 @@ func TestNewOrderItem(t *testing.T) {
 }
 
 func TestOrderItem_PackageSize(t *testing.T) {
-	packageSize := 250
+	packageSize := int64(250)
 	item, _ := NewOrderItem(packageSize, 2)
 
 	if item.PackageSize() != packageSize {
@@ -518,8 +589,8 @@ func TestOrderItem_PackageSize(t *testing.T) {
 }
 
 func TestOrderItem_Quantity(t *testing.T) {
-	packageSize := 250
-	quantity := 3
+	packageSize := int64(250)
+	quantity := int64(3)
 	item, _ := NewOrderItem(packageSize, quantity)
 
 	if item.Quantity() != quantity {
@@ -528,12 +599,12 @@ func TestOrderItem_Quantity(t *testing.T) {
 }
 
 func TestOrderItem_SetQuantity(t *testing.T) {
-	packageSize := 250
+	packageSize := int64(250)
 	item, _ := NewOrderItem(packageSize, 2)
 
 	tests := []struct {
 		name        string
-		quantity    int
+		quantity    int64
 		expectError bool
 		expectedErr error
 	}{
@@ -554,6 +625,12 @@ func TestOrderItem_SetQuantity(t *testing.T) {
 			expectError: true,
 			expectedErr: ErrInvalidQuantity,
 		},
+		{
+			name:        "Invalid quantity - above the largest amount",
+			quantity:    MaxAmount/packageSize + 1,
+			expectError: true,
+			expectedErr: ErrAmountTooLarge,
+		},
 	}
 
 	for _, tt := range tests {
@@ -590,11 +667,11 @@ func TestOrderItem_SetQuantity(t *testing.T) {
 }
 
 func TestOrderItem_GetAmount(t *testing.T) {
-	packageSize := 250
-	quantity := 3
+	packageSize := int64(250)
+	quantity := int64(3)
 	item, _ := NewOrderItem(packageSize, quantity)
 
-	expectedAmount := 750 // 250 * 3
+	expectedAmount := int64(750) // 250 * 3
 	if item.GetAmount() != expectedAmount {
 		t.Errorf("Expected amount %d, got %d", expectedAmount, item.GetAmount())
 	}
@@ -602,15 +679,15 @@ func TestOrderItem_GetAmount(t *testing.T) {
 
 func TestOrder_Integration(t *testing.T) {
 	order := NewOrder(uuid.New())
-	packageSize1 := 250
-	packageSize2 := 500
-	packageSize3 := 1000
+	packageSize1 := int64(250)
+	packageSize2 := int64(500)
+	packageSize3 := int64(1000)
 
 	_ = order.AddItem(packageSize1, 2)
 	_ = order.AddItem(packageSize2, 1)
 	_ = order.AddItem(packageSize3, 1)
 
-	expectedTotal := 2000 // (250 * 2) + (500 * 1) + (1000 * 1)
+	expectedTotal := int64(2000) // (250 * 2) + (500 * 1) + (1000 * 1)
 	if order.GetTotalAmount() != expectedTotal {
 		t.Errorf("Expected total amount %d, got %d", expectedTotal, order.GetTotalAmount())
 	}
@@ -639,3 +716,187 @@ func TestOrder_Integration(t *testing.T) {
 		t.Errorf("Expected total amount to be 0 after clearing, got %d", order.GetTotalAmount())
 	}
 }
+
+func TestOrder_TransitionTo(t *testing.T) {
+	order := NewOrder(uuid.New())
+
+	if order.Status() != OrderStatusDraft {
+		t.Fatalf("Expected new order to be draft, got %s", order.Status())
+	}
+
+	lifecycle := []OrderStatus{OrderStatusConfirmed, OrderStatusPicking, OrderStatusPacked, OrderStatusShipped}
+	previous := OrderStatusDraft
+	for _, next := range lifecycle {
+		change, err := order.TransitionTo(next)
+		require.NoError(t, err)
+
+		if change.From != previous || change.To != next {
+			t.Errorf("Expected change %s to %s, got %s to %s", previous, next, change.From, change.To)
+		}
+		if !change.ChangedAt.Equal(order.UpdatedAt()) {
+			t.Errorf("Expected change time to match the order update time")
+		}
+		if order.Status() != next {
+			t.Errorf("Expected status %s, got %s", next, order.Status())
+		}
+		previous = next
+	}
+
+	_, err := order.TransitionTo(OrderStatusCancelled)
+	if !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Errorf("Expected ErrInvalidStatusTransition cancelling a shipped order, got %v", err)
+	}
+	if order.Status() != OrderStatusShipped {
+		t.Errorf("Expected a rejected transition to keep status shipped, got %s", order.Status())
+	}
+
+	_, err = NewOrder(uuid.New()).TransitionTo("lost")
+	if !errors.Is(err, ErrUnknownOrderStatus) {
+		t.Errorf("Expected ErrUnknownOrderStatus, got %v", err)
+	}
+}
+
+func TestOrder_SetStatus(t *testing.T) {
+	order := NewOrder(uuid.New())
+
+	require.NoError(t, order.SetStatus(OrderStatusPacked))
+	if order.Status() != OrderStatusPacked {
+		t.Errorf("Expected status packed, got %s", order.Status())
+	}
+
+	if err := order.SetStatus("lost"); !errors.Is(err, ErrUnknownOrderStatus) {
+		t.Errorf("Expected ErrUnknownOrderStatus, got %v", err)
+	}
+	if order.Status() != OrderStatusPacked {
+		t.Errorf("Expected an unknown status to be rejected, got %s", order.Status())
+	}
+}
+
+func TestOrder_Amend(t *testing.T) {
+	order := NewOrder(uuid.New())
+	require.NoError(t, order.AddItem(500, 1))
+	require.NoError(t, order.SetCalculation(OrderCalculation{RequestedAmount: 251, PackSizes: []int64{250, 500}, Objective: "min_waste"}))
+
+	if order.Revision() != 1 {
+		t.Fatalf("Expected new order at revision 1, got %d", order.Revision())
+	}
+	if !order.CalculatedAt().Equal(order.CreatedAt()) {
+		t.Errorf("Expected a new order to be calculated when it was created")
+	}
+
+	line, err := NewCalculatedOrderLine(1, "", DefaultProductID, OrderCalculation{RequestedAmount: 1250, PackSizes: []int64{250, 1000}}, map[int64]int64{250: 1, 1000: 1})
+	require.NoError(t, err)
+
+	previous, err := order.Amend([]OrderLine{*line})
+	require.NoError(t, err)
+
+	if previous.Revision != 1 {
+		t.Errorf("Expected replaced revision 1, got %d", previous.Revision)
+	}
+	if len(previous.Lines) != 1 {
+		t.Fatalf("Expected the replaced version to have 1 line, got %d", len(previous.Lines))
+	}
+	if calculation, ok := previous.Lines[0].Calculation(); !ok || calculation.RequestedAmount != 251 {
+		t.Errorf("Expected replaced calculation for 251, got %+v", calculation)
+	}
+	if items := previous.Lines[0].GetItems(); len(items) != 1 || items[0].PackageSize() != 500 {
+		t.Errorf("Expected replaced items to be one 500 pack, got %v", items)
+	}
+	if !previous.RevisedAt.Equal(order.UpdatedAt()) {
+		t.Errorf("Expected revision time to match the order update time")
+	}
+	if !order.CalculatedAt().Equal(previous.RevisedAt) {
+		t.Errorf("Expected the amended lines to be calculated when the order was amended")
+	}
+
+	if order.Revision() != 2 {
+		t.Errorf("Expected revision 2, got %d", order.Revision())
+	}
+	if order.GetTotalAmount() != 1250 {
+		t.Errorf("Expected total amount 1250, got %d", order.GetTotalAmount())
+	}
+	if order.GetRequestedAmount() != 1250 {
+		t.Errorf("Expected requested amount 1250, got %d", order.GetRequestedAmount())
+	}
+	items := order.GetItems()
+	if len(items) != 2 || items[0].PackageSize() != 1000 || items[1].PackageSize() != 250 {
+		t.Errorf("Expected items sorted largest pack first, got %v", items)
+	}
+}
+
+func TestOrder_Version(t *testing.T) {
+	order := NewOrder(uuid.New())
+	require.NoError(t, order.AddItem(500, 1))
+	require.NoError(t, order.SetCalculation(OrderCalculation{RequestedAmount: 500}))
+	require.Equal(t, 1, order.Version())
+
+	line, err := NewCalculatedOrderLine(1, "", DefaultProductID, OrderCalculation{RequestedAmount: 250}, map[int64]int64{250: 1})
+	require.NoError(t, err)
+	_, err = order.Amend([]OrderLine{*line})
+	require.NoError(t, err)
+	require.Equal(t, 2, order.Version())
+
+	// Status changes leave the revision alone but still change the version
+	_, err = order.TransitionTo(OrderStatusConfirmed)
+	require.NoError(t, err)
+	require.Equal(t, 2, order.Revision())
+	require.Equal(t, 3, order.Version())
+
+	// Rejected changes keep the version
+	_, err = order.TransitionTo(OrderStatusShipped)
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+	require.Equal(t, 3, order.Version())
+
+	require.NoError(t, order.SetVersion(7))
+	require.Equal(t, 7, order.Version())
+	if err := order.SetVersion(0); !errors.Is(err, ErrInvalidVersion) {
+		t.Errorf("Expected ErrInvalidVersion, got %v", err)
+	}
+}
+
+func TestOrder_Amend_Rejected(t *testing.T) {
+	calculated := func(number int, reference string) OrderLine {
+		line, err := NewCalculatedOrderLine(number, reference, DefaultProductID, OrderCalculation{RequestedAmount: 500}, map[int64]int64{500: 1})
+		require.NoError(t, err)
+		return *line
+	}
+	uncalculated, err := NewOrderLine(1, "", DefaultProductID)
+	require.NoError(t, err)
+	require.NoError(t, uncalculated.AddItem(500, 1))
+	empty, err := NewOrderLine(1, "", DefaultProductID)
+	require.NoError(t, err)
+	require.NoError(t, empty.SetCalculation(OrderCalculation{RequestedAmount: 500}))
+
+	tests := []struct {
+		name        string
+		status      OrderStatus
+		lines       []OrderLine
+		expectedErr error
+	}{
+		{name: "Confirmed order", status: OrderStatusConfirmed, lines: []OrderLine{calculated(1, "")}},
+		{name: "Picking order", status: OrderStatusPicking, lines: []OrderLine{calculated(1, "")}, expectedErr: ErrOrderNotAmendable},
+		{name: "Shipped order", status: OrderStatusShipped, lines: []OrderLine{calculated(1, "")}, expectedErr: ErrOrderNotAmendable},
+		{name: "Cancelled order", status: OrderStatusCancelled, lines: []OrderLine{calculated(1, "")}, expectedErr: ErrOrderNotAmendable},
+		{name: "No lines", status: OrderStatusDraft, lines: nil, expectedErr: ErrInvalidOrderLine},
+		{name: "Missing calculation", status: OrderStatusDraft, lines: []OrderLine{*uncalculated}, expectedErr: ErrInvalidAmount},
+		{name: "Empty line", status: OrderStatusDraft, lines: []OrderLine{*empty}, expectedErr: ErrEmptyOrder},
+		{name: "Misnumbered lines", status: OrderStatusDraft, lines: []OrderLine{calculated(2, "")}, expectedErr: ErrInvalidOrderLine},
+		{name: "Duplicate references", status: OrderStatusDraft, lines: []OrderLine{calculated(1, "A"), calculated(2, "A")}, expectedErr: ErrInvalidOrderLine},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := NewOrder(uuid.New())
+			require.NoError(t, order.AddItem(250, 1))
+			require.NoError(t, order.SetStatus(tt.status))
+
+			_, err := order.Amend(tt.lines)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if tt.expectedErr != nil && (order.Revision() != 1 || order.GetTotalAmount() != 250) {
+				t.Errorf("Expected a rejected amendment to leave the order unchanged")
+			}
+		})
+	}
+}
@@ -1,36 +1,298 @@
 package entity
 
 import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+
 	"github.com/google/uuid"
 )
 
+// Pack is one revision of a pack definition. Revisions are immutable: a change
+// of size or attributes creates the next revision, effective from a given
+// time, and retiring a pack ends its current revision instead of removing it.
 type Pack struct {
 	BaseEntity
-	size int
+	productID  uuid.UUID
+	size       int64
+	attributes PackAttributes
+	revision   int
+	validFrom  time.Time
+	validTo    time.Time // zero while the revision has no end
+	active     bool
 }
 
-func NewPack(id uuid.UUID, size int) (*Pack, error) {
-	if size <= 0 {
-		return nil, ErrPackSize
+// Dimensions are the outer measurements of a pack in millimetres
+type Dimensions struct {
+	Length int
+	Width  int
+	Height int
+}
+
+// PackAttributes are the optional commercial and physical properties of a pack.
+// A nil field means the value is unknown.
+type PackAttributes struct {
+	UnitCost   *int64 // price of one pack in minor currency units (cents)
+	Weight     *int   // gross weight of one filled pack in grams
+	Dimensions *Dimensions
+}
+
+// Validate checks that every known attribute has a sensible value
+func (a PackAttributes) Validate() error {
+	if a.UnitCost != nil && *a.UnitCost < 0 {
+		return ErrPackCost
+	}
+	if a.Weight != nil && *a.Weight <= 0 {
+		return ErrPackWeight
+	}
+	if a.Dimensions != nil && (a.Dimensions.Length <= 0 || a.Dimensions.Width <= 0 || a.Dimensions.Height <= 0) {
+		return ErrPackDimensions
+	}
+	return nil
+}
+
+// copy returns attributes that share no pointers with a
+func (a PackAttributes) copy() PackAttributes {
+	var c PackAttributes
+	if a.UnitCost != nil {
+		cost := *a.UnitCost
+		c.UnitCost = &cost
+	}
+	if a.Weight != nil {
+		weight := *a.Weight
+		c.Weight = &weight
+	}
+	if a.Dimensions != nil {
+		dimensions := *a.Dimensions
+		c.Dimensions = &dimensions
+	}
+	return c
+}
+
+func NewPack(id uuid.UUID, size int64) (*Pack, error) {
+	return NewPackWithAttributes(id, size, PackAttributes{})
+}
+
+// NewPackWithAttributes creates a pack of the default product with optional
+// cost, weight and dimensions
+func NewPackWithAttributes(id uuid.UUID, size int64, attributes PackAttributes) (*Pack, error) {
+	return NewProductPack(id, DefaultProductID, size, attributes)
+}
+
+// NewProductPack creates a pack of the given product that takes effect now
+func NewProductPack(id, productID uuid.UUID, size int64, attributes PackAttributes) (*Pack, error) {
+	return NewScheduledPack(id, productID, size, attributes, time.Time{})
+}
+
+// NewScheduledPack creates a pack of the given product that takes effect at
+// validFrom, or now when validFrom is zero
+func NewScheduledPack(id, productID uuid.UUID, size int64, attributes PackAttributes, validFrom time.Time) (*Pack, error) {
+	if productID == uuid.Nil {
+		return nil, ErrProductNotFound
+	}
+	if err := validatePack(size, attributes); err != nil {
+		return nil, err
 	}
 
-	return &Pack{
+	pack := &Pack{
 		BaseEntity: NewBaseEntity(id),
+		productID:  productID,
 		size:       size,
-	}, nil
-}
+		attributes: attributes.copy(),
+		revision:   1,
+		active:     true,
+	}
+	from, err := pack.effectiveFrom(validFrom)
+	if err != nil {
+		return nil, err
+	}
+	pack.validFrom = from
 
-func (p *Pack) Size() int {
-	return p.size
+	return pack, nil
 }
 
-func (p *Pack) ChangeSize(size int) error {
+// validatePack checks the size and attributes of a pack revision
+func validatePack(size int64, attributes PackAttributes) error {
 	if size <= 0 {
 		return ErrPackSize
 	}
+	if size > MaxAmount {
+		return ErrAmountTooLarge
+	}
+	return attributes.Validate()
+}
+
+// effectiveFrom returns the time a change takes effect: now when from is zero.
+// Changes cannot take effect in the past, so that the history of the catalog
+// is never rewritten.
+func (p *Pack) effectiveFrom(from time.Time) (time.Time, error) {
+	now := time.Now()
+	if from.IsZero() {
+		return now, nil
+	}
+	if from.Before(now) {
+		return time.Time{}, ErrPackEffectiveDate
+	}
+	return from, nil
+}
+
+// ProductID returns the ID of the product this pack belongs to
+func (p *Pack) ProductID() uuid.UUID {
+	return p.productID
+}
+
+func (p *Pack) Size() int64 {
+	return p.size
+}
+
+// Attributes returns a copy of the pack attributes
+func (p *Pack) Attributes() PackAttributes {
+	return p.attributes.copy()
+}
+
+// UnitCost returns the price of one pack in cents, if known
+func (p *Pack) UnitCost() (int64, bool) {
+	if p.attributes.UnitCost == nil {
+		return 0, false
+	}
+	return *p.attributes.UnitCost, true
+}
+
+// Weight returns the gross weight of one pack in grams, if known
+func (p *Pack) Weight() (int, bool) {
+	if p.attributes.Weight == nil {
+		return 0, false
+	}
+	return *p.attributes.Weight, true
+}
+
+// Dimensions returns the outer measurements of the pack, if known
+func (p *Pack) Dimensions() (Dimensions, bool) {
+	if p.attributes.Dimensions == nil {
+		return Dimensions{}, false
+	}
+	return *p.attributes.Dimensions, true
+}
+
+// Revision returns the version number of the pack, starting at 1 and
+// increasing with every change
+func (p *Pack) Revision() int {
+	return p.revision
+}
+
+// ValidFrom returns the time this revision takes effect
+func (p *Pack) ValidFrom() time.Time {
+	return p.validFrom
+}
+
+// ValidTo returns the time this revision stops being effective, if it ends
+func (p *Pack) ValidTo() (time.Time, bool) {
+	return p.validTo, !p.validTo.IsZero()
+}
+
+// Active reports whether the pack has not been retired
+func (p *Pack) Active() bool {
+	return p.active
+}
+
+// EffectiveAt reports whether this revision was, is or will be in effect at the given time
+func (p *Pack) EffectiveAt(at time.Time) bool {
+	return !at.Before(p.validFrom) && (p.validTo.IsZero() || at.Before(p.validTo))
+}
+
+// ChangeSize creates a revision of the pack with the new size, effective now
+func (p *Pack) ChangeSize(size int64) error {
+	return p.Revise(size, p.attributes, time.Time{})
+}
+
+// ChangeAttributes creates a revision of the pack with new cost, weight and
+// dimensions, effective now
+func (p *Pack) ChangeAttributes(attributes PackAttributes) error {
+	return p.Revise(p.size, attributes, time.Time{})
+}
+
+// Revise makes the pack the next revision with the given size and attributes,
+// effective from the given time, or now when from is zero. A later revision
+// supersedes any earlier one that has not taken effect by then.
+func (p *Pack) Revise(size int64, attributes PackAttributes, from time.Time) error {
+	if !p.active {
+		return ErrPackRetired
+	}
+	if err := validatePack(size, attributes); err != nil {
+		return err
+	}
+	from, err := p.effectiveFrom(from)
+	if err != nil {
+		return err
+	}
 
 	p.size = size
+	p.attributes = attributes.copy()
+	p.revision++
+	p.validFrom = from
+	p.validTo = time.Time{}
+	p.Update()
+
+	return nil
+}
+
+// Deactivate retires the pack from the given time, or now when at is zero.
+// The current revision ends then; a revision that has not taken effect by
+// then never does.
+func (p *Pack) Deactivate(at time.Time) error {
+	if !p.active {
+		return ErrPackRetired
+	}
+	at, err := p.effectiveFrom(at)
+	if err != nil {
+		return err
+	}
+
+	p.active = false
+	p.validTo = at
+	if at.Before(p.validFrom) {
+		p.validTo = p.validFrom
+	}
 	p.Update()
 
 	return nil
 }
+
+// SetRevision restores a stored revision number, its effective dates and
+// whether the pack is active. A zero validTo means the revision has no end.
+func (p *Pack) SetRevision(revision int, validFrom, validTo time.Time, active bool) error {
+	if revision < 1 {
+		return ErrPackRevision
+	}
+	if !validTo.IsZero() && validTo.Before(validFrom) {
+		return ErrPackValidity
+	}
+
+	p.revision = revision
+	p.validFrom = validFrom
+	p.validTo = validTo
+	p.active = active
+	return nil
+}
+
+// PackSetVersion returns the version of a product's pack set given the latest
+// revisions of its active packs. It changes whenever a pack of the set is
+// added, revised or retired, so that replacing the whole set can be made
+// conditional like a write to one pack.
+func PackSetVersion(packs []Pack) int {
+	keys := make([][]byte, len(packs))
+	for i := range packs {
+		id := packs[i].ID()
+		keys[i] = binary.BigEndian.AppendUint64(id[:], uint64(packs[i].Revision()))
+	}
+	sort.Slice(keys, func(i, j int) bool { return string(keys[i]) < string(keys[j]) })
+
+	hash := fnv.New64a()
+	for _, key := range keys {
+		_, _ = hash.Write(key)
+	}
+	// Versions are positive, and fit an int on every platform
+	return int(hash.Sum64()%math.MaxInt32) + 1
+}
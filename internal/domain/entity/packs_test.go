@@ -3,6 +3,7 @@ package entity
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,7 +12,7 @@ func TestNewPack(t *testing.T) {
 	tests := []struct {
 		name        string
 		id          uuid.UUID
-		size        int
+		size        int64
 		expectError bool
 		expectedErr error
 	}{
@@ -41,6 +42,13 @@ func TestNewPack(t *testing.T) {
 			expectError: true,
 			expectedErr: ErrPackSize,
 		},
+		{
+			name:        "Invalid pack above the largest amount",
+			id:          uuid.New(),
+			size:        MaxAmount + 1,
+			expectError: true,
+			expectedErr: ErrAmountTooLarge,
+		},
 	}
 
 	for _, tt := range tests {
@@ -92,7 +100,7 @@ func TestNewPack(t *testing.T) {
 
 func TestPack_Size(t *testing.T) {
 	id := uuid.New()
-	size := 500
+	size := int64(500)
 
 	pack, err := NewPack(id, size)
 	if err != nil {
@@ -106,7 +114,7 @@ func TestPack_Size(t *testing.T) {
 
 func TestPack_ChangeSize(t *testing.T) {
 	id := uuid.New()
-	originalSize := 250
+	originalSize := int64(250)
 
 	pack, err := NewPack(id, originalSize)
 	if err != nil {
@@ -117,7 +125,7 @@ func TestPack_ChangeSize(t *testing.T) {
 
 	tests := []struct {
 		name        string
-		newSize     int
+		newSize     int64
 		expectError bool
 		expectedErr error
 	}{
@@ -217,7 +225,7 @@ func TestPack_MultipleSizeChanges(t *testing.T) {
 		t.Fatalf("Failed to create pack: %v", err)
 	}
 
-	sizes := []int{500, 1000, 750, 2000}
+	sizes := []int64{500, 1000, 750, 2000}
 
 	for _, size := range sizes {
 		err := pack.ChangeSize(size)
@@ -270,7 +278,7 @@ func TestPack_BaseEntityIntegration(t *testing.T) {
 
 func TestPack_SizeImmutabilityOnError(t *testing.T) {
 	id := uuid.New()
-	originalSize := 250
+	originalSize := int64(250)
 	pack, err := NewPack(id, originalSize)
 	if err != nil {
 		t.Fatalf("Failed to create pack: %v", err)
@@ -291,3 +299,279 @@ func TestPack_SizeImmutabilityOnError(t *testing.T) {
 		t.Errorf("Expected UpdatedAt to remain unchanged after failed size change")
 	}
 }
+
+func TestNewPackWithAttributes(t *testing.T) {
+	cost := int64(125)
+	negativeCost := int64(-1)
+	weight := 900
+	zeroWeight := 0
+
+	tests := []struct {
+		name        string
+		attributes  PackAttributes
+		expectedErr error
+	}{
+		{
+			name: "All attributes set",
+			attributes: PackAttributes{
+				UnitCost:   &cost,
+				Weight:     &weight,
+				Dimensions: &Dimensions{Length: 300, Width: 200, Height: 100},
+			},
+		},
+		{
+			name:       "No attributes set",
+			attributes: PackAttributes{},
+		},
+		{
+			name:        "Negative cost",
+			attributes:  PackAttributes{UnitCost: &negativeCost},
+			expectedErr: ErrPackCost,
+		},
+		{
+			name:        "Zero weight",
+			attributes:  PackAttributes{Weight: &zeroWeight},
+			expectedErr: ErrPackWeight,
+		},
+		{
+			name:        "Missing dimension",
+			attributes:  PackAttributes{Dimensions: &Dimensions{Length: 300, Width: 200}},
+			expectedErr: ErrPackDimensions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack, err := NewPackWithAttributes(uuid.New(), 250, tt.attributes)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+				if pack != nil {
+					t.Errorf("Expected pack to be nil when error occurs, got %v", pack)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			unitCost, hasCost := pack.UnitCost()
+			if hasCost != (tt.attributes.UnitCost != nil) || (hasCost && unitCost != *tt.attributes.UnitCost) {
+				t.Errorf("Expected unit cost %v, got %d (set: %v)", tt.attributes.UnitCost, unitCost, hasCost)
+			}
+
+			packWeight, hasWeight := pack.Weight()
+			if hasWeight != (tt.attributes.Weight != nil) || (hasWeight && packWeight != *tt.attributes.Weight) {
+				t.Errorf("Expected weight %v, got %d (set: %v)", tt.attributes.Weight, packWeight, hasWeight)
+			}
+
+			dimensions, hasDimensions := pack.Dimensions()
+			if hasDimensions != (tt.attributes.Dimensions != nil) || (hasDimensions && dimensions != *tt.attributes.Dimensions) {
+				t.Errorf("Expected dimensions %v, got %v (set: %v)", tt.attributes.Dimensions, dimensions, hasDimensions)
+			}
+		})
+	}
+}
+
+func TestPack_ChangeAttributes(t *testing.T) {
+	pack, err := NewPack(uuid.New(), 250)
+	if err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+
+	cost := int64(99)
+	if err := pack.ChangeAttributes(PackAttributes{UnitCost: &cost}); err != nil {
+		t.Fatalf("Unexpected error changing attributes: %v", err)
+	}
+
+	cost = 1
+	if unitCost, _ := pack.UnitCost(); unitCost != 99 {
+		t.Errorf("Expected pack to keep its own copy of the unit cost 99, got %d", unitCost)
+	}
+
+	zeroWeight := 0
+	if err := pack.ChangeAttributes(PackAttributes{Weight: &zeroWeight}); !errors.Is(err, ErrPackWeight) {
+		t.Errorf("Expected ErrPackWeight, got %v", err)
+	}
+
+	if unitCost, ok := pack.UnitCost(); !ok || unitCost != 99 {
+		t.Errorf("Expected attributes to remain unchanged after failed change, got cost %d (set: %v)", unitCost, ok)
+	}
+}
+
+func TestNewProductPack(t *testing.T) {
+	productID := uuid.New()
+	pack, err := NewProductPack(uuid.New(), productID, 250, PackAttributes{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pack.ProductID() != productID {
+		t.Errorf("Expected product ID %s, got %s", productID, pack.ProductID())
+	}
+
+	if _, err := NewProductPack(uuid.New(), uuid.Nil, 250, PackAttributes{}); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Expected ErrProductNotFound for a pack without product, got %v", err)
+	}
+
+	defaultPack, _ := NewPack(uuid.New(), 250)
+	if defaultPack.ProductID() != DefaultProductID {
+		t.Errorf("Expected NewPack to use the default product, got %s", defaultPack.ProductID())
+	}
+}
+
+func TestPack_Revise(t *testing.T) {
+	pack, err := NewPack(uuid.New(), 250)
+	if err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	if pack.Revision() != 1 || !pack.Active() || !pack.EffectiveAt(time.Now()) {
+		t.Fatalf("Expected a new pack to be active revision 1 in effect now, got revision %d (active: %v)", pack.Revision(), pack.Active())
+	}
+	if _, ends := pack.ValidTo(); ends {
+		t.Error("Expected a new pack to have no end date")
+	}
+
+	monday := time.Now().Add(72 * time.Hour)
+	if err := pack.Revise(300, PackAttributes{}, monday); err != nil {
+		t.Fatalf("Unexpected error scheduling a revision: %v", err)
+	}
+	if pack.Revision() != 2 || pack.Size() != 300 || !pack.ValidFrom().Equal(monday) {
+		t.Errorf("Expected revision 2 of size 300 from %v, got revision %d of size %d from %v", monday, pack.Revision(), pack.Size(), pack.ValidFrom())
+	}
+	if pack.EffectiveAt(time.Now()) || !pack.EffectiveAt(monday) {
+		t.Error("Expected the scheduled revision to take effect on Monday only")
+	}
+
+	if err := pack.ChangeSize(400); err != nil {
+		t.Fatalf("Unexpected error changing size: %v", err)
+	}
+	if pack.Revision() != 3 || !pack.EffectiveAt(time.Now()) {
+		t.Errorf("Expected the size change to be revision 3 in effect now, got revision %d", pack.Revision())
+	}
+
+	tests := []struct {
+		name        string
+		size        int64
+		from        time.Time
+		expectedErr error
+	}{
+		{name: "Past effective date", size: 500, from: time.Now().Add(-time.Hour), expectedErr: ErrPackEffectiveDate},
+		{name: "Invalid size", size: 0, expectedErr: ErrPackSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := pack.Revise(tt.size, PackAttributes{}, tt.from); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if pack.Revision() != 3 || pack.Size() != 400 {
+				t.Errorf("Expected revision 3 of size 400 to remain after a failed revision, got revision %d of size %d", pack.Revision(), pack.Size())
+			}
+		})
+	}
+}
+
+func TestPack_Deactivate(t *testing.T) {
+	pack, err := NewPack(uuid.New(), 250)
+	if err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+
+	if err := pack.Deactivate(time.Now().Add(-time.Hour)); !errors.Is(err, ErrPackEffectiveDate) {
+		t.Errorf("Expected ErrPackEffectiveDate for a retirement in the past, got %v", err)
+	}
+
+	friday := time.Now().Add(24 * time.Hour)
+	if err := pack.Deactivate(friday); err != nil {
+		t.Fatalf("Unexpected error retiring the pack: %v", err)
+	}
+	if validTo, ends := pack.ValidTo(); pack.Active() || !ends || !validTo.Equal(friday) {
+		t.Errorf("Expected the pack to be retired from %v, got %v (active: %v)", friday, validTo, pack.Active())
+	}
+	if !pack.EffectiveAt(time.Now()) || pack.EffectiveAt(friday) {
+		t.Error("Expected the pack to stay in effect until Friday")
+	}
+
+	if err := pack.Deactivate(time.Time{}); !errors.Is(err, ErrPackRetired) {
+		t.Errorf("Expected ErrPackRetired when retiring twice, got %v", err)
+	}
+	if err := pack.ChangeSize(500); !errors.Is(err, ErrPackRetired) {
+		t.Errorf("Expected ErrPackRetired when changing a retired pack, got %v", err)
+	}
+}
+
+func TestPack_DeactivateScheduled(t *testing.T) {
+	monday := time.Now().Add(72 * time.Hour)
+	pack, err := NewScheduledPack(uuid.New(), DefaultProductID, 250, PackAttributes{}, monday)
+	if err != nil {
+		t.Fatalf("Failed to create scheduled pack: %v", err)
+	}
+
+	// Retiring a pack before it takes effect means it never does
+	if err := pack.Deactivate(time.Time{}); err != nil {
+		t.Fatalf("Unexpected error retiring the pack: %v", err)
+	}
+	if validTo, _ := pack.ValidTo(); !validTo.Equal(monday) || pack.EffectiveAt(monday) {
+		t.Errorf("Expected the pack never to take effect, got %v to %v", pack.ValidFrom(), validTo)
+	}
+}
+
+func TestNewScheduledPack(t *testing.T) {
+	if _, err := NewScheduledPack(uuid.New(), DefaultProductID, 250, PackAttributes{}, time.Now().Add(-time.Minute)); !errors.Is(err, ErrPackEffectiveDate) {
+		t.Errorf("Expected ErrPackEffectiveDate for a pack effective in the past, got %v", err)
+	}
+
+	before := time.Now()
+	pack, err := NewScheduledPack(uuid.New(), DefaultProductID, 250, PackAttributes{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pack.ValidFrom().Before(before) || !pack.EffectiveAt(time.Now()) {
+		t.Errorf("Expected a pack without an effective date to take effect now, got %v", pack.ValidFrom())
+	}
+}
+
+func TestPack_SetRevision(t *testing.T) {
+	pack, _ := NewPack(uuid.New(), 250)
+	from := time.Now().Add(-48 * time.Hour)
+	to := from.Add(24 * time.Hour)
+
+	if err := pack.SetRevision(3, from, to, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pack.Revision() != 3 || pack.Active() || !pack.EffectiveAt(from) || pack.EffectiveAt(to) {
+		t.Errorf("Expected retired revision 3 in effect from %v to %v, got revision %d", from, to, pack.Revision())
+	}
+
+	if err := pack.SetRevision(0, from, time.Time{}, true); !errors.Is(err, ErrPackRevision) {
+		t.Errorf("Expected ErrPackRevision, got %v", err)
+	}
+	if err := pack.SetRevision(4, to, from, true); !errors.Is(err, ErrPackValidity) {
+		t.Errorf("Expected ErrPackValidity, got %v", err)
+	}
+}
+
+func TestPackSetVersion(t *testing.T) {
+	first, _ := NewPack(uuid.New(), 250)
+	second, _ := NewPack(uuid.New(), 500)
+
+	version := PackSetVersion([]Pack{*first, *second})
+	if version < 1 {
+		t.Errorf("Expected a positive version, got %d", version)
+	}
+	if PackSetVersion([]Pack{*second, *first}) != version {
+		t.Errorf("Expected the version not to depend on the order of the packs")
+	}
+	if PackSetVersion([]Pack{*first}) == version {
+		t.Errorf("Expected removing a pack to change the version")
+	}
+
+	if err := second.Revise(600, PackAttributes{}, time.Time{}); err != nil {
+		t.Fatalf("Failed to revise pack: %v", err)
+	}
+	if PackSetVersion([]Pack{*first, *second}) == version {
+		t.Errorf("Expected revising a pack to change the version")
+	}
+}
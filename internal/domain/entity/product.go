@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// MaxProductNameLength is the longest product name, in characters
+const MaxProductNameLength = 100
+
+// DefaultProductID identifies the product that owns the packs created before
+// products were introduced. Requests that name no product use it.
+var DefaultProductID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// Product is a sellable item with its own set of pack sizes
+type Product struct {
+	BaseEntity
+	name string
+}
+
+// NewProduct creates a product with the given name
+func NewProduct(id uuid.UUID, name string) (*Product, error) {
+	name, err := normalizeProductName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Product{
+		BaseEntity: NewBaseEntity(id),
+		name:       name,
+	}, nil
+}
+
+func (p *Product) Name() string {
+	return p.name
+}
+
+// IsDefault reports whether this is the product that owns the original packs
+func (p *Product) IsDefault() bool {
+	return p.ID() == DefaultProductID
+}
+
+// Rename changes the name of the product
+func (p *Product) Rename(name string) error {
+	name, err := normalizeProductName(name)
+	if err != nil {
+		return err
+	}
+
+	p.name = name
+	p.Update()
+
+	return nil
+}
+
+// normalizeProductName trims the name and checks its length
+func normalizeProductName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxProductNameLength {
+		return "", ErrInvalidProductName
+	}
+	return name, nil
+}
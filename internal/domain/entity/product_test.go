@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewProduct(t *testing.T) {
+	tests := []struct {
+		name         string
+		productName  string
+		expectedName string
+		expectedErr  error
+	}{
+		{
+			name:         "Valid product",
+			productName:  "Widgets",
+			expectedName: "Widgets",
+		},
+		{
+			name:         "Name is trimmed",
+			productName:  "  Gadgets \n",
+			expectedName: "Gadgets",
+		},
+		{
+			name:         "Longest name",
+			productName:  strings.Repeat("ü", MaxProductNameLength),
+			expectedName: strings.Repeat("ü", MaxProductNameLength),
+		},
+		{
+			name:        "Empty name",
+			productName: "",
+			expectedErr: ErrInvalidProductName,
+		},
+		{
+			name:        "Blank name",
+			productName: "   ",
+			expectedErr: ErrInvalidProductName,
+		},
+		{
+			name:        "Name too long",
+			productName: strings.Repeat("a", MaxProductNameLength+1),
+			expectedErr: ErrInvalidProductName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := uuid.New()
+			product, err := NewProduct(id, tt.productName)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+				if product != nil {
+					t.Errorf("Expected product to be nil when error occurs, got %v", product)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if product.ID() != id {
+				t.Errorf("Expected product ID %s, got %s", id, product.ID())
+			}
+			if product.Name() != tt.expectedName {
+				t.Errorf("Expected product name %q, got %q", tt.expectedName, product.Name())
+			}
+			if product.IsDefault() {
+				t.Errorf("Expected product with random ID not to be the default product")
+			}
+		})
+	}
+}
+
+func TestProduct_Rename(t *testing.T) {
+	product, err := NewProduct(DefaultProductID, "Default")
+	if err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	if !product.IsDefault() {
+		t.Errorf("Expected product with DefaultProductID to be the default product")
+	}
+
+	if err := product.Rename(" Widgets "); err != nil {
+		t.Fatalf("Unexpected error renaming product: %v", err)
+	}
+	if product.Name() != "Widgets" {
+		t.Errorf("Expected product name Widgets, got %q", product.Name())
+	}
+
+	if err := product.Rename(""); !errors.Is(err, ErrInvalidProductName) {
+		t.Errorf("Expected ErrInvalidProductName, got %v", err)
+	}
+	if product.Name() != "Widgets" {
+		t.Errorf("Expected name to remain unchanged after failed rename, got %q", product.Name())
+	}
+}
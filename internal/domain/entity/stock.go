@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+)
+
+// StockLevel is the number of packs of one size physically on hand.
+// It shares its ID with the pack it counts.
+type StockLevel struct {
+	BaseEntity
+	packSize int64
+	quantity int64
+}
+
+// NewStockLevel creates a stock level for the given pack
+func NewStockLevel(packID uuid.UUID, packSize, quantity int64) (*StockLevel, error) {
+	if packSize <= 0 {
+		return nil, ErrPackSize
+	}
+	if quantity < 0 {
+		return nil, ErrInvalidStock
+	}
+
+	return &StockLevel{
+		BaseEntity: NewBaseEntity(packID),
+		packSize:   packSize,
+		quantity:   quantity,
+	}, nil
+}
+
+// PackID returns the ID of the pack this stock level counts
+func (s *StockLevel) PackID() uuid.UUID {
+	return s.ID()
+}
+
+// PackSize returns the size of the pack this stock level counts
+func (s *StockLevel) PackSize() int64 {
+	return s.packSize
+}
+
+// Quantity returns the number of packs on hand
+func (s *StockLevel) Quantity() int64 {
+	return s.quantity
+}
+
+// SetQuantity replaces the number of packs on hand
+func (s *StockLevel) SetQuantity(quantity int64) error {
+	if quantity < 0 {
+		return ErrInvalidStock
+	}
+
+	s.quantity = quantity
+	s.Update()
+
+	return nil
+}
+
+// Adjust adds delta packs to the stock, or removes them when delta is negative
+func (s *StockLevel) Adjust(delta int64) error {
+	quantity, ok := CheckedAdd(s.quantity, delta)
+	if !ok {
+		return ErrInvalidStock
+	}
+	if quantity < 0 {
+		return ErrInsufficientStock
+	}
+
+	s.quantity = quantity
+	s.Update()
+
+	return nil
+}
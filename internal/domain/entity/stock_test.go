@@ -0,0 +1,125 @@
+package entity
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewStockLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		packSize    int64
+		quantity    int64
+		expectedErr error
+	}{
+		{
+			name:     "Valid stock level",
+			packSize: 250,
+			quantity: 10,
+		},
+		{
+			name:     "Empty stock is valid",
+			packSize: 250,
+			quantity: 0,
+		},
+		{
+			name:        "Negative quantity",
+			packSize:    250,
+			quantity:    -1,
+			expectedErr: ErrInvalidStock,
+		},
+		{
+			name:        "Invalid pack size",
+			packSize:    0,
+			quantity:    10,
+			expectedErr: ErrPackSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packID := uuid.New()
+			stock, err := NewStockLevel(packID, tt.packSize, tt.quantity)
+
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+				if stock != nil {
+					t.Errorf("Expected stock level to be nil when error occurs, got %v", stock)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if stock.PackID() != packID {
+				t.Errorf("Expected pack ID %s, got %s", packID, stock.PackID())
+			}
+			if stock.PackSize() != tt.packSize {
+				t.Errorf("Expected pack size %d, got %d", tt.packSize, stock.PackSize())
+			}
+			if stock.Quantity() != tt.quantity {
+				t.Errorf("Expected quantity %d, got %d", tt.quantity, stock.Quantity())
+			}
+		})
+	}
+}
+
+func TestStockLevel_Adjust(t *testing.T) {
+	tests := []struct {
+		name        string
+		delta       int64
+		expected    int64
+		expectedErr error
+	}{
+		{name: "Receive packs", delta: 5, expected: 15},
+		{name: "Ship packs", delta: -4, expected: 6},
+		{name: "Ship every pack", delta: -10, expected: 0},
+		{name: "Ship more than on hand", delta: -11, expected: 10, expectedErr: ErrInsufficientStock},
+		{name: "Receive more than can be counted", delta: math.MaxInt64, expected: 10, expectedErr: ErrInvalidStock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stock, err := NewStockLevel(uuid.New(), 500, 10)
+			if err != nil {
+				t.Fatalf("Failed to create stock level: %v", err)
+			}
+
+			err = stock.Adjust(tt.delta)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if stock.Quantity() != tt.expected {
+				t.Errorf("Expected quantity %d, got %d", tt.expected, stock.Quantity())
+			}
+		})
+	}
+}
+
+func TestStockLevel_SetQuantity(t *testing.T) {
+	stock, err := NewStockLevel(uuid.New(), 500, 10)
+	if err != nil {
+		t.Fatalf("Failed to create stock level: %v", err)
+	}
+
+	if err := stock.SetQuantity(3); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if stock.Quantity() != 3 {
+		t.Errorf("Expected quantity 3, got %d", stock.Quantity())
+	}
+
+	if err := stock.SetQuantity(-1); !errors.Is(err, ErrInvalidStock) {
+		t.Errorf("Expected ErrInvalidStock, got %v", err)
+	}
+	if stock.Quantity() != 3 {
+		t.Errorf("Expected quantity to remain 3 after error, got %d", stock.Quantity())
+	}
+}
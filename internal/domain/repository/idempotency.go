@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRepository stores the responses of requests made with an
+// idempotency key. Records are unique per scope and key.
+type IdempotencyRepository interface {
+	// Claim stores record unless a live record exists for its scope and key.
+	// It returns the live record and false when the key is already taken.
+	// Records that expired before record.CreatedAt do not count, so a claim
+	// whose lease ran out without a response can be taken over.
+	Claim(ctx context.Context, record IdempotencyRecord) (*IdempotencyRecord, bool, error)
+	// Complete stores the response of a claimed record
+	Complete(ctx context.Context, record IdempotencyRecord) error
+	// Release forgets a claimed record so the key can be used again
+	Release(ctx context.Context, scope, key string) error
+}
+
+// IdempotencyRecord is a claimed idempotency key and, once the request has
+// finished, the response it produced
+type IdempotencyRecord struct {
+	// Scope is the endpoint the key was used on
+	Scope string
+	Key   string
+	// Fingerprint identifies the request payload the key was first used with
+	Fingerprint string
+
+	// Completed is false while the first request is still being handled
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	Headers     map[string]string
+	Body        []byte
+
+	CreatedAt time.Time
+	// ExpiresAt ends the lease of a claim, or the retention of a response
+	ExpiresAt time.Time
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
 	"github.com/google/uuid"
@@ -9,7 +10,64 @@ import (
 
 // OrderRepository domain interface
 type OrderRepository interface {
-	List(ctx context.Context) []entity.Order
+	List(ctx context.Context, query OrderQuery) (*OrderPage, error)
 	Get(ctx context.Context, id uuid.UUID) (*entity.Order, error)
 	Create(ctx context.Context, order *entity.Order) error
+	// UpdateStatus stores a status change made with entity.Order.TransitionTo.
+	// It returns entity.ErrInvalidStatusTransition when the stored order is no
+	// longer in change.From and entity.ErrOrderModified when it is no longer at
+	// the version before order.Version(). Cancelled orders return their packs
+	// to stock.
+	UpdateStatus(ctx context.Context, order *entity.Order, change entity.OrderStatusChange) error
+	// Update stores an amended order and the version it replaced. Items and
+	// stock reservations are swapped in the same transaction. It returns
+	// entity.ErrOrderModified when the stored order is no longer at the
+	// version before order.Version().
+	Update(ctx context.Context, order *entity.Order, previous entity.OrderRevision) error
+	// Revisions returns the replaced versions of an order, oldest first
+	Revisions(ctx context.Context, id uuid.UUID) ([]entity.OrderRevision, error)
+	// StatusHistory returns the status changes of an order, oldest first
+	StatusHistory(ctx context.Context, id uuid.UUID) ([]entity.OrderStatusChange, error)
+}
+
+// OrderSortField names the value orders are sorted by
+type OrderSortField string
+
+const (
+	// OrderSortCreatedAt sorts orders by creation time
+	OrderSortCreatedAt OrderSortField = "created_at"
+	// OrderSortAmount sorts orders by requested amount
+	OrderSortAmount OrderSortField = "amount"
+)
+
+// OrderCursor marks the last order of a page; the next page starts right after it
+type OrderCursor struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Amount    int64
+}
+
+// OrderQuery selects one page of orders. Nil filters are not applied.
+// Amounts are requested amounts, see entity.Order.GetRequestedAmount.
+type OrderQuery struct {
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MinAmount   *int64
+	MaxAmount   *int64
+	ProductID   *uuid.UUID
+	// PackSize keeps orders that ship at least one pack of this size
+	PackSize *int64
+
+	SortBy     OrderSortField
+	Descending bool
+	// After continues a listing from the cursor of the previous page
+	After *OrderCursor
+	Limit int
+}
+
+// OrderPage is one page of an order listing, with items loaded
+type OrderPage struct {
+	Orders []entity.Order
+	// Next is set when more orders follow this page
+	Next *OrderCursor
 }
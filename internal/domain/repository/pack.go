@@ -2,17 +2,51 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
 	"github.com/google/uuid"
 )
 
-// PackRepository domain interface
+// PackCatalog names the packs of a product in effect at a given time
+type PackCatalog struct {
+	ProductID uuid.UUID
+	At        time.Time
+}
+
+// PackRepository domain interface.
+// Every revision of a pack is kept with the time it was in effect.
 type PackRepository interface {
-	List(ctx context.Context) []entity.Pack
+	// List returns the packs of every product that are in effect now
+	List(ctx context.Context) ([]entity.Pack, error)
+	// ListByProduct returns the packs of one product that are in effect now, in
+	// ascending order by size, or entity.ErrProductNotFound when the product
+	// does not exist
+	ListByProduct(ctx context.Context, productID uuid.UUID) ([]entity.Pack, error)
+	// ListAsOf returns the revisions of one product's packs that were, or are
+	// scheduled to be, in effect at the given time, in ascending order by size
+	ListAsOf(ctx context.Context, productID uuid.UUID, at time.Time) ([]entity.Pack, error)
+	// ListAsOfMany is ListAsOf for many products and times at once. The
+	// result holds the packs of each catalog in the order the catalogs are given.
+	ListAsOfMany(ctx context.Context, catalogs []PackCatalog) ([][]entity.Pack, error)
+	// ListActive returns the latest revisions of one product's active packs,
+	// including those that have not taken effect yet, in ascending order by size
+	ListActive(ctx context.Context, productID uuid.UUID) ([]entity.Pack, error)
+	// Get returns the latest revision of an active pack, which may not be in
+	// effect yet
 	Get(ctx context.Context, id uuid.UUID) (*entity.Pack, error)
 	Create(ctx context.Context, pack *entity.Pack) error
+	// Update stores the pack's new revision and ends the earlier ones when it
+	// takes effect
 	Update(ctx context.Context, pack *entity.Pack) error
+	// Delete retires a deactivated pack. Its revisions are kept.
 	Delete(ctx context.Context, pack *entity.Pack) error
-	ExistsBySize(ctx context.Context, size int) (bool, error)
+	// ReplaceSet retires the deactivated packs and creates the new ones of a
+	// product in one transaction, so that no reader sees a partly replaced pack
+	// set. It fails with entity.ErrPackModified unless the product's pack set
+	// is still at version, see entity.PackSetVersion.
+	ReplaceSet(ctx context.Context, productID uuid.UUID, version int, created, retired []*entity.Pack) error
+	// ExistsBySize reports whether a pack of the product other than exceptID
+	// has a revision of this size in effect at the given time or later
+	ExistsBySize(ctx context.Context, productID uuid.UUID, size int64, at time.Time, exceptID uuid.UUID) (bool, error)
 }
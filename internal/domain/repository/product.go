@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/google/uuid"
+)
+
+// ProductRepository domain interface
+type ProductRepository interface {
+	List(ctx context.Context) []entity.Product
+	Get(ctx context.Context, id uuid.UUID) (*entity.Product, error)
+	Create(ctx context.Context, product *entity.Product) error
+	Update(ctx context.Context, product *entity.Product) error
+	// Delete removes a product with its retired packs; it returns
+	// entity.ErrProductInUse while the product still has active packs or orders
+	Delete(ctx context.Context, product *entity.Product) error
+	ExistsByName(ctx context.Context, name string) (bool, error)
+}
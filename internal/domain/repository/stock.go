@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/google/uuid"
+)
+
+// StockRepository domain interface.
+// Packs without a stock level are not tracked and count as unlimited.
+type StockRepository interface {
+	List(ctx context.Context) ([]entity.StockLevel, error)
+	Get(ctx context.Context, packID uuid.UUID) (*entity.StockLevel, error)
+	Save(ctx context.Context, stock *entity.StockLevel) error
+	Adjust(ctx context.Context, packID uuid.UUID, delta int64) (*entity.StockLevel, error)
+	Delete(ctx context.Context, packID uuid.UUID) error
+}
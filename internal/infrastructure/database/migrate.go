@@ -0,0 +1,421 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// versionTable records the applied migrations. It is the table goose keeps,
+// so databases migrated with the goose CLI carry on where they left off.
+const versionTable = "goose_db_version"
+
+// migrationLockID is the key of the advisory lock held while migrating, so
+// replicas starting together apply each migration once
+const migrationLockID int64 = 7_146_381_920_554_187_001
+
+// migrationFile matches migration file names, e.g. 001_create_packs_table.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_\w+\.sql$`)
+
+// Migration is a schema migration parsed from a goose SQL file
+type Migration struct {
+	Version int64
+	Name    string
+	// up and down are the statements of each section, run one at a time
+	up   []string
+	down []string
+	// noTransaction is set by "-- +goose NO TRANSACTION", for statements
+	// PostgreSQL refuses to run in a transaction
+	noTransaction bool
+}
+
+// MigrationStatus tells whether a migration has been applied, and when
+type MigrationStatus struct {
+	Migration Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations of a file system to a database
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+	logger     *logger.Logger
+}
+
+// NewMigrator creates a migrator for the *.sql migrations at the root of fsys
+func NewMigrator(db *sqlx.DB, fsys fs.FS, logger *logger.Logger) (*Migrator, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	seen := make(map[int64]string, len(names))
+	for _, name := range names {
+		match := migrationFile.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>.sql", name)
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s has an invalid version", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, name, version)
+		}
+		seen[version] = name
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		migration, err := parseMigration(version, name, string(content))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{db: db, migrations: migrations, logger: logger}, nil
+}
+
+// parseMigration splits a goose SQL file into the statements of its up and
+// down sections. As with goose, a statement ends with the line that ends in a
+// semicolon, unless it is wrapped in -- +goose StatementBegin and
+// -- +goose StatementEnd, as function bodies and DO blocks must be.
+func parseMigration(version int64, name, content string) (Migration, error) {
+	migration := Migration{Version: version, Name: name}
+
+	var section *[]string
+	var statement strings.Builder
+	inBlock := false
+	// flush ends the statement being read, if it has any SQL
+	flush := func() {
+		if sql := strings.TrimSpace(statement.String()); sql != "" && section != nil {
+			*section = append(*section, sql)
+		}
+		statement.Reset()
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "-- +goose Up"), strings.HasPrefix(trimmed, "-- +goose Down"):
+			if inBlock {
+				return migration, fmt.Errorf("migration %s: line %d: section starts before -- +goose StatementEnd", name, number)
+			}
+			flush()
+			section = &migration.up
+			if strings.HasPrefix(trimmed, "-- +goose Down") {
+				section = &migration.down
+			}
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose NO TRANSACTION"):
+			migration.noTransaction = true
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose StatementBegin"):
+			if section == nil || inBlock {
+				return migration, fmt.Errorf("migration %s: line %d: unexpected -- +goose StatementBegin", name, number)
+			}
+			flush()
+			inBlock = true
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose StatementEnd"):
+			if !inBlock {
+				return migration, fmt.Errorf("migration %s: line %d: -- +goose StatementEnd without StatementBegin", name, number)
+			}
+			flush()
+			inBlock = false
+			continue
+		case strings.HasPrefix(trimmed, "-- +goose"):
+			return migration, fmt.Errorf("migration %s: line %d: unsupported annotation %q", name, number, trimmed)
+		}
+		if section == nil {
+			continue
+		}
+		// Comments between statements are not statements of their own
+		if statement.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+
+		statement.WriteString(line)
+		statement.WriteByte('\n')
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return migration, fmt.Errorf("failed to read migration %s: %w", name, err)
+	}
+	if inBlock {
+		return migration, fmt.Errorf("migration %s: -- +goose StatementBegin without StatementEnd", name)
+	}
+	flush()
+
+	if len(migration.up) == 0 {
+		return migration, fmt.Errorf("migration %s has no -- +goose Up section", name)
+	}
+	return migration, nil
+}
+
+// Up applies every pending migration in version order and returns how many
+// were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.Applied {
+				continue
+			}
+			if err := m.apply(ctx, conn, status.Migration, true); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the latest applied migration. It returns nil when no
+// migration is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var rolledBack *Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(statuses) - 1; i >= 0; i-- {
+			if !statuses[i].Applied {
+				continue
+			}
+			migration := statuses[i].Migration
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			rolledBack = &migration
+			return nil
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Reset rolls back every applied migration, latest first, and returns how
+// many were rolled back
+func (m *Migrator) Reset(ctx context.Context) (int, error) {
+	rolledBack := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(statuses) - 1; i >= 0; i-- {
+			if !statuses[i].Applied {
+				continue
+			}
+			if err := m.apply(ctx, conn, statuses[i].Migration, false); err != nil {
+				return err
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status reports every known migration in version order
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		var err error
+		statuses, err = m.status(ctx, conn)
+		return err
+	})
+	return statuses, err
+}
+
+// locked runs fn on a single connection holding the migration lock, creating
+// the version table first if needed
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			m.logger.Error("Failed to release migration connection: %v", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// The lock belongs to the session, so it must be released even when
+		// ctx has ended
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			m.logger.Error("Failed to release migration lock: %v", err)
+		}
+	}()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureVersionTable creates the version table the way goose does, with the
+// row for version 0 that goose expects
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	var exists bool
+	err := conn.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", versionTable).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to look up %s: %w", versionTable, err)
+	}
+	if exists {
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, `CREATE TABLE `+versionTable+` (
+		id SERIAL PRIMARY KEY,
+		version_id BIGINT NOT NULL,
+		is_applied BOOLEAN NOT NULL,
+		tstamp TIMESTAMP DEFAULT NOW()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", versionTable, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO `+versionTable+` (version_id, is_applied) VALUES (0, TRUE)`)
+	if err != nil {
+		return fmt.Errorf("failed to initialise %s: %w", versionTable, err)
+	}
+	return tx.Commit()
+}
+
+// status reads which migrations are applied. A version is applied when its
+// latest row says so; goose marked rollbacks with is_applied = FALSE before
+// it took to deleting the rows.
+func (m *Migrator) status(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT DISTINCT ON (version_id) version_id, is_applied, tstamp
+		FROM `+versionTable+`
+		WHERE version_id > 0
+		ORDER BY version_id, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", versionTable, err)
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var tstamp sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &tstamp); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", versionTable, err)
+		}
+		if isApplied {
+			appliedAt[version] = tstamp.Time
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", versionTable, err)
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		at, applied := appliedAt[migration.Version]
+		statuses = append(statuses, MigrationStatus{Migration: migration, Applied: applied, AppliedAt: at})
+		delete(appliedAt, migration.Version)
+	}
+	for version := range appliedAt {
+		m.logger.Warn("Migration %d is applied but not known to this build", version)
+	}
+	return statuses, nil
+}
+
+// apply runs the up or down section of a migration and records the result,
+// both in one transaction unless the migration opts out
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	direction := "up"
+	statements := migration.up
+	record := `INSERT INTO ` + versionTable + ` (version_id, is_applied) VALUES ($1, TRUE)`
+	if !up {
+		direction = "down"
+		statements = migration.down
+		record = `DELETE FROM ` + versionTable + ` WHERE version_id = $1`
+		if len(statements) == 0 {
+			return fmt.Errorf("migration %s has no -- +goose Down section", migration.Name)
+		}
+	}
+
+	m.logger.Info("Migrating %s %s", direction, migration.Name)
+	start := time.Now()
+
+	if migration.noTransaction {
+		for _, statement := range statements {
+			if _, err := conn.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to migrate %s %s: %w", direction, migration.Name, err)
+			}
+		}
+		if _, err := conn.ExecContext(ctx, record, migration.Version); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+		}
+	} else {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() {
+			if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+				m.logger.Error("Failed to roll back migration %s: %v", migration.Name, err)
+			}
+		}()
+
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to migrate %s %s: %w", direction, migration.Name, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, record, migration.Version); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %s: %w", migration.Name, err)
+		}
+	}
+
+	m.logger.Info("Migrated %s %s in %v", direction, migration.Name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
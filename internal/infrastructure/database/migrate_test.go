@@ -0,0 +1,214 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Strahinja-Polovina/packs/migrations"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+)
+
+func TestParseMigration(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedUp    []string
+		expectedDown  []string
+		noTransaction bool
+		expectedErr   string
+	}{
+		{
+			name: "Up and down",
+			content: `-- +goose Up
+CREATE TABLE packs (id UUID PRIMARY KEY);
+CREATE INDEX idx_packs ON packs(id);
+
+-- +goose Down
+DROP TABLE packs;
+`,
+			expectedUp:   []string{"CREATE TABLE packs (id UUID PRIMARY KEY);", "CREATE INDEX idx_packs ON packs(id);"},
+			expectedDown: []string{"DROP TABLE packs;"},
+		},
+		{
+			name: "Statement over several lines",
+			content: `-- +goose Up
+-- The comment is not a statement
+ALTER TABLE packs
+    ADD COLUMN size BIGINT,
+    ADD COLUMN active BOOLEAN;
+`,
+			expectedUp: []string{"ALTER TABLE packs\n    ADD COLUMN size BIGINT,\n    ADD COLUMN active BOOLEAN;"},
+		},
+		{
+			name: "Statement block",
+			content: `-- +goose Up
+-- +goose StatementBegin
+DO $$
+BEGIN
+    UPDATE packs SET active = TRUE;
+END
+$$;
+-- +goose StatementEnd
+SELECT 1;
+
+-- +goose Down
+SELECT 2;
+`,
+			expectedUp:   []string{"DO $$\nBEGIN\n    UPDATE packs SET active = TRUE;\nEND\n$$;", "SELECT 1;"},
+			expectedDown: []string{"SELECT 2;"},
+		},
+		{
+			name: "No transaction",
+			content: `-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY idx_packs ON packs(id);
+`,
+			expectedUp:    []string{"CREATE INDEX CONCURRENTLY idx_packs ON packs(id);"},
+			noTransaction: true,
+		},
+		{
+			name:        "Missing up section",
+			content:     "-- +goose Down\nDROP TABLE packs;\n",
+			expectedErr: "has no -- +goose Up section",
+		},
+		{
+			name:        "Empty file",
+			content:     "",
+			expectedErr: "has no -- +goose Up section",
+		},
+		{
+			name:        "Unterminated statement block",
+			content:     "-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n",
+			expectedErr: "StatementBegin without StatementEnd",
+		},
+		{
+			name:        "Statement end without begin",
+			content:     "-- +goose Up\nSELECT 1;\n-- +goose StatementEnd\n",
+			expectedErr: "StatementEnd without StatementBegin",
+		},
+		{
+			name:        "Section inside statement block",
+			content:     "-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n-- +goose Down\nSELECT 2;\n",
+			expectedErr: "section starts before -- +goose StatementEnd",
+		},
+		{
+			name:        "Unsupported annotation",
+			content:     "-- +goose Up\n-- +goose ENVSUB ON\nSELECT 1;\n",
+			expectedErr: "unsupported annotation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migration, err := parseMigration(1, "001_test.sql", tt.content)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(migration.up, tt.expectedUp) {
+				t.Errorf("Expected up statements %q, got %q", tt.expectedUp, migration.up)
+			}
+			if !reflect.DeepEqual(migration.down, tt.expectedDown) {
+				t.Errorf("Expected down statements %q, got %q", tt.expectedDown, migration.down)
+			}
+			if migration.noTransaction != tt.noTransaction {
+				t.Errorf("Expected noTransaction %t, got %t", tt.noTransaction, migration.noTransaction)
+			}
+		})
+	}
+}
+
+func TestNewMigrator(t *testing.T) {
+	file := func(content string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(content)}
+	}
+	valid := "-- +goose Up\nSELECT 1;\n"
+
+	tests := []struct {
+		name             string
+		files            fstest.MapFS
+		expectedVersions []int64
+		expectedErr      string
+	}{
+		{
+			name: "Ordered by version",
+			files: fstest.MapFS{
+				"010_third.sql":  file(valid),
+				"002_second.sql": file(valid),
+				"001_first.sql":  file(valid),
+				"README.md":      file("not a migration"),
+			},
+			expectedVersions: []int64{1, 2, 10},
+		},
+		{
+			name: "Duplicate versions",
+			files: fstest.MapFS{
+				"001_first.sql": file(valid),
+				"01_other.sql":  file(valid),
+			},
+			expectedErr: "share version 1",
+		},
+		{
+			name:        "Unnamed migration",
+			files:       fstest.MapFS{"first.sql": file(valid)},
+			expectedErr: "is not named <version>_<name>.sql",
+		},
+		{
+			name:        "Zero version",
+			files:       fstest.MapFS{"000_first.sql": file(valid)},
+			expectedErr: "has an invalid version",
+		},
+		{
+			name:        "Invalid migration",
+			files:       fstest.MapFS{"001_first.sql": file("SELECT 1;\n")},
+			expectedErr: "has no -- +goose Up section",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrator, err := NewMigrator(nil, tt.files, logger.GetLogger())
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			versions := make([]int64, len(migrator.migrations))
+			for i, migration := range migrator.migrations {
+				versions[i] = migration.Version
+			}
+			if !reflect.DeepEqual(versions, tt.expectedVersions) {
+				t.Errorf("Expected versions %v, got %v", tt.expectedVersions, versions)
+			}
+		})
+	}
+}
+
+func TestNewMigrator_Embedded(t *testing.T) {
+	migrator, err := NewMigrator(nil, migrations.FS, logger.GetLogger())
+	if err != nil {
+		t.Fatalf("Failed to parse the embedded migrations: %v", err)
+	}
+
+	for i, migration := range migrator.migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("Expected migration %d to have version %d, got %d", i, i+1, migration.Version)
+		}
+		if len(migration.down) == 0 {
+			t.Errorf("Expected migration %s to have a down section", migration.Name)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+type idempotencyPostgres struct {
+	db     *sqlx.DB
+	logger *logger.Logger
+}
+
+func NewIdempotencyPostgres(db *sqlx.DB, logger *logger.Logger) repository.IdempotencyRepository {
+	return &idempotencyPostgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// maxClaimAttempts bounds how often Claim tries again when the conflicting
+// claim is released before it can be read
+const maxClaimAttempts = 3
+
+// idempotencyColumns are the columns read by scanIdempotencyRecord, in order
+const idempotencyColumns = `scope, key, fingerprint, completed, status_code, content_type, headers, body, created_at, expires_at`
+
+// scanIdempotencyRecord reads a record selected with idempotencyColumns
+func scanIdempotencyRecord(row rowScanner) (*repository.IdempotencyRecord, error) {
+	var record repository.IdempotencyRecord
+	var statusCode sql.NullInt32
+	var contentType sql.NullString
+	var headers []byte
+
+	err := row.Scan(&record.Scope, &record.Key, &record.Fingerprint, &record.Completed, &statusCode, &contentType,
+		&headers, &record.Body, &record.CreatedAt, &record.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	record.StatusCode = int(statusCode.Int32)
+	record.ContentType = contentType.String
+	if headers != nil {
+		if err := json.Unmarshal(headers, &record.Headers); err != nil {
+			return nil, fmt.Errorf("failed to decode stored headers: %w", err)
+		}
+	}
+	return &record, nil
+}
+
+// Claim inserts the record, replacing an expired one for the same key.
+// Expired records of other keys are purged along the way.
+func (r *idempotencyPostgres) Claim(ctx context.Context, record repository.IdempotencyRecord) (*repository.IdempotencyRecord, bool, error) {
+	r.logger.Debug("Claiming idempotency key %q for %s", record.Key, record.Scope)
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, record.CreatedAt); err != nil {
+		r.logger.Error("Failed to purge expired idempotency keys: %v", err)
+		return nil, false, fmt.Errorf("failed to purge expired idempotency keys: %w", err)
+	}
+
+	query := `INSERT INTO idempotency_keys (scope, key, fingerprint, completed, created_at, expires_at)
+			  VALUES ($1, $2, $3, FALSE, $4, $5) ON CONFLICT (scope, key) DO NOTHING`
+	for attempt := 0; attempt < maxClaimAttempts; attempt++ {
+		result, err := r.db.ExecContext(ctx, query, record.Scope, record.Key, record.Fingerprint, record.CreatedAt,
+			record.ExpiresAt)
+		if err != nil {
+			r.logger.Error("Failed to claim idempotency key %q: %v", record.Key, err)
+			return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 1 {
+			return nil, true, nil
+		}
+
+		existing, err := scanIdempotencyRecord(r.db.QueryRowContext(ctx,
+			`SELECT `+idempotencyColumns+` FROM idempotency_keys WHERE scope = $1 AND key = $2`, record.Scope, record.Key))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// The other claim was released in the meantime; try again
+				continue
+			}
+			r.logger.Error("Failed to get idempotency key %q: %v", record.Key, err)
+			return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+
+		return existing, false, nil
+	}
+
+	r.logger.Error("Failed to claim idempotency key %q after %d attempts", record.Key, maxClaimAttempts)
+	return nil, false, fmt.Errorf("failed to claim idempotency key: other claims were released %d times in a row", maxClaimAttempts)
+}
+
+// Complete stores the response of a claimed record
+func (r *idempotencyPostgres) Complete(ctx context.Context, record repository.IdempotencyRecord) error {
+	r.logger.Debug("Storing response %d for idempotency key %q", record.StatusCode, record.Key)
+	headers, err := json.Marshal(record.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode headers: %w", err)
+	}
+
+	query := `UPDATE idempotency_keys
+			  SET completed = TRUE, status_code = $4, content_type = $5, headers = $6, body = $7, expires_at = $8
+			  WHERE scope = $1 AND key = $2 AND fingerprint = $3`
+
+	_, err = r.db.ExecContext(ctx, query, record.Scope, record.Key, record.Fingerprint, record.StatusCode,
+		record.ContentType, headers, record.Body, record.ExpiresAt)
+	if err != nil {
+		r.logger.Error("Failed to store response for idempotency key %q: %v", record.Key, err)
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release deletes a claimed record that has no response yet
+func (r *idempotencyPostgres) Release(ctx context.Context, scope, key string) error {
+	r.logger.Debug("Releasing idempotency key %q for %s", key, scope)
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND NOT completed`
+
+	if _, err := r.db.ExecContext(ctx, query, scope, key); err != nil {
+		r.logger.Error("Failed to release idempotency key %q: %v", key, err)
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
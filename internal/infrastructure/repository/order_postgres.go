@@ -3,14 +3,18 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
 	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
 	"github.com/Strahinja-Polovina/packs/pkg/logger"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type orderPostgres struct {
@@ -25,78 +29,225 @@ func NewOrderPostgres(db *sqlx.DB, logger *logger.Logger) repository.OrderReposi
 	}
 }
 
-// List orders from database in descending order by creation date.
-func (r *orderPostgres) List(ctx context.Context) []entity.Order {
-	r.logger.Debug("Listing all orders from database")
+// orderColumns are the columns read by scanOrder, in order
+const orderColumns = `id, status, revision, version, created_at, updated_at, calculated_at`
 
-	query := `SELECT id, created_at, updated_at FROM orders ORDER BY created_at DESC`
+// scanOrder reads an order selected with orderColumns, without its lines
+func scanOrder(row rowScanner) (*entity.Order, error) {
+	var id uuid.UUID
+	var status string
+	var revision, version int
+	var createdAt, updatedAt sql.NullTime
+	var calculatedAt time.Time
+
+	if err := row.Scan(&id, &status, &revision, &version, &createdAt, &updatedAt, &calculatedAt); err != nil {
+		return nil, err
+	}
+
+	order := entity.NewOrder(id)
+	if err := order.SetStatus(entity.OrderStatus(status)); err != nil {
+		return nil, fmt.Errorf("failed to restore order status: %w", err)
+	}
+	if err := order.SetRevision(revision); err != nil {
+		return nil, fmt.Errorf("failed to restore order revision: %w", err)
+	}
+	if err := order.SetVersion(version); err != nil {
+		return nil, fmt.Errorf("failed to restore order version: %w", err)
+	}
+
+	// Set timestamps from database if they exist
+	if createdAt.Valid && updatedAt.Valid {
+		order.SetTimestamps(createdAt.Time, updatedAt.Time)
+	}
+	order.SetCalculatedAt(calculatedAt)
+
+	return order, nil
+}
+
+// restoreCalculation rebuilds a calculation from its nullable columns, or
+// returns nil when none was recorded
+func restoreCalculation(requestedAmount sql.NullInt64, packSizes pq.Int64Array, objective, solverVersion sql.NullString) *entity.OrderCalculation {
+	if !requestedAmount.Valid {
+		return nil
+	}
+
+	return &entity.OrderCalculation{
+		RequestedAmount: requestedAmount.Int64,
+		PackSizes:       append([]int64{}, packSizes...),
+		Objective:       objective.String,
+		SolverVersion:   solverVersion.String,
+	}
+}
+
+// lineCalculationArgs returns the nullable column values for the calculation of a line
+func lineCalculationArgs(line *entity.OrderLine) (requestedAmount, packSizes, objective, solverVersion any) {
+	calculation, ok := line.Calculation()
+	if !ok {
+		return nil, nil, nil, nil
+	}
+	return calculation.RequestedAmount, pq.Array(calculation.PackSizes), calculation.Objective, calculation.SolverVersion
+}
+
+// nullableReference stores an empty line reference as NULL
+func nullableReference(reference string) any {
+	if reference == "" {
+		return nil
+	}
+	return reference
+}
+
+// revisionItem is the JSON form of an order item stored with a revision
+type revisionItem struct {
+	PackSize int64 `json:"pack_size"`
+	Quantity int64 `json:"quantity"`
+}
+
+// revisionLine is the JSON form of an order line stored with a revision. A
+// nil RequestedAmount marks a line created before calculations were recorded.
+type revisionLine struct {
+	Line            int            `json:"line"`
+	Reference       string         `json:"reference,omitempty"`
+	ProductID       uuid.UUID      `json:"product_id"`
+	RequestedAmount *int64         `json:"requested_amount"`
+	PackSizes       []int64        `json:"pack_sizes"`
+	Objective       string         `json:"objective,omitempty"`
+	SolverVersion   string         `json:"solver_version,omitempty"`
+	Items           []revisionItem `json:"items"`
+}
 
-	rows, err := r.db.QueryContext(ctx, query)
+// orderAmount is the SQL expression for entity.Order.GetRequestedAmount
+const orderAmount = `COALESCE(o.requested_amount,
+	(SELECT COALESCE(SUM(i.package_size * i.quantity), 0) FROM order_items i WHERE i.order_id = o.id))`
+
+// List one page of orders matching the query. Pages are keyset paginated on
+// the sort key and id, and lines are loaded for the whole page at once.
+func (r *orderPostgres) List(ctx context.Context, query repository.OrderQuery) (*repository.OrderPage, error) {
+	r.logger.Debug("Listing orders sorted by %s (descending: %t), limit %d", query.SortBy, query.Descending, query.Limit)
+
+	var conditions []string
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if query.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+arg(*query.CreatedFrom))
+	}
+	if query.CreatedTo != nil {
+		conditions = append(conditions, "created_at < "+arg(*query.CreatedTo))
+	}
+	if query.MinAmount != nil {
+		conditions = append(conditions, "amount >= "+arg(*query.MinAmount))
+	}
+	if query.MaxAmount != nil {
+		conditions = append(conditions, "amount <= "+arg(*query.MaxAmount))
+	}
+	if query.ProductID != nil {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM order_lines l WHERE l.order_id = listed.id AND l.product_id = "+
+			arg(*query.ProductID)+")")
+	}
+	if query.PackSize != nil {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = listed.id AND i.package_size = "+
+			arg(*query.PackSize)+")")
+	}
+
+	sortColumn := "created_at"
+	if query.SortBy == repository.OrderSortAmount {
+		sortColumn = "amount"
+	}
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.After != nil {
+		var key any = query.After.CreatedAt
+		if query.SortBy == repository.OrderSortAmount {
+			key = query.After.Amount
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)",
+			sortColumn, comparison, arg(key), arg(query.After.ID)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// One extra row tells whether another page follows
+	sqlQuery := `SELECT ` + orderColumns + ` FROM (SELECT o.*, ` + orderAmount + ` AS amount FROM orders o) listed` +
+		where + fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", sortColumn, direction, direction, arg(query.Limit+1))
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		r.logger.Error("Failed to query orders: %v", err)
-		return []entity.Order{}
+		return nil, fmt.Errorf("failed to query orders: %w", err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
-	var orders []entity.Order
+	var orders []*entity.Order
 	for rows.Next() {
-		var id uuid.UUID
-		var createdAt, updatedAt sql.NullTime
-
-		if err := rows.Scan(&id, &createdAt, &updatedAt); err != nil {
-			continue
+		order, err := scanOrder(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan order: %v", err)
+			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to iterate orders: %v", err)
+		return nil, fmt.Errorf("failed to iterate orders: %w", err)
+	}
 
-		order := entity.NewOrder(id)
+	page := &repository.OrderPage{Orders: []entity.Order{}}
+	if len(orders) > query.Limit {
+		orders = orders[:query.Limit]
+		page.Next = &repository.OrderCursor{}
+	}
 
-		// Set timestamps from database if they exist
-		if createdAt.Valid && updatedAt.Valid {
-			order.SetTimestamps(createdAt.Time, updatedAt.Time)
-		}
+	if err := r.loadOrderLines(ctx, orders...); err != nil {
+		r.logger.Error("Failed to load order lines: %v", err)
+		return nil, fmt.Errorf("failed to load order lines: %w", err)
+	}
 
-		if err := r.loadOrderItems(ctx, order); err != nil {
-			r.logger.Warn("Failed to load items for order %s: %v", id, err)
-			continue
+	for _, order := range orders {
+		page.Orders = append(page.Orders, *order)
+	}
+	if page.Next != nil {
+		last := orders[len(orders)-1]
+		*page.Next = repository.OrderCursor{
+			ID:        last.ID(),
+			CreatedAt: last.CreatedAt(),
+			Amount:    last.GetRequestedAmount(),
 		}
-
-		orders = append(orders, *order)
 	}
 
-	r.logger.Debug("Retrieved %d orders from database", len(orders))
-	return orders
+	r.logger.Debug("Retrieved %d orders from database", len(page.Orders))
+	return page, nil
 }
 
 // Get order by id
 func (r *orderPostgres) Get(ctx context.Context, id uuid.UUID) (*entity.Order, error) {
 	r.logger.Debug("Getting order by ID: %s", id)
 
-	query := `SELECT id, created_at, updated_at FROM orders WHERE id = $1`
-
-	var orderID uuid.UUID
-	var createdAt, updatedAt sql.NullTime
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = $1`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&orderID, &createdAt, &updatedAt)
+	order, err := scanOrder(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.logger.Warn("Order not found with ID: %s", id)
-			return nil, fmt.Errorf("order not found")
+			return nil, entity.ErrOrderNotFound
 		}
 		r.logger.Error("Failed to get order %s: %v", id, err)
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
-	order := entity.NewOrder(orderID)
-
-	// Set timestamps from database if they exist
-	if createdAt.Valid && updatedAt.Valid {
-		order.SetTimestamps(createdAt.Time, updatedAt.Time)
-	}
-
-	if err := r.loadOrderItems(ctx, order); err != nil {
-		r.logger.Error("Failed to load order items for order %s: %v", id, err)
-		return nil, fmt.Errorf("failed to load order items: %w", err)
+	if err := r.loadOrderLines(ctx, order); err != nil {
+		r.logger.Error("Failed to load order lines for order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to load order lines: %w", err)
 	}
 
 	r.logger.Debug("Order retrieved successfully with ID: %s", id)
@@ -115,23 +266,293 @@ func (r *orderPostgres) Create(ctx context.Context, order *entity.Order) error {
 		_ = tx.Rollback()
 	}()
 
-	orderQuery := `INSERT INTO orders (id, created_at, updated_at) VALUES ($1, $2, $3)`
-	_, err = tx.ExecContext(ctx, orderQuery, order.ID(), order.CreatedAt(), order.UpdatedAt())
+	// requested_amount is the total over all lines, kept on the order for listing
+	orderQuery := `INSERT INTO orders (id, status, revision, version, requested_amount, created_at, updated_at, calculated_at)
+				   VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err = tx.ExecContext(ctx, orderQuery, order.ID(), order.Status(), order.Revision(), order.Version(),
+		order.GetRequestedAmount(), order.CreatedAt(), order.UpdatedAt(), order.CalculatedAt())
 	if err != nil {
 		r.logger.Error("Failed to create order %s: %v", order.ID(), err)
 		return fmt.Errorf("failed to create order: %w", err)
 	}
 
-	items := order.GetItems()
-	r.logger.Debug("Creating %d order items for order %s", len(items), order.ID())
-	for _, item := range items {
-		itemQuery := `INSERT INTO order_items (order_id, package_size, quantity, created_at, updated_at) 
-					  VALUES ($1, $2, $3, $4, $5)`
-		_, err = tx.ExecContext(ctx, itemQuery, order.ID(), item.PackageSize(), item.Quantity(),
-			order.CreatedAt(), order.UpdatedAt())
+	err = insertStatusChange(ctx, tx, order.ID(), entity.OrderStatusChange{To: order.Status(), ChangedAt: order.CreatedAt()})
+	if err != nil {
+		r.logger.Error("Failed to record initial status of order %s: %v", order.ID(), err)
+		return err
+	}
+
+	lines := order.Lines()
+	r.logger.Debug("Creating %d order lines for order %s", len(lines), order.ID())
+	if err := insertOrderLines(ctx, tx, order); err != nil {
+		r.logger.Error("Failed to create order lines for order %s: %v", order.ID(), err)
+		return err
+	}
+
+	if err := reserveStock(ctx, tx, lines); err != nil {
+		r.logger.Warn("Failed to reserve stock for order %s: %v", order.ID(), err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit transaction for order %s: %v", order.ID(), err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Order created successfully with ID: %s", order.ID())
+	return nil
+}
+
+// Update stores an amended order. The order row is locked so the version
+// check, the line swap and the stock reservation happen atomically.
+func (r *orderPostgres) Update(ctx context.Context, order *entity.Order, previous entity.OrderRevision) error {
+	r.logger.Info("Updating order %s to revision %d", order.ID(), order.Revision())
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction for order %s: %v", order.ID(), err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var version int
+	err = tx.QueryRowContext(ctx, `SELECT version FROM orders WHERE id = $1 FOR UPDATE`, order.ID()).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.ErrOrderNotFound
+		}
+		r.logger.Error("Failed to lock order %s: %v", order.ID(), err)
+		return fmt.Errorf("failed to lock order: %w", err)
+	}
+	if version != order.Version()-1 {
+		r.logger.Warn("Order %s changed while it was being amended", order.ID())
+		return fmt.Errorf("%w: order is at version %d", entity.ErrOrderModified, version)
+	}
+
+	lines, err := json.Marshal(revisionLines(previous.Lines))
+	if err != nil {
+		return fmt.Errorf("failed to encode order revision lines: %w", err)
+	}
+	revisionQuery := `INSERT INTO order_revisions (order_id, revision, lines, revised_at) VALUES ($1, $2, $3, $4)`
+	_, err = tx.ExecContext(ctx, revisionQuery, order.ID(), previous.Revision, lines, previous.RevisedAt)
+	if err != nil {
+		r.logger.Error("Failed to record revision %d of order %s: %v", previous.Revision, order.ID(), err)
+		return fmt.Errorf("failed to record order revision: %w", err)
+	}
+
+	// Return the packs of the replaced version before reserving the new ones
+	if err := releaseStock(ctx, tx, previous.Lines); err != nil {
+		r.logger.Error("Failed to release stock for order %s: %v", order.ID(), err)
+		return err
+	}
+
+	// Deleting the lines deletes their items too
+	if _, err := tx.ExecContext(ctx, `DELETE FROM order_lines WHERE order_id = $1`, order.ID()); err != nil {
+		r.logger.Error("Failed to delete lines of order %s: %v", order.ID(), err)
+		return fmt.Errorf("failed to delete order lines: %w", err)
+	}
+	if err := insertOrderLines(ctx, tx, order); err != nil {
+		r.logger.Error("Failed to create order lines for order %s: %v", order.ID(), err)
+		return err
+	}
+
+	if err := reserveStock(ctx, tx, order.Lines()); err != nil {
+		r.logger.Warn("Failed to reserve stock for order %s: %v", order.ID(), err)
+		return err
+	}
+
+	orderQuery := `UPDATE orders SET revision = $2, version = $3, requested_amount = $4, updated_at = $5,
+				   calculated_at = $6 WHERE id = $1`
+	_, err = tx.ExecContext(ctx, orderQuery, order.ID(), order.Revision(), order.Version(), order.GetRequestedAmount(),
+		order.UpdatedAt(), order.CalculatedAt())
+	if err != nil {
+		r.logger.Error("Failed to update order %s: %v", order.ID(), err)
+		return fmt.Errorf("failed to update order: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit transaction for order %s: %v", order.ID(), err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Order %s updated to revision %d", order.ID(), order.Revision())
+	return nil
+}
+
+// Revisions returns the replaced versions of an order, oldest first
+func (r *orderPostgres) Revisions(ctx context.Context, id uuid.UUID) ([]entity.OrderRevision, error) {
+	r.logger.Debug("Getting revisions of order: %s", id)
+	query := `SELECT revision, lines, revised_at FROM order_revisions WHERE order_id = $1 ORDER BY revision`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		r.logger.Error("Failed to query revisions of order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to query order revisions: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	revisions := []entity.OrderRevision{}
+	for rows.Next() {
+		var revision entity.OrderRevision
+		var lines []byte
+
+		if err := rows.Scan(&revision.Revision, &lines, &revision.RevisedAt); err != nil {
+			r.logger.Error("Failed to scan revision of order %s: %v", id, err)
+			return nil, fmt.Errorf("failed to scan order revision: %w", err)
+		}
+
+		var stored []revisionLine
+		if err := json.Unmarshal(lines, &stored); err != nil {
+			return nil, fmt.Errorf("failed to decode order revision lines: %w", err)
+		}
+		for _, storedLine := range stored {
+			line, err := restoreRevisionLine(storedLine)
+			if err != nil {
+				return nil, fmt.Errorf("failed to restore order revision line: %w", err)
+			}
+			revision.Lines = append(revision.Lines, *line)
+		}
+
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate order revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// revisionLines converts order lines to their stored JSON form
+func revisionLines(lines []entity.OrderLine) []revisionLine {
+	stored := make([]revisionLine, len(lines))
+	for i, line := range lines {
+		stored[i] = revisionLine{
+			Line:      line.Number(),
+			Reference: line.Reference(),
+			ProductID: line.ProductID(),
+			Items:     []revisionItem{},
+		}
+		if calculation, ok := line.Calculation(); ok {
+			stored[i].RequestedAmount = &calculation.RequestedAmount
+			stored[i].PackSizes = calculation.PackSizes
+			stored[i].Objective = calculation.Objective
+			stored[i].SolverVersion = calculation.SolverVersion
+		}
+		for _, item := range line.GetItems() {
+			stored[i].Items = append(stored[i].Items, revisionItem{PackSize: item.PackageSize(), Quantity: item.Quantity()})
+		}
+	}
+	return stored
+}
+
+// restoreRevisionLine rebuilds an order line from its stored JSON form
+func restoreRevisionLine(stored revisionLine) (*entity.OrderLine, error) {
+	line, err := entity.NewOrderLine(stored.Line, stored.Reference, stored.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range stored.Items {
+		if err := line.AddItem(item.PackSize, item.Quantity); err != nil {
+			return nil, err
+		}
+	}
+	if stored.RequestedAmount != nil {
+		err := line.SetCalculation(entity.OrderCalculation{
+			RequestedAmount: *stored.RequestedAmount,
+			PackSizes:       stored.PackSizes,
+			Objective:       stored.Objective,
+			SolverVersion:   stored.SolverVersion,
+		})
 		if err != nil {
-			r.logger.Error("Failed to create order item for order %s: %v", order.ID(), err)
-			return fmt.Errorf("failed to create order item: %w", err)
+			return nil, err
+		}
+	}
+	return line, nil
+}
+
+// insertOrderLines stores the lines of an order and their items inside tx
+func insertOrderLines(ctx context.Context, tx *sqlx.Tx, order *entity.Order) error {
+	lineQuery := `INSERT INTO order_lines (order_id, line_number, reference, product_id, requested_amount, pack_sizes,
+				  objective, solver_version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	itemQuery := `INSERT INTO order_items (order_id, line_number, package_size, quantity, created_at, updated_at)
+				  VALUES ($1, $2, $3, $4, $5, $6)`
+
+	for _, line := range order.Lines() {
+		requestedAmount, packSizes, objective, solverVersion := lineCalculationArgs(&line)
+		_, err := tx.ExecContext(ctx, lineQuery, order.ID(), line.Number(), nullableReference(line.Reference()),
+			line.ProductID(), requestedAmount, packSizes, objective, solverVersion)
+		if err != nil {
+			return fmt.Errorf("failed to create order line %d: %w", line.Number(), err)
+		}
+
+		for _, item := range line.GetItems() {
+			_, err := tx.ExecContext(ctx, itemQuery, order.ID(), line.Number(), item.PackageSize(), item.Quantity(),
+				order.UpdatedAt(), order.UpdatedAt())
+			if err != nil {
+				return fmt.Errorf("failed to create order item: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateStatus stores a status change and its history entry in one transaction
+func (r *orderPostgres) UpdateStatus(ctx context.Context, order *entity.Order, change entity.OrderStatusChange) error {
+	r.logger.Info("Moving order %s from %s to %s", order.ID(), change.From, change.To)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction for order %s: %v", order.ID(), err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// The status and version guard rejects changes that raced with another write
+	query := `UPDATE orders SET status = $3, version = $5, updated_at = $4
+			  WHERE id = $1 AND status = $2 AND version = $5 - 1`
+	result, err := tx.ExecContext(ctx, query, order.ID(), change.From, change.To, change.ChangedAt, order.Version())
+	if err != nil {
+		r.logger.Error("Failed to update status of order %s: %v", order.ID(), err)
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected for order %s: %v", order.ID(), err)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		var status string
+		var version int
+		err := tx.QueryRowContext(ctx, `SELECT status, version FROM orders WHERE id = $1`, order.ID()).Scan(&status, &version)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return entity.ErrOrderNotFound
+			}
+			return fmt.Errorf("failed to check order status: %w", err)
+		}
+		if entity.OrderStatus(status) != change.From {
+			r.logger.Warn("Order %s is no longer %s", order.ID(), change.From)
+			return fmt.Errorf("%w: order is no longer %s", entity.ErrInvalidStatusTransition, change.From)
+		}
+		r.logger.Warn("Order %s changed while its status was being changed", order.ID())
+		return fmt.Errorf("%w: order is at version %d", entity.ErrOrderModified, version)
+	}
+
+	if err := insertStatusChange(ctx, tx, order.ID(), change); err != nil {
+		r.logger.Error("Failed to record status change of order %s: %v", order.ID(), err)
+		return err
+	}
+
+	if change.To == entity.OrderStatusCancelled {
+		if err := releaseStock(ctx, tx, order.Lines()); err != nil {
+			r.logger.Error("Failed to release stock for order %s: %v", order.ID(), err)
+			return err
 		}
 	}
 
@@ -140,35 +561,147 @@ func (r *orderPostgres) Create(ctx context.Context, order *entity.Order) error {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	r.logger.Info("Order created successfully with ID: %s", order.ID())
+	r.logger.Info("Order %s is now %s", order.ID(), change.To)
 	return nil
 }
 
-func (r *orderPostgres) loadOrderItems(ctx context.Context, order *entity.Order) error {
-	query := `SELECT package_size, quantity FROM order_items WHERE order_id = $1`
-	rows, err := r.db.QueryContext(ctx, query, order.ID())
+// StatusHistory returns the status changes of an order, oldest first
+func (r *orderPostgres) StatusHistory(ctx context.Context, id uuid.UUID) ([]entity.OrderStatusChange, error) {
+	r.logger.Debug("Getting status history for order: %s", id)
+	query := `SELECT from_status, to_status, changed_at FROM order_status_history
+			  WHERE order_id = $1 ORDER BY changed_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("failed to query order items: %w", err)
+		r.logger.Error("Failed to query status history for order %s: %v", id, err)
+		return nil, fmt.Errorf("failed to query status history: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	history := []entity.OrderStatusChange{}
+	for rows.Next() {
+		var from sql.NullString
+		var change entity.OrderStatusChange
+		if err := rows.Scan(&from, &change.To, &change.ChangedAt); err != nil {
+			r.logger.Error("Failed to scan status change for order %s: %v", id, err)
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		change.From = entity.OrderStatus(from.String)
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate status history: %w", err)
+	}
+
+	return history, nil
+}
+
+// insertStatusChange appends a status change to the order history inside tx
+func insertStatusChange(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, change entity.OrderStatusChange) error {
+	var from any
+	if change.From != "" {
+		from = change.From
+	}
+
+	query := `INSERT INTO order_status_history (order_id, from_status, to_status, changed_at) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, orderID, from, change.To, change.ChangedAt); err != nil {
+		return fmt.Errorf("failed to record order status change: %w", err)
+	}
+	return nil
+}
+
+// loadOrderLines loads the lines and items of all given orders with two queries
+func (r *orderPostgres) loadOrderLines(ctx context.Context, orders ...*entity.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID().String()
+	}
+
+	lineQuery := `SELECT order_id, line_number, reference, product_id, requested_amount, pack_sizes, objective, solver_version
+				  FROM order_lines WHERE order_id = ANY($1::uuid[]) ORDER BY order_id, line_number`
+	rows, err := r.db.QueryContext(ctx, lineQuery, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query order lines: %w", err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
+	lines := make(map[uuid.UUID][]entity.OrderLine, len(orders))
 	for rows.Next() {
-		var packageSize int
-		var quantity int
+		var orderID, productID uuid.UUID
+		var number int
+		var reference, objective, solverVersion sql.NullString
+		var requestedAmount sql.NullInt64
+		var packSizes pq.Int64Array
 
-		if err := rows.Scan(&packageSize, &quantity); err != nil {
-			r.logger.Error("Failed to scan order item for order %s: %v", order.ID(), err)
+		err := rows.Scan(&orderID, &number, &reference, &productID, &requestedAmount, &packSizes, &objective, &solverVersion)
+		if err != nil {
+			r.logger.Error("Failed to scan order line: %v", err)
+			return fmt.Errorf("failed to scan order line: %w", err)
+		}
+
+		line, err := entity.NewOrderLine(number, reference.String, productID)
+		if err != nil {
+			return fmt.Errorf("failed to restore line %d of order %s: %w", number, orderID, err)
+		}
+		// Lines of orders created before calculations were recorded have no requested amount
+		if calculation := restoreCalculation(requestedAmount, packSizes, objective, solverVersion); calculation != nil {
+			if err := line.SetCalculation(*calculation); err != nil {
+				return fmt.Errorf("failed to restore calculation of line %d of order %s: %w", number, orderID, err)
+			}
+		}
+		lines[orderID] = append(lines[orderID], *line)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate order lines: %w", err)
+	}
+
+	itemQuery := `SELECT order_id, line_number, package_size, quantity FROM order_items
+				  WHERE order_id = ANY($1::uuid[]) ORDER BY package_size DESC`
+	itemRows, err := r.db.QueryContext(ctx, itemQuery, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query order items: %w", err)
+	}
+	defer func() {
+		_ = itemRows.Close()
+	}()
+
+	for itemRows.Next() {
+		var orderID uuid.UUID
+		var number int
+		var packageSize, quantity int64
+
+		if err := itemRows.Scan(&orderID, &number, &packageSize, &quantity); err != nil {
+			r.logger.Error("Failed to scan order item: %v", err)
 			return fmt.Errorf("failed to scan order item: %w", err)
 		}
 
-		if err := order.AddItem(packageSize, quantity); err != nil {
-			r.logger.Error("Failed to add item to order %s: %v", order.ID(), err)
-			return fmt.Errorf("failed to add item to order: %w", err)
+		orderLines := lines[orderID]
+		if number < 1 || number > len(orderLines) {
+			continue
+		}
+		if err := orderLines[number-1].AddItem(packageSize, quantity); err != nil {
+			r.logger.Error("Failed to add item to line %d of order %s: %v", number, orderID, err)
+			return fmt.Errorf("failed to add item to order line: %w", err)
+		}
+	}
+	if err := itemRows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate order items: %w", err)
+	}
+
+	for _, order := range orders {
+		if err := order.SetLines(lines[order.ID()]); err != nil {
+			return fmt.Errorf("failed to restore lines of order %s: %w", order.ID(), err)
 		}
 	}
 
-	r.logger.Debug("Successfully loaded order items for order ID: %s", order.ID())
+	r.logger.Debug("Successfully loaded order lines for %d orders", len(orders))
 	return nil
 }
@@ -5,12 +5,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
 	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
 	"github.com/Strahinja-Polovina/packs/pkg/logger"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type packPostgres struct {
@@ -25,152 +27,581 @@ func NewPackPostgres(db *sqlx.DB, logger *logger.Logger) repository.PackReposito
 	}
 }
 
-// List packs from database in ascending order by size.
-func (r *packPostgres) List(ctx context.Context) []entity.Pack {
+// packColumns are the columns read by scanPack, in order. They describe one
+// revision r of the pack p.
+const packColumns = `p.id, p.product_id, r.size, r.unit_cost, r.weight_grams, r.length_mm, r.width_mm, r.height_mm,
+	p.created_at, r.created_at, r.revision, r.valid_from, r.valid_to, p.active`
+
+// headColumns select the latest revision of a pack from the packs table in
+// the order of packColumns
+const headColumns = `p.id, p.product_id, p.size, p.unit_cost, p.weight_grams, p.length_mm, p.width_mm, p.height_mm,
+	p.created_at, p.updated_at, p.revision, p.valid_from, p.valid_to, p.active`
+
+// revisionInEffect joins every pack p to its revision r that is in effect at
+// the time given as $1
+const revisionInEffect = `JOIN pack_revisions r ON r.pack_id = p.id AND r.valid_from <= $1 AND (r.valid_to IS NULL OR r.valid_to > $1)`
+
+// sizeOverlapConstraint is the exclusion constraint that keeps the sizes of a
+// product's revisions distinct while they are in effect
+const sizeOverlapConstraint = "pack_revisions_size_overlap"
+
+// isDuplicateSize reports whether err is a violation of sizeOverlapConstraint.
+// The constraint settles races the service's ExistsBySize check cannot.
+func isDuplicateSize(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == "exclusion_violation" && pqErr.Constraint == sizeOverlapConstraint
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanPack reads a pack selected with packColumns or headColumns
+func scanPack(row rowScanner) (*entity.Pack, error) {
+	var id, productID uuid.UUID
+	var size int64
+	var unitCost sql.NullInt64
+	var weight, length, width, height sql.NullInt32
+	var createdAt, updatedAt sql.NullTime
+	var revision int
+	var validFrom time.Time
+	var validTo sql.NullTime
+	var active bool
+
+	if err := row.Scan(&id, &productID, &size, &unitCost, &weight, &length, &width, &height, &createdAt, &updatedAt,
+		&revision, &validFrom, &validTo, &active); err != nil {
+		return nil, err
+	}
+
+	var attributes entity.PackAttributes
+	if unitCost.Valid {
+		attributes.UnitCost = &unitCost.Int64
+	}
+	if weight.Valid {
+		grams := int(weight.Int32)
+		attributes.Weight = &grams
+	}
+	if length.Valid && width.Valid && height.Valid {
+		attributes.Dimensions = &entity.Dimensions{
+			Length: int(length.Int32),
+			Width:  int(width.Int32),
+			Height: int(height.Int32),
+		}
+	}
+
+	pack, err := entity.NewProductPack(id, productID, size, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pack entity: %w", err)
+	}
+	if err := pack.SetRevision(revision, validFrom, validTo.Time, active); err != nil {
+		return nil, fmt.Errorf("failed to restore pack revision: %w", err)
+	}
+
+	// Set timestamps from database if they exist
+	if createdAt.Valid && updatedAt.Valid {
+		pack.SetTimestamps(createdAt.Time, updatedAt.Time)
+	}
+
+	return pack, nil
+}
+
+// packAttributeArgs returns the nullable column values for the pack attributes
+func packAttributeArgs(pack *entity.Pack) (unitCost, weight, length, width, height any) {
+	if cost, ok := pack.UnitCost(); ok {
+		unitCost = cost
+	}
+	if grams, ok := pack.Weight(); ok {
+		weight = grams
+	}
+	if dimensions, ok := pack.Dimensions(); ok {
+		length, width, height = dimensions.Length, dimensions.Width, dimensions.Height
+	}
+	return unitCost, weight, length, width, height
+}
+
+// List the packs of every product that are in effect now, in ascending order by size.
+func (r *packPostgres) List(ctx context.Context) ([]entity.Pack, error) {
 	r.logger.Debug("Listing all packs from database")
-	query := `SELECT id, size, created_at, updated_at FROM packs ORDER BY size`
+	query := `SELECT ` + packColumns + ` FROM packs p ` + revisionInEffect + ` ORDER BY r.size`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, time.Now())
 	if err != nil {
 		r.logger.Error("Failed to query packs: %v", err)
-		return []entity.Pack{}
+		return nil, fmt.Errorf("failed to query packs: %w", err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
-	var packs []entity.Pack
+	packs := []entity.Pack{}
 	for rows.Next() {
-		var id uuid.UUID
-		var size int
-		var createdAt, updatedAt sql.NullTime
+		pack, err := scanPack(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan pack: %v", err)
+			return nil, fmt.Errorf("failed to scan pack: %w", err)
+		}
+
+		packs = append(packs, *pack)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate packs: %w", err)
+	}
+
+	return packs, nil
+}
 
-		if err := rows.Scan(&id, &size, &createdAt, &updatedAt); err != nil {
-			continue
+// ListByProduct lists the packs of one product that are in effect now, in ascending order by size
+func (r *packPostgres) ListByProduct(ctx context.Context, productID uuid.UUID) ([]entity.Pack, error) {
+	return r.ListAsOf(ctx, productID, time.Now())
+}
+
+// ListAsOf lists the revisions of one product's packs in effect at the given time, in ascending order by size
+func (r *packPostgres) ListAsOf(ctx context.Context, productID uuid.UUID, at time.Time) ([]entity.Pack, error) {
+	r.logger.Debug("Listing packs of product %s as of %v from database", productID, at)
+	query := `SELECT ` + packColumns + ` FROM packs p ` + revisionInEffect + ` WHERE p.product_id = $2 ORDER BY r.size`
+
+	rows, err := r.db.QueryContext(ctx, query, at, productID)
+	if err != nil {
+		r.logger.Error("Failed to query packs of product %s: %v", productID, err)
+		return nil, fmt.Errorf("failed to query packs: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	packs := []entity.Pack{}
+	for rows.Next() {
+		pack, err := scanPack(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan pack of product %s: %v", productID, err)
+			return nil, fmt.Errorf("failed to scan pack: %w", err)
 		}
 
-		pack, err := entity.NewPack(id, size)
+		packs = append(packs, *pack)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate packs: %w", err)
+	}
+
+	// A product without packs is valid; an unknown product is not
+	if len(packs) == 0 {
+		if err := r.checkProduct(ctx, productID); err != nil {
+			return nil, err
+		}
+	}
+
+	return packs, nil
+}
+
+// ListAsOfMany lists the revisions of the packs of many products in effect at
+// the given times with one query, in ascending order by size
+func (r *packPostgres) ListAsOfMany(ctx context.Context, catalogs []repository.PackCatalog) ([][]entity.Pack, error) {
+	packs := make([][]entity.Pack, len(catalogs))
+	if len(catalogs) == 0 {
+		return packs, nil
+	}
+	r.logger.Debug("Listing packs of %d catalogs from database", len(catalogs))
+
+	productIDs := make([]string, len(catalogs))
+	times := make([]string, len(catalogs))
+	for i, catalog := range catalogs {
+		productIDs[i] = catalog.ProductID.String()
+		times[i] = catalog.At.Format(time.RFC3339Nano)
+		packs[i] = []entity.Pack{}
+	}
+
+	// Each catalog is numbered by its position, from 1
+	query := `SELECT c.position, ` + packColumns + `
+			  FROM unnest($1::uuid[], $2::timestamptz[]) WITH ORDINALITY AS c(product_id, at, position)
+			  JOIN packs p ON p.product_id = c.product_id
+			  JOIN pack_revisions r ON r.pack_id = p.id AND r.valid_from <= c.at AND (r.valid_to IS NULL OR r.valid_to > c.at)
+			  ORDER BY c.position, r.size`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs), pq.Array(times))
+	if err != nil {
+		r.logger.Error("Failed to query packs of %d catalogs: %v", len(catalogs), err)
+		return nil, fmt.Errorf("failed to query packs: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var position int
+		pack, err := scanPack(positionScanner{row: rows, position: &position})
 		if err != nil {
-			continue
+			r.logger.Error("Failed to scan pack: %v", err)
+			return nil, fmt.Errorf("failed to scan pack: %w", err)
 		}
+		packs[position-1] = append(packs[position-1], *pack)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate packs: %w", err)
+	}
+
+	return packs, nil
+}
+
+// positionScanner scans a leading position column before the pack columns
+type positionScanner struct {
+	row      rowScanner
+	position *int
+}
+
+func (s positionScanner) Scan(dest ...any) error {
+	return s.row.Scan(append([]any{s.position}, dest...)...)
+}
+
+// ListActive lists the latest revisions of one product's active packs in ascending order by size
+func (r *packPostgres) ListActive(ctx context.Context, productID uuid.UUID) ([]entity.Pack, error) {
+	r.logger.Debug("Listing active packs of product %s from database", productID)
+	query := `SELECT ` + headColumns + ` FROM packs p WHERE p.product_id = $1 AND p.active ORDER BY p.size`
 
-		// Set timestamps from database if they exist
-		if createdAt.Valid && updatedAt.Valid {
-			pack.SetTimestamps(createdAt.Time, updatedAt.Time)
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		r.logger.Error("Failed to query active packs of product %s: %v", productID, err)
+		return nil, fmt.Errorf("failed to query packs: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	packs := []entity.Pack{}
+	for rows.Next() {
+		pack, err := scanPack(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan pack of product %s: %v", productID, err)
+			return nil, fmt.Errorf("failed to scan pack: %w", err)
 		}
 
 		packs = append(packs, *pack)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate packs: %w", err)
+	}
 
-	return packs
+	if len(packs) == 0 {
+		if err := r.checkProduct(ctx, productID); err != nil {
+			return nil, err
+		}
+	}
+
+	return packs, nil
+}
+
+// checkProduct returns entity.ErrProductNotFound when the product does not exist
+func (r *packPostgres) checkProduct(ctx context.Context, productID uuid.UUID) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)`, productID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check product existence: %w", err)
+	}
+	if !exists {
+		r.logger.Warn("Product not found with ID: %s", productID)
+		return entity.ErrProductNotFound
+	}
+	return nil
 }
 
-// Get pack by id
+// Get the latest revision of an active pack by id
 func (r *packPostgres) Get(ctx context.Context, id uuid.UUID) (*entity.Pack, error) {
 	r.logger.Debug("Getting pack by ID: %s", id)
-	query := `SELECT id, size, created_at, updated_at FROM packs WHERE id = $1`
-
-	var packID uuid.UUID
-	var size int
-	var createdAt, updatedAt sql.NullTime
+	query := `SELECT ` + headColumns + ` FROM packs p WHERE p.id = $1 AND p.active`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&packID, &size, &createdAt, &updatedAt)
+	pack, err := scanPack(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.logger.Warn("Pack not found with ID: %s", id)
-			return nil, fmt.Errorf("pack not found")
+			return nil, entity.ErrPackNotFound
 		}
 		r.logger.Error("Failed to get pack %s: %v", id, err)
 		return nil, fmt.Errorf("failed to get pack: %w", err)
 	}
 
-	pack, err := entity.NewPack(packID, size)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create pack entity: %w", err)
-	}
-
-	// Set timestamps from database if they exist
-	if createdAt.Valid && updatedAt.Valid {
-		pack.SetTimestamps(createdAt.Time, updatedAt.Time)
-	}
-
 	return pack, nil
 }
 
-// Create pack
+// Create pack with its first revision
 func (r *packPostgres) Create(ctx context.Context, pack *entity.Pack) error {
 	r.logger.Info("Creating pack with ID: %s, size: %d", pack.ID(), pack.Size())
-	query := `INSERT INTO packs (id, size, created_at, updated_at) VALUES ($1, $2, $3, $4)`
-
-	_, err := r.db.ExecContext(ctx, query, pack.ID(), pack.Size(), pack.CreatedAt(), pack.UpdatedAt())
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
+		r.logger.Error("Failed to begin transaction for pack %s: %v", pack.ID(), err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := createPack(ctx, tx, pack); err != nil {
 		r.logger.Error("Failed to create pack %s: %v", pack.ID(), err)
-		return fmt.Errorf("failed to create pack: %w", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit pack %s: %v", pack.ID(), err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	r.logger.Info("Pack created successfully with ID: %s", pack.ID())
 	return nil
 }
 
-// Update pack
+// Update stores the new revision of a pack. Earlier revisions end when it
+// takes effect; those that would only take effect later never do.
 func (r *packPostgres) Update(ctx context.Context, pack *entity.Pack) error {
-	r.logger.Info("Updating pack with ID: %s, new size: %d", pack.ID(), pack.Size())
-	query := `UPDATE packs SET size = $2, updated_at = $3 WHERE id = $1`
+	r.logger.Info("Updating pack with ID: %s to revision %d, new size: %d", pack.ID(), pack.Revision(), pack.Size())
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction for pack update %s: %v", pack.ID(), err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := lockRevision(ctx, tx, pack); err != nil {
+		r.logger.Warn("Failed to update pack %s: %v", pack.ID(), err)
+		return err
+	}
+
+	if err := endRevisions(ctx, tx, pack.ID(), pack.ValidFrom()); err != nil {
+		r.logger.Error("Failed to end revisions of pack %s: %v", pack.ID(), err)
+		return err
+	}
+
+	if err := insertRevision(ctx, tx, pack); err != nil {
+		if errors.Is(err, entity.ErrDuplicatePackSize) {
+			r.logger.Warn("Pack size %d already exists for product %s", pack.Size(), pack.ProductID())
+		} else {
+			r.logger.Error("Failed to create revision of pack %s: %v", pack.ID(), err)
+		}
+		return err
+	}
+
+	query := `UPDATE packs SET size = $2, unit_cost = $3, weight_grams = $4, length_mm = $5, width_mm = $6,
+			  height_mm = $7, revision = $8, valid_from = $9, valid_to = NULL, updated_at = $10 WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, pack.ID(), pack.Size(), pack.UpdatedAt())
+	unitCost, weight, length, width, height := packAttributeArgs(pack)
+	_, err = tx.ExecContext(ctx, query, pack.ID(), pack.Size(), unitCost, weight, length, width, height,
+		pack.Revision(), pack.ValidFrom(), pack.UpdatedAt())
 	if err != nil {
 		r.logger.Error("Failed to update pack %s: %v", pack.ID(), err)
 		return fmt.Errorf("failed to update pack: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit update of pack %s: %v", pack.ID(), err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Pack updated successfully with ID: %s", pack.ID())
+	return nil
+}
+
+// Delete retires a deactivated pack. Its revisions end when it retires and
+// stay in the history of the catalog.
+func (r *packPostgres) Delete(ctx context.Context, pack *entity.Pack) error {
+	r.logger.Info("Retiring pack with ID: %s", pack.ID())
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		r.logger.Error("Failed to get rows affected for pack %s: %v", pack.ID(), err)
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		r.logger.Error("Failed to begin transaction for pack deletion %s: %v", pack.ID(), err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
 
-	if rowsAffected == 0 {
-		r.logger.Warn("Pack not found for update with ID: %s", pack.ID())
-		return fmt.Errorf("pack not found")
+	if err := retirePack(ctx, tx, pack); err != nil {
+		r.logger.Warn("Failed to retire pack %s: %v", pack.ID(), err)
+		return err
 	}
 
-	r.logger.Info("Pack updated successfully with ID: %s", pack.ID())
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit retirement of pack %s: %v", pack.ID(), err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Pack retired successfully with ID: %s", pack.ID())
 	return nil
 }
 
-// Delete pack
-func (r *packPostgres) Delete(ctx context.Context, pack *entity.Pack) error {
-	r.logger.Info("Deleting pack with ID: %s", pack.ID())
-	query := `DELETE FROM packs WHERE id = $1`
+// ReplaceSet retires and creates packs of a product in one transaction, if
+// its pack set is still at version
+func (r *packPostgres) ReplaceSet(ctx context.Context, productID uuid.UUID, version int, created, retired []*entity.Pack) error {
+	r.logger.Info("Replacing pack set of product %s: creating %d packs, retiring %d", productID, len(created), len(retired))
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction for pack set replacement: %v", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := lockPackSet(ctx, tx, productID, version); err != nil {
+		r.logger.Warn("Failed to lock pack set of product %s: %v", productID, err)
+		return err
+	}
+	for _, pack := range retired {
+		if err := retirePack(ctx, tx, pack); err != nil {
+			r.logger.Warn("Failed to retire pack %s: %v", pack.ID(), err)
+			return err
+		}
+	}
+	for _, pack := range created {
+		if err := createPack(ctx, tx, pack); err != nil {
+			r.logger.Error("Failed to create pack %s: %v", pack.ID(), err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit pack set replacement: %v", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 
-	result, err := r.db.ExecContext(ctx, query, pack.ID())
+	r.logger.Info("Pack set replaced successfully")
+	return nil
+}
+
+// lockPackSet locks a product and its active packs inside tx and checks that
+// its pack set is at version. Locking the product row holds off packs being
+// added meanwhile, whose foreign keys need a share lock on it.
+func lockPackSet(ctx context.Context, tx *sqlx.Tx, productID uuid.UUID, version int) error {
+	var locked uuid.UUID
+	err := tx.QueryRowContext(ctx, `SELECT id FROM products WHERE id = $1 FOR UPDATE`, productID).Scan(&locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.ErrProductNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	query := `SELECT ` + headColumns + ` FROM packs p WHERE p.product_id = $1 AND p.active FOR UPDATE`
+	rows, err := tx.QueryContext(ctx, query, productID)
+	if err != nil {
+		return fmt.Errorf("failed to lock packs: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var packs []entity.Pack
+	for rows.Next() {
+		pack, err := scanPack(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan pack: %w", err)
+		}
+		packs = append(packs, *pack)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate packs: %w", err)
+	}
+
+	if stored := entity.PackSetVersion(packs); stored != version {
+		return fmt.Errorf("%w: pack set is at version %d, not %d", entity.ErrPackModified, stored, version)
+	}
+	return nil
+}
+
+// createPack inserts a pack with its first revision inside tx
+func createPack(ctx context.Context, tx *sqlx.Tx, pack *entity.Pack) error {
+	query := `INSERT INTO packs (id, product_id, size, unit_cost, weight_grams, length_mm, width_mm, height_mm,
+			  revision, active, valid_from, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	unitCost, weight, length, width, height := packAttributeArgs(pack)
+	_, err := tx.ExecContext(ctx, query, pack.ID(), pack.ProductID(), pack.Size(), unitCost, weight, length, width, height,
+		pack.Revision(), pack.Active(), pack.ValidFrom(), pack.CreatedAt(), pack.UpdatedAt())
 	if err != nil {
-		r.logger.Error("Failed to delete pack %s: %v", pack.ID(), err)
-		return fmt.Errorf("failed to delete pack: %w", err)
+		return fmt.Errorf("failed to create pack: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return insertRevision(ctx, tx, pack)
+}
+
+// retirePack marks a deactivated pack retired and ends its revisions inside tx
+func retirePack(ctx context.Context, tx *sqlx.Tx, pack *entity.Pack) error {
+	validTo, ends := pack.ValidTo()
+	if pack.Active() || !ends {
+		return fmt.Errorf("pack %s must be deactivated before it is retired", pack.ID())
+	}
+
+	if err := lockRevision(ctx, tx, pack); err != nil {
+		return err
+	}
+
+	if err := endRevisions(ctx, tx, pack.ID(), validTo); err != nil {
+		return err
+	}
+
+	query := `UPDATE packs SET active = FALSE, valid_to = $2, updated_at = $3 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, pack.ID(), validTo, pack.UpdatedAt()); err != nil {
+		return fmt.Errorf("failed to retire pack: %w", err)
+	}
+	return nil
+}
+
+// lockRevision locks an active pack inside tx and checks that the pack's
+// revision is newer than the stored one, or the same one for a retirement
+func lockRevision(ctx context.Context, tx *sqlx.Tx, pack *entity.Pack) error {
+	var stored int
+	err := tx.QueryRowContext(ctx, `SELECT revision FROM packs WHERE id = $1 AND active FOR UPDATE`, pack.ID()).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.ErrPackNotFound
+	}
 	if err != nil {
-		r.logger.Error("Failed to get rows affected for pack deletion %s: %v", pack.ID(), err)
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to lock pack: %w", err)
+	}
+
+	expected := stored + 1
+	if !pack.Active() {
+		expected = stored
+	}
+	if pack.Revision() != expected {
+		return fmt.Errorf("%w: revision %d does not follow stored revision %d", entity.ErrPackModified, pack.Revision(), stored)
 	}
+	return nil
+}
 
-	if rowsAffected == 0 {
-		r.logger.Warn("Pack not found for deletion with ID: %s", pack.ID())
-		return fmt.Errorf("pack not found")
+// endRevisions ends the revisions of a pack that are still in effect at the
+// given time, inside tx. Revisions that take effect later end as they start.
+func endRevisions(ctx context.Context, tx *sqlx.Tx, packID uuid.UUID, at time.Time) error {
+	query := `UPDATE pack_revisions SET valid_to = GREATEST(valid_from, $2)
+			  WHERE pack_id = $1 AND (valid_to IS NULL OR valid_to > $2)`
+	if _, err := tx.ExecContext(ctx, query, packID, at); err != nil {
+		return fmt.Errorf("failed to end pack revisions: %w", err)
 	}
+	return nil
+}
 
-	r.logger.Info("Pack deleted successfully with ID: %s", pack.ID())
+// insertRevision records the current revision of a pack inside tx. It fails
+// with entity.ErrDuplicatePackSize when another pack of the product has the
+// same size while the revision is in effect.
+func insertRevision(ctx context.Context, tx *sqlx.Tx, pack *entity.Pack) error {
+	query := `INSERT INTO pack_revisions (pack_id, product_id, revision, size, unit_cost, weight_grams, length_mm, width_mm,
+			  height_mm, valid_from, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	unitCost, weight, length, width, height := packAttributeArgs(pack)
+	_, err := tx.ExecContext(ctx, query, pack.ID(), pack.ProductID(), pack.Revision(), pack.Size(), unitCost, weight,
+		length, width, height, pack.ValidFrom(), pack.UpdatedAt())
+	if isDuplicateSize(err) {
+		return fmt.Errorf("%w: %d", entity.ErrDuplicatePackSize, pack.Size())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create pack revision: %w", err)
+	}
 	return nil
 }
 
-// ExistsBySize check is pack exists
-func (r *packPostgres) ExistsBySize(ctx context.Context, size int) (bool, error) {
-	r.logger.Debug("Checking if pack size exists: %d", size)
-	query := `SELECT EXISTS(SELECT 1 FROM packs WHERE size = $1)`
+// ExistsBySize reports whether a pack of the product other than exceptID has a
+// revision of this size in effect at the given time or later
+func (r *packPostgres) ExistsBySize(ctx context.Context, productID uuid.UUID, size int64, at time.Time, exceptID uuid.UUID) (bool, error) {
+	r.logger.Debug("Checking if pack size %d exists for product %s from %v", size, productID, at)
+	query := `SELECT EXISTS(SELECT 1 FROM pack_revisions WHERE product_id = $1 AND size = $2 AND pack_id <> $4
+			  AND (valid_to IS NULL OR (valid_to > $3 AND valid_to > valid_from)))`
 
 	var exists bool
-	err := r.db.QueryRowContext(ctx, query, size).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, query, productID, size, at, exceptID).Scan(&exists)
 	if err != nil {
 		r.logger.Error("Failed to check if pack size %d exists: %v", size, err)
 		return false, fmt.Errorf("failed to check pack size existence: %w", err)
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/Strahinja-Polovina/packs/internal/infrastructure/database"
+	"github.com/Strahinja-Polovina/packs/migrations"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// testDB connects to the database named by TEST_DATABASE_URL and migrates it,
+// or skips the test when the variable is not set
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrator, err := database.NewMigrator(db, migrations.FS, logger.GetLogger())
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+// testProduct creates a product of its own for a test
+func testProduct(t *testing.T, db *sqlx.DB) uuid.UUID {
+	t.Helper()
+
+	product, err := entity.NewProduct(uuid.New(), "Test "+uuid.NewString())
+	if err != nil {
+		t.Fatalf("Failed to create product entity: %v", err)
+	}
+	if err := NewProductPostgres(db, logger.GetLogger()).Create(context.Background(), product); err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	return product.ID()
+}
+
+func TestPackPostgres_ScheduledSizeChange(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewPackPostgres(db, logger.GetLogger())
+	productID := testProduct(t, db)
+
+	pack, _ := entity.NewProductPack(uuid.New(), productID, 10, entity.PackAttributes{})
+	if err := repo.Create(ctx, pack); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	monday := time.Now().Add(72 * time.Hour)
+	if err := pack.Revise(20, entity.PackAttributes{}, monday); err != nil {
+		t.Fatalf("Failed to revise pack: %v", err)
+	}
+	if err := repo.Update(ctx, pack); err != nil {
+		t.Fatalf("Failed to schedule size change: %v", err)
+	}
+
+	// Size 10 stays in effect until Monday, size 20 is taken from then on
+	for _, size := range []int64{10, 20} {
+		exists, err := repo.ExistsBySize(ctx, productID, size, time.Now(), uuid.Nil)
+		if err != nil || !exists {
+			t.Errorf("Expected size %d to exist, got %t (error: %v)", size, exists, err)
+		}
+	}
+	exists, err := repo.ExistsBySize(ctx, productID, 10, monday, uuid.Nil)
+	if err != nil || exists {
+		t.Errorf("Expected size 10 to be free from Monday, got %t (error: %v)", exists, err)
+	}
+
+	duplicate, _ := entity.NewProductPack(uuid.New(), productID, 10, entity.PackAttributes{})
+	if err := repo.Create(ctx, duplicate); !errors.Is(err, entity.ErrDuplicatePackSize) {
+		t.Errorf("Expected ErrDuplicatePackSize adding the size in effect, got %v", err)
+	}
+
+	// From Monday the old size may be added again
+	later, _ := entity.NewScheduledPack(uuid.New(), productID, 10, entity.PackAttributes{}, monday)
+	if err := repo.Create(ctx, later); err != nil {
+		t.Errorf("Unexpected error adding size 10 from Monday: %v", err)
+	}
+
+	// Only one pack of size 10 is in effect now, and one from Monday
+	for _, at := range []time.Time{time.Now(), monday} {
+		packs, err := repo.ListAsOf(ctx, productID, at)
+		if err != nil {
+			t.Fatalf("Failed to list packs: %v", err)
+		}
+		count := 0
+		for _, p := range packs {
+			if p.Size() == 10 {
+				count++
+			}
+		}
+		if count != 1 {
+			t.Errorf("Expected one pack of size 10 in effect at %v, got %d", at, count)
+		}
+	}
+}
+
+func TestPackPostgres_ListAsOfMany(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repo := NewPackPostgres(db, logger.GetLogger())
+	productID := testProduct(t, db)
+	otherID := testProduct(t, db)
+
+	pack, _ := entity.NewProductPack(uuid.New(), productID, 10, entity.PackAttributes{})
+	if err := repo.Create(ctx, pack); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	before := time.Now()
+	if err := pack.Revise(20, entity.PackAttributes{}, before.Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to revise pack: %v", err)
+	}
+	if err := repo.Update(ctx, pack); err != nil {
+		t.Fatalf("Failed to schedule size change: %v", err)
+	}
+
+	catalogs := []repository.PackCatalog{
+		{ProductID: productID, At: before.Add(2 * time.Hour)},
+		{ProductID: otherID, At: before},
+		{ProductID: productID, At: before},
+	}
+	packs, err := repo.ListAsOfMany(ctx, catalogs)
+	if err != nil {
+		t.Fatalf("Failed to list packs: %v", err)
+	}
+
+	expected := [][]int64{{20}, {}, {10}}
+	if len(packs) != len(expected) {
+		t.Fatalf("Expected %d pack lists, got %d", len(expected), len(packs))
+	}
+	for i, sizes := range expected {
+		if len(packs[i]) != len(sizes) {
+			t.Errorf("Expected %d packs for catalog %d, got %d", len(sizes), i, len(packs[i]))
+			continue
+		}
+		for j, size := range sizes {
+			if packs[i][j].Size() != size {
+				t.Errorf("Expected size %d for catalog %d, got %d", size, i, packs[i][j].Size())
+			}
+		}
+	}
+}
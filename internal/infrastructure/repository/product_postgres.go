@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type productPostgres struct {
+	db     *sqlx.DB
+	logger *logger.Logger
+}
+
+func NewProductPostgres(db *sqlx.DB, logger *logger.Logger) repository.ProductRepository {
+	return &productPostgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// productColumns are the columns read by scanProduct, in order
+const productColumns = `id, name, created_at, updated_at`
+
+// scanProduct reads a product selected with productColumns
+func scanProduct(row rowScanner) (*entity.Product, error) {
+	var id uuid.UUID
+	var name string
+	var createdAt, updatedAt sql.NullTime
+
+	if err := row.Scan(&id, &name, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	product, err := entity.NewProduct(id, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create product entity: %w", err)
+	}
+
+	// Set timestamps from database if they exist
+	if createdAt.Valid && updatedAt.Valid {
+		product.SetTimestamps(createdAt.Time, updatedAt.Time)
+	}
+
+	return product, nil
+}
+
+// List products from database in ascending order by name.
+func (r *productPostgres) List(ctx context.Context) []entity.Product {
+	r.logger.Debug("Listing all products from database")
+	query := `SELECT ` + productColumns + ` FROM products ORDER BY name`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to query products: %v", err)
+		return []entity.Product{}
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var products []entity.Product
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			r.logger.Warn("Failed to scan product: %v", err)
+			continue
+		}
+
+		products = append(products, *product)
+	}
+
+	return products
+}
+
+// Get product by id
+func (r *productPostgres) Get(ctx context.Context, id uuid.UUID) (*entity.Product, error) {
+	r.logger.Debug("Getting product by ID: %s", id)
+	query := `SELECT ` + productColumns + ` FROM products WHERE id = $1`
+
+	product, err := scanProduct(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn("Product not found with ID: %s", id)
+			return nil, entity.ErrProductNotFound
+		}
+		r.logger.Error("Failed to get product %s: %v", id, err)
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	return product, nil
+}
+
+// Create product
+func (r *productPostgres) Create(ctx context.Context, product *entity.Product) error {
+	r.logger.Info("Creating product with ID: %s, name: %s", product.ID(), product.Name())
+	query := `INSERT INTO products (id, name, created_at, updated_at) VALUES ($1, $2, $3, $4)`
+
+	_, err := r.db.ExecContext(ctx, query, product.ID(), product.Name(), product.CreatedAt(), product.UpdatedAt())
+	if err != nil {
+		r.logger.Error("Failed to create product %s: %v", product.ID(), err)
+		return fmt.Errorf("failed to create product: %w", err)
+	}
+
+	r.logger.Info("Product created successfully with ID: %s", product.ID())
+	return nil
+}
+
+// Update product
+func (r *productPostgres) Update(ctx context.Context, product *entity.Product) error {
+	r.logger.Info("Updating product with ID: %s, new name: %s", product.ID(), product.Name())
+	query := `UPDATE products SET name = $2, updated_at = $3 WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, product.ID(), product.Name(), product.UpdatedAt())
+	if err != nil {
+		r.logger.Error("Failed to update product %s: %v", product.ID(), err)
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected for product %s: %v", product.ID(), err)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		r.logger.Warn("Product not found for update with ID: %s", product.ID())
+		return entity.ErrProductNotFound
+	}
+
+	r.logger.Info("Product updated successfully with ID: %s", product.ID())
+	return nil
+}
+
+// Delete product. Products that still have active packs or orders are kept.
+func (r *productPostgres) Delete(ctx context.Context, product *entity.Product) error {
+	r.logger.Info("Deleting product with ID: %s", product.ID())
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction for product deletion %s: %v", product.ID(), err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Locking the product keeps packs and orders from being added to it meanwhile
+	var locked uuid.UUID
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM products WHERE id = $1 FOR UPDATE`, product.ID()).Scan(&locked); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Warn("Product not found for deletion with ID: %s", product.ID())
+			return entity.ErrProductNotFound
+		}
+		r.logger.Error("Failed to lock product %s: %v", product.ID(), err)
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+
+	var inUse bool
+	query := `SELECT EXISTS(SELECT 1 FROM packs WHERE product_id = $1 AND active) OR EXISTS(SELECT 1 FROM order_lines WHERE product_id = $1)`
+	if err := tx.QueryRowContext(ctx, query, product.ID()).Scan(&inUse); err != nil {
+		r.logger.Error("Failed to check usage of product %s: %v", product.ID(), err)
+		return fmt.Errorf("failed to check product usage: %w", err)
+	}
+	if inUse {
+		r.logger.Warn("Product %s still has packs or orders", product.ID())
+		return entity.ErrProductInUse
+	}
+
+	// Without orders, nothing needs the history of the product's retired packs
+	if _, err := tx.ExecContext(ctx, `DELETE FROM packs WHERE product_id = $1`, product.ID()); err != nil {
+		r.logger.Error("Failed to delete retired packs of product %s: %v", product.ID(), err)
+		return fmt.Errorf("failed to delete retired packs: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM products WHERE id = $1`, product.ID()); err != nil {
+		r.logger.Error("Failed to delete product %s: %v", product.ID(), err)
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit product deletion %s: %v", product.ID(), err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Product deleted successfully with ID: %s", product.ID())
+	return nil
+}
+
+// ExistsByName checks if a product with this name exists
+func (r *productPostgres) ExistsByName(ctx context.Context, name string) (bool, error) {
+	r.logger.Debug("Checking if product name exists: %s", name)
+	query := `SELECT EXISTS(SELECT 1 FROM products WHERE name = $1)`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&exists)
+	if err != nil {
+		r.logger.Error("Failed to check if product name %s exists: %v", name, err)
+		return false, fmt.Errorf("failed to check product name existence: %w", err)
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type stockPostgres struct {
+	db     *sqlx.DB
+	logger *logger.Logger
+}
+
+func NewStockPostgres(db *sqlx.DB, logger *logger.Logger) repository.StockRepository {
+	return &stockPostgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// stockColumns are the columns read by scanStock, in order
+const stockColumns = `s.pack_id, p.size, s.quantity, s.created_at, s.updated_at`
+
+// scanStock reads a stock level selected with stockColumns
+func scanStock(row rowScanner) (*entity.StockLevel, error) {
+	var packID uuid.UUID
+	var size, quantity int64
+	var createdAt, updatedAt sql.NullTime
+
+	if err := row.Scan(&packID, &size, &quantity, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	stock, err := entity.NewStockLevel(packID, size, quantity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stock entity: %w", err)
+	}
+
+	// Set timestamps from database if they exist
+	if createdAt.Valid && updatedAt.Valid {
+		stock.SetTimestamps(createdAt.Time, updatedAt.Time)
+	}
+
+	return stock, nil
+}
+
+// List tracked stock levels of active packs in ascending order by pack size.
+func (r *stockPostgres) List(ctx context.Context) ([]entity.StockLevel, error) {
+	r.logger.Debug("Listing stock levels from database")
+	query := `SELECT ` + stockColumns + ` FROM pack_stock s JOIN packs p ON p.id = s.pack_id WHERE p.active ORDER BY p.size`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to query stock levels: %v", err)
+		return nil, fmt.Errorf("failed to query stock levels: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	levels := []entity.StockLevel{}
+	for rows.Next() {
+		stock, err := scanStock(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan stock level: %v", err)
+			return nil, fmt.Errorf("failed to scan stock level: %w", err)
+		}
+
+		levels = append(levels, *stock)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate stock levels: %w", err)
+	}
+
+	return levels, nil
+}
+
+// Get stock level by pack id
+func (r *stockPostgres) Get(ctx context.Context, packID uuid.UUID) (*entity.StockLevel, error) {
+	r.logger.Debug("Getting stock level for pack: %s", packID)
+	query := `SELECT ` + stockColumns + ` FROM pack_stock s JOIN packs p ON p.id = s.pack_id WHERE s.pack_id = $1`
+
+	stock, err := scanStock(r.db.QueryRowContext(ctx, query, packID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entity.ErrStockNotTracked
+		}
+		r.logger.Error("Failed to get stock level for pack %s: %v", packID, err)
+		return nil, fmt.Errorf("failed to get stock level: %w", err)
+	}
+
+	return stock, nil
+}
+
+// Save creates or replaces the stock level of a pack
+func (r *stockPostgres) Save(ctx context.Context, stock *entity.StockLevel) error {
+	r.logger.Info("Setting stock for pack %s to %d", stock.PackID(), stock.Quantity())
+	query := `INSERT INTO pack_stock (pack_id, quantity, created_at, updated_at) VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (pack_id) DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = EXCLUDED.updated_at`
+
+	_, err := r.db.ExecContext(ctx, query, stock.PackID(), stock.Quantity(), stock.CreatedAt(), stock.UpdatedAt())
+	if err != nil {
+		r.logger.Error("Failed to save stock for pack %s: %v", stock.PackID(), err)
+		return fmt.Errorf("failed to save stock: %w", err)
+	}
+
+	return nil
+}
+
+// Adjust changes the stock level of a pack by delta under a row lock.
+// Packs that are not tracked yet start from zero.
+func (r *stockPostgres) Adjust(ctx context.Context, packID uuid.UUID, delta int64) (*entity.StockLevel, error) {
+	r.logger.Info("Adjusting stock for pack %s by %d", packID, delta)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction for stock adjustment %s: %v", packID, err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Locking the pack row serializes adjustments even before a stock row exists
+	query := `SELECT p.size, s.quantity FROM packs p LEFT JOIN pack_stock s ON s.pack_id = p.id
+			  WHERE p.id = $1 AND p.active FOR UPDATE OF p`
+
+	var size int64
+	var quantity sql.NullInt64
+	if err := tx.QueryRowContext(ctx, query, packID).Scan(&size, &quantity); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entity.ErrPackNotFound
+		}
+		r.logger.Error("Failed to lock stock for pack %s: %v", packID, err)
+		return nil, fmt.Errorf("failed to lock stock: %w", err)
+	}
+
+	stock, err := entity.NewStockLevel(packID, size, quantity.Int64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stock entity: %w", err)
+	}
+	if err := stock.Adjust(delta); err != nil {
+		return nil, err
+	}
+
+	upsert := `INSERT INTO pack_stock (pack_id, quantity, created_at, updated_at) VALUES ($1, $2, $3, $4)
+			   ON CONFLICT (pack_id) DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = EXCLUDED.updated_at`
+	if _, err := tx.ExecContext(ctx, upsert, packID, stock.Quantity(), stock.CreatedAt(), stock.UpdatedAt()); err != nil {
+		r.logger.Error("Failed to adjust stock for pack %s: %v", packID, err)
+		return nil, fmt.Errorf("failed to adjust stock: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit stock adjustment for pack %s: %v", packID, err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Stock for pack %s is now %d", packID, stock.Quantity())
+	return stock, nil
+}
+
+// Delete stops tracking stock for a pack
+func (r *stockPostgres) Delete(ctx context.Context, packID uuid.UUID) error {
+	r.logger.Info("Removing stock tracking for pack %s", packID)
+	query := `DELETE FROM pack_stock WHERE pack_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, packID)
+	if err != nil {
+		r.logger.Error("Failed to delete stock for pack %s: %v", packID, err)
+		return fmt.Errorf("failed to delete stock: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected for stock deletion %s: %v", packID, err)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return entity.ErrStockNotTracked
+	}
+
+	return nil
+}
+
+// productPacks is a quantity of one pack size of one product
+type productPacks struct {
+	productID   uuid.UUID
+	packageSize int64
+	quantity    int64
+}
+
+// lineStock sums the items of order lines by product and pack size, in the
+// order their stock rows are locked
+func lineStock(lines []entity.OrderLine) []productPacks {
+	type key struct {
+		productID   uuid.UUID
+		packageSize int64
+	}
+	quantities := make(map[key]int64)
+	for _, line := range lines {
+		for _, item := range line.GetItems() {
+			quantities[key{line.ProductID(), item.PackageSize()}] += item.Quantity()
+		}
+	}
+
+	packs := make([]productPacks, 0, len(quantities))
+	for k, quantity := range quantities {
+		packs = append(packs, productPacks{productID: k.productID, packageSize: k.packageSize, quantity: quantity})
+	}
+	sort.Slice(packs, func(i, j int) bool {
+		if packs[i].productID != packs[j].productID {
+			return packs[i].productID.String() < packs[j].productID.String()
+		}
+		return packs[i].packageSize < packs[j].packageSize
+	})
+	return packs
+}
+
+// reserveStock takes the packs of order lines out of the stock of their
+// products' packs in effect now, inside tx. Packs are locked in product and size order so concurrent orders
+// cannot deadlock.
+func reserveStock(ctx context.Context, tx *sqlx.Tx, lines []entity.OrderLine) error {
+	lockQuery := `SELECT s.pack_id, s.quantity FROM pack_stock s JOIN packs p ON p.id = s.pack_id ` + revisionInEffect + `
+				  WHERE p.product_id = $2 AND r.size = $3 FOR UPDATE OF s`
+	updateQuery := `UPDATE pack_stock SET quantity = quantity - $2, updated_at = NOW() WHERE pack_id = $1`
+
+	now := time.Now()
+	for _, packs := range lineStock(lines) {
+		var packID uuid.UUID
+		var quantity int64
+		err := tx.QueryRowContext(ctx, lockQuery, now, packs.productID, packs.packageSize).Scan(&packID, &quantity)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Stock is not tracked for this size
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock stock for pack size %d: %w", packs.packageSize, err)
+		}
+
+		if quantity < packs.quantity {
+			return fmt.Errorf("%w: pack size %d has %d on hand, %d needed",
+				entity.ErrInsufficientStock, packs.packageSize, quantity, packs.quantity)
+		}
+
+		if _, err := tx.ExecContext(ctx, updateQuery, packID, packs.quantity); err != nil {
+			return fmt.Errorf("failed to reserve stock for pack size %d: %w", packs.packageSize, err)
+		}
+	}
+
+	return nil
+}
+
+// releaseStock returns the packs of order lines to the tracked stock of their
+// products' packs in effect now, inside the caller's transaction. Sizes
+// without tracked stock, or no longer in effect, are skipped.
+func releaseStock(ctx context.Context, tx *sqlx.Tx, lines []entity.OrderLine) error {
+	updateQuery := `UPDATE pack_stock s SET quantity = s.quantity + $4, updated_at = NOW()
+					FROM packs p ` + revisionInEffect + `
+					WHERE p.id = s.pack_id AND p.product_id = $2 AND r.size = $3`
+
+	now := time.Now()
+	for _, packs := range lineStock(lines) {
+		if _, err := tx.ExecContext(ctx, updateQuery, now, packs.productID, packs.packageSize, packs.quantity); err != nil {
+			return fmt.Errorf("failed to release stock for pack size %d: %w", packs.packageSize, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Strahinja-Polovina/packs/internal/application/service"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/problem"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// CalculationHandler handles HTTP requests for stateless pack calculations
+type CalculationHandler struct {
+	service *service.PackService
+	logger  *logger.Logger
+}
+
+// NewCalculationHandler creates a new calculation handler
+func NewCalculationHandler(service *service.PackService, logger *logger.Logger) *CalculationHandler {
+	return &CalculationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Calculate handles POST /api/v1/calculations
+// @Summary Calculate a pack combination
+// @Description Calculate the optimal pack combination for an amount without creating an order. When the solver runs out of time it returns the best combination found so far with optimal set to false, or 503 when it found none.
+// @Tags calculations
+// @Accept json
+// @Produce json
+// @Param request body service.PackCalculationRequest true "Pack calculation request"
+// @Success 200 {object} service.PackCalculationResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 503 {object} problem.Details
+// @Router /api/v1/calculations [post]
+func (h *CalculationHandler) Calculate(c *gin.Context) {
+	h.logger.Info("Received calculation request")
+
+	var req service.PackCalculationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request format: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	result, err := h.service.CalculateOptimalPacks(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Calculation failed: %v", err)
+		problem.Error(c, err)
+		return
+	}
+
+	h.logger.Info("Calculation completed for amount %d with %d packs", result.Amount, result.TotalPacks)
+	c.JSON(http.StatusOK, result)
+}
+
+// CalculateBatch handles POST /api/v1/calculations/batch
+// @Summary Calculate pack combinations for many amounts
+// @Description Calculate the optimal pack combination for up to 1000 amounts in one request. Results are returned in input order; an amount that cannot be calculated gets an error instead of a result.
+// @Tags calculations
+// @Accept json
+// @Produce json
+// @Param request body service.BatchCalculationRequest true "Batch calculation request"
+// @Success 200 {object} service.BatchCalculationResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 503 {object} problem.Details
+// @Router /api/v1/calculations/batch [post]
+func (h *CalculationHandler) CalculateBatch(c *gin.Context) {
+	h.logger.Info("Received batch calculation request")
+
+	var req service.BatchCalculationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request format: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	result, err := h.service.CalculateBatch(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Batch calculation failed: %v", err)
+		problem.Error(c, err)
+		return
+	}
+
+	h.logger.Info("Batch calculation completed for %d amounts", result.Count)
+	c.JSON(http.StatusOK, result)
+}
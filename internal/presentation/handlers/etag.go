@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Strahinja-Polovina/packs/internal/application/service"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// setETag sets the ETag of a response to the version of the resource it
+// returns. ETags are strong, so they can be sent back in If-Match.
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatchVersion returns the version a write is conditional on, taken from the
+// If-Match header. It responds with the problem parseIfMatch finds and
+// returns false then.
+func ifMatchVersion(c *gin.Context) (int, bool) {
+	version, details := parseIfMatch(c)
+	if details != nil {
+		problem.Respond(c, *details)
+		return 0, false
+	}
+	return version, true
+}
+
+// parseIfMatch reads the version in the If-Match header of a request. "*"
+// matches any version. A missing header is a 428 problem, and a header that
+// holds no version of the resource a 412 one.
+func parseIfMatch(c *gin.Context) (int, *problem.Details) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		details := problem.New(http.StatusPreconditionRequired, problem.CodePreconditionRequired,
+			"send the ETag of the resource in If-Match to change it")
+		return 0, &details
+	}
+	if header == "*" {
+		return service.AnyVersion, nil
+	}
+
+	// Weak tags and lists never name a single stored version
+	unquoted, err := strconv.Unquote(header)
+	if err == nil && strings.HasPrefix(header, `"`) {
+		if version, err := strconv.Atoi(unquoted); err == nil && version > 0 {
+			return version, nil
+		}
+	}
+
+	details := problem.New(http.StatusPreconditionFailed, problem.CodePreconditionFailed,
+		"If-Match does not hold the current ETag of the resource")
+	return 0, &details
+}
@@ -4,8 +4,11 @@ import (
 	"net/http"
 
 	"github.com/Strahinja-Polovina/packs/internal/application/service"
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/problem"
 	"github.com/Strahinja-Polovina/packs/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // OrderHandler handles HTTP requests for orders
@@ -24,14 +27,19 @@ func NewOrderHandler(service *service.OrderService, logger *logger.Logger) *Orde
 
 // CreateOrder handles POST /api/v1/orders
 // @Summary Create a new order
-// @Description Create a new order from pack calculation
+// @Description Create a new order from pack calculation. Send either an amount, or lines that are each calculated on their own with their own amount and product. Send an Idempotency-Key header to make retries safe: a repeated request returns the original response, and reusing the key with a different payload returns 422.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param request body service.OrderRequest true "Order creation request"
+// @Param Idempotency-Key header string false "Client-chosen key identifying this order request"
 // @Success 201 {object} service.OrderResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 422 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 503 {object} problem.Details
 // @Router /api/v1/orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	h.logger.Info("Received create order request")
@@ -40,48 +48,339 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Invalid request format: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request format",
-			Message: err.Error(),
-		})
+		problem.Respond(c, problem.InvalidRequest(err))
 		return
 	}
 
 	result, err := h.service.CreateOrderFromCalculation(c.Request.Context(), req)
 	if err != nil {
 		h.logger.Error("Order creation failed: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Order creation failed",
-			Message: err.Error(),
-		})
+		problem.Error(c, err)
 		return
 	}
 
 	h.logger.Info("Order created successfully with ID: %s", result.OrderID)
+	setETag(c, result.Version)
 	c.JSON(http.StatusCreated, result)
 }
 
-// GetAllOrders handles GET /api/v1/orders
-// @Summary Get all orders
-// @Description Retrieve all orders from the system
+// GetOrder handles GET /api/v1/orders/:id
+// @Summary Get an order
+// @Description Retrieve a single order with its line items. The ETag header holds its version for conditional changes.
 // @Tags orders
 // @Produce json
-// @Success 200 {array} service.OrderResponse
-// @Failure 500 {object} ErrorResponse
+// @Param id path string true "Order ID" format(uuid)
+// @Success 200 {object} service.OrderResponse
+// @Header 200 {string} ETag "Version of the order"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/orders/{id} [get]
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	idStr := c.Param("id")
+	h.logger.Info("Received get order request for ID: %s", idStr)
+
+	orderID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid order ID format: %s", idStr)
+		problem.Respond(c, problem.InvalidID("Order ID"))
+		return
+	}
+
+	order, err := h.service.GetOrder(c.Request.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve order %s: %v", orderID, err)
+		problem.Error(c, err)
+		return
+	}
+
+	h.logger.Info("Successfully retrieved order with ID: %s", orderID)
+	setETag(c, order.Version)
+	c.JSON(http.StatusOK, order)
+}
+
+// ListOrders handles GET /api/v1/orders
+// @Summary List orders
+// @Description Retrieve one page of orders. Pass next_cursor from a page as cursor to fetch the next one.
+// @Tags orders
+// @Produce json
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param limit query int false "Page size, 1 to 100" default(20)
+// @Param sort query string false "Sort order" Enums(created_at, -created_at, amount, -amount) default(-created_at)
+// @Param created_from query string false "Only orders created at or after this time (RFC 3339)" format(date-time)
+// @Param created_to query string false "Only orders created before this time (RFC 3339)" format(date-time)
+// @Param min_amount query int false "Minimum requested amount"
+// @Param max_amount query int false "Maximum requested amount"
+// @Param pack_size query int false "Only orders that ship packs of this size"
+// @Param product_id query string false "Only orders of this product" format(uuid)
+// @Success 200 {object} service.OrderListResponse
+// @Failure 400 {object} problem.Details
+// @Failure 500 {object} problem.Details
 // @Router /api/v1/orders [get]
-func (h *OrderHandler) GetAllOrders(c *gin.Context) {
-	h.logger.Info("Received get all orders request")
+func (h *OrderHandler) ListOrders(c *gin.Context) {
+	h.logger.Info("Received list orders request")
 
-	orders, err := h.service.GetAllOrders(c.Request.Context())
+	var query service.OrderListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("Invalid query parameters: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	orders, err := h.service.ListOrders(c.Request.Context(), query)
 	if err != nil {
 		h.logger.Error("Failed to retrieve orders: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to retrieve orders",
-			Message: err.Error(),
-		})
+		problem.Error(c, err)
 		return
 	}
 
-	h.logger.Info("Successfully retrieved %d orders", len(orders))
+	h.logger.Info("Successfully retrieved %d orders", orders.Count)
 	c.JSON(http.StatusOK, orders)
 }
+
+// AmendOrder handles PUT /api/v1/orders/:id
+// @Summary Amend an order
+// @Description Recalculate an order for a new requested amount or new lines against the current pack sets and replace its lines. Lines without a product keep the product of the line they replace. The replaced version is kept as a revision. Orders can be amended until picking starts. If-Match must hold the ETag of the order version the amendment is based on.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Param If-Match header string true "ETag of the order, or * to amend any version"
+// @Param request body service.OrderRequest true "New requested amount or lines"
+// @Success 200 {object} service.OrderResponse
+// @Header 200 {string} ETag "Version of the order"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 503 {object} problem.Details
+// @Router /api/v1/orders/{id} [put]
+func (h *OrderHandler) AmendOrder(c *gin.Context) {
+	idStr := c.Param("id")
+	h.logger.Info("Received amend order request for ID: %s", idStr)
+
+	orderID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid order ID format: %s", idStr)
+		problem.Respond(c, problem.InvalidID("Order ID"))
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req service.OrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request format: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	order, err := h.service.AmendOrder(c.Request.Context(), orderID, req, version)
+	if err != nil {
+		h.logger.Error("Order amendment failed: %v", err)
+		problem.Error(c, err)
+		return
+	}
+
+	h.logger.Info("Order %s amended to revision %d", orderID, order.Revision)
+	setETag(c, order.Version)
+	c.JSON(http.StatusOK, order)
+}
+
+// GetOrderRevisions handles GET /api/v1/orders/:id/revisions
+// @Summary Get order revisions
+// @Description Retrieve the versions an order had before it was amended, oldest first
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Success 200 {array} service.OrderRevisionResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/orders/{id}/revisions [get]
+func (h *OrderHandler) GetOrderRevisions(c *gin.Context) {
+	idStr := c.Param("id")
+	h.logger.Info("Received order revisions request for ID: %s", idStr)
+
+	orderID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid order ID format: %s", idStr)
+		problem.Respond(c, problem.InvalidID("Order ID"))
+		return
+	}
+
+	revisions, err := h.service.GetOrderRevisions(c.Request.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve revisions of order %s: %v", orderID, err)
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// ConfirmOrder handles POST /api/v1/orders/:id/confirm
+// @Summary Confirm an order
+// @Description Move a draft order to confirmed
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Param If-Match header string true "ETag of the order, or * to change any version"
+// @Success 200 {object} service.OrderResponse
+// @Header 200 {string} ETag "Version of the order"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/orders/{id}/confirm [post]
+func (h *OrderHandler) ConfirmOrder(c *gin.Context) {
+	h.transitionOrder(c, entity.OrderStatusConfirmed)
+}
+
+// PickOrder handles POST /api/v1/orders/:id/pick
+// @Summary Start picking an order
+// @Description Move a confirmed order to picking
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Param If-Match header string true "ETag of the order, or * to change any version"
+// @Success 200 {object} service.OrderResponse
+// @Header 200 {string} ETag "Version of the order"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/orders/{id}/pick [post]
+func (h *OrderHandler) PickOrder(c *gin.Context) {
+	h.transitionOrder(c, entity.OrderStatusPicking)
+}
+
+// PackOrder handles POST /api/v1/orders/:id/pack
+// @Summary Mark an order packed
+// @Description Move an order that is being picked to packed
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Param If-Match header string true "ETag of the order, or * to change any version"
+// @Success 200 {object} service.OrderResponse
+// @Header 200 {string} ETag "Version of the order"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/orders/{id}/pack [post]
+func (h *OrderHandler) PackOrder(c *gin.Context) {
+	h.transitionOrder(c, entity.OrderStatusPacked)
+}
+
+// ShipOrder handles POST /api/v1/orders/:id/ship
+// @Summary Ship an order
+// @Description Move a packed order to shipped. Shipped orders are final.
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Param If-Match header string true "ETag of the order, or * to change any version"
+// @Success 200 {object} service.OrderResponse
+// @Header 200 {string} ETag "Version of the order"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/orders/{id}/ship [post]
+func (h *OrderHandler) ShipOrder(c *gin.Context) {
+	h.transitionOrder(c, entity.OrderStatusShipped)
+}
+
+// CancelOrder handles POST /api/v1/orders/:id/cancel
+// @Summary Cancel an order
+// @Description Cancel an order that has not shipped yet. Its packs are returned to tracked stock.
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Param If-Match header string true "ETag of the order, or * to change any version"
+// @Success 200 {object} service.OrderResponse
+// @Header 200 {string} ETag "Version of the order"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/orders/{id}/cancel [post]
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	h.transitionOrder(c, entity.OrderStatusCancelled)
+}
+
+// transitionOrder moves the order named in the path to status
+func (h *OrderHandler) transitionOrder(c *gin.Context, status entity.OrderStatus) {
+	idStr := c.Param("id")
+	h.logger.Info("Received request to move order %s to %s", idStr, status)
+
+	orderID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid order ID format: %s", idStr)
+		problem.Respond(c, problem.InvalidID("Order ID"))
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.service.TransitionOrder(c.Request.Context(), orderID, status, version)
+	if err != nil {
+		h.logger.Error("Failed to update order %s: %v", orderID, err)
+		problem.Error(c, err)
+		return
+	}
+
+	h.logger.Info("Order %s is now %s", orderID, order.Status)
+	setETag(c, order.Version)
+	c.JSON(http.StatusOK, order)
+}
+
+// GetOrderHistory handles GET /api/v1/orders/:id/history
+// @Summary Get order status history
+// @Description Retrieve the status changes of an order, oldest first
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID" format(uuid)
+// @Success 200 {array} service.OrderStatusChangeResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/orders/{id}/history [get]
+func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	h.logger.Info("Received order history request for ID: %s", idStr)
+
+	orderID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid order ID format: %s", idStr)
+		problem.Respond(c, problem.InvalidID("Order ID"))
+		return
+	}
+
+	history, err := h.service.GetOrderHistory(c.Request.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve history of order %s: %v", orderID, err)
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -1,11 +1,12 @@
 package handlers
 
 import (
-	"errors"
 	"net/http"
+	"time"
 
 	"github.com/Strahinja-Polovina/packs/internal/application/service"
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/problem"
 	"github.com/Strahinja-Polovina/packs/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -27,24 +28,63 @@ func NewPackCalculatorHandler(service *service.PackCalculatorService, logger *lo
 
 // GetPackSizes handles GET /api/v1/pack-sizes
 // @Summary Get available pack sizes
-// @Description Get all available pack sizes from the system
+// @Description Get the pack sizes in effect now of every product, or of one product when product_id is given. With as_of, list the revisions of one product's pack sizes that were in effect at that time, or are scheduled to be; the default product is used when product_id is omitted. When one product's pack sizes in effect now are listed, the ETag header holds the version of its pack set for PUT /api/v1/pack-sizes.
 // @Tags packs
 // @Produce json
+// @Param product_id query string false "Only list the pack sizes of this product" format(uuid)
+// @Param as_of query string false "List the pack sizes in effect at this time (RFC 3339)" format(date-time)
 // @Success 200 {object} PackSizesResponse
-// @Failure 500 {object} ErrorResponse
+// @Header 200 {string} ETag "Version of the product's pack set, without as_of"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
 // @Router /api/v1/pack-sizes [get]
 func (h *PackCalculatorHandler) GetPackSizes(c *gin.Context) {
 	h.logger.Info("Received get pack sizes request")
 
-	packs := h.service.GetPackService().GetAllPacks(c.Request.Context())
+	productID := entity.DefaultProductID
+	idStr := c.Query("product_id")
+	if idStr != "" {
+		var err error
+		productID, err = uuid.Parse(idStr)
+		if err != nil {
+			h.logger.Error("Invalid product ID format: %s", idStr)
+			problem.Respond(c, problem.InvalidID("Product ID"))
+			return
+		}
+	}
+
+	var packs []entity.Pack
+	var err error
+	switch asOf := c.Query("as_of"); {
+	case asOf != "":
+		at, parseErr := time.Parse(time.RFC3339, asOf)
+		if parseErr != nil {
+			h.logger.Error("Invalid as_of time: %s", asOf)
+			problem.Respond(c, problem.New(http.StatusBadRequest, problem.CodeInvalidRequest, "as_of must be an RFC 3339 time"))
+			return
+		}
+		packs, err = h.service.GetProductService().GetProductPacksAsOf(c.Request.Context(), productID, at)
+	case idStr != "":
+		packs, err = h.service.GetProductService().GetProductPacks(c.Request.Context(), productID)
+		if err == nil {
+			var version int
+			if version, err = h.service.GetPackService().PackSetVersion(c.Request.Context(), productID); err == nil {
+				setETag(c, version)
+			}
+		}
+	default:
+		packs, err = h.service.GetPackService().GetAllPacks(c.Request.Context())
+	}
+	if err != nil {
+		h.respondProductLookupError(c, productID, err)
+		return
+	}
 
 	// Convert pack entities to PackResponse objects
 	packResponses := make([]PackResponse, len(packs))
 	for i, pack := range packs {
-		packResponses[i] = PackResponse{
-			ID:   pack.ID(),
-			Size: pack.Size(),
-		}
+		packResponses[i] = newPackResponse(&pack)
 	}
 
 	h.logger.Info("Successfully retrieved %d pack sizes", len(packResponses))
@@ -56,15 +96,16 @@ func (h *PackCalculatorHandler) GetPackSizes(c *gin.Context) {
 
 // CreatePackSize handles POST /api/v1/pack-sizes
 // @Summary Create a new pack size
-// @Description Add a new pack size to the system
+// @Description Add a new pack size to a product, or to the default product when product_id is omitted. Sizes are unique among a product's active packs. The pack takes effect at valid_from, or now when it is omitted.
 // @Tags packs
 // @Accept json
 // @Produce json
 // @Param request body CreatePackSizeRequest true "Pack size creation request"
 // @Success 201 {object} PackResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 409 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 500 {object} problem.Details
 // @Router /api/v1/pack-sizes [post]
 func (h *PackCalculatorHandler) CreatePackSize(c *gin.Context) {
 	h.logger.Info("Received create pack size request")
@@ -73,60 +114,141 @@ func (h *PackCalculatorHandler) CreatePackSize(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Invalid request format for create pack size: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request format",
-			Message: err.Error(),
-		})
+		problem.Respond(c, problem.InvalidRequest(err))
 		return
 	}
 
-	pack, err := entity.NewPack(uuid.New(), req.Size)
+	productID := entity.DefaultProductID
+	if req.ProductID != uuid.Nil {
+		product, err := h.service.GetProductService().GetProduct(c.Request.Context(), req.ProductID)
+		if err != nil {
+			h.respondProductLookupError(c, req.ProductID, err)
+			return
+		}
+		productID = product.ID()
+	}
+
+	pack, err := entity.NewScheduledPack(uuid.New(), productID, req.Size, req.attributes(), req.validFrom())
 	if err != nil {
-		h.logger.Error("Invalid pack size %d: %v", req.Size, err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid pack size",
-			Message: err.Error(),
-		})
+		h.logger.Error("Invalid pack of size %d: %v", req.Size, err)
+		problem.Error(c, err)
 		return
 	}
 
 	err = h.service.GetPackService().CreatePack(c.Request.Context(), pack)
 	if err != nil {
-		if errors.Is(err, entity.ErrDuplicatePackSize) {
-			h.logger.Warn("Attempted to create duplicate pack size: %d", req.Size)
-			c.JSON(http.StatusConflict, ErrorResponse{
-				Error:   "Duplicate pack size",
-				Message: "A pack with this size already exists",
-			})
-		} else {
-			h.logger.Error("Failed to create pack size %d: %v", req.Size, err)
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "Failed to create pack size",
-				Message: err.Error(),
-			})
-		}
+		h.logger.Error("Failed to create pack size %d: %v", req.Size, err)
+		problem.Error(c, err)
 		return
 	}
 
 	h.logger.Info("Pack size created successfully with ID: %s, size: %d", pack.ID(), pack.Size())
-	c.JSON(http.StatusCreated, PackResponse{
-		ID:   pack.ID(),
-		Size: pack.Size(),
-	})
+	setETag(c, pack.Revision())
+	c.JSON(http.StatusCreated, newPackResponse(pack))
+}
+
+// ReplacePackSizes handles PUT /api/v1/pack-sizes
+// @Summary Replace the pack set of a product
+// @Description Make the listed sizes the complete pack set of a product, or of the default product when product_id is omitted. Missing sizes are created and sizes left out are retired, all in one transaction, effective at valid_from or now when it is omitted. The sizes the product keeps are left alone. The response lists the changes; with dry_run=true they are only computed and validated. If-Match must hold the ETag of the pack set the changes are based on, unless dry_run is set; a dry run returns that ETag.
+// @Tags packs
+// @Accept json
+// @Produce json
+// @Param dry_run query bool false "Only compute and validate the changes"
+// @Param If-Match header string false "ETag of the pack set, or * to replace any pack set; required unless dry_run is set"
+// @Param request body service.PackSetRequest true "Complete pack set"
+// @Success 200 {object} service.PackSetResponse
+// @Header 200 {string} ETag "Version of the pack set"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/pack-sizes [put]
+func (h *PackCalculatorHandler) ReplacePackSizes(c *gin.Context) {
+	h.logger.Info("Received replace pack sizes request")
+
+	var req service.PackSetRequest
+	err := c.ShouldBindQuery(&req)
+	if err == nil {
+		err = c.ShouldBindJSON(&req)
+	}
+	if err != nil {
+		h.logger.Error("Invalid request format for replace pack sizes: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	// A dry run changes nothing, so it needs no precondition
+	version := service.AnyVersion
+	if !req.DryRun || c.GetHeader("If-Match") != "" {
+		var ok bool
+		if version, ok = ifMatchVersion(c); !ok {
+			return
+		}
+	}
+
+	result, err := h.service.GetPackService().ReplacePackSet(c.Request.Context(), req, version)
+	if err != nil {
+		h.logger.Error("Failed to replace pack sizes: %v", err)
+		problem.Error(c, err)
+		return
+	}
+
+	h.logger.Info("Pack sizes of product %s: added %v, removed %v (dry run: %v)", result.ProductID, result.Added, result.Removed, result.DryRun)
+	setETag(c, result.Version)
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPackSize handles GET /api/v1/pack-sizes/:id
+// @Summary Get a pack size
+// @Description Get the latest revision of an active pack. The ETag header holds its revision for conditional updates.
+// @Tags packs
+// @Produce json
+// @Param id path string true "Pack ID" format(uuid)
+// @Success 200 {object} PackResponse
+// @Header 200 {string} ETag "Revision of the pack"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /api/v1/pack-sizes/{id} [get]
+func (h *PackCalculatorHandler) GetPackSize(c *gin.Context) {
+	idStr := c.Param("id")
+
+	packID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid pack ID format: %s", idStr)
+		problem.Respond(c, problem.InvalidID("Pack ID"))
+		return
+	}
+
+	pack, err := h.service.GetPackService().GetPackByID(c.Request.Context(), packID.String())
+	if err != nil {
+		h.logger.Warn("Pack not found with ID: %s", packID)
+		problem.Error(c, err)
+		return
+	}
+
+	setETag(c, pack.Revision())
+	c.JSON(http.StatusOK, newPackResponse(pack))
 }
 
 // UpdatePackSize handles PUT /api/v1/pack-sizes/:id
 // @Summary Update a pack size
-// @Description Update an existing pack size
+// @Description Change the size and attributes of a pack as its next revision, effective at valid_from or now when it is omitted. Earlier revisions stay in the history of the catalog. If-Match must hold the ETag of the revision the change is based on.
 // @Tags packs
 // @Accept json
 // @Produce json
 // @Param id path string true "Pack ID" format(uuid)
+// @Param If-Match header string true "ETag of the pack, or * to update any revision"
 // @Param request body UpdatePackSizeRequest true "Pack size update request"
 // @Success 200 {object} PackResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Header 200 {string} ETag "Revision of the pack"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Failure 500 {object} problem.Details
 // @Router /api/v1/pack-sizes/{id} [put]
 func (h *PackCalculatorHandler) UpdatePackSize(c *gin.Context) {
 	idStr := c.Param("id")
@@ -135,77 +257,50 @@ func (h *PackCalculatorHandler) UpdatePackSize(c *gin.Context) {
 	packID, err := uuid.Parse(idStr)
 	if err != nil {
 		h.logger.Error("Invalid pack ID format: %s", idStr)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid pack ID",
-			Message: "Pack ID must be a valid UUID",
-		})
-		return
-	}
-
-	var req UpdatePackSizeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Invalid request format for update pack size: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request format",
-			Message: err.Error(),
-		})
+		problem.Respond(c, problem.InvalidID("Pack ID"))
 		return
 	}
 
-	pack, err := h.service.GetPackService().GetPackByID(c.Request.Context(), packID.String())
-	if err != nil {
-		h.logger.Error("Pack not found for update with ID: %s", packID)
-		c.JSON(http.StatusNotFound, ErrorResponse{
-			Error:   "Pack not found",
-			Message: err.Error(),
-		})
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
-	err = pack.ChangeSize(req.Size)
-	if err != nil {
-		h.logger.Error("Invalid pack size %d for pack %s: %v", req.Size, packID, err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid pack size",
-			Message: err.Error(),
-		})
+	var req UpdatePackSizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request format for update pack size: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
 		return
 	}
 
-	err = h.service.GetPackService().UpdatePack(c.Request.Context(), pack)
+	pack, err := h.service.GetPackService().UpdatePack(c.Request.Context(), packID, service.PackChange{
+		Size:       req.Size,
+		Attributes: req.attributes(),
+		ValidFrom:  req.validFrom(),
+	}, version)
 	if err != nil {
-		if errors.Is(err, entity.ErrDuplicatePackSize) {
-			h.logger.Warn("Attempted to update pack %s to duplicate size: %d", packID, req.Size)
-			c.JSON(http.StatusConflict, ErrorResponse{
-				Error:   "Duplicate pack size",
-				Message: "A pack with this size already exists",
-			})
-		} else {
-			h.logger.Error("Failed to update pack %s: %v", packID, err)
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "Failed to update pack size",
-				Message: err.Error(),
-			})
-		}
+		h.logger.Error("Failed to update pack %s: %v", packID, err)
+		problem.Error(c, err)
 		return
 	}
 
 	h.logger.Info("Pack updated successfully with ID: %s, new size: %d", packID, pack.Size())
-	c.JSON(http.StatusOK, PackResponse{
-		ID:   pack.ID(),
-		Size: pack.Size(),
-	})
+	setETag(c, pack.Revision())
+	c.JSON(http.StatusOK, newPackResponse(pack))
 }
 
 // DeletePackSize handles DELETE /api/v1/pack-sizes/:id
 // @Summary Delete a pack size
-// @Description Remove a pack size from the system
+// @Description Retire a pack size now. It is no longer listed or used for new calculations, but its revisions stay in the history of the catalog. If-Match must hold the ETag of the pack's latest revision.
 // @Tags packs
 // @Param id path string true "Pack ID" format(uuid)
+// @Param If-Match header string true "ETag of the pack, or * to delete any revision"
 // @Success 204 "No Content"
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 412 {object} problem.Details
+// @Failure 428 {object} problem.Details
+// @Failure 500 {object} problem.Details
 // @Router /api/v1/pack-sizes/{id} [delete]
 func (h *PackCalculatorHandler) DeletePackSize(c *gin.Context) {
 	idStr := c.Param("id")
@@ -214,30 +309,26 @@ func (h *PackCalculatorHandler) DeletePackSize(c *gin.Context) {
 	packID, err := uuid.Parse(idStr)
 	if err != nil {
 		h.logger.Error("Invalid pack ID format for deletion: %s", idStr)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid pack ID",
-			Message: "Pack ID must be a valid UUID",
-		})
+		problem.Respond(c, problem.InvalidID("Pack ID"))
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
 	pack, err := h.service.GetPackService().GetPackByID(c.Request.Context(), packID.String())
 	if err != nil {
 		h.logger.Error("Pack not found for deletion with ID: %s", packID)
-		c.JSON(http.StatusNotFound, ErrorResponse{
-			Error:   "Pack not found",
-			Message: err.Error(),
-		})
+		problem.Error(c, err)
 		return
 	}
 
-	err = h.service.GetPackService().DeletePack(c.Request.Context(), pack)
+	err = h.service.GetPackService().DeletePack(c.Request.Context(), pack, version)
 	if err != nil {
 		h.logger.Error("Failed to delete pack %s: %v", packID, err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to delete pack size",
-			Message: err.Error(),
-		})
+		problem.Error(c, err)
 		return
 	}
 
@@ -245,10 +336,50 @@ func (h *PackCalculatorHandler) DeletePackSize(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+// AnalyzePackSizes handles GET /api/v1/pack-sizes/analysis
+// @Summary Analyse the coverage of a pack set
+// @Description Solve every amount from 1 to max for the least waste and report the GCD of the sizes, the largest amount no combination hits exactly (Frobenius number), the waste distribution, the average pack count and the most wasteful amounts. The current pack set of the product is analysed unless the body proposes sizes.
+// @Tags packs
+// @Accept json
+// @Produce json
+// @Param max query int false "Largest amount to analyse" default(10000) minimum(1) maximum(100000)
+// @Param product_id query string false "Analyse the pack set of this product instead of the default product" format(uuid)
+// @Param request body service.PackAnalysisRequest false "Proposed pack set"
+// @Success 200 {object} service.PackAnalysisResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/pack-sizes/analysis [get]
+func (h *PackCalculatorHandler) AnalyzePackSizes(c *gin.Context) {
+	h.logger.Info("Received pack analysis request")
+
+	var req service.PackAnalysisRequest
+
+	err := c.ShouldBindQuery(&req)
+	if err == nil && c.Request.ContentLength > 0 {
+		err = c.ShouldBindJSON(&req)
+	}
+	if err != nil {
+		h.logger.Error("Invalid request format: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	result, err := h.service.GetPackService().AnalyzePackSet(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Pack analysis failed: %v", err)
+		problem.Error(c, err)
+		return
+	}
+
+	h.logger.Info("Pack analysis completed for %d sizes up to %d", len(result.PackSizes), result.Max)
+	c.JSON(http.StatusOK, result)
+}
+
+// respondProductLookupError responds to a failed lookup of the product a pack request names
+func (h *PackCalculatorHandler) respondProductLookupError(c *gin.Context, productID uuid.UUID, err error) {
+	h.logger.Error("Failed to get product %s: %v", productID, err)
+	problem.Error(c, err)
 }
 
 // PackSizesResponse represents the response for pack sizes endpoint
@@ -257,18 +388,103 @@ type PackSizesResponse struct {
 	Count int            `json:"count"`
 }
 
+// DimensionsPayload represents the outer measurements of a pack in millimetres
+type DimensionsPayload struct {
+	Length int `json:"length" binding:"min=1"`
+	Width  int `json:"width" binding:"min=1"`
+	Height int `json:"height" binding:"min=1"`
+}
+
+// PackAttributesPayload represents the optional cost, weight and dimensions of a pack
+type PackAttributesPayload struct {
+	UnitCost   *int64             `json:"unit_cost_cents,omitempty" binding:"omitempty,min=0"`
+	Weight     *int               `json:"weight_grams,omitempty" binding:"omitempty,min=1"`
+	Dimensions *DimensionsPayload `json:"dimensions_mm,omitempty"`
+}
+
+// attributes converts the payload to domain pack attributes
+func (p PackAttributesPayload) attributes() entity.PackAttributes {
+	attributes := entity.PackAttributes{
+		UnitCost: p.UnitCost,
+		Weight:   p.Weight,
+	}
+	if p.Dimensions != nil {
+		attributes.Dimensions = &entity.Dimensions{
+			Length: p.Dimensions.Length,
+			Width:  p.Dimensions.Width,
+			Height: p.Dimensions.Height,
+		}
+	}
+	return attributes
+}
+
+// EffectivePayload represents when a pack change takes effect
+type EffectivePayload struct {
+	// ValidFrom is when the change takes effect; it takes effect now when omitted
+	ValidFrom *time.Time `json:"valid_from,omitempty" format:"date-time"`
+}
+
+// validFrom returns the requested effective time, or zero for now
+func (p EffectivePayload) validFrom() time.Time {
+	if p.ValidFrom == nil {
+		return time.Time{}
+	}
+	return *p.ValidFrom
+}
+
 // CreatePackSizeRequest represents a request to create a pack size
 type CreatePackSizeRequest struct {
-	Size int `json:"size" binding:"required,min=1"`
+	// ProductID is the product the pack belongs to; the default product is used when it is omitted
+	ProductID uuid.UUID `json:"product_id,omitempty" format:"uuid"`
+	Size      int64     `json:"size" binding:"required,min=1"`
+	PackAttributesPayload
+	EffectivePayload
 }
 
-// UpdatePackSizeRequest represents a request to update a pack size
+// UpdatePackSizeRequest represents a request to update a pack size.
+// Attributes left out of the request are cleared.
 type UpdatePackSizeRequest struct {
-	Size int `json:"size" binding:"required,min=1"`
+	Size int64 `json:"size" binding:"required,min=1"`
+	PackAttributesPayload
+	EffectivePayload
 }
 
-// PackResponse represents a pack in the response
+// PackResponse represents a revision of a pack in the response
 type PackResponse struct {
-	ID   uuid.UUID `json:"id"`
-	Size int       `json:"size"`
+	ID        uuid.UUID `json:"id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Size      int64     `json:"size"`
+	PackAttributesPayload
+	// Revision increases with every change to the pack and is its ETag
+	Revision  int        `json:"revision"`
+	ValidFrom time.Time  `json:"valid_from"`
+	ValidTo   *time.Time `json:"valid_to,omitempty"`
+	Active    bool       `json:"active"`
+}
+
+// newPackResponse converts a pack entity to its response representation
+func newPackResponse(pack *entity.Pack) PackResponse {
+	response := PackResponse{
+		ID:        pack.ID(),
+		ProductID: pack.ProductID(),
+		Size:      pack.Size(),
+		Revision:  pack.Revision(),
+		ValidFrom: pack.ValidFrom(),
+		Active:    pack.Active(),
+	}
+	if validTo, ends := pack.ValidTo(); ends {
+		response.ValidTo = &validTo
+	}
+
+	attributes := pack.Attributes()
+	response.UnitCost = attributes.UnitCost
+	response.Weight = attributes.Weight
+	if attributes.Dimensions != nil {
+		response.Dimensions = &DimensionsPayload{
+			Length: attributes.Dimensions.Length,
+			Width:  attributes.Dimensions.Width,
+			Height: attributes.Dimensions.Height,
+		}
+	}
+	return response
 }
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/application/service"
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/problem"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ProductHandler handles HTTP requests for products
+type ProductHandler struct {
+	service *service.ProductService
+	logger  *logger.Logger
+}
+
+// NewProductHandler creates a new product handler
+func NewProductHandler(service *service.ProductService, logger *logger.Logger) *ProductHandler {
+	return &ProductHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetProducts handles GET /api/v1/products
+// @Summary Get products
+// @Description Get all products, each with its own set of pack sizes
+// @Tags products
+// @Produce json
+// @Success 200 {object} ProductsResponse
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/products [get]
+func (h *ProductHandler) GetProducts(c *gin.Context) {
+	h.logger.Info("Received get products request")
+
+	products := h.service.GetAllProducts(c.Request.Context())
+
+	responses := make([]ProductResponse, len(products))
+	for i, product := range products {
+		responses[i] = newProductResponse(&product)
+	}
+
+	h.logger.Info("Successfully retrieved %d products", len(responses))
+	c.JSON(http.StatusOK, ProductsResponse{
+		Products: responses,
+		Count:    len(responses),
+	})
+}
+
+// GetProduct handles GET /api/v1/products/:id
+// @Summary Get a product
+// @Description Get a product by its ID
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/products/{id} [get]
+func (h *ProductHandler) GetProduct(c *gin.Context) {
+	productID, ok := h.parseProductID(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.GetProduct(c.Request.Context(), productID)
+	if err != nil {
+		h.respondProductError(c, productID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newProductResponse(product))
+}
+
+// GetProductPackSizes handles GET /api/v1/products/:id/pack-sizes
+// @Summary Get the pack sizes of a product
+// @Description Get the pack sizes of one product in ascending order
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Success 200 {object} PackSizesResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/products/{id}/pack-sizes [get]
+func (h *ProductHandler) GetProductPackSizes(c *gin.Context) {
+	productID, ok := h.parseProductID(c)
+	if !ok {
+		return
+	}
+
+	packs, err := h.service.GetProductPacks(c.Request.Context(), productID)
+	if err != nil {
+		h.respondProductError(c, productID, err)
+		return
+	}
+
+	packResponses := make([]PackResponse, len(packs))
+	for i, pack := range packs {
+		packResponses[i] = newPackResponse(&pack)
+	}
+
+	h.logger.Info("Successfully retrieved %d pack sizes of product %s", len(packResponses), productID)
+	c.JSON(http.StatusOK, PackSizesResponse{
+		Packs: packResponses,
+		Count: len(packResponses),
+	})
+}
+
+// CreateProduct handles POST /api/v1/products
+// @Summary Create a product
+// @Description Add a new product. Its pack sizes are added with the pack-sizes endpoints.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param request body ProductRequest true "Product creation request"
+// @Success 201 {object} ProductResponse
+// @Failure 400 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/products [post]
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	h.logger.Info("Received create product request")
+
+	var req ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request format for create product: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	product, err := entity.NewProduct(uuid.New(), req.Name)
+	if err != nil {
+		h.respondProductError(c, uuid.Nil, err)
+		return
+	}
+
+	if err := h.service.CreateProduct(c.Request.Context(), product); err != nil {
+		h.respondProductError(c, product.ID(), err)
+		return
+	}
+
+	h.logger.Info("Product created successfully with ID: %s, name: %s", product.ID(), product.Name())
+	c.JSON(http.StatusCreated, newProductResponse(product))
+}
+
+// UpdateProduct handles PUT /api/v1/products/:id
+// @Summary Update a product
+// @Description Rename an existing product
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param request body ProductRequest true "Product update request"
+// @Success 200 {object} ProductResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/products/{id} [put]
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	productID, ok := h.parseProductID(c)
+	if !ok {
+		return
+	}
+
+	var req ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request format for update product: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	product, err := h.service.GetProduct(c.Request.Context(), productID)
+	if err != nil {
+		h.respondProductError(c, productID, err)
+		return
+	}
+
+	if err := product.Rename(req.Name); err != nil {
+		h.respondProductError(c, productID, err)
+		return
+	}
+
+	if err := h.service.UpdateProduct(c.Request.Context(), product); err != nil {
+		h.respondProductError(c, productID, err)
+		return
+	}
+
+	h.logger.Info("Product updated successfully with ID: %s, new name: %s", productID, product.Name())
+	c.JSON(http.StatusOK, newProductResponse(product))
+}
+
+// DeleteProduct handles DELETE /api/v1/products/:id
+// @Summary Delete a product
+// @Description Remove a product. Products that still have pack sizes or orders, and the default product, cannot be deleted.
+// @Tags products
+// @Param id path string true "Product ID" format(uuid)
+// @Success 204 "No Content"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/products/{id} [delete]
+func (h *ProductHandler) DeleteProduct(c *gin.Context) {
+	productID, ok := h.parseProductID(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.GetProduct(c.Request.Context(), productID)
+	if err != nil {
+		h.respondProductError(c, productID, err)
+		return
+	}
+
+	if err := h.service.DeleteProduct(c.Request.Context(), product); err != nil {
+		h.respondProductError(c, productID, err)
+		return
+	}
+
+	h.logger.Info("Product deleted successfully with ID: %s", productID)
+	c.Status(http.StatusNoContent)
+}
+
+// parseProductID reads the product ID path parameter, responding with 400 when it is invalid
+func (h *ProductHandler) parseProductID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("id")
+	h.logger.Info("Received product request for ID: %s", idStr)
+
+	productID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid product ID format: %s", idStr)
+		problem.Respond(c, problem.InvalidID("Product ID"))
+		return uuid.Nil, false
+	}
+	return productID, true
+}
+
+// respondProductError responds with the problem a product service error maps
+// to, logging only the errors the domain does not describe
+func (h *ProductHandler) respondProductError(c *gin.Context, productID uuid.UUID, err error) {
+	if _, ok := entity.AsError(err); !ok {
+		h.logger.Error("Product operation failed for product %s: %v", productID, err)
+	}
+	problem.Error(c, err)
+}
+
+// ProductRequest represents a request to create or rename a product
+type ProductRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// ProductResponse represents a product in the response
+type ProductResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductsResponse represents the response for the products endpoint
+type ProductsResponse struct {
+	Products []ProductResponse `json:"products"`
+	Count    int               `json:"count"`
+}
+
+// newProductResponse converts a product entity to its response representation
+func newProductResponse(product *entity.Product) ProductResponse {
+	return ProductResponse{
+		ID:        product.ID(),
+		Name:      product.Name(),
+		CreatedAt: product.CreatedAt(),
+		UpdatedAt: product.UpdatedAt(),
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Strahinja-Polovina/packs/internal/application/service"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/problem"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// RecommendationHandler handles HTTP requests for pack size recommendations
+type RecommendationHandler struct {
+	service *service.RecommendationService
+	logger  *logger.Logger
+}
+
+// NewRecommendationHandler creates a new recommendation handler
+func NewRecommendationHandler(service *service.RecommendationService, logger *logger.Logger) *RecommendationHandler {
+	return &RecommendationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RecommendPackSizes handles POST /api/v1/pack-sizes/recommendations
+// @Summary Recommend pack sizes from order history
+// @Description Suggest the count pack sizes, between min_size and max_size and including any fixed sizes, with the least total waste and then the fewest packs over the requested amounts of the product's orders. Cancelled orders are left out. The recommended set is compared with the product's current pack sizes; negative changes are savings. The search adds sizes greedily and then swaps them while that helps, so the result is good but not guaranteed optimal.
+// @Tags packs
+// @Accept json
+// @Produce json
+// @Param request body service.RecommendationRequest true "Recommendation request"
+// @Success 200 {object} service.RecommendationResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/pack-sizes/recommendations [post]
+func (h *RecommendationHandler) RecommendPackSizes(c *gin.Context) {
+	h.logger.Info("Received pack size recommendation request")
+
+	var req service.RecommendationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request format: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	result, err := h.service.RecommendPackSizes(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Pack size recommendation failed: %v", err)
+		problem.Error(c, err)
+		return
+	}
+
+	h.logger.Info("Recommended pack sizes %v for product %s", result.PackSizes, result.ProductID)
+	c.JSON(http.StatusOK, result)
+}
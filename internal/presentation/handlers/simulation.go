@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Strahinja-Polovina/packs/internal/application/service"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/problem"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SimulationHandler handles HTTP requests for pack set simulations
+type SimulationHandler struct {
+	service *service.SimulationService
+	logger  *logger.Logger
+}
+
+// NewSimulationHandler creates a new simulation handler
+func NewSimulationHandler(service *service.SimulationService, logger *logger.Logger) *SimulationHandler {
+	return &SimulationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateSimulation handles POST /api/v1/simulations
+// @Summary Simulate a candidate pack set
+// @Description Replay the requested amount of every order line of a product against a candidate pack set and compare the waste, pack count and cost with what was actually shipped. Cancelled orders are left out. The simulation runs in the background; poll the returned location until its status is completed or failed. Simulations are kept in memory and lost on restart.
+// @Tags simulations
+// @Accept json
+// @Produce json
+// @Param request body service.SimulationRequest true "Candidate pack set"
+// @Success 202 {object} service.SimulationResponse
+// @Header 202 {string} Location "URL of the simulation"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 429 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/simulations [post]
+func (h *SimulationHandler) CreateSimulation(c *gin.Context) {
+	h.logger.Info("Received simulation request")
+
+	var req service.SimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request format: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	job, err := h.service.StartSimulation(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Failed to start simulation: %v", err)
+		problem.Error(c, err)
+		return
+	}
+
+	h.logger.Info("Simulation %s started", job.ID)
+	c.Header("Location", "/api/v1/simulations/"+job.ID.String())
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetSimulation handles GET /api/v1/simulations/:id
+// @Summary Get a simulation
+// @Description Get the status of a simulation, with its progress while it runs and its result once it has completed
+// @Tags simulations
+// @Produce json
+// @Param id path string true "Simulation ID" format(uuid)
+// @Success 200 {object} service.SimulationResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Router /api/v1/simulations/{id} [get]
+func (h *SimulationHandler) GetSimulation(c *gin.Context) {
+	idStr := c.Param("id")
+	h.logger.Info("Received get simulation request for ID: %s", idStr)
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid simulation ID format: %s", idStr)
+		problem.Respond(c, problem.InvalidID("Simulation ID"))
+		return
+	}
+
+	job, err := h.service.GetSimulation(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Warn("Simulation not found with ID: %s", id)
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
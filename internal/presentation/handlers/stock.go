@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/application/service"
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/problem"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// StockHandler handles HTTP requests for pack stock levels
+type StockHandler struct {
+	service *service.StockService
+	logger  *logger.Logger
+}
+
+// NewStockHandler creates a new stock handler
+func NewStockHandler(service *service.StockService, logger *logger.Logger) *StockHandler {
+	return &StockHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetStockLevels handles GET /api/v1/stock
+// @Summary Get stock levels
+// @Description Get the number of packs on hand for every pack size with tracked stock. Pack sizes that are not listed are not tracked and count as unlimited.
+// @Tags stock
+// @Produce json
+// @Success 200 {object} StockLevelsResponse
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/stock [get]
+func (h *StockHandler) GetStockLevels(c *gin.Context) {
+	h.logger.Info("Received get stock levels request")
+
+	levels, err := h.service.GetStockLevels(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get stock levels: %v", err)
+		problem.Error(c, err)
+		return
+	}
+
+	responses := make([]StockLevelResponse, len(levels))
+	for i, level := range levels {
+		responses[i] = newStockLevelResponse(&level)
+	}
+
+	h.logger.Info("Successfully retrieved %d stock levels", len(responses))
+	c.JSON(http.StatusOK, StockLevelsResponse{
+		Stock: responses,
+		Count: len(responses),
+	})
+}
+
+// SetStock handles PUT /api/v1/stock/:pack_id
+// @Summary Set a stock level
+// @Description Set the number of packs on hand for a pack size and start tracking its stock
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param pack_id path string true "Pack ID" format(uuid)
+// @Param request body SetStockRequest true "Stock level"
+// @Success 200 {object} StockLevelResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/stock/{pack_id} [put]
+func (h *StockHandler) SetStock(c *gin.Context) {
+	packID, ok := h.parsePackID(c)
+	if !ok {
+		return
+	}
+
+	var req SetStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request format for set stock: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	stock, err := h.service.SetStock(c.Request.Context(), packID, *req.Quantity)
+	if err != nil {
+		h.respondStockError(c, packID, err)
+		return
+	}
+
+	h.logger.Info("Stock for pack %s set to %d", packID, stock.Quantity())
+	c.JSON(http.StatusOK, newStockLevelResponse(stock))
+}
+
+// AdjustStock handles POST /api/v1/stock/:pack_id/adjustments
+// @Summary Adjust a stock level
+// @Description Add packs to stock with a positive delta or remove them with a negative one. Untracked pack sizes start from zero.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param pack_id path string true "Pack ID" format(uuid)
+// @Param request body AdjustStockRequest true "Stock adjustment"
+// @Success 200 {object} StockLevelResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/stock/{pack_id}/adjustments [post]
+func (h *StockHandler) AdjustStock(c *gin.Context) {
+	packID, ok := h.parsePackID(c)
+	if !ok {
+		return
+	}
+
+	var req AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Invalid request format for adjust stock: %v", err)
+		problem.Respond(c, problem.InvalidRequest(err))
+		return
+	}
+
+	stock, err := h.service.AdjustStock(c.Request.Context(), packID, req.Delta)
+	if err != nil {
+		h.respondStockError(c, packID, err)
+		return
+	}
+
+	h.logger.Info("Stock for pack %s adjusted to %d", packID, stock.Quantity())
+	c.JSON(http.StatusOK, newStockLevelResponse(stock))
+}
+
+// StopTracking handles DELETE /api/v1/stock/:pack_id
+// @Summary Stop tracking stock
+// @Description Remove the stock level of a pack size so it counts as unlimited again
+// @Tags stock
+// @Param pack_id path string true "Pack ID" format(uuid)
+// @Success 204 "No Content"
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Router /api/v1/stock/{pack_id} [delete]
+func (h *StockHandler) StopTracking(c *gin.Context) {
+	packID, ok := h.parsePackID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.StopTracking(c.Request.Context(), packID); err != nil {
+		h.respondStockError(c, packID, err)
+		return
+	}
+
+	h.logger.Info("Stopped stock tracking for pack %s", packID)
+	c.Status(http.StatusNoContent)
+}
+
+// parsePackID reads the pack ID path parameter, responding with 400 when it is invalid
+func (h *StockHandler) parsePackID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("pack_id")
+	h.logger.Info("Received stock request for pack ID: %s", idStr)
+
+	packID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid pack ID format: %s", idStr)
+		problem.Respond(c, problem.InvalidID("Pack ID"))
+		return uuid.Nil, false
+	}
+	return packID, true
+}
+
+// respondStockError responds with the problem a stock service error maps to,
+// logging only the errors the domain does not describe
+func (h *StockHandler) respondStockError(c *gin.Context, packID uuid.UUID, err error) {
+	if _, ok := entity.AsError(err); !ok {
+		h.logger.Error("Stock operation failed for pack %s: %v", packID, err)
+	}
+	problem.Error(c, err)
+}
+
+// SetStockRequest represents a request to set the packs on hand
+type SetStockRequest struct {
+	Quantity *int64 `json:"quantity" binding:"required,min=0"`
+}
+
+// AdjustStockRequest represents a request to add or remove packs from stock
+type AdjustStockRequest struct {
+	Delta int64 `json:"delta" binding:"required"`
+}
+
+// StockLevelResponse represents the stock of one pack size
+type StockLevelResponse struct {
+	PackID    uuid.UUID `json:"pack_id"`
+	PackSize  int64     `json:"pack_size"`
+	Quantity  int64     `json:"quantity"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockLevelsResponse represents the response for the stock endpoint
+type StockLevelsResponse struct {
+	Stock []StockLevelResponse `json:"stock"`
+	Count int                  `json:"count"`
+}
+
+// newStockLevelResponse converts a stock level entity to its response representation
+func newStockLevelResponse(stock *entity.StockLevel) StockLevelResponse {
+	return StockLevelResponse{
+		PackID:    stock.PackID(),
+		PackSize:  stock.PackSize(),
+		Quantity:  stock.Quantity(),
+		UpdatedAt: stock.UpdatedAt(),
+	}
+}
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/Strahinja-Polovina/packs/internal/application/service"
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/problem"
 	"github.com/Strahinja-Polovina/packs/internal/presentation/templates"
 	"github.com/Strahinja-Polovina/packs/pkg/logger"
 	"github.com/gin-gonic/gin"
@@ -13,54 +17,90 @@ import (
 
 // WebHandler handles web requests for the frontend
 type WebHandler struct {
-	packService  *service.PackService
-	orderService *service.OrderService
-	logger       *logger.Logger
+	productService *service.ProductService
+	packService    *service.PackService
+	orderService   *service.OrderService
+	logger         *logger.Logger
 }
 
 // NewWebHandler creates a new web handler
-func NewWebHandler(packService *service.PackService, orderService *service.OrderService, logger *logger.Logger) *WebHandler {
+func NewWebHandler(productService *service.ProductService, packService *service.PackService, orderService *service.OrderService, logger *logger.Logger) *WebHandler {
 	return &WebHandler{
-		packService:  packService,
-		orderService: orderService,
-		logger:       logger,
+		productService: productService,
+		packService:    packService,
+		orderService:   orderService,
+		logger:         logger,
 	}
 }
 
-// Index serves the main page
+// selectedProduct returns the product chosen with the product_id query or
+// form parameter, or the default product when none is chosen. A malformed ID
+// is the same 400 problem the API returns for it.
+func selectedProduct(c *gin.Context) (uuid.UUID, *problem.Details) {
+	idStr := c.Query("product_id")
+	if idStr == "" {
+		idStr = c.PostForm("product_id")
+	}
+	if idStr == "" {
+		return entity.DefaultProductID, nil
+	}
+
+	productID, err := uuid.Parse(idStr)
+	if err != nil {
+		details := problem.InvalidID("Product ID")
+		return uuid.Nil, &details
+	}
+	return productID, nil
+}
+
+// Index serves the main page with the packages of the selected product
 func (h *WebHandler) Index(c *gin.Context) {
 	h.logger.Info("Serving main page")
 
-	packs := h.packService.GetAllPacks(c.Request.Context())
+	products := h.productService.GetAllProducts(c.Request.Context())
+
+	productID, details := selectedProduct(c)
+	if details != nil {
+		h.logger.Warn("Ignoring invalid product selection: %s", details.Detail)
+		productID = entity.DefaultProductID
+	}
+
+	packs, err := h.productService.GetProductPacks(c.Request.Context(), productID)
+	if err != nil {
+		h.logger.Error("Failed to get packs of product %s: %v", productID, err)
+		packs = []entity.Pack{}
+	}
 
-	orders, err := h.orderService.GetAllOrders(c.Request.Context())
+	orders, err := h.orderService.ListOrders(c.Request.Context(), service.OrderListQuery{})
 	if err != nil {
 		h.logger.Error("Failed to get orders: %v", err)
-		orders = []service.OrderResponse{}
+		h.respondError(c, err)
+		return
 	}
 
-	component := templates.Index(packs, orders)
+	component := templates.Index(products, productID, packs, orders.Orders, orders.NextCursor)
 	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
 		h.logger.Error("Failed to render index template: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Template rendering failed",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
 		return
 	}
 }
 
-// GetPackageForm serves the package creation form
+// GetPackageForm serves the package creation form for the selected product
 func (h *WebHandler) GetPackageForm(c *gin.Context) {
 	h.logger.Info("Serving package creation form")
 
-	component := templates.PackageForm(nil, false)
+	productID, details := selectedProduct(c)
+	if details != nil {
+		h.logger.Warn("Invalid product selection: %s", details.Detail)
+		h.renderProblem(c, *details)
+		return
+	}
+
+	component := templates.PackageForm(nil, false, productID)
 	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
 		h.logger.Error("Failed to render package form template: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Template rendering failed",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
 		return
 	}
 }
@@ -73,29 +113,70 @@ func (h *WebHandler) GetPackageEditForm(c *gin.Context) {
 	pack, err := h.packService.GetPackByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get pack: %v", err)
-		c.JSON(http.StatusNotFound, ErrorResponse{
-			Error:   "Pack not found",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
 		return
 	}
 
-	component := templates.PackageForm(pack, true)
+	component := templates.PackageForm(pack, true, pack.ProductID())
 	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
 		h.logger.Error("Failed to render package edit form template: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Template rendering failed",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
 		return
 	}
 }
 
-// GetPackagesTableBody serves the packages table body for HTMX updates
+// GetPackagesTableBody serves the packages table body of the selected product
+// for HTMX updates
 func (h *WebHandler) GetPackagesTableBody(c *gin.Context) {
 	h.logger.Info("Serving packages table body")
 
-	packs := h.packService.GetAllPacks(c.Request.Context())
+	productID, details := selectedProduct(c)
+	if details != nil {
+		h.logger.Warn("Invalid product selection: %s", details.Detail)
+		h.renderProblem(c, *details)
+		return
+	}
+
+	h.renderPackagesTable(c, productID)
+}
+
+// GetPackageAnalysis serves the coverage analysis of the selected product's
+// pack set for HTMX updates
+func (h *WebHandler) GetPackageAnalysis(c *gin.Context) {
+	h.logger.Info("Serving package analysis")
+
+	var req service.PackAnalysisRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("Invalid request format: %v", err)
+		h.renderProblem(c, problem.InvalidRequest(err))
+		return
+	}
+
+	// The range is validated by binding, so an invalid analysis here means the
+	// product has no pack sizes yet
+	analysis, err := h.packService.AnalyzePackSet(c.Request.Context(), req)
+	if err != nil && !errors.Is(err, entity.ErrInvalidPackAnalysis) {
+		h.logger.Error("Pack analysis failed: %v", err)
+		h.respondError(c, err)
+		return
+	}
+
+	component := templates.PackageAnalysis(analysis)
+	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+		h.logger.Error("Failed to render package analysis template: %v", err)
+		h.respondError(c, err)
+		return
+	}
+}
+
+// renderPackagesTable renders the packages table body of a product
+func (h *WebHandler) renderPackagesTable(c *gin.Context, productID uuid.UUID) {
+	packs, err := h.productService.GetProductPacks(c.Request.Context(), productID)
+	if err != nil {
+		h.logger.Error("Failed to get packs of product %s: %v", productID, err)
+		h.respondError(c, err)
+		return
+	}
 
 	c.Header("Content-Type", "text/html")
 	for _, pack := range packs {
@@ -107,23 +188,146 @@ func (h *WebHandler) GetPackagesTableBody(c *gin.Context) {
 	}
 }
 
-// GetOrdersList serves the orders list for HTMX updates
+// GetOrdersList serves a page of the orders list for HTMX updates
 func (h *WebHandler) GetOrdersList(c *gin.Context) {
 	h.logger.Info("Serving orders list")
 
-	orders, err := h.orderService.GetAllOrders(c.Request.Context())
+	var query service.OrderListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.logger.Error("Invalid query parameters: %v", err)
+		h.renderProblem(c, problem.InvalidRequest(err))
+		return
+	}
+
+	orders, err := h.orderService.ListOrders(c.Request.Context(), query)
 	if err != nil {
 		h.logger.Error("Failed to get orders: %v", err)
-		orders = []service.OrderResponse{}
+		h.respondError(c, err)
+		return
 	}
 
 	c.Header("Content-Type", "text/html")
-	for _, order := range orders {
-		component := templates.OrderCard(order)
-		if err := component.Render(c.Request.Context(), c.Writer); err != nil {
-			h.logger.Error("Failed to render order card template: %v", err)
-			continue
+	component := templates.OrdersPage(orders.Orders, orders.NextCursor)
+	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+		h.logger.Error("Failed to render orders page template: %v", err)
+	}
+}
+
+// GetOrderDetail serves the detail page of a single order
+func (h *WebHandler) GetOrderDetail(c *gin.Context) {
+	idStr := c.Param("id")
+	h.logger.Info("Serving order detail page for ID: %s", idStr)
+
+	orderID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid order ID format: %s", idStr)
+		h.renderOrderNotFound(c, http.StatusBadRequest, idStr)
+		return
+	}
+
+	order, err := h.orderService.GetOrder(c.Request.Context(), orderID)
+	if err != nil {
+		if errors.Is(err, entity.ErrOrderNotFound) {
+			h.logger.Warn("Order not found with ID: %s", orderID)
+			h.renderOrderNotFound(c, http.StatusNotFound, idStr)
+			return
 		}
+		h.logger.Error("Failed to get order %s: %v", orderID, err)
+		h.respondError(c, err)
+		return
+	}
+
+	history, err := h.orderService.GetOrderHistory(c.Request.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to get history of order %s: %v", orderID, err)
+		history = []service.OrderStatusChangeResponse{}
+	}
+
+	revisions, err := h.orderService.GetOrderRevisions(c.Request.Context(), orderID)
+	if err != nil {
+		h.logger.Error("Failed to get revisions of order %s: %v", orderID, err)
+		revisions = []service.OrderRevisionResponse{}
+	}
+
+	component := templates.OrderDetail(*order, history, revisions)
+	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+		h.logger.Error("Failed to render order detail template: %v", err)
+		h.respondError(c, err)
+		return
+	}
+}
+
+// HandleOrderAmendment amends an order from the detail page form and asks
+// HTMX to reload the page
+func (h *WebHandler) HandleOrderAmendment(c *gin.Context) {
+	idStr := c.Param("id")
+	h.logger.Info("Handling amendment of order %s from web", idStr)
+
+	orderID, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("Invalid order ID format: %s", idStr)
+		h.renderProblem(c, problem.InvalidID("Order ID"))
+		return
+	}
+
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req service.OrderRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.logger.Error("Invalid request format: %v", err)
+		h.renderProblem(c, problem.InvalidRequest(err))
+		return
+	}
+
+	if _, err := h.orderService.AmendOrder(c.Request.Context(), orderID, req, version); err != nil {
+		h.logger.Error("Order amendment failed: %v", err)
+		h.respondError(c, err)
+		return
+	}
+
+	c.Header("HX-Refresh", "true")
+	c.Status(http.StatusNoContent)
+}
+
+// renderProblem responds with details as an HTML fragment. HTMX swaps it into
+// the problem area of the page, whichever element the request targets.
+func (h *WebHandler) renderProblem(c *gin.Context, details problem.Details) {
+	details.Instance = c.Request.URL.Path
+	c.Header("Content-Type", "text/html")
+	c.Header("HX-Retarget", "#problem")
+	c.Header("HX-Reswap", "innerHTML")
+	c.Status(details.Status)
+	if err := templates.Problem(details).Render(c.Request.Context(), c.Writer); err != nil {
+		h.logger.Error("Failed to render problem template: %v", err)
+	}
+}
+
+// respondError renders the problem err maps to, see problem.From
+func (h *WebHandler) respondError(c *gin.Context, err error) {
+	h.renderProblem(c, problem.From(err))
+}
+
+// ifMatchVersion returns the version in the If-Match header the page sends
+// with a write, rendering the problem when there is none
+func (h *WebHandler) ifMatchVersion(c *gin.Context) (int, bool) {
+	version, details := parseIfMatch(c)
+	if details != nil {
+		h.logger.Warn("Write to %s without a usable If-Match header", c.Request.URL.Path)
+		h.renderProblem(c, *details)
+		return 0, false
+	}
+	return version, true
+}
+
+// renderOrderNotFound serves the page shown for unknown or malformed order IDs
+func (h *WebHandler) renderOrderNotFound(c *gin.Context, status int, orderID string) {
+	c.Status(status)
+	component := templates.OrderNotFound(orderID)
+	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+		h.logger.Error("Failed to render order not found template: %v", err)
 	}
 }
 
@@ -134,20 +338,14 @@ func (h *WebHandler) HandleOrderCreation(c *gin.Context) {
 	var req service.OrderRequest
 	if err := c.ShouldBind(&req); err != nil {
 		h.logger.Error("Invalid request format: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request format",
-			Message: err.Error(),
-		})
+		h.renderProblem(c, problem.InvalidRequest(err))
 		return
 	}
 
 	result, err := h.orderService.CreateOrderFromCalculation(c.Request.Context(), req)
 	if err != nil {
 		h.logger.Error("Order creation failed: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Order creation failed",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
 		return
 	}
 
@@ -156,10 +354,33 @@ func (h *WebHandler) HandleOrderCreation(c *gin.Context) {
 	component := templates.OrderResult(*result)
 	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
 		h.logger.Error("Failed to render order result template: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Template rendering failed",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
+		return
+	}
+}
+
+// HandleCalculation calculates a pack combination without creating an order
+func (h *WebHandler) HandleCalculation(c *gin.Context) {
+	h.logger.Info("Handling calculation from web")
+
+	var req service.PackCalculationRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.logger.Error("Invalid request format: %v", err)
+		h.renderProblem(c, problem.InvalidRequest(err))
+		return
+	}
+
+	result, err := h.packService.CalculateOptimalPacks(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Calculation failed: %v", err)
+		h.respondError(c, err)
+		return
+	}
+
+	component := templates.CalculationResult(*result)
+	if err := component.Render(c.Request.Context(), c.Writer); err != nil {
+		h.logger.Error("Failed to render calculation result template: %v", err)
+		h.respondError(c, err)
 		return
 	}
 }
@@ -168,42 +389,50 @@ func (h *WebHandler) HandleOrderCreation(c *gin.Context) {
 func (h *WebHandler) HandlePackageCreation(c *gin.Context) {
 	h.logger.Info("Handling package creation from web")
 
-	var req struct {
-		Size int `form:"size" json:"size" binding:"required,min=1"`
-	}
+	var req packageForm
 
 	if err := c.ShouldBind(&req); err != nil {
 		h.logger.Error("Invalid request format: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request format",
-			Message: err.Error(),
-		})
+		h.renderProblem(c, problem.InvalidRequest(err))
+		return
+	}
+
+	attributes, err := req.attributes()
+	if err != nil {
+		h.logger.Error("Invalid package attributes: %v", err)
+		h.renderProblem(c, problem.InvalidRequest(err))
 		return
 	}
 
-	pack, err := entity.NewPack(uuid.New(), req.Size)
+	productID, details := selectedProduct(c)
+	if details != nil {
+		h.logger.Warn("Invalid product selection: %s", details.Detail)
+		h.renderProblem(c, *details)
+		return
+	}
+	if _, err := h.productService.GetProduct(c.Request.Context(), productID); err != nil {
+		h.logger.Error("Invalid product for package: %v", err)
+		h.respondError(c, err)
+		return
+	}
+
+	pack, err := entity.NewProductPack(uuid.New(), productID, req.Size, attributes)
 	if err != nil {
 		h.logger.Error("Failed to create pack entity: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid pack data",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
 		return
 	}
 
 	err = h.packService.CreatePack(c.Request.Context(), pack)
 	if err != nil {
 		h.logger.Error("Failed to create pack: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to create pack",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
 		return
 	}
 
 	h.logger.Info("Pack created successfully with ID: %s", pack.ID())
 
-	h.GetPackagesTableBody(c)
+	h.renderPackagesTable(c, pack.ProductID())
 }
 
 // HandlePackageUpdate handles package update and returns updated table
@@ -211,52 +440,46 @@ func (h *WebHandler) HandlePackageUpdate(c *gin.Context) {
 	id := c.Param("id")
 	h.logger.Info("Handling package update for ID: %s", id)
 
-	var req struct {
-		Size int `form:"size" json:"size" binding:"required,min=1"`
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
 	}
 
+	var req packageForm
+
 	if err := c.ShouldBind(&req); err != nil {
 		h.logger.Error("Invalid request format: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request format",
-			Message: err.Error(),
-		})
+		h.renderProblem(c, problem.InvalidRequest(err))
 		return
 	}
 
-	pack, err := h.packService.GetPackByID(c.Request.Context(), id)
+	attributes, err := req.attributes()
 	if err != nil {
-		h.logger.Error("Failed to get pack: %v", err)
-		c.JSON(http.StatusNotFound, ErrorResponse{
-			Error:   "Pack not found",
-			Message: err.Error(),
-		})
+		h.logger.Error("Invalid package attributes: %v", err)
+		h.renderProblem(c, problem.InvalidRequest(err))
 		return
 	}
 
-	err = pack.ChangeSize(req.Size)
+	packID, err := uuid.Parse(id)
 	if err != nil {
-		h.logger.Error("Failed to change pack size: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid pack size",
-			Message: err.Error(),
-		})
+		h.logger.Warn("Invalid pack ID: %s", id)
+		h.respondError(c, entity.ErrPackNotFound)
 		return
 	}
 
-	err = h.packService.UpdatePack(c.Request.Context(), pack)
+	pack, err := h.packService.UpdatePack(c.Request.Context(), packID, service.PackChange{
+		Size:       req.Size,
+		Attributes: attributes,
+	}, version)
 	if err != nil {
 		h.logger.Error("Failed to update pack: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to update pack",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
 		return
 	}
 
 	h.logger.Info("Pack updated successfully with ID: %s", pack.ID())
 
-	h.GetPackagesTableBody(c)
+	h.renderPackagesTable(c, pack.ProductID())
 }
 
 // HandlePackageDelete handles package deletion and returns updated table
@@ -264,27 +487,80 @@ func (h *WebHandler) HandlePackageDelete(c *gin.Context) {
 	id := c.Param("id")
 	h.logger.Info("Handling package deletion for ID: %s", id)
 
+	version, ok := h.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	pack, err := h.packService.GetPackByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.Error("Failed to get pack: %v", err)
-		c.JSON(http.StatusNotFound, ErrorResponse{
-			Error:   "Pack not found",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
 		return
 	}
 
-	err = h.packService.DeletePack(c.Request.Context(), pack)
+	err = h.packService.DeletePack(c.Request.Context(), pack, version)
 	if err != nil {
 		h.logger.Error("Failed to delete pack: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "Failed to delete pack",
-			Message: err.Error(),
-		})
+		h.respondError(c, err)
 		return
 	}
 
 	h.logger.Info("Pack deleted successfully with ID: %s", pack.ID())
 
-	h.GetPackagesTableBody(c)
+	h.renderPackagesTable(c, pack.ProductID())
+}
+
+// packageForm is the package form submitted by the web UI. Optional numeric
+// fields arrive as strings so that an empty input means "not set".
+type packageForm struct {
+	Size     int64  `form:"size" binding:"required,min=1"`
+	UnitCost string `form:"unit_cost_cents"`
+	Weight   string `form:"weight_grams"`
+	Length   string `form:"length_mm"`
+	Width    string `form:"width_mm"`
+	Height   string `form:"height_mm"`
+}
+
+// attributes parses the optional package fields
+func (f packageForm) attributes() (entity.PackAttributes, error) {
+	var attributes entity.PackAttributes
+
+	if f.UnitCost != "" {
+		cost, err := strconv.ParseInt(f.UnitCost, 10, 64)
+		if err != nil {
+			return attributes, fmt.Errorf("invalid unit cost %q", f.UnitCost)
+		}
+		attributes.UnitCost = &cost
+	}
+
+	if f.Weight != "" {
+		weight, err := strconv.Atoi(f.Weight)
+		if err != nil {
+			return attributes, fmt.Errorf("invalid weight %q", f.Weight)
+		}
+		attributes.Weight = &weight
+	}
+
+	if f.Length != "" || f.Width != "" || f.Height != "" {
+		var dimensions entity.Dimensions
+		for _, field := range []struct {
+			name  string
+			value string
+			dest  *int
+		}{
+			{"length", f.Length, &dimensions.Length},
+			{"width", f.Width, &dimensions.Width},
+			{"height", f.Height, &dimensions.Height},
+		} {
+			value, err := strconv.Atoi(field.value)
+			if err != nil {
+				return attributes, fmt.Errorf("invalid %s %q: all three dimensions are required", field.name, field.value)
+			}
+			*field.dest = value
+		}
+		attributes.Dimensions = &dimensions
+	}
+
+	return attributes, attributes.Validate()
 }
@@ -47,11 +47,15 @@ func DefaultCORSConfig() CORSConfig {
 			"X-Requested-With",
 			"X-CSRF-Token",
 			"X-API-Key",
+			IdempotencyKeyHeader,
+			"If-Match",
 		},
 		ExposeHeaders: []string{
 			"Content-Length",
 			"Content-Type",
 			"Content-Disposition",
+			"ETag",
+			IdempotentReplayedHeader,
 		},
 		AllowCredentials: true,
 		MaxAge:           86400, // 24 hours
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/Strahinja-Polovina/packs/internal/application/service"
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/problem"
+	"github.com/Strahinja-Polovina/packs/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client's idempotency key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// replayedHeaders are the response headers stored with a response and set
+// again when it is replayed
+var replayedHeaders = []string{"ETag", "Location"}
+
+// Idempotency returns a middleware that replays the stored response when a
+// request is retried with the same Idempotency-Key header. Requests without
+// the header are handled as usual. Server errors and panics are not stored,
+// so a retry after one runs the request again.
+func Idempotency(idempotency *service.IdempotencyService, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			log.Error("Failed to read request body: %v", err)
+			problem.Abort(c, problem.InvalidRequest(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := c.Request.Method + " " + c.FullPath()
+		fingerprint := requestFingerprint(c.Request, body)
+
+		stored, err := idempotency.Begin(c.Request.Context(), scope, key, fingerprint)
+		if err != nil {
+			if _, ok := entity.AsError(err); !ok {
+				log.Error("Idempotency check failed: %v", err)
+			}
+			problem.Abort(c, problem.From(err))
+			return
+		}
+
+		if stored != nil {
+			for name, value := range stored.Headers {
+				c.Header(name, value)
+			}
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		// Keep the request context out of it: the client may be gone by now
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		// Give the key up when the request fails, also when a handler panics,
+		// so a retry is not refused as in flight until the lease ends
+		release := true
+		defer func() {
+			if release {
+				_ = idempotency.Release(ctx, scope, key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		// The request took effect, so a retry must not run it again
+		release = false
+
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+
+		err = idempotency.Complete(ctx, scope, key, fingerprint, service.StoredResponse{
+			StatusCode:  recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Headers:     headers,
+			Body:        recorder.body.Bytes(),
+		})
+		if err != nil {
+			log.Error("Failed to store response for idempotency key %q: %v", key, err)
+		}
+	}
+}
+
+// requestFingerprint identifies the request payload an idempotency key is used with
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write([]byte(r.Header.Get("Content-Type") + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder copies the response body while it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,106 @@
+// Package problem maps errors to RFC 7807 problem details, so every API
+// response describes a failure the same way
+package problem
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of problem details responses
+const ContentType = "application/problem+json"
+
+// typePrefix turns a problem code into the URI that identifies its type
+const typePrefix = "urn:packs:problem:"
+
+// Codes of problems found by the presentation layer rather than the domain
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeInvalidID            = "invalid_id"
+	CodePreconditionRequired = "precondition_required"
+	CodePreconditionFailed   = "precondition_failed"
+	CodeTimeout              = "timeout"
+	CodeInternal             = "internal_error"
+)
+
+// Details describes a failed request as an RFC 7807 problem. Code is a
+// stable, machine-readable identifier; Type is derived from it.
+type Details struct {
+	Type     string `json:"type" example:"urn:packs:problem:pack_not_found"`
+	Title    string `json:"title" example:"Not Found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail,omitempty" example:"pack not found"`
+	Instance string `json:"instance,omitempty" example:"/api/v1/pack-sizes/5f0c6a1e-2b1d-4a53-9d3e-7c1f2a9b8e10"`
+	Code     string `json:"code" example:"pack_not_found"`
+}
+
+// kindStatus is the HTTP status of each kind of domain error
+var kindStatus = map[entity.ErrorKind]int{
+	entity.ErrorKindInvalid:       http.StatusBadRequest,
+	entity.ErrorKindNotFound:      http.StatusNotFound,
+	entity.ErrorKindConflict:      http.StatusConflict,
+	entity.ErrorKindStale:         http.StatusPreconditionFailed,
+	entity.ErrorKindUnprocessable: http.StatusUnprocessableEntity,
+	entity.ErrorKindBusy:          http.StatusTooManyRequests,
+	entity.ErrorKindUnavailable:   http.StatusServiceUnavailable,
+}
+
+// New describes a problem with the given status, code and detail
+func New(status int, code, detail string) Details {
+	return Details{
+		Type:   typePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// From describes err. Domain errors keep their code and message and get the
+// status of their kind. Running out of request time is a 503; anything else
+// is a 500 whose cause is left out, as it may expose internals.
+func From(err error) Details {
+	if domainErr, ok := entity.AsError(err); ok {
+		status, known := kindStatus[domainErr.Kind()]
+		if !known {
+			status = http.StatusInternalServerError
+		}
+		return New(status, domainErr.Code(), err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return New(http.StatusServiceUnavailable, CodeTimeout, "the request ran out of time")
+	}
+	return New(http.StatusInternalServerError, CodeInternal, "an unexpected error occurred")
+}
+
+// InvalidRequest describes a request that could not be read or bound
+func InvalidRequest(err error) Details {
+	return New(http.StatusBadRequest, CodeInvalidRequest, err.Error())
+}
+
+// InvalidID describes a path parameter that is not a valid UUID
+func InvalidID(name string) Details {
+	return New(http.StatusBadRequest, CodeInvalidID, name+" must be a valid UUID")
+}
+
+// Respond writes details as the response, with the request path as instance
+func Respond(c *gin.Context, details Details) {
+	details.Instance = c.Request.URL.Path
+	c.Header("Content-Type", ContentType)
+	c.JSON(details.Status, details)
+}
+
+// Abort writes details as the response and stops the handler chain
+func Abort(c *gin.Context, details Details) {
+	Respond(c, details)
+	c.Abort()
+}
+
+// Error responds with the problem err maps to, see From
+func Error(c *gin.Context, err error) {
+	Respond(c, From(err))
+}
@@ -1,10 +1,13 @@
 package routes
 
 import (
+	"time"
+
 	_ "github.com/Strahinja-Polovina/packs/docs"
 	"github.com/Strahinja-Polovina/packs/internal/application/service"
 	"github.com/Strahinja-Polovina/packs/internal/domain/repository"
 	"github.com/Strahinja-Polovina/packs/internal/presentation/handlers"
+	"github.com/Strahinja-Polovina/packs/internal/presentation/middleware"
 	"github.com/Strahinja-Polovina/packs/pkg/logger"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -12,25 +15,50 @@ import (
 )
 
 type RouteConfig struct {
-	ServiceName   string
-	Port          int
-	PackRepo      repository.PackRepository
-	OrderRepo     repository.OrderRepository
+	ServiceName     string
+	Port            int
+	ProductRepo     repository.ProductRepository
+	PackRepo        repository.PackRepository
+	OrderRepo       repository.OrderRepository
+	StockRepo       repository.StockRepository
+	IdempotencyRepo repository.IdempotencyRepository
+	IdempotencyTTL  time.Duration
+	// IdempotencyLease is how long an idempotency key stays claimed by a
+	// request that never stored a response
+	IdempotencyLease time.Duration
+	SolverConfig     service.SolverConfig
+	// RequestTimeout is the deadline of routes that calculate pack combinations
+	RequestTimeout time.Duration
+	// BatchTimeout is the deadline of routes that calculate many combinations at once
+	BatchTimeout  time.Duration
 	Logger        *logger.Logger
 	EnableSwagger bool
 }
 
 func SetupRoutes(router *gin.Engine, config RouteConfig) {
 	// Initialize services
-	packCalculatorService := service.NewPackCalculatorService(config.PackRepo, config.OrderRepo, config.Logger)
+	packCalculatorService := service.NewPackCalculatorService(config.ProductRepo, config.PackRepo, config.OrderRepo, config.StockRepo, config.SolverConfig, config.Logger)
 	orderService := packCalculatorService.GetOrderService()
-	packService := service.NewPackService(config.PackRepo, config.Logger)
+	packService := packCalculatorService.GetPackService()
+	stockService := packCalculatorService.GetStockService()
+	productService := packCalculatorService.GetProductService()
+	simulationService := packCalculatorService.GetSimulationService()
+	recommendationService := packCalculatorService.GetRecommendationService()
+	idempotencyService := service.NewIdempotencyService(config.IdempotencyRepo, config.IdempotencyTTL, config.IdempotencyLease, config.Logger)
+	idempotent := middleware.Idempotency(idempotencyService, config.Logger)
+	timeout := middleware.Timeout(config.RequestTimeout)
+	batchTimeout := middleware.Timeout(config.BatchTimeout)
 
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler(config.ServiceName, config.Port, config.Logger)
 	packCalculatorHandler := handlers.NewPackCalculatorHandler(packCalculatorService, config.Logger)
 	orderHandler := handlers.NewOrderHandler(orderService, config.Logger)
-	webHandler := handlers.NewWebHandler(packService, orderService, config.Logger)
+	calculationHandler := handlers.NewCalculationHandler(packService, config.Logger)
+	stockHandler := handlers.NewStockHandler(stockService, config.Logger)
+	productHandler := handlers.NewProductHandler(productService, config.Logger)
+	simulationHandler := handlers.NewSimulationHandler(simulationService, config.Logger)
+	recommendationHandler := handlers.NewRecommendationHandler(recommendationService, config.Logger)
+	webHandler := handlers.NewWebHandler(productService, packService, orderService, config.Logger)
 
 	// Swagger documentation (only in development/debug mode)
 	if config.EnableSwagger {
@@ -43,15 +71,50 @@ func SetupRoutes(router *gin.Engine, config RouteConfig) {
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
+		// Product CRUD routes
+		v1.GET("/products", productHandler.GetProducts)
+		v1.POST("/products", productHandler.CreateProduct)
+		v1.GET("/products/:id", productHandler.GetProduct)
+		v1.PUT("/products/:id", productHandler.UpdateProduct)
+		v1.DELETE("/products/:id", productHandler.DeleteProduct)
+		v1.GET("/products/:id/pack-sizes", productHandler.GetProductPackSizes)
+
 		// Pack-sizes CRUD routes
 		v1.GET("/pack-sizes", packCalculatorHandler.GetPackSizes)
 		v1.POST("/pack-sizes", packCalculatorHandler.CreatePackSize)
+		v1.PUT("/pack-sizes", packCalculatorHandler.ReplacePackSizes)
+		v1.GET("/pack-sizes/analysis", timeout, packCalculatorHandler.AnalyzePackSizes)
+		v1.POST("/pack-sizes/recommendations", batchTimeout, recommendationHandler.RecommendPackSizes)
+		v1.GET("/pack-sizes/:id", packCalculatorHandler.GetPackSize)
 		v1.PUT("/pack-sizes/:id", packCalculatorHandler.UpdatePackSize)
 		v1.DELETE("/pack-sizes/:id", packCalculatorHandler.DeletePackSize)
 
+		// Stock routes
+		v1.GET("/stock", stockHandler.GetStockLevels)
+		v1.PUT("/stock/:pack_id", stockHandler.SetStock)
+		v1.POST("/stock/:pack_id/adjustments", stockHandler.AdjustStock)
+		v1.DELETE("/stock/:pack_id", stockHandler.StopTracking)
+
+		// Calculation routes
+		v1.POST("/calculations", timeout, calculationHandler.Calculate)
+		v1.POST("/calculations/batch", batchTimeout, calculationHandler.CalculateBatch)
+
+		// Simulation routes
+		v1.POST("/simulations", simulationHandler.CreateSimulation)
+		v1.GET("/simulations/:id", simulationHandler.GetSimulation)
+
 		// Order routes
-		v1.POST("/orders", orderHandler.CreateOrder)
-		v1.GET("/orders", orderHandler.GetAllOrders)
+		v1.POST("/orders", idempotent, timeout, orderHandler.CreateOrder)
+		v1.GET("/orders", orderHandler.ListOrders)
+		v1.GET("/orders/:id", orderHandler.GetOrder)
+		v1.PUT("/orders/:id", timeout, orderHandler.AmendOrder)
+		v1.GET("/orders/:id/revisions", orderHandler.GetOrderRevisions)
+		v1.GET("/orders/:id/history", orderHandler.GetOrderHistory)
+		v1.POST("/orders/:id/confirm", orderHandler.ConfirmOrder)
+		v1.POST("/orders/:id/pick", orderHandler.PickOrder)
+		v1.POST("/orders/:id/pack", orderHandler.PackOrder)
+		v1.POST("/orders/:id/ship", orderHandler.ShipOrder)
+		v1.POST("/orders/:id/cancel", orderHandler.CancelOrder)
 	}
 
 	// Web routes
@@ -61,13 +124,19 @@ func SetupRoutes(router *gin.Engine, config RouteConfig) {
 		web.GET("/packages/new", webHandler.GetPackageForm)
 		web.GET("/packages/:id/edit", webHandler.GetPackageEditForm)
 		web.GET("/packages/table", webHandler.GetPackagesTableBody)
+		web.GET("/packages/analysis", timeout, webHandler.GetPackageAnalysis)
 		web.POST("/packages", webHandler.HandlePackageCreation)
 		web.PUT("/packages/:id", webHandler.HandlePackageUpdate)
 		web.DELETE("/packages/:id", webHandler.HandlePackageDelete)
 
 		// Order management routes
 		web.GET("/orders", webHandler.GetOrdersList)
-		web.POST("/orders", webHandler.HandleOrderCreation)
+		web.POST("/orders", idempotent, timeout, webHandler.HandleOrderCreation)
+		web.GET("/orders/:id", webHandler.GetOrderDetail)
+		web.PUT("/orders/:id", timeout, webHandler.HandleOrderAmendment)
+
+		// Calculation routes
+		web.POST("/calculations", timeout, webHandler.HandleCalculation)
 	}
 
 	// Main page route
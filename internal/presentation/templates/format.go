@@ -0,0 +1,227 @@
+package templates
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Strahinja-Polovina/packs/internal/application/service"
+	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+)
+
+// formatCents renders an amount in minor currency units as a decimal price
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// formatGrams renders a weight in grams, switching to kilograms for heavy loads
+func formatGrams(grams int64) string {
+	if grams >= 1000 {
+		return strconv.FormatFloat(float64(grams)/1000, 'f', -1, 64) + " kg"
+	}
+	return strconv.FormatInt(grams, 10) + " g"
+}
+
+// packCost renders the unit cost of a pack, or a dash when unknown
+func packCost(pack entity.Pack) string {
+	if cost, ok := pack.UnitCost(); ok {
+		return formatCents(cost)
+	}
+	return "-"
+}
+
+// packWeight renders the weight of a pack, or a dash when unknown
+func packWeight(pack entity.Pack) string {
+	if weight, ok := pack.Weight(); ok {
+		return formatGrams(int64(weight))
+	}
+	return "-"
+}
+
+// packDimensions renders the dimensions of a pack, or a dash when unknown
+func packDimensions(pack entity.Pack) string {
+	if d, ok := pack.Dimensions(); ok {
+		return fmt.Sprintf("%d × %d × %d mm", d.Length, d.Width, d.Height)
+	}
+	return "-"
+}
+
+// packFormValue returns the current value of an optional package form field,
+// or an empty string when the pack is new or the value is unknown
+func packFormValue(pack *entity.Pack, field string) string {
+	if pack == nil {
+		return ""
+	}
+
+	dimensions, hasDimensions := pack.Dimensions()
+	switch field {
+	case "unit_cost_cents":
+		if cost, ok := pack.UnitCost(); ok {
+			return strconv.FormatInt(cost, 10)
+		}
+	case "weight_grams":
+		if weight, ok := pack.Weight(); ok {
+			return strconv.Itoa(weight)
+		}
+	case "length_mm":
+		if hasDimensions {
+			return strconv.Itoa(dimensions.Length)
+		}
+	case "width_mm":
+		if hasDimensions {
+			return strconv.Itoa(dimensions.Width)
+		}
+	case "height_mm":
+		if hasDimensions {
+			return strconv.Itoa(dimensions.Height)
+		}
+	}
+	return ""
+}
+
+// formatCombination renders a pack combination from the largest pack size down, e.g. "2 × 500, 1 × 250"
+func formatCombination(combination map[int64]int64) string {
+	sizes := make([]int64, 0, len(combination))
+	for size := range combination {
+		sizes = append(sizes, size)
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		return sizes[i] > sizes[j]
+	})
+
+	parts := make([]string, len(sizes))
+	for i, size := range sizes {
+		parts[i] = fmt.Sprintf("%d × %d", combination[size], size)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatSizes renders a list of pack sizes in ascending order, e.g. "250, 500, 1000"
+func formatSizes(sizes []int64) string {
+	sorted := append([]int64(nil), sizes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	parts := make([]string, len(sorted))
+	for i, size := range sorted {
+		parts[i] = strconv.FormatInt(size, 10)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatTime renders a timestamp in UTC
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+// formatRevisionLines renders the lines of an order revision like
+// formatCombination, prefixed with the line label when there are several
+func formatRevisionLines(lines []service.OrderLineResponse) string {
+	if len(lines) == 1 {
+		return formatCombination(lines[0].Combination)
+	}
+
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = formatLineLabel(line) + ": " + formatCombination(line.Combination)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatLineLabel names an order line, e.g. "Line 2 (PO-1/20)"
+func formatLineLabel(line service.OrderLineResponse) string {
+	label := "Line " + strconv.Itoa(line.Line)
+	if line.Reference != "" {
+		label += " (" + line.Reference + ")"
+	}
+	return label
+}
+
+// statusBadgeClasses are the badge colours of each order status
+var statusBadgeClasses = map[entity.OrderStatus]string{
+	entity.OrderStatusDraft:     "bg-gray-100 text-gray-800",
+	entity.OrderStatusConfirmed: "bg-blue-100 text-blue-800",
+	entity.OrderStatusPicking:   "bg-yellow-100 text-yellow-800",
+	entity.OrderStatusPacked:    "bg-indigo-100 text-indigo-800",
+	entity.OrderStatusShipped:   "bg-green-100 text-green-800",
+	entity.OrderStatusCancelled: "bg-red-100 text-red-800",
+}
+
+// statusBadgeClass returns the classes of the badge for an order status
+func statusBadgeClass(status string) string {
+	colours, ok := statusBadgeClasses[entity.OrderStatus(status)]
+	if !ok {
+		colours = statusBadgeClasses[entity.OrderStatusDraft]
+	}
+	return "px-2 py-1 text-xs font-semibold rounded-full " + colours
+}
+
+// statusActions are the API actions that move an order to each status
+var statusActions = map[entity.OrderStatus]string{
+	entity.OrderStatusConfirmed: "confirm",
+	entity.OrderStatusPicking:   "pick",
+	entity.OrderStatusPacked:    "pack",
+	entity.OrderStatusShipped:   "ship",
+	entity.OrderStatusCancelled: "cancel",
+}
+
+// statusActionURL returns the endpoint that moves an order to status
+func statusActionURL(orderID, status string) string {
+	return "/api/v1/orders/" + orderID + "/" + statusActions[entity.OrderStatus(status)]
+}
+
+// ifMatchHeaders returns the hx-headers that make a write conditional on the
+// version of the order or pack the page shows
+func ifMatchHeaders(version int) string {
+	return fmt.Sprintf(`{"If-Match": "\"%d\""}`, version)
+}
+
+// statusActionLabel returns the button label for moving an order to status
+func statusActionLabel(status string) string {
+	action := statusActions[entity.OrderStatus(status)]
+	if action == "" {
+		return status
+	}
+	return strings.ToUpper(action[:1]) + action[1:]
+}
+
+// formatWasteBucket renders the waste range of a distribution bucket, e.g. "1-28"
+func formatWasteBucket(bucket service.WasteBucket) string {
+	if bucket.From == bucket.To {
+		return strconv.FormatInt(bucket.From, 10)
+	}
+	return strconv.FormatInt(bucket.From, 10) + "-" + strconv.FormatInt(bucket.To, 10)
+}
+
+// wasteBarStyle sizes a distribution bar relative to the fullest bucket
+func wasteBarStyle(bucket service.WasteBucket, buckets []service.WasteBucket) string {
+	fullest := 0
+	for _, b := range buckets {
+		fullest = max(fullest, b.Count)
+	}
+	width := 0.0
+	if fullest > 0 {
+		width = float64(bucket.Count) * 100 / float64(fullest)
+	}
+	return fmt.Sprintf("width: %.1f%%", width)
+}
+
+// formatFrobenius renders the Frobenius number of an analysis, which is only
+// defined when the pack sizes have no common divisor
+func formatFrobenius(analysis service.PackAnalysisResponse) string {
+	if analysis.FrobeniusNumber == nil {
+		return "only multiples of " + strconv.FormatInt(analysis.GCD, 10)
+	}
+	if *analysis.FrobeniusNumber == 0 {
+		return "none"
+	}
+	return strconv.FormatInt(*analysis.FrobeniusNumber, 10)
+}
@@ -11,9 +11,10 @@ import templruntime "github.com/a-h/templ/runtime"
 import (
 	"github.com/Strahinja-Polovina/packs/internal/application/service"
 	"github.com/Strahinja-Polovina/packs/internal/domain/entity"
+	"github.com/google/uuid"
 )
 
-func Index(packs []entity.Pack, orders []service.OrderResponse) templ.Component {
+func Index(products []entity.Product, productID uuid.UUID, packs []entity.Pack, orders []service.OrderResponse, nextCursor string) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -50,7 +51,7 @@ func Index(packs []entity.Pack, orders []service.OrderResponse) templ.Component
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = PackageList(packs).Render(ctx, templ_7745c5c3_Buffer)
+			templ_7745c5c3_Err = PackageList(products, productID, packs).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -58,7 +59,7 @@ func Index(packs []entity.Pack, orders []service.OrderResponse) templ.Component
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = OrderForm().Render(ctx, templ_7745c5c3_Buffer)
+			templ_7745c5c3_Err = OrderForm(products, productID).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -66,7 +67,7 @@ func Index(packs []entity.Pack, orders []service.OrderResponse) templ.Component
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = OrdersList(orders).Render(ctx, templ_7745c5c3_Buffer)
+			templ_7745c5c3_Err = OrdersList(orders, nextCursor).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE packs
+    ADD COLUMN unit_cost BIGINT CHECK (unit_cost >= 0),
+    ADD COLUMN weight_grams INTEGER CHECK (weight_grams > 0),
+    ADD COLUMN length_mm INTEGER CHECK (length_mm > 0),
+    ADD COLUMN width_mm INTEGER CHECK (width_mm > 0),
+    ADD COLUMN height_mm INTEGER CHECK (height_mm > 0),
+    ADD CONSTRAINT packs_dimensions_complete CHECK (
+        (length_mm IS NULL AND width_mm IS NULL AND height_mm IS NULL) OR
+        (length_mm IS NOT NULL AND width_mm IS NOT NULL AND height_mm IS NOT NULL)
+    );
+
+-- +goose Down
+ALTER TABLE packs
+    DROP CONSTRAINT IF EXISTS packs_dimensions_complete,
+    DROP COLUMN IF EXISTS height_mm,
+    DROP COLUMN IF EXISTS width_mm,
+    DROP COLUMN IF EXISTS length_mm,
+    DROP COLUMN IF EXISTS weight_grams,
+    DROP COLUMN IF EXISTS unit_cost;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE pack_stock (
+    pack_id UUID PRIMARY KEY REFERENCES packs(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS pack_stock;
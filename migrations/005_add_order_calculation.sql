@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE orders
+    ADD COLUMN requested_amount INTEGER CHECK (requested_amount > 0),
+    ADD COLUMN pack_sizes INTEGER[],
+    ADD COLUMN objective TEXT,
+    ADD COLUMN solver_version TEXT;
+
+-- +goose Down
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS solver_version,
+    DROP COLUMN IF EXISTS objective,
+    DROP COLUMN IF EXISTS pack_sizes,
+    DROP COLUMN IF EXISTS requested_amount;
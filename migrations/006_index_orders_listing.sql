@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX idx_orders_created_at_id ON orders(created_at, id);
+CREATE INDEX idx_order_items_package_size ON order_items(package_size, order_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_order_items_package_size;
+DROP INDEX IF EXISTS idx_orders_created_at_id;
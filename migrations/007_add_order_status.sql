@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE orders
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'confirmed', 'picking', 'packed', 'shipped', 'cancelled'));
+
+CREATE TABLE order_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id, changed_at);
+
+-- Existing orders start their history as drafts
+INSERT INTO order_status_history (order_id, from_status, to_status, changed_at)
+SELECT id, NULL, 'draft', created_at FROM orders;
+
+-- +goose Down
+DROP TABLE IF EXISTS order_status_history;
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE orders ADD COLUMN revision INTEGER NOT NULL DEFAULT 1 CHECK (revision > 0);
+
+CREATE TABLE order_revisions (
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL CHECK (revision > 0),
+    requested_amount INTEGER,
+    pack_sizes INTEGER[],
+    objective TEXT,
+    solver_version TEXT,
+    items JSONB NOT NULL,
+    revised_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (order_id, revision)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS order_revisions;
+ALTER TABLE orders DROP COLUMN IF EXISTS revision;
@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE products (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE CHECK (length(name) BETWEEN 1 AND 100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Packs and orders that existed before products belong to the default product
+INSERT INTO products (id, name) VALUES ('00000000-0000-0000-0000-000000000001', 'Default');
+
+ALTER TABLE packs
+    ADD COLUMN product_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES products(id);
+ALTER TABLE packs ALTER COLUMN product_id DROP DEFAULT;
+ALTER TABLE packs DROP CONSTRAINT packs_size_key;
+ALTER TABLE packs ADD CONSTRAINT packs_product_id_size_key UNIQUE (product_id, size);
+
+ALTER TABLE orders
+    ADD COLUMN product_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES products(id);
+ALTER TABLE orders ALTER COLUMN product_id DROP DEFAULT;
+
+CREATE INDEX idx_orders_product_id ON orders(product_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_orders_product_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS product_id;
+
+-- Only the default product's packs fit the global size constraint
+DELETE FROM packs WHERE product_id <> '00000000-0000-0000-0000-000000000001';
+ALTER TABLE packs DROP CONSTRAINT IF EXISTS packs_product_id_size_key;
+ALTER TABLE packs ADD CONSTRAINT packs_size_key UNIQUE (size);
+ALTER TABLE packs DROP COLUMN IF EXISTS product_id;
+
+DROP TABLE IF EXISTS products;
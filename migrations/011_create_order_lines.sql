@@ -0,0 +1,90 @@
+-- +goose Up
+CREATE TABLE order_lines (
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    line_number INTEGER NOT NULL CHECK (line_number > 0),
+    reference TEXT CHECK (length(reference) BETWEEN 1 AND 100),
+    product_id UUID NOT NULL REFERENCES products(id),
+    requested_amount INTEGER CHECK (requested_amount > 0),
+    pack_sizes INTEGER[],
+    objective TEXT,
+    solver_version TEXT,
+    PRIMARY KEY (order_id, line_number)
+);
+
+CREATE UNIQUE INDEX idx_order_lines_reference ON order_lines(order_id, reference) WHERE reference IS NOT NULL;
+CREATE INDEX idx_order_lines_product_id ON order_lines(product_id);
+
+-- Every existing order becomes a single-line order
+INSERT INTO order_lines (order_id, line_number, product_id, requested_amount, pack_sizes, objective, solver_version)
+SELECT id, 1, product_id, requested_amount, pack_sizes, objective, solver_version FROM orders;
+
+ALTER TABLE order_items ADD COLUMN line_number INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE order_items ALTER COLUMN line_number DROP DEFAULT;
+ALTER TABLE order_items DROP CONSTRAINT order_items_order_id_package_size_key;
+ALTER TABLE order_items ADD CONSTRAINT order_items_order_id_line_number_package_size_key
+    UNIQUE (order_id, line_number, package_size);
+ALTER TABLE order_items ADD CONSTRAINT order_items_order_line_fkey
+    FOREIGN KEY (order_id, line_number) REFERENCES order_lines(order_id, line_number) ON DELETE CASCADE;
+
+-- Revisions keep their lines as JSON, like their items before
+ALTER TABLE order_revisions ADD COLUMN lines JSONB;
+UPDATE order_revisions r SET lines = jsonb_build_array(jsonb_build_object(
+    'line', 1,
+    'product_id', o.product_id,
+    'requested_amount', r.requested_amount,
+    'pack_sizes', to_jsonb(r.pack_sizes),
+    'objective', r.objective,
+    'solver_version', r.solver_version,
+    'items', r.items))
+FROM orders o WHERE o.id = r.order_id;
+ALTER TABLE order_revisions ALTER COLUMN lines SET NOT NULL;
+ALTER TABLE order_revisions
+    DROP COLUMN requested_amount,
+    DROP COLUMN pack_sizes,
+    DROP COLUMN objective,
+    DROP COLUMN solver_version,
+    DROP COLUMN items;
+
+-- requested_amount stays on orders as the total over all lines, for listing
+DROP INDEX IF EXISTS idx_orders_product_id;
+ALTER TABLE orders
+    DROP COLUMN product_id,
+    DROP COLUMN pack_sizes,
+    DROP COLUMN objective,
+    DROP COLUMN solver_version;
+
+-- +goose Down
+-- Orders keep only their first line
+ALTER TABLE orders
+    ADD COLUMN product_id UUID REFERENCES products(id),
+    ADD COLUMN pack_sizes INTEGER[],
+    ADD COLUMN objective TEXT,
+    ADD COLUMN solver_version TEXT;
+UPDATE orders o SET product_id = l.product_id, requested_amount = l.requested_amount, pack_sizes = l.pack_sizes,
+    objective = l.objective, solver_version = l.solver_version
+FROM order_lines l WHERE l.order_id = o.id AND l.line_number = 1;
+ALTER TABLE orders ALTER COLUMN product_id SET NOT NULL;
+CREATE INDEX idx_orders_product_id ON orders(product_id);
+
+ALTER TABLE order_revisions
+    ADD COLUMN requested_amount INTEGER,
+    ADD COLUMN pack_sizes INTEGER[],
+    ADD COLUMN objective TEXT,
+    ADD COLUMN solver_version TEXT,
+    ADD COLUMN items JSONB;
+UPDATE order_revisions SET
+    requested_amount = (lines->0->>'requested_amount')::INTEGER,
+    pack_sizes = ARRAY(SELECT jsonb_array_elements_text(COALESCE(NULLIF(lines->0->'pack_sizes', 'null'), '[]'))::INTEGER),
+    objective = lines->0->>'objective',
+    solver_version = lines->0->>'solver_version',
+    items = lines->0->'items';
+ALTER TABLE order_revisions ALTER COLUMN items SET NOT NULL;
+ALTER TABLE order_revisions DROP COLUMN lines;
+
+DELETE FROM order_items WHERE line_number > 1;
+ALTER TABLE order_items DROP CONSTRAINT IF EXISTS order_items_order_line_fkey;
+ALTER TABLE order_items DROP CONSTRAINT IF EXISTS order_items_order_id_line_number_package_size_key;
+ALTER TABLE order_items ADD CONSTRAINT order_items_order_id_package_size_key UNIQUE (order_id, package_size);
+ALTER TABLE order_items DROP COLUMN IF EXISTS line_number;
+
+DROP TABLE IF EXISTS order_lines;
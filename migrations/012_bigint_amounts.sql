@@ -0,0 +1,23 @@
+-- +goose Up
+-- Bulk orders need amounts, sizes and quantities beyond the 32-bit range
+ALTER TABLE packs ALTER COLUMN size TYPE BIGINT;
+ALTER TABLE pack_stock ALTER COLUMN quantity TYPE BIGINT;
+ALTER TABLE orders ALTER COLUMN requested_amount TYPE BIGINT;
+ALTER TABLE order_lines
+    ALTER COLUMN requested_amount TYPE BIGINT,
+    ALTER COLUMN pack_sizes TYPE BIGINT[];
+ALTER TABLE order_items
+    ALTER COLUMN package_size TYPE BIGINT,
+    ALTER COLUMN quantity TYPE BIGINT;
+
+-- +goose Down
+-- Fails when a stored value no longer fits in an INTEGER
+ALTER TABLE order_items
+    ALTER COLUMN quantity TYPE INTEGER,
+    ALTER COLUMN package_size TYPE INTEGER;
+ALTER TABLE order_lines
+    ALTER COLUMN pack_sizes TYPE INTEGER[],
+    ALTER COLUMN requested_amount TYPE INTEGER;
+ALTER TABLE orders ALTER COLUMN requested_amount TYPE INTEGER;
+ALTER TABLE pack_stock ALTER COLUMN quantity TYPE INTEGER;
+ALTER TABLE packs ALTER COLUMN size TYPE INTEGER;
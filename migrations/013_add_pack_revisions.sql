@@ -0,0 +1,51 @@
+-- +goose Up
+ALTER TABLE packs
+    ADD COLUMN revision INTEGER NOT NULL DEFAULT 1 CHECK (revision > 0),
+    ADD COLUMN active BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN valid_from TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN valid_to TIMESTAMP WITH TIME ZONE;
+UPDATE packs SET valid_from = created_at;
+ALTER TABLE packs
+    ALTER COLUMN valid_from SET NOT NULL,
+    ALTER COLUMN valid_from SET DEFAULT NOW(),
+    ADD CONSTRAINT packs_validity CHECK (valid_to IS NULL OR valid_to >= valid_from);
+
+-- Retired packs keep their sizes, so only active packs need unique ones
+ALTER TABLE packs DROP CONSTRAINT packs_product_id_size_key;
+CREATE UNIQUE INDEX packs_product_id_size_active_key ON packs(product_id, size) WHERE active;
+
+-- Every revision of a pack with the time it was in effect. A revision that
+-- was superseded before it took effect has valid_to = valid_from.
+CREATE TABLE pack_revisions (
+    pack_id UUID NOT NULL REFERENCES packs(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL CHECK (revision > 0),
+    size BIGINT NOT NULL CHECK (size > 0),
+    unit_cost BIGINT CHECK (unit_cost >= 0),
+    weight_grams INTEGER CHECK (weight_grams > 0),
+    length_mm INTEGER CHECK (length_mm > 0),
+    width_mm INTEGER CHECK (width_mm > 0),
+    height_mm INTEGER CHECK (height_mm > 0),
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_to TIMESTAMP WITH TIME ZONE CHECK (valid_to IS NULL OR valid_to >= valid_from),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pack_id, revision)
+);
+
+CREATE INDEX idx_pack_revisions_validity ON pack_revisions(pack_id, valid_from, valid_to);
+
+INSERT INTO pack_revisions (pack_id, revision, size, unit_cost, weight_grams, length_mm, width_mm, height_mm, valid_from, created_at)
+SELECT id, 1, size, unit_cost, weight_grams, length_mm, width_mm, height_mm, created_at, updated_at FROM packs;
+
+-- +goose Down
+DROP TABLE IF EXISTS pack_revisions;
+
+-- Retired packs would break the unique sizes
+DELETE FROM packs WHERE NOT active;
+DROP INDEX IF EXISTS packs_product_id_size_active_key;
+ALTER TABLE packs ADD CONSTRAINT packs_product_id_size_key UNIQUE (product_id, size);
+ALTER TABLE packs
+    DROP CONSTRAINT IF EXISTS packs_validity,
+    DROP COLUMN IF EXISTS valid_to,
+    DROP COLUMN IF EXISTS valid_from,
+    DROP COLUMN IF EXISTS active,
+    DROP COLUMN IF EXISTS revision;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- version guards every write to an order, status changes included, so
+-- clients can make them conditional with If-Match. Packs use their revision.
+ALTER TABLE orders ADD COLUMN version INTEGER NOT NULL DEFAULT 1 CHECK (version > 0);
+UPDATE orders SET version = revision;
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN version;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE idempotency_keys ADD COLUMN headers JSONB;
+
+-- +goose Down
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS headers;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- The head row in packs holds a pack's latest revision, which may only take
+-- effect later, so sizes are kept unique over the revisions in effect at the
+-- same time instead
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE pack_revisions ADD COLUMN product_id UUID;
+UPDATE pack_revisions r SET product_id = p.product_id FROM packs p WHERE p.id = r.pack_id;
+ALTER TABLE pack_revisions ALTER COLUMN product_id SET NOT NULL;
+
+-- A revision superseded before it took effect has an empty range and
+-- overlaps nothing
+ALTER TABLE pack_revisions ADD CONSTRAINT pack_revisions_size_overlap
+    EXCLUDE USING gist (product_id WITH =, size WITH =, tstzrange(valid_from, valid_to) WITH &&);
+
+DROP INDEX IF EXISTS packs_product_id_size_active_key;
+
+-- +goose Down
+CREATE UNIQUE INDEX packs_product_id_size_active_key ON packs(product_id, size) WHERE active;
+
+ALTER TABLE pack_revisions
+    DROP CONSTRAINT IF EXISTS pack_revisions_size_overlap,
+    DROP COLUMN IF EXISTS product_id;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- When the current lines of an order were calculated, so that they are priced
+-- and weighed with the pack revisions in effect then
+ALTER TABLE orders ADD COLUMN calculated_at TIMESTAMP WITH TIME ZONE;
+UPDATE orders o SET calculated_at = COALESCE(
+    (SELECT MAX(r.revised_at) FROM order_revisions r WHERE r.order_id = o.id), o.created_at);
+ALTER TABLE orders
+    ALTER COLUMN calculated_at SET NOT NULL,
+    ALTER COLUMN calculated_at SET DEFAULT NOW();
+
+-- +goose Down
+ALTER TABLE orders DROP COLUMN IF EXISTS calculated_at;
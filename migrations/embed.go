@@ -0,0 +1,10 @@
+// Package migrations holds the SQL schema migrations, embedded so the
+// application binary can apply them without the files on disk
+package migrations
+
+import "embed"
+
+// FS holds the migration files, named <version>_<name>.sql in goose format
+//
+//go:embed *.sql
+var FS embed.FS
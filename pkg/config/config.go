@@ -3,13 +3,16 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	App      AppConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	App         AppConfig
+	Solver      SolverConfig
+	Idempotency IdempotencyConfig
 }
 
 // ServerConfig holds server-related configuration
@@ -17,6 +20,10 @@ type ServerConfig struct {
 	Name string
 	Port int
 	Mode string // gin mode: debug, release, test
+	// RequestTimeout is the deadline of requests that calculate pack combinations
+	RequestTimeout time.Duration
+	// BatchTimeout is the deadline of batch calculations and recommendations
+	BatchTimeout time.Duration
 }
 
 // DatabaseConfig holds database-related configuration
@@ -27,6 +34,8 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// MigrateOnStartup applies pending schema migrations before serving
+	MigrateOnStartup bool
 }
 
 // AppConfig holds application-specific configuration
@@ -36,27 +45,63 @@ type AppConfig struct {
 	EnableSwagger bool
 }
 
+// SolverConfig holds pack solver configuration
+type SolverConfig struct {
+	DefaultObjective string
+	WasteWeight      float64
+	PackWeight       float64
+	CostWeight       float64
+	BatchWorkers     int
+	// TimeBudget bounds how long one calculation searches before settling for
+	// the best combination found so far
+	TimeBudget time.Duration
+}
+
+// IdempotencyConfig holds configuration for idempotent order creation
+type IdempotencyConfig struct {
+	// TTL is how long responses are kept for replay
+	TTL time.Duration
+	// Lease is how long a request holds its key before a retry may take it
+	// over; it should exceed the request timeout
+	Lease time.Duration
+}
+
 // Load loads configuration from environment variables with defaults
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Name: getEnv("SERVER_NAME", "PacksAPI"),
-			Port: getEnvAsInt("SERVER_PORT", 8080),
-			Mode: getEnv("GIN_MODE", "release"),
+			Name:           getEnv("SERVER_NAME", "PacksAPI"),
+			Port:           getEnvAsInt("SERVER_PORT", 8080),
+			Mode:           getEnv("GIN_MODE", "release"),
+			RequestTimeout: getEnvAsDuration("REQUEST_TIMEOUT", 10*time.Second),
+			BatchTimeout:   getEnvAsDuration("BATCH_REQUEST_TIMEOUT", time.Minute),
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			DBName:   getEnv("DB_NAME", "packs_db"),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+			Host:             getEnv("DB_HOST", "localhost"),
+			Port:             getEnv("DB_PORT", "5432"),
+			User:             getEnv("DB_USER", "postgres"),
+			Password:         getEnv("DB_PASSWORD", "postgres"),
+			DBName:           getEnv("DB_NAME", "packs_db"),
+			SSLMode:          getEnv("DB_SSL_MODE", "disable"),
+			MigrateOnStartup: getEnvAsBool("DB_MIGRATE_ON_STARTUP", false),
 		},
 		App: AppConfig{
 			LogLevel:      getEnv("LOG_LEVEL", "INFO"),
 			Version:       getEnv("APP_VERSION", "1.0.0"),
 			EnableSwagger: getEnvAsBool("ENABLE_SWAGGER", true),
 		},
+		Solver: SolverConfig{
+			DefaultObjective: getEnv("SOLVER_OBJECTIVE", "min_waste"),
+			WasteWeight:      getEnvAsFloat("SOLVER_WEIGHT_WASTE", 1),
+			PackWeight:       getEnvAsFloat("SOLVER_WEIGHT_PACKS", 1),
+			CostWeight:       getEnvAsFloat("SOLVER_WEIGHT_COST", 0),
+			BatchWorkers:     getEnvAsInt("SOLVER_BATCH_WORKERS", 0),
+			TimeBudget:       getEnvAsDuration("SOLVER_TIME_BUDGET", 2*time.Second),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:   getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			Lease: getEnvAsDuration("IDEMPOTENCY_LEASE", time.Minute),
+		},
 	}
 }
 
@@ -84,6 +129,16 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsFloat gets an environment variable as float with a default value
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvAsBool gets an environment variable as boolean with a default value
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
@@ -93,3 +148,13 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvAsDuration gets an environment variable as duration (e.g. "24h") with a default value
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationValue, err := time.ParseDuration(value); err == nil {
+			return durationValue
+		}
+	}
+	return defaultValue
+}